@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"slices"
@@ -25,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/v5test"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/urfave/cli/v2"
 )
 
@@ -38,6 +40,8 @@ var (
 			discv5CrawlCommand,
 			discv5TestCommand,
 			discv5ListenCommand,
+			discv5RegisterCommand,
+			discv5TopicCommand,
 		},
 	}
 	discv5PingCommand = &cli.Command{
@@ -77,8 +81,30 @@ var (
 		Action: discv5Listen,
 		Flags:  discoveryNodeFlags,
 	}
+	discv5RegisterCommand = &cli.Command{
+		Name:      "register",
+		Usage:     "Runs a node advertising a topic",
+		ArgsUsage: "<topic>",
+		Action:    discv5Register,
+		Flags:     discoveryNodeFlags,
+	}
+	discv5TopicCommand = &cli.Command{
+		Name:      "topic",
+		Usage:     "Finds the nodes advertising a topic in the DHT",
+		ArgsUsage: "<topic>",
+		Action:    discv5Topic,
+		Flags: slices.Concat(discoveryNodeFlags, []cli.Flag{
+			topicTimeoutFlag,
+		}),
+	}
 )
 
+var topicTimeoutFlag = &cli.DurationFlag{
+	Name:  "timeout",
+	Usage: "Time limit for the topic search.",
+	Value: time.Minute,
+}
+
 func discv5Ping(ctx *cli.Context) error {
 	n := getNodeArg(ctx)
 	disc, _ := startV5(ctx)
@@ -139,6 +165,49 @@ func discv5Listen(ctx *cli.Context) error {
 	select {}
 }
 
+func discv5Register(ctx *cli.Context) error {
+	topic, err := getTopicArg(ctx)
+	if err != nil {
+		return err
+	}
+	disc, _ := startV5(ctx)
+	defer disc.Close()
+
+	fmt.Println(disc.Self())
+	disc.RegisterTopic(context.Background(), topic)
+	return nil
+}
+
+func discv5Topic(ctx *cli.Context) error {
+	topic, err := getTopicArg(ctx)
+	if err != nil {
+		return err
+	}
+	disc, _ := startV5(ctx)
+	defer disc.Close()
+
+	it := disc.TopicNodes(topic)
+	timer := time.AfterFunc(ctx.Duration(topicTimeoutFlag.Name), it.Close)
+	defer timer.Stop()
+
+	seen := make(map[enode.ID]bool)
+	for it.Next() {
+		if n := it.Node(); !seen[n.ID()] {
+			seen[n.ID()] = true
+			fmt.Println(n)
+		}
+	}
+	return nil
+}
+
+// getTopicArg returns the topic identifier of the service name argument.
+func getTopicArg(ctx *cli.Context) (discover.Topic, error) {
+	if ctx.NArg() < 1 {
+		return discover.Topic{}, errors.New("need topic as argument")
+	}
+	return discover.NewTopic(ctx.Args().First()), nil
+}
+
 // startV5 starts an ephemeral discovery v5 node.
 func startV5(ctx *cli.Context) (*discover.UDPv5, discover.Config) {
 	ln, config := makeDiscoveryConfig(ctx)
@@ -0,0 +1,96 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/membership"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	membershipCommand = &cli.Command{
+		Name:  "membership",
+		Usage: "Operations on network membership lists",
+		Subcommands: []*cli.Command{
+			membershipSignCommand,
+			membershipVerifyCommand,
+		},
+	}
+	membershipSignCommand = &cli.Command{
+		Name:      "sign",
+		Usage:     "Signs a network membership list",
+		ArgsUsage: "<list.json> <keyfile>",
+		Action:    membershipSign,
+	}
+	membershipVerifyCommand = &cli.Command{
+		Name:      "verify",
+		Usage:     "Verifies a network membership list and prints the signer",
+		ArgsUsage: "<list.json>",
+		Action:    membershipVerify,
+	}
+)
+
+// membershipSign signs a membership list with a keystore key, updating the file in place.
+func membershipSign(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("need membership list and key file as arguments")
+	}
+	var (
+		file    = ctx.Args().Get(0)
+		keyfile = ctx.Args().Get(1)
+	)
+	list, err := membership.LoadList(file)
+	if err != nil {
+		return err
+	}
+	key := loadSigningKey(keyfile)
+	if err := list.Sign(key); err != nil {
+		return err
+	}
+	enc, err := json.MarshalIndent(list, "", jsonIndent)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(enc, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Signed membership list version %d (%d members) with %v\n", list.Version, len(list.Members), crypto.PubkeyToAddress(key.PublicKey))
+	return nil
+}
+
+// membershipVerify checks the signature of a membership list.
+func membershipVerify(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("need membership list as argument")
+	}
+	list, err := membership.LoadList(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	signer, err := list.Signer()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d\nmembers: %d\nsigner:  %v\n", list.Version, len(list.Members), signer)
+	return nil
+}
@@ -0,0 +1,391 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/ethtest"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+// Base protocol message codes, from p2p/peer.go.
+const (
+	baseProtocolLength = 16
+	handshakeMsg       = 0x00
+	discMsg            = 0x01
+	pingMsg            = 0x02
+	pongMsg            = 0x03
+)
+
+// capturedMsg describes a message type which can be decoded from a capture file.
+type capturedMsg struct {
+	name string
+	new  func(version uint) any // creates the decoding target, nil if the message has no content
+}
+
+// eth/68 receipts use the consensus encoding, eth/69 drops the bloom.
+type capturedReceipts68 struct {
+	RequestId uint64
+	List      [][]*types.Receipt
+}
+
+type capturedReceipts69 struct {
+	RequestId uint64
+	List      [][]*capturedReceipt69
+}
+
+type capturedReceipt69 struct {
+	Type              uint8
+	PostStateOrStatus hexutil.Bytes
+	CumulativeGasUsed uint64
+	Logs              []*types.Log
+}
+
+func newMsg[T any](uint) any { return new(T) }
+
+// capturedMsgs are the known message types, by protocol name and message code.
+var capturedMsgs = map[string]map[uint64]capturedMsg{
+	"": {
+		handshakeMsg: {"Hello", newMsg[ethtest.Hello]},
+		discMsg:      {"Disconnect", newMsg[[]p2p.DiscReason]},
+		pingMsg:      {"Ping", nil},
+		pongMsg:      {"Pong", nil},
+	},
+	"eth": {
+		eth.StatusMsg: {"Status", func(version uint) any {
+			if version < eth.ETH69 {
+				return new(eth.StatusPacket68)
+			}
+			return new(eth.StatusPacket69)
+		}},
+		eth.NewBlockHashesMsg:             {"NewBlockHashes", newMsg[eth.NewBlockHashesPacket]},
+		eth.TransactionsMsg:               {"Transactions", newMsg[eth.TransactionsPacket]},
+		eth.GetBlockHeadersMsg:            {"GetBlockHeaders", newMsg[eth.GetBlockHeadersPacket]},
+		eth.BlockHeadersMsg:               {"BlockHeaders", newMsg[eth.BlockHeadersPacket]},
+		eth.GetBlockBodiesMsg:             {"GetBlockBodies", newMsg[eth.GetBlockBodiesPacket]},
+		eth.BlockBodiesMsg:                {"BlockBodies", newMsg[eth.BlockBodiesPacket]},
+		eth.NewBlockMsg:                   {"NewBlock", newMsg[eth.NewBlockPacket]},
+		eth.NewPooledTransactionHashesMsg: {"NewPooledTransactionHashes", newMsg[eth.NewPooledTransactionHashesPacket]},
+		eth.GetPooledTransactionsMsg:      {"GetPooledTransactions", newMsg[eth.GetPooledTransactionsPacket]},
+		eth.PooledTransactionsMsg:         {"PooledTransactions", newMsg[eth.PooledTransactionsPacket]},
+		eth.GetReceiptsMsg:                {"GetReceipts", newMsg[eth.GetReceiptsPacket]},
+		eth.ReceiptsMsg: {"Receipts", func(version uint) any {
+			if version < eth.ETH69 {
+				return new(capturedReceipts68)
+			}
+			return new(capturedReceipts69)
+		}},
+		eth.BlockRangeUpdateMsg: {"BlockRangeUpdate", newMsg[eth.BlockRangeUpdatePacket]},
+	},
+	"snap": {
+		snap.GetAccountRangeMsg:  {"GetAccountRange", newMsg[snap.GetAccountRangePacket]},
+		snap.AccountRangeMsg:     {"AccountRange", newMsg[snap.AccountRangePacket]},
+		snap.GetStorageRangesMsg: {"GetStorageRanges", newMsg[snap.GetStorageRangesPacket]},
+		snap.StorageRangesMsg:    {"StorageRanges", newMsg[snap.StorageRangesPacket]},
+		snap.GetByteCodesMsg:     {"GetByteCodes", newMsg[snap.GetByteCodesPacket]},
+		snap.ByteCodesMsg:        {"ByteCodes", newMsg[snap.ByteCodesPacket]},
+		snap.GetTrieNodesMsg:     {"GetTrieNodes", newMsg[snap.GetTrieNodesPacket]},
+		snap.TrieNodesMsg:        {"TrieNodes", newMsg[snap.TrieNodesPacket]},
+	},
+}
+
+// printCapturedMsg pretty-prints a recorded message.
+func printCapturedMsg(w io.Writer, rec *p2p.CaptureRecord, raw bool) {
+	var (
+		dir   = "->"
+		proto = "p2p"
+		name  = "Unknown"
+	)
+	if rec.Inbound {
+		dir = "<-"
+	}
+	if rec.Protocol != "" {
+		proto = fmt.Sprintf("%s/%d", rec.Protocol, rec.Version)
+	}
+	msg, known := capturedMsgs[rec.Protocol][rec.Code]
+	if known {
+		name = msg.name
+	}
+	fmt.Fprintf(w, "%s %s %s %s (%#02x) %d bytes\n", rec.Timestamp().UTC().Format(time.RFC3339Nano), dir, proto, name, rec.Code, len(rec.Payload))
+
+	if !known || msg.new == nil || raw {
+		if len(rec.Payload) > 0 && (raw || !known) {
+			fmt.Fprintf(w, "%x\n", rec.Payload)
+		}
+		return
+	}
+	v := msg.new(rec.Version)
+	if err := rlp.DecodeBytes(rec.Payload, v); err != nil {
+		fmt.Fprintf(w, "invalid message: %v\n%x\n", err, rec.Payload)
+		return
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintf(w, "%+v\n", v)
+		return
+	}
+	fmt.Fprintf(w, "%s\n", out)
+}
+
+// openCapture opens a capture file.
+func openCapture(file string) (*p2p.CaptureReader, *os.File, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := p2p.NewCaptureReader(fd)
+	if err != nil {
+		fd.Close()
+		return nil, nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return r, fd, nil
+}
+
+func rlpxDecode(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("need capture file as argument")
+	}
+	r, fd, err := openCapture(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	h := r.Header
+	direction := "outbound"
+	if h.Inbound {
+		direction = "inbound"
+	}
+	fmt.Printf("Session: %s connection with %v (%s)\n", direction, h.Remote, h.Name)
+	fmt.Printf("Local node: %v\n", h.Local)
+	fmt.Printf("Started: %s\n", time.Unix(0, int64(h.Time)).UTC().Format(time.RFC3339Nano))
+	for _, p := range h.Protocols {
+		fmt.Printf("Protocol: %s/%d\n", p.Name, p.Version)
+	}
+	fmt.Println()
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		printCapturedMsg(os.Stdout, rec, ctx.Bool(captureRawFlag.Name))
+	}
+}
+
+// replayConn is an RLPx connection to the node targeted by a replay.
+type replayConn struct {
+	*rlpx.Conn
+	wmu     sync.Mutex
+	offsets map[string]uint64 // message code offsets of the negotiated protocols
+	protos  []p2p.CaptureProtocol
+}
+
+func (c *replayConn) write(code uint64, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.Write(code, payload)
+	return err
+}
+
+// handshake performs the protocol handshake, announcing the protocols of the
+// recorded session.
+func (c *replayConn) handshake(ourKey *ecdsa.PrivateKey, protos []p2p.CaptureProtocol) error {
+	hello := &ethtest.Hello{Version: 5, Name: "devp2p-replay", ID: crypto.FromECDSAPub(&ourKey.PublicKey)[1:]}
+	for _, p := range protos {
+		hello.Caps = append(hello.Caps, p2p.Cap{Name: p.Name, Version: p.Version})
+	}
+	payload, _ := rlp.EncodeToBytes(hello)
+	if err := c.write(handshakeMsg, payload); err != nil {
+		return err
+	}
+	code, data, _, err := c.Read()
+	if err != nil {
+		return err
+	}
+	switch code {
+	case handshakeMsg:
+	case discMsg:
+		var reason []p2p.DiscReason
+		if rlp.DecodeBytes(data, &reason); len(reason) > 0 {
+			return fmt.Errorf("node disconnected: %v", reason[0])
+		}
+		return errors.New("node disconnected")
+	default:
+		return fmt.Errorf("expected handshake, got message code %d", code)
+	}
+	var theirs ethtest.Hello
+	if err := rlp.DecodeBytes(data, &theirs); err != nil {
+		return fmt.Errorf("invalid handshake: %v", err)
+	}
+	if theirs.Version >= 5 {
+		c.SetSnappy(true)
+	}
+	// Assign message code offsets like p2p.Server does, based on the sorted list
+	// of shared protocols.
+	c.offsets = make(map[string]uint64)
+	shared := slices.DeleteFunc(slices.Clone(hello.Caps), func(cap p2p.Cap) bool {
+		return !slices.Contains(theirs.Caps, cap)
+	})
+	slices.SortFunc(shared, p2p.Cap.Cmp)
+	offset := uint64(baseProtocolLength)
+	for _, cap := range shared {
+		i := slices.IndexFunc(protos, func(p p2p.CaptureProtocol) bool { return p.Name == cap.Name })
+		c.offsets[cap.Name] = offset
+		c.protos = append(c.protos, protos[i])
+		offset += protos[i].Length
+	}
+	return nil
+}
+
+// resolve converts an absolute message code into a protocol-relative record.
+func (c *replayConn) resolve(code uint64, payload []byte) *p2p.CaptureRecord {
+	rec := &p2p.CaptureRecord{Time: uint64(time.Now().UnixNano()), Inbound: true, Code: code, Payload: payload}
+	for _, p := range c.protos {
+		if off := c.offsets[p.Name]; code >= off && code < off+p.Length {
+			rec.Protocol, rec.Version, rec.Code = p.Name, p.Version, code-off
+		}
+	}
+	return rec
+}
+
+func rlpxReplay(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("need node and capture file as arguments")
+	}
+	n, err := parseNode(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	r, fd, err := openCapture(ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	// Collect the messages sent by the local node in the recorded session.
+	var sent []*p2p.CaptureRecord
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if !rec.Inbound && rec.Protocol != "" {
+			sent = append(sent, rec)
+		}
+	}
+
+	// Connect to the node.
+	tcpEndpoint, ok := n.TCPEndpoint()
+	if !ok {
+		return errors.New("node has no TCP endpoint")
+	}
+	nc, err := net.Dial("tcp", tcpEndpoint.String())
+	if err != nil {
+		return err
+	}
+	conn := &replayConn{Conn: rlpx.NewConn(nc, n.Pubkey())}
+	defer conn.Close()
+	ourKey, _ := crypto.GenerateKey()
+	if _, err := conn.Handshake(ourKey); err != nil {
+		return err
+	}
+	if err := conn.handshake(ourKey, r.Header.Protocols); err != nil {
+		return err
+	}
+
+	// Print everything sent by the node, answering pings to keep the connection.
+	var (
+		raw  = ctx.Bool(captureRawFlag.Name)
+		done = make(chan error, 1)
+	)
+	go func() {
+		for {
+			code, data, _, err := conn.Read()
+			if err != nil {
+				done <- err
+				return
+			}
+			rec := conn.resolve(code, data)
+			printCapturedMsg(os.Stdout, rec, raw)
+			switch {
+			case rec.Protocol == "" && code == pingMsg:
+				conn.write(pongMsg, []byte{0xc0})
+			case rec.Protocol == "" && code == discMsg:
+				done <- errors.New("node disconnected")
+				return
+			}
+		}
+	}()
+
+	// Replay the recorded messages.
+	start := time.Now()
+	for _, rec := range sent {
+		offset, ok := conn.offsets[rec.Protocol]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Skipping %s/%d message, protocol not negotiated\n", rec.Protocol, rec.Version)
+			continue
+		}
+		if ctx.Bool(replayRealtimeFlag.Name) {
+			time.Sleep(time.Until(start.Add(time.Duration(rec.Time - sent[0].Time))))
+		}
+		select {
+		case err := <-done:
+			return err
+		default:
+		}
+		printCapturedMsg(os.Stdout, &p2p.CaptureRecord{
+			Time:     uint64(time.Now().UnixNano()),
+			Protocol: rec.Protocol,
+			Version:  rec.Version,
+			Code:     rec.Code,
+			Payload:  rec.Payload,
+		}, raw)
+		if err := conn.write(offset+rec.Code, rec.Payload); err != nil {
+			return err
+		}
+	}
+
+	// Wait for the responses.
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(ctx.Duration(replayWaitFlag.Name)):
+		return nil
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/ethtest"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -38,6 +39,8 @@ var (
 			rlpxPingCommand,
 			rlpxEthTestCommand,
 			rlpxSnapTestCommand,
+			rlpxDecodeCommand,
+			rlpxReplayCommand,
 		},
 	}
 	rlpxPingCommand = &cli.Command{
@@ -73,6 +76,36 @@ var (
 			testNodeEngineFlag,
 		},
 	}
+	rlpxDecodeCommand = &cli.Command{
+		Name:      "decode",
+		Usage:     "Pretty-prints the messages of a session capture file",
+		ArgsUsage: "<capture-file>",
+		Action:    rlpxDecode,
+		Flags:     []cli.Flag{captureRawFlag},
+	}
+	rlpxReplayCommand = &cli.Command{
+		Name:      "replay",
+		Usage:     "Replays the messages sent in a session capture file against a node",
+		ArgsUsage: "<node> <capture-file>",
+		Action:    rlpxReplay,
+		Flags:     []cli.Flag{captureRawFlag, replayRealtimeFlag, replayWaitFlag},
+	}
+)
+
+var (
+	captureRawFlag = &cli.BoolFlag{
+		Name:  "raw",
+		Usage: "Print message payloads as hex instead of decoding them",
+	}
+	replayRealtimeFlag = &cli.BoolFlag{
+		Name:  "realtime",
+		Usage: "Keep the original timing between messages",
+	}
+	replayWaitFlag = &cli.DurationFlag{
+		Name:  "wait",
+		Usage: "Time to wait for responses after the last message was sent",
+		Value: 5 * time.Second,
+	}
 )
 
 func rlpxPing(ctx *cli.Context) error {
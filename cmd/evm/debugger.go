@@ -0,0 +1,144 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/debugger"
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/t8ntool"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v2"
+)
+
+var DebugCommandsFlag = &cli.StringFlag{
+	Name:     "commands",
+	Usage:    "File containing debugger commands to execute instead of reading them from stdin",
+	Category: flags.VMCategory,
+}
+
+var debugCommand = &cli.Command{
+	Name:  "debug",
+	Usage: "Interactively steps through an EVM execution",
+	Description: `
+The debug command executes code, a state test or a state transition while
+recording every executed instruction, then opens an interactive session to
+step through the recording. Breakpoints can be set on program counters,
+opcodes and call depths, and execution can be stepped backwards as well as
+forwards. Type 'help' within the session for a list of commands.`,
+	Subcommands: []*cli.Command{
+		{
+			Name:      "run",
+			Usage:     "Debug arbitrary evm binary",
+			ArgsUsage: "<code>",
+			Action:    debugRunCmd,
+			Flags: []cli.Flag{
+				CodeFileFlag,
+				CreateFlag,
+				GasFlag,
+				GenesisFlag,
+				InputFlag,
+				InputFileFlag,
+				PriceFlag,
+				ReceiverFlag,
+				SenderFlag,
+				ValueFlag,
+				DebugCommandsFlag,
+			},
+		},
+		{
+			Name:      "statetest",
+			Usage:     "Debug the given state test",
+			ArgsUsage: "<file>",
+			Action:    debugStateTestCmd,
+			Flags: []cli.Flag{
+				forkFlag,
+				idxFlag,
+				RunFlag,
+				DebugCommandsFlag,
+			},
+		},
+		{
+			Name:    "transition",
+			Aliases: []string{"t8n"},
+			Usage:   "Debug a state transition given in the t8n input format",
+			Action:  debugTransitionCmd,
+			Flags: []cli.Flag{
+				t8ntool.InputAllocFlag,
+				t8ntool.InputEnvFlag,
+				t8ntool.InputTxsFlag,
+				t8ntool.ForknameFlag,
+				t8ntool.ChainIDFlag,
+				t8ntool.RewardFlag,
+				DebugCommandsFlag,
+			},
+		},
+	},
+}
+
+func debugRunCmd(ctx *cli.Context) error {
+	return debugExecution(ctx, func(tracer *tracing.Hooks) error {
+		return runCode(ctx, tracer)
+	})
+}
+
+func debugStateTestCmd(ctx *cli.Context) error {
+	path := ctx.Args().First()
+	if path == "" {
+		return errors.New("path to state test required")
+	}
+	return debugExecution(ctx, func(tracer *tracing.Hooks) error {
+		_, err := runStateTest(ctx, path, tracer)
+		return err
+	})
+}
+
+func debugTransitionCmd(ctx *cli.Context) error {
+	return debugExecution(ctx, func(tracer *tracing.Hooks) error {
+		return t8ntool.Replay(ctx, tracer)
+	})
+}
+
+// debugExecution records the execution done by exec and opens a debugging
+// session over it.
+func debugExecution(ctx *cli.Context, exec func(tracer *tracing.Hooks) error) error {
+	rec := debugger.NewRecorder()
+	if err := exec(rec.Hooks()); err != nil {
+		if len(rec.Steps()) == 0 {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Execution failed: %v\n", err)
+	}
+	var (
+		in          io.Reader = os.Stdin
+		interactive           = isatty.IsTerminal(os.Stdin.Fd())
+	)
+	if path := ctx.String(DebugCommandsFlag.Name); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in, interactive = f, false
+	}
+	return debugger.NewSession(rec).Run(in, os.Stdout, interactive)
+}
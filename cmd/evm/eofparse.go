@@ -0,0 +1,189 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	HexFlag = &cli.StringFlag{
+		Name:  "hex",
+		Usage: "Single container data to parse and validate",
+	}
+	InitcodeFlag = &cli.BoolFlag{
+		Name:  "initcode",
+		Usage: "Validate the container as initcode instead of runtime code",
+	}
+)
+
+var eofParseCommand = &cli.Command{
+	Name:      "eofparse",
+	Aliases:   []string{"eof"},
+	Usage:     "Parses and validates EOF containers, or runs EOF validation tests",
+	ArgsUsage: "[<path>]",
+	Action:    eofParseAction,
+	Flags: []cli.Flag{
+		HexFlag,
+		InitcodeFlag,
+		HumanReadableFlag,
+		RunFlag,
+	},
+}
+
+// eofParseAction validates the EOF containers given via the --hex flag or on
+// the standard input, one hex encoded container per line. If a path is given,
+// the EOF validation tests within are executed instead.
+func eofParseAction(ctx *cli.Context) error {
+	jt := vm.NewEOFInstructionSetForTesting()
+
+	if path := ctx.Args().First(); path != "" {
+		var results []testResult
+		for _, fname := range collectFiles(path) {
+			r, err := runEOFTest(ctx, jt, fname)
+			if err != nil {
+				return err
+			}
+			results = append(results, r...)
+		}
+		report(ctx, results)
+		return nil
+	}
+	initcode := ctx.Bool(InitcodeFlag.Name)
+	if input := ctx.String(HexFlag.Name); input != "" {
+		container, err := parseAndValidate(jt, input, initcode)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatCodeSections(container))
+		return nil
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		container, err := parseAndValidate(jt, line, initcode)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			continue
+		}
+		fmt.Printf("OK %s\n", formatCodeSections(container))
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// parseAndValidate decodes a hex encoded EOF container and validates it as
+// either initcode or runtime code.
+func parseAndValidate(jt *vm.JumpTable, input string, initcode bool) (*vm.Container, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode data: %w", err)
+	}
+	var container vm.Container
+	if err := container.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	if err := container.ValidateCode(jt, initcode); err != nil {
+		return nil, err
+	}
+	return &container, nil
+}
+
+// formatCodeSections returns the code sections of the container as comma
+// separated hex strings.
+func formatCodeSections(container *vm.Container) string {
+	var sections []string
+	for _, code := range container.CodeSections() {
+		sections = append(sections, hex.EncodeToString(code))
+	}
+	return strings.Join(sections, ",")
+}
+
+// eofTest is a single EOF validation test, as filled by the execution spec
+// tests.
+type eofTest struct {
+	Vectors map[string]eofTestVector `json:"vectors"`
+}
+
+type eofTestVector struct {
+	Code          string                   `json:"code"`
+	ContainerKind string                   `json:"containerKind"`
+	Results       map[string]eofTestResult `json:"results"`
+}
+
+type eofTestResult struct {
+	Result    bool   `json:"result"`
+	Exception string `json:"exception,omitempty"`
+}
+
+// runEOFTest executes the EOF validation tests in the given file, checking the
+// validity of each vector against the expectation of every EOF enabled fork.
+func runEOFTest(ctx *cli.Context, jt *vm.JumpTable, fname string) ([]testResult, error) {
+	src, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var tests map[string]eofTest
+	if err := json.Unmarshal(src, &tests); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(ctx.String(RunFlag.Name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex -%s: %v", RunFlag.Name, err)
+	}
+	var results []testResult
+	for _, name := range slices.Sorted(maps.Keys(tests)) {
+		vectors := tests[name].Vectors
+		for _, vector := range slices.Sorted(maps.Keys(vectors)) {
+			key := fmt.Sprintf("%s/%s", name, vector)
+			if !re.MatchString(key) {
+				continue
+			}
+			tc := vectors[vector]
+			_, verr := parseAndValidate(jt, tc.Code, tc.ContainerKind == "INITCODE")
+			for _, fork := range slices.Sorted(maps.Keys(tc.Results)) {
+				want := tc.Results[fork]
+				result := testResult{Name: key, Fork: fork, Pass: true}
+				if have := verr == nil; have != want.Result {
+					result.Pass = false
+					result.Error = fmt.Sprintf("validity mismatch: have %v (%v), want %v (%s)", have, verr, want.Result, want.Exception)
+				}
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
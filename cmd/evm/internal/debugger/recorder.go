@@ -0,0 +1,285 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Package debugger implements an interactive step debugger for EVM executions.
+// The execution is recorded up front via tracing hooks, which allows stepping
+// through it both forwards and backwards.
+package debugger
+
+import (
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// memorySnapshotInterval is the maximum number of memory deltas recorded in a
+// row within a call frame, before a full snapshot of the memory is taken. It
+// bounds the work of restoring the memory of a step.
+const memorySnapshotInterval = 64
+
+// Step is the state of the EVM right before executing an instruction.
+type Step struct {
+	Tx      int // Index of the transaction within the recording
+	Depth   int // Call depth, 1 for the outermost frame
+	Address common.Address
+	PC      uint64
+	Op      vm.OpCode
+	Gas     uint64
+	Cost    uint64
+	Stack   []uint256.Int
+	Err     error // Error the instruction failed with, if any
+
+	memory memoryRecord // Memory of the step, see Recorder.Memory
+}
+
+// memoryRecord is the memory of a step, either as a full snapshot or as the
+// change relative to the memory of an earlier step of the same call frame.
+type memoryRecord struct {
+	base   int    // Step whose memory is changed, -1 for snapshots
+	deltas int    // Number of deltas since the last snapshot
+	size   int    // Size of the memory
+	offset int    // Start of the changed range
+	data   []byte // Changed range, or the full memory for snapshots
+}
+
+// callFrame tracks the memory of an open call frame.
+type callFrame struct {
+	last   int    // Index of the last step of the frame, -1 if none yet
+	memory []byte // Memory as of the last step
+}
+
+// storageAccess is a read or write of a storage slot during execution.
+type storageAccess struct {
+	step   int // Index of the step performing the access
+	addr   common.Address
+	slot   common.Hash
+	value  common.Hash
+	prev   common.Hash // Value overwritten by writes
+	write  bool
+	revert bool // Write undoing an earlier one, as its frame failed
+}
+
+// pendingLoad is an SLOAD whose result is only known at the next step.
+type pendingLoad struct {
+	step  int
+	depth int
+	addr  common.Address
+	slot  common.Hash
+}
+
+// Recorder collects the execution steps of one or more transactions.
+type Recorder struct {
+	steps     []*Step
+	accesses  []storageAccess
+	txs       int
+	load      *pendingLoad
+	frames    []*callFrame // Open call frames
+	reverting bool         // Whether the state changes of a frame are being undone
+}
+
+// NewRecorder creates an empty execution recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{txs: -1}
+}
+
+// Hooks returns the tracing hooks feeding the recorder. Storage writes are
+// tracked through state change events, which are journaled so that the writes
+// of failed frames are undone.
+func (r *Recorder) Hooks() *tracing.Hooks {
+	hooks, err := tracing.WrapWithJournal(&tracing.Hooks{
+		OnTxStart:       r.onTxStart,
+		OnEnter:         r.onEnter,
+		OnExit:          r.onExit,
+		OnOpcode:        r.onOpcode,
+		OnFault:         r.onFault,
+		OnStorageChange: r.onStorageChange,
+	})
+	if err != nil {
+		panic(err) // only fails on conflicting hooks
+	}
+	// The journal undoes the changes of a failed frame right before its exit
+	// is reported, flag them to tell them apart from regular writes.
+	onExit := hooks.OnExit
+	hooks.OnExit = func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+		r.reverting = reverted
+		onExit(depth, output, gasUsed, err, reverted)
+	}
+	return hooks
+}
+
+// Steps returns the recorded execution steps.
+func (r *Recorder) Steps() []*Step {
+	return r.steps
+}
+
+// Memory returns the memory of the EVM right before executing the given step.
+func (r *Recorder) Memory(step int) []byte {
+	var chain []*memoryRecord
+	for i := step; ; {
+		record := &r.steps[i].memory
+		chain = append(chain, record)
+		if record.base < 0 {
+			break
+		}
+		i = record.base
+	}
+	var mem []byte
+	for i := len(chain) - 1; i >= 0; i-- {
+		if size := chain[i].size; len(mem) < size {
+			mem = append(mem, make([]byte, size-len(mem))...)
+		}
+		copy(mem[chain[i].offset:], chain[i].data)
+	}
+	return mem
+}
+
+func (r *Recorder) onTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	r.txs++
+	r.load = nil
+}
+
+func (r *Recorder) onEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Executions not wrapped in a transaction, e.g. raw code runs, still need
+	// a transaction index.
+	if depth == 0 && r.txs < 0 {
+		r.txs = 0
+	}
+	r.frames = append(r.frames, &callFrame{last: -1})
+}
+
+func (r *Recorder) onExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	r.reverting = false
+	if len(r.frames) > 0 {
+		r.frames = r.frames[:len(r.frames)-1]
+	}
+}
+
+func (r *Recorder) onStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+	r.accesses = append(r.accesses, storageAccess{
+		step:   max(len(r.steps)-1, 0),
+		addr:   addr,
+		slot:   slot,
+		value:  new,
+		prev:   prev,
+		write:  true,
+		revert: r.reverting,
+	})
+}
+
+func (r *Recorder) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	// Resolve the value loaded by a preceding SLOAD in the same frame
+	stack := scope.StackData()
+	if r.load != nil && r.load.depth == depth && len(stack) > 0 {
+		r.accesses = append(r.accesses, storageAccess{
+			step:  r.load.step,
+			addr:  r.load.addr,
+			slot:  r.load.slot,
+			value: stack[len(stack)-1].Bytes32(),
+		})
+	}
+	r.load = nil
+
+	step := &Step{
+		Tx:      max(r.txs, 0),
+		Depth:   depth,
+		Address: scope.Address(),
+		PC:      pc,
+		Op:      vm.OpCode(op),
+		Gas:     gas,
+		Cost:    cost,
+		Stack:   slices.Clone(stack),
+		Err:     err,
+		memory:  r.recordMemory(scope.MemoryData()),
+	}
+	r.steps = append(r.steps, step)
+
+	if step.Op == vm.SLOAD && len(stack) > 0 {
+		r.load = &pendingLoad{
+			step:  len(r.steps) - 1,
+			depth: depth,
+			addr:  step.Address,
+			slot:  stack[len(stack)-1].Bytes32(),
+		}
+	}
+}
+
+// recordMemory records the memory of the next step as the change since the
+// previous step of the same call frame, or as a full snapshot if there is no
+// previous step or too many deltas were recorded in a row.
+func (r *Recorder) recordMemory(mem []byte) memoryRecord {
+	if len(r.frames) == 0 {
+		r.frames = append(r.frames, &callFrame{last: -1})
+	}
+	frame := r.frames[len(r.frames)-1]
+	base := frame.last
+	frame.last = len(r.steps)
+
+	if base < 0 || r.steps[base].memory.deltas >= memorySnapshotInterval {
+		frame.memory = append(frame.memory[:0], mem...)
+		return memoryRecord{base: -1, size: len(mem), data: slices.Clone(mem)}
+	}
+	// The memory only grows within a call frame, with zeroes initially
+	if len(frame.memory) < len(mem) {
+		frame.memory = append(frame.memory, make([]byte, len(mem)-len(frame.memory))...)
+	}
+	start, end := 0, len(mem)
+	for start < end && mem[start] == frame.memory[start] {
+		start++
+	}
+	for end > start && mem[end-1] == frame.memory[end-1] {
+		end--
+	}
+	copy(frame.memory[start:end], mem[start:end])
+
+	record := memoryRecord{
+		base:   base,
+		deltas: r.steps[base].memory.deltas + 1,
+		size:   len(mem),
+	}
+	if start < end {
+		record.offset, record.data = start, slices.Clone(mem[start:end])
+	}
+	return record
+}
+
+func (r *Recorder) onFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if len(r.steps) > 0 {
+		if step := r.steps[len(r.steps)-1]; step.PC == pc && step.Depth == depth && step.Err == nil {
+			step.Err = err
+		}
+	}
+}
+
+// storage returns the known storage slots of the given account before the
+// given step is executed. Slots only become known once read or written.
+func (r *Recorder) storage(addr common.Address, step int) map[common.Hash]storageAccess {
+	slots := make(map[common.Hash]storageAccess)
+	for _, access := range r.accesses {
+		if access.step >= step {
+			break
+		}
+		if access.addr != addr {
+			continue
+		}
+		slots[access.slot] = access
+	}
+	return slots
+}
@@ -0,0 +1,377 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// breakpoint halts execution at steps matching its condition.
+type breakpoint struct {
+	id    int
+	kind  string // "pc", "op" or "depth"
+	pc    uint64
+	op    vm.OpCode
+	depth int
+}
+
+func (b *breakpoint) matches(step *Step) bool {
+	switch b.kind {
+	case "pc":
+		return step.PC == b.pc
+	case "op":
+		return step.Op == b.op
+	case "depth":
+		return step.Depth == b.depth
+	}
+	return false
+}
+
+func (b *breakpoint) String() string {
+	switch b.kind {
+	case "pc":
+		return fmt.Sprintf("#%d pc %d", b.id, b.pc)
+	case "op":
+		return fmt.Sprintf("#%d op %v", b.id, b.op)
+	default:
+		return fmt.Sprintf("#%d depth %d", b.id, b.depth)
+	}
+}
+
+// Session is an interactive debugging session over a recorded execution.
+type Session struct {
+	rec    *Recorder
+	cursor int
+	breaks []*breakpoint
+	nextID int
+	out    io.Writer
+}
+
+// NewSession creates a debugging session positioned at the first step of
+// the recorded execution.
+func NewSession(rec *Recorder) *Session {
+	return &Session{rec: rec, nextID: 1}
+}
+
+const helpText = `Commands:
+  step, s [n]            execute n instructions (default 1)
+  next, n                execute the next instruction, stepping over calls
+  out, o                 run until the current call frame returns
+  continue, c            run until the next breakpoint or the end
+  rstep, rs [n]          step backwards n instructions (default 1)
+  rnext, rn              step backwards, stepping over calls
+  rcontinue, rc          run backwards until the previous breakpoint or the start
+  goto, g <step>         jump to the given step
+  break, b pc|op|depth <value>
+                         add a breakpoint on a program counter, opcode or call depth
+  breakpoints, bl        list breakpoints
+  delete, d <id>         delete a breakpoint
+  where, w               show the current step
+  stack, st              show the stack
+  memory, m [off [len]]  show the memory
+  storage, sto           show the known storage of the current contract
+  help, h                show this help
+  quit, q                leave the debugger
+An empty line repeats the previous command.
+`
+
+// Run reads commands from in and writes their results to out until the input
+// is exhausted or the session is quit.
+func (s *Session) Run(in io.Reader, out io.Writer, interactive bool) error {
+	s.out = out
+	if len(s.rec.steps) == 0 {
+		fmt.Fprintln(out, "No instructions were executed.")
+		return nil
+	}
+	fmt.Fprintf(out, "Recorded %d steps in %d transaction(s). Type 'help' for a list of commands.\n", len(s.rec.steps), s.rec.txs+1)
+	s.where()
+
+	var (
+		scanner = bufio.NewScanner(in)
+		last    string
+	)
+	for {
+		if interactive {
+			fmt.Fprint(out, "(evm) ")
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			line = last
+		}
+		if line == "" {
+			continue
+		}
+		last = line
+		if !interactive {
+			fmt.Fprintf(out, "(evm) %s\n", line)
+		}
+		quit, err := s.execute(strings.Fields(line))
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// execute runs a single command, returning whether the session is over.
+func (s *Session) execute(args []string) (bool, error) {
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "step", "s":
+		n, err := optionalCount(args)
+		if err != nil {
+			return false, err
+		}
+		s.move(min(s.cursor+n, len(s.rec.steps)-1))
+	case "rstep", "rs":
+		n, err := optionalCount(args)
+		if err != nil {
+			return false, err
+		}
+		s.move(max(s.cursor-n, 0))
+	case "next", "n":
+		s.move(s.find(1, func(i int, step *Step) bool { return step.Depth <= s.current().Depth }))
+	case "rnext", "rn":
+		s.move(s.find(-1, func(i int, step *Step) bool { return step.Depth <= s.current().Depth }))
+	case "out", "o":
+		s.move(s.find(1, func(i int, step *Step) bool { return step.Depth < s.current().Depth || step.Tx != s.current().Tx }))
+	case "continue", "c":
+		s.move(s.find(1, func(i int, step *Step) bool { return s.hit(step) }))
+	case "rcontinue", "rc":
+		s.move(s.find(-1, func(i int, step *Step) bool { return s.hit(step) }))
+	case "goto", "g":
+		if len(args) != 1 {
+			return false, errors.New("usage: goto <step>")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 || n >= len(s.rec.steps) {
+			return false, fmt.Errorf("invalid step %q, have %d steps", args[0], len(s.rec.steps))
+		}
+		s.move(n)
+	case "break", "b":
+		return false, s.addBreakpoint(args)
+	case "breakpoints", "bl":
+		if len(s.breaks) == 0 {
+			fmt.Fprintln(s.out, "No breakpoints.")
+		}
+		for _, b := range s.breaks {
+			fmt.Fprintln(s.out, b)
+		}
+	case "delete", "d":
+		if len(args) != 1 {
+			return false, errors.New("usage: delete <id>")
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil {
+			return false, fmt.Errorf("invalid breakpoint id %q", args[0])
+		}
+		idx := slices.IndexFunc(s.breaks, func(b *breakpoint) bool { return b.id == id })
+		if idx < 0 {
+			return false, fmt.Errorf("no breakpoint #%d", id)
+		}
+		s.breaks = slices.Delete(s.breaks, idx, idx+1)
+	case "where", "w":
+		s.where()
+	case "stack", "st":
+		s.printStack()
+	case "memory", "m":
+		return false, s.printMemory(args)
+	case "storage", "sto":
+		s.printStorage()
+	case "help", "h":
+		fmt.Fprint(s.out, helpText)
+	case "quit", "q", "exit":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown command %q, type 'help' for a list of commands", cmd)
+	}
+	return false, nil
+}
+
+func optionalCount(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid count %q", args[0])
+	}
+	return n, nil
+}
+
+func (s *Session) current() *Step {
+	return s.rec.steps[s.cursor]
+}
+
+// hit reports whether the step matches any breakpoint.
+func (s *Session) hit(step *Step) bool {
+	for _, b := range s.breaks {
+		if b.matches(step) {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the first step in the given direction from the cursor which
+// satisfies the condition, or the first or last step if none does.
+func (s *Session) find(dir int, cond func(int, *Step) bool) int {
+	i := s.cursor + dir
+	for ; i >= 0 && i < len(s.rec.steps); i += dir {
+		if cond(i, s.rec.steps[i]) {
+			return i
+		}
+	}
+	return i - dir
+}
+
+// move sets the cursor and prints the new position.
+func (s *Session) move(i int) {
+	if i == s.cursor {
+		if i == 0 {
+			fmt.Fprintln(s.out, "At the start of the execution.")
+		} else if i == len(s.rec.steps)-1 {
+			fmt.Fprintln(s.out, "At the end of the execution.")
+		}
+	}
+	s.cursor = i
+	s.where()
+}
+
+func (s *Session) where() {
+	step := s.current()
+	fmt.Fprintf(s.out, "[%d/%d] tx %d depth %d %v pc %d: %v gas %d cost %d", s.cursor, len(s.rec.steps)-1, step.Tx, step.Depth, step.Address, step.PC, step.Op, step.Gas, step.Cost)
+	if s.hit(step) {
+		fmt.Fprint(s.out, " (breakpoint)")
+	}
+	if step.Err != nil {
+		fmt.Fprintf(s.out, " error: %v", step.Err)
+	}
+	fmt.Fprintln(s.out)
+}
+
+func (s *Session) addBreakpoint(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: break pc|op|depth <value>")
+	}
+	b := &breakpoint{id: s.nextID, kind: args[0]}
+	switch args[0] {
+	case "pc":
+		pc, err := strconv.ParseUint(args[1], 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid pc %q", args[1])
+		}
+		b.pc = pc
+	case "op":
+		op := vm.StringToOp(strings.ToUpper(args[1]))
+		if op == 0 && !strings.EqualFold(args[1], "STOP") {
+			return fmt.Errorf("unknown opcode %q", args[1])
+		}
+		b.op = op
+	case "depth":
+		depth, err := strconv.Atoi(args[1])
+		if err != nil || depth <= 0 {
+			return fmt.Errorf("invalid depth %q", args[1])
+		}
+		b.depth = depth
+	default:
+		return fmt.Errorf("unknown breakpoint type %q", args[0])
+	}
+	s.nextID++
+	s.breaks = append(s.breaks, b)
+	fmt.Fprintf(s.out, "Breakpoint %v\n", b)
+	return nil
+}
+
+func (s *Session) printStack() {
+	stack := s.current().Stack
+	if len(stack) == 0 {
+		fmt.Fprintln(s.out, "Stack is empty.")
+		return
+	}
+	// Print the top of the stack first
+	for i := len(stack) - 1; i >= 0; i-- {
+		fmt.Fprintf(s.out, "%4d: %#x\n", len(stack)-1-i, stack[i].Bytes32())
+	}
+}
+
+func (s *Session) printMemory(args []string) error {
+	mem := s.rec.Memory(s.cursor)
+	var (
+		offset uint64
+		length = uint64(len(mem))
+		err    error
+	)
+	if len(args) > 0 {
+		if offset, err = strconv.ParseUint(args[0], 0, 64); err != nil {
+			return fmt.Errorf("invalid offset %q", args[0])
+		}
+		length = 32
+	}
+	if len(args) > 1 {
+		if length, err = strconv.ParseUint(args[1], 0, 64); err != nil {
+			return fmt.Errorf("invalid length %q", args[1])
+		}
+	}
+	if offset >= uint64(len(mem)) {
+		fmt.Fprintf(s.out, "Memory size is %d bytes.\n", len(mem))
+		return nil
+	}
+	end := min(offset+length, uint64(len(mem)))
+	for i := offset; i < end; i += 32 {
+		fmt.Fprintf(s.out, "%#06x: %x\n", i, mem[i:min(i+32, end)])
+	}
+	return nil
+}
+
+func (s *Session) printStorage() {
+	step := s.current()
+	slots := s.rec.storage(step.Address, s.cursor)
+	if len(slots) == 0 {
+		fmt.Fprintf(s.out, "No known storage slots of %v.\n", step.Address)
+		return
+	}
+	keys := make([]common.Hash, 0, len(slots))
+	for slot := range slots {
+		keys = append(keys, slot)
+	}
+	slices.SortFunc(keys, func(a, b common.Hash) int { return a.Cmp(b) })
+	for _, slot := range keys {
+		access := slots[slot]
+		switch {
+		case access.revert:
+			fmt.Fprintf(s.out, "%#x: %#x (reverted, was %#x)\n", slot, access.value, access.prev)
+		case access.write:
+			fmt.Fprintf(s.out, "%#x: %#x (written, was %#x)\n", slot, access.value, access.prev)
+		default:
+			fmt.Fprintf(s.out, "%#x: %#x (read)\n", slot, access.value)
+		}
+	}
+}
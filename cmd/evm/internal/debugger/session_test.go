@@ -0,0 +1,174 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+// record executes the callee via a caller contract and returns the recording.
+// The caller calls the callee, which stores 2 at slot 1, then reverts.
+func record(t *testing.T) *Recorder {
+	t.Helper()
+
+	var (
+		caller = common.HexToAddress("0xc0")
+		callee = common.HexToAddress("0xca")
+		rec    = NewRecorder()
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.SetCode(callee, []byte{
+		byte(vm.PUSH1), 0x2, byte(vm.PUSH1), 0x1, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.REVERT),
+	}, tracing.CodeChangeUnspecified)
+	statedb.SetCode(caller, []byte{
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+		byte(vm.PUSH1), 0xca, byte(vm.GAS), byte(vm.CALL),
+		byte(vm.PUSH1), 0x1, byte(vm.SLOAD), byte(vm.STOP),
+	}, tracing.CodeChangeUnspecified)
+
+	if _, _, err := runtime.Call(caller, nil, &runtime.Config{State: statedb, EVMConfig: vm.Config{Tracer: rec.Hooks()}}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	return rec
+}
+
+// debug runs the given commands against the recording, returning the output
+// of the last one.
+func debug(t *testing.T, rec *Recorder, commands ...string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := NewSession(rec).Run(strings.NewReader(strings.Join(commands, "\n")), &out, false); err != nil {
+		t.Fatalf("session failed: %v", err)
+	}
+	parts := strings.Split(out.String(), "(evm) ")
+	return parts[len(parts)-1]
+}
+
+func TestSessionStepping(t *testing.T) {
+	rec := record(t)
+	if have := len(rec.Steps()); have != 17 {
+		t.Fatalf("step count mismatch: have %d, want 17", have)
+	}
+	tests := []struct {
+		commands []string
+		want     string
+	}{
+		{[]string{"step 3"}, "[3/16] tx 0 depth 1 0x00000000000000000000000000000000000000C0 pc 4: DUP1"},
+		{[]string{"step 7"}, "[7/16] tx 0 depth 1 0x00000000000000000000000000000000000000C0 pc 9: CALL"},
+		{[]string{"step 7", "next"}, "[14/16] tx 0 depth 1 0x00000000000000000000000000000000000000C0 pc 10: PUSH1"},
+		{[]string{"step 9", "out"}, "[14/16] tx 0 depth 1"},
+		{[]string{"break op sstore", "continue"}, "[10/16] tx 0 depth 2 0x00000000000000000000000000000000000000ca pc 4: SSTORE gas"},
+		{[]string{"break depth 2", "goto 16", "rcontinue"}, "[13/16] tx 0 depth 2 0x00000000000000000000000000000000000000ca pc 8: REVERT"},
+		{[]string{"goto 14", "rnext"}, "[7/16] tx 0 depth 1"},
+		{[]string{"goto 5", "rstep 2"}, "[3/16]"},
+		{[]string{"rstep"}, "At the start of the execution."},
+		{[]string{"goto 17"}, "error: invalid step"},
+		{[]string{"bogus"}, "error: unknown command"},
+	}
+	for i, tt := range tests {
+		if have := debug(t, rec, tt.commands...); !strings.Contains(have, tt.want) {
+			t.Errorf("test %d: output mismatch\nhave: %q\nwant: %q", i, have, tt.want)
+		}
+	}
+}
+
+func TestSessionInspection(t *testing.T) {
+	rec := record(t)
+
+	tests := []struct {
+		commands []string
+		want     string
+	}{
+		{[]string{"goto 7", "stack"}, "   1: 0x" + strings.Repeat("0", 62) + "ca"},
+		{[]string{"goto 11", "storage"}, "0x" + strings.Repeat("0", 63) + "1: 0x" + strings.Repeat("0", 63) + "2 (written, was 0x" + strings.Repeat("0", 64) + ")"},
+		{[]string{"goto 16", "storage"}, "0x" + strings.Repeat("0", 63) + "1: 0x" + strings.Repeat("0", 64) + " (read)"},
+		{[]string{"goto 16", "memory"}, "Memory size is 0 bytes."},
+	}
+	for i, tt := range tests {
+		if have := debug(t, rec, tt.commands...); !strings.Contains(have, tt.want) {
+			t.Errorf("test %d: output mismatch\nhave: %q\nwant: %q", i, have, tt.want)
+		}
+	}
+	// The write of the callee is rolled back with its reverting frame
+	var (
+		callee = common.HexToAddress("0xca")
+		slot   = common.Hash{31: 1}
+	)
+	if slots := rec.storage(callee, 13); slots[slot].value != (common.Hash{31: 2}) || slots[slot].revert {
+		t.Errorf("write missing before revert: %v", slots)
+	}
+	if slots := rec.storage(callee, 14); slots[slot].value != (common.Hash{}) || !slots[slot].revert {
+		t.Errorf("write not rolled back: %v", slots)
+	}
+}
+
+func TestRecorderMemory(t *testing.T) {
+	var (
+		caller = common.HexToAddress("0xc0")
+		callee = common.HexToAddress("0xca")
+		rec    = NewRecorder()
+		hooks  = rec.Hooks()
+		want   [][]byte
+	)
+	// Record the full memory of every step alongside the recorder
+	onOpcode := hooks.OnOpcode
+	hooks.OnOpcode = func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+		want = append(want, slices.Clone(scope.MemoryData()))
+		onOpcode(pc, op, gas, cost, scope, rData, depth, err)
+	}
+	// Both contracts write into their memory across more steps than recorded
+	// in a row without a snapshot, the caller both before and after the call.
+	mstores := func(n int, seed byte) []byte {
+		var code []byte
+		for i := 0; i < n; i++ {
+			code = append(code, byte(vm.PUSH1), seed+byte(i), byte(vm.PUSH1), byte(i*7)%200, byte(vm.MSTORE8))
+		}
+		return code
+	}
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.SetCode(callee, append(mstores(50, 0x80), byte(vm.STOP)), tracing.CodeChangeUnspecified)
+
+	code := mstores(40, 0x01)
+	code = append(code, byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+		byte(vm.PUSH1), 0xca, byte(vm.GAS), byte(vm.CALL))
+	code = append(code, mstores(40, 0x40)...)
+	statedb.SetCode(caller, append(code, byte(vm.STOP)), tracing.CodeChangeUnspecified)
+
+	if _, _, err := runtime.Call(caller, nil, &runtime.Config{State: statedb, EVMConfig: vm.Config{Tracer: hooks}}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if len(rec.Steps()) != len(want) {
+		t.Fatalf("step count mismatch: have %d, want %d", len(rec.Steps()), len(want))
+	}
+	for i := range want {
+		if have := rec.Memory(i); !bytes.Equal(have, want[i]) {
+			t.Fatalf("step %d: memory mismatch\nhave %x\nwant %x", i, have, want[i])
+		}
+	}
+}
@@ -86,12 +86,56 @@ func Transition(ctx *cli.Context) error {
 	if err != nil {
 		return NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
 	}
+	prestate, txIt, chainConfig, vmConfig, err := loadTransition(ctx)
+	if err != nil {
+		return err
+	}
+	// Configure tracer
+	if ctx.IsSet(TraceTracerFlag.Name) { // Custom tracing
+		config := json.RawMessage(ctx.String(TraceTracerConfigFlag.Name))
+		tracer, err := tracers.DefaultDirectory.New(ctx.String(TraceTracerFlag.Name),
+			nil, config, chainConfig)
+		if err != nil {
+			return NewError(ErrorConfig, fmt.Errorf("failed instantiating tracer: %v", err))
+		}
+		vmConfig.Tracer = newResultWriter(baseDir, tracer)
+	} else if ctx.Bool(TraceFlag.Name) { // JSON opcode tracing
+		logConfig := &logger.Config{
+			DisableStack:     ctx.Bool(TraceDisableStackFlag.Name),
+			EnableMemory:     ctx.Bool(TraceEnableMemoryFlag.Name),
+			EnableReturnData: ctx.Bool(TraceEnableReturnDataFlag.Name),
+		}
+		if ctx.Bool(TraceEnableCallFramesFlag.Name) {
+			vmConfig.Tracer = newFileWriter(baseDir, func(out io.Writer) *tracing.Hooks {
+				return logger.NewJSONLoggerWithCallFrames(logConfig, out)
+			})
+		} else {
+			vmConfig.Tracer = newFileWriter(baseDir, func(out io.Writer) *tracing.Hooks {
+				return logger.NewJSONLogger(logConfig, out)
+			})
+		}
+	}
+	// Run the test and aggregate the result
+	s, result, body, err := prestate.Apply(vmConfig, chainConfig, txIt, ctx.Int64(RewardFlag.Name))
+	if err != nil {
+		return err
+	}
+	// Dump the execution result
+	collector := make(Alloc)
+	s.DumpToCollector(collector, nil)
+	return dispatchOutput(ctx, baseDir, result, collector, body)
+}
+
+// loadTransition reads the prestate, environment and transactions of a state
+// transition from the input flags, and constructs the chain configuration.
+func loadTransition(ctx *cli.Context) (*Prestate, txIterator, *params.ChainConfig, vm.Config, error) {
 	// We need to load three things: alloc, env and transactions. May be either in
 	// stdin input or in files.
 	// Check if anything needs to be read from stdin
 	var (
 		prestate Prestate
 		txIt     txIterator // txs to apply
+		err      error
 		allocStr = ctx.String(InputAllocFlag.Name)
 
 		envStr    = ctx.String(InputEnvFlag.Name)
@@ -102,12 +146,12 @@ func Transition(ctx *cli.Context) error {
 	if allocStr == stdinSelector || envStr == stdinSelector || txStr == stdinSelector {
 		decoder := json.NewDecoder(os.Stdin)
 		if err := decoder.Decode(inputData); err != nil {
-			return NewError(ErrorJson, fmt.Errorf("failed unmarshalling stdin: %v", err))
+			return nil, nil, nil, vm.Config{}, NewError(ErrorJson, fmt.Errorf("failed unmarshalling stdin: %v", err))
 		}
 	}
 	if allocStr != stdinSelector {
 		if err := readFile(allocStr, "alloc", &inputData.Alloc); err != nil {
-			return err
+			return nil, nil, nil, vm.Config{}, err
 		}
 	}
 	prestate.Pre = inputData.Alloc
@@ -116,7 +160,7 @@ func Transition(ctx *cli.Context) error {
 	if envStr != stdinSelector {
 		var env stEnv
 		if err := readFile(envStr, "env", &env); err != nil {
-			return err
+			return nil, nil, nil, vm.Config{}, err
 		}
 		inputData.Env = &env
 	}
@@ -126,7 +170,7 @@ func Transition(ctx *cli.Context) error {
 	// Construct the chainconfig
 	var chainConfig *params.ChainConfig
 	if cConf, extraEips, err := tests.GetChainConfig(ctx.String(ForknameFlag.Name)); err != nil {
-		return NewError(ErrorConfig, fmt.Errorf("failed constructing chain configuration: %v", err))
+		return nil, nil, nil, vm.Config{}, NewError(ErrorConfig, fmt.Errorf("failed constructing chain configuration: %v", err))
 	} else {
 		chainConfig = cConf
 		vmConfig.ExtraEips = extraEips
@@ -136,55 +180,33 @@ func Transition(ctx *cli.Context) error {
 	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
 
 	if txIt, err = loadTransactions(txStr, inputData, chainConfig); err != nil {
-		return err
+		return nil, nil, nil, vm.Config{}, err
 	}
 	if err := applyLondonChecks(&prestate.Env, chainConfig); err != nil {
-		return err
+		return nil, nil, nil, vm.Config{}, err
 	}
 	if err := applyShanghaiChecks(&prestate.Env, chainConfig); err != nil {
-		return err
+		return nil, nil, nil, vm.Config{}, err
 	}
 	if err := applyMergeChecks(&prestate.Env, chainConfig); err != nil {
-		return err
+		return nil, nil, nil, vm.Config{}, err
 	}
 	if err := applyCancunChecks(&prestate.Env, chainConfig); err != nil {
-		return err
+		return nil, nil, nil, vm.Config{}, err
 	}
+	return &prestate, txIt, chainConfig, vmConfig, nil
+}
 
-	// Configure tracer
-	if ctx.IsSet(TraceTracerFlag.Name) { // Custom tracing
-		config := json.RawMessage(ctx.String(TraceTracerConfigFlag.Name))
-		tracer, err := tracers.DefaultDirectory.New(ctx.String(TraceTracerFlag.Name),
-			nil, config, chainConfig)
-		if err != nil {
-			return NewError(ErrorConfig, fmt.Errorf("failed instantiating tracer: %v", err))
-		}
-		vmConfig.Tracer = newResultWriter(baseDir, tracer)
-	} else if ctx.Bool(TraceFlag.Name) { // JSON opcode tracing
-		logConfig := &logger.Config{
-			DisableStack:     ctx.Bool(TraceDisableStackFlag.Name),
-			EnableMemory:     ctx.Bool(TraceEnableMemoryFlag.Name),
-			EnableReturnData: ctx.Bool(TraceEnableReturnDataFlag.Name),
-		}
-		if ctx.Bool(TraceEnableCallFramesFlag.Name) {
-			vmConfig.Tracer = newFileWriter(baseDir, func(out io.Writer) *tracing.Hooks {
-				return logger.NewJSONLoggerWithCallFrames(logConfig, out)
-			})
-		} else {
-			vmConfig.Tracer = newFileWriter(baseDir, func(out io.Writer) *tracing.Hooks {
-				return logger.NewJSONLogger(logConfig, out)
-			})
-		}
-	}
-	// Run the test and aggregate the result
-	s, result, body, err := prestate.Apply(vmConfig, chainConfig, txIt, ctx.Int64(RewardFlag.Name))
+// Replay executes the state transition given by the input flags with the
+// given tracer attached, without producing any outputs.
+func Replay(ctx *cli.Context, tracer *tracing.Hooks) error {
+	prestate, txIt, chainConfig, vmConfig, err := loadTransition(ctx)
 	if err != nil {
 		return err
 	}
-	// Dump the execution result
-	collector := make(Alloc)
-	s.DumpToCollector(collector, nil)
-	return dispatchOutput(ctx, baseDir, result, collector, body)
+	vmConfig.Tracer = tracer
+	_, _, _, err = prestate.Apply(vmConfig, chainConfig, txIt, ctx.Int64(RewardFlag.Name))
+	return err
 }
 
 func applyLondonChecks(env *stEnv, chainConfig *params.ChainConfig) error {
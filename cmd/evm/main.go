@@ -18,7 +18,9 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -26,6 +28,8 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/evm/internal/t8ntool"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/eth/tracers/logger"
 	"github.com/ethereum/go-ethereum/internal/debug"
 	"github.com/ethereum/go-ethereum/internal/flags"
@@ -83,7 +87,7 @@ var (
 	}
 	TraceFormatFlag = &cli.StringFlag{
 		Name:     "trace.format",
-		Usage:    "Trace output format to use (json|struct|md)",
+		Usage:    "Trace output format to use (json|struct|md|gasprofile)",
 		Value:    "json",
 		Category: traceCategory,
 	}
@@ -210,6 +214,8 @@ func init() {
 		stateTransitionCommand,
 		transactionCommand,
 		blockBuilderCommand,
+		eofParseCommand,
+		debugCommand,
 	}
 	app.Before = func(ctx *cli.Context) error {
 		flags.MigrateGlobalFlags(ctx)
@@ -245,6 +251,8 @@ func tracerFromFlags(ctx *cli.Context) *tracing.Hooks {
 			return logger.NewJSONLogger(config, os.Stderr)
 		case "md", "markdown":
 			return logger.NewMarkdownLogger(config, os.Stderr).Hooks()
+		case "gasprofile":
+			return newGasProfileLogger(os.Stderr)
 		default:
 			fmt.Fprintf(os.Stderr, "unknown trace format: %q\n", format)
 			os.Exit(1)
@@ -260,6 +268,38 @@ func tracerFromFlags(ctx *cli.Context) *tracing.Hooks {
 	}
 }
 
+// newGasProfileLogger returns the hooks of a gas profiling tracer which writes
+// the folded stacks of every transaction to out, ready to be rendered as a
+// flame graph.
+func newGasProfileLogger(out io.Writer) *tracing.Hooks {
+	tracer, err := tracers.DefaultDirectory.New("gasProfileTracer", new(tracers.Context), nil, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create gas profiler: %v\n", err)
+		os.Exit(1)
+	}
+	hooks := *tracer.Hooks
+	hooks.OnTxEnd = func(receipt *types.Receipt, err error) {
+		tracer.OnTxEnd(receipt, err)
+
+		res, err := tracer.GetResult()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to collect gas profile: %v\n", err)
+			return
+		}
+		var profile struct {
+			Folded []string `json:"folded"`
+		}
+		if err := json.Unmarshal(res, &profile); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to decode gas profile: %v\n", err)
+			return
+		}
+		for _, line := range profile.Folded {
+			fmt.Fprintln(out, line)
+		}
+	}
+	return &hooks
+}
+
 // collectFiles walks the given path. If the path is a directory, it will
 // return a list of all accumulates all files with json extension.
 // Otherwise (if path points to a file), it will return the path.
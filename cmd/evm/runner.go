@@ -197,8 +197,13 @@ func timedExec(bench bool, execFunc func() ([]byte, uint64, error)) ([]byte, exe
 }
 
 func runCmd(ctx *cli.Context) error {
+	return runCode(ctx, tracerFromFlags(ctx))
+}
+
+// runCode executes the code configured by the cli flags, feeding the execution
+// into the given tracer.
+func runCode(ctx *cli.Context, tracer *tracing.Hooks) error {
 	var (
-		tracer      *tracing.Hooks
 		prestate    *state.StateDB
 		chainConfig *params.ChainConfig
 		sender      = common.BytesToAddress([]byte("sender"))
@@ -207,7 +212,6 @@ func runCmd(ctx *cli.Context) error {
 		blobHashes  []common.Hash  // TODO (MariusVanDerWijden) implement blob hashes in state tests
 		blobBaseFee = new(big.Int) // TODO (MariusVanDerWijden) implement blob fee in state tests
 	)
-	tracer = tracerFromFlags(ctx)
 	initialGas := ctx.Uint64(GasFlag.Name)
 	genesisConfig := new(core.Genesis)
 	genesisConfig.GasLimit = initialGas
@@ -26,6 +26,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/tests"
@@ -70,7 +71,7 @@ func stateTestCmd(ctx *cli.Context) error {
 			results   []testResult
 		)
 		for _, fname := range collected {
-			r, err := runStateTest(ctx, fname)
+			r, err := runStateTest(ctx, fname, tracerFromFlags(ctx))
 			if err != nil {
 				return err
 			}
@@ -86,7 +87,7 @@ func stateTestCmd(ctx *cli.Context) error {
 		if len(fname) == 0 {
 			return nil
 		}
-		results, err := runStateTest(ctx, fname)
+		results, err := runStateTest(ctx, fname, tracerFromFlags(ctx))
 		if err != nil {
 			return err
 		}
@@ -96,7 +97,7 @@ func stateTestCmd(ctx *cli.Context) error {
 }
 
 // runStateTest loads the state-test given by fname, and executes the test.
-func runStateTest(ctx *cli.Context, fname string) ([]testResult, error) {
+func runStateTest(ctx *cli.Context, fname string, tracer *tracing.Hooks) ([]testResult, error) {
 	src, err := os.ReadFile(fname)
 	if err != nil {
 		return nil, err
@@ -106,7 +107,7 @@ func runStateTest(ctx *cli.Context, fname string) ([]testResult, error) {
 		return nil, fmt.Errorf("unable to read test file %s: %w", fname, err)
 	}
 
-	cfg := vm.Config{Tracer: tracerFromFlags(ctx)}
+	cfg := vm.Config{Tracer: tracer}
 	re, err := regexp.Compile(ctx.String(RunFlag.Name))
 	if err != nil {
 		return nil, fmt.Errorf("invalid regex -%s: %v", RunFlag.Name, err)
@@ -642,6 +642,15 @@ func TestEvmRun(t *testing.T) {
 			wantStdout: "./testdata/evmrun/8.out.1.txt",
 			wantStderr: "./testdata/evmrun/8.out.2.txt",
 		},
+		{ // debugger session
+			input:      []string{"debug", "run", "--commands", "./testdata/debug/commands.txt", "0x60016001556000546000556002600155"},
+			wantStdout: "./testdata/evmrun/12.out.1.txt",
+		},
+		{ // gas profiling
+			input:      []string{"run", "--trace", "--trace.format=gasprofile", "0x600160015560006000600060006000600161fffff1"},
+			wantStdout: "./testdata/evmrun/11.out.1.txt",
+			wantStderr: "./testdata/evmrun/11.out.2.txt",
+		},
 	} {
 		tt.Logf("args: go run ./cmd/evm %v\n", strings.Join(tc.input, " "))
 		tt.Run("evm-test", tc.input...)
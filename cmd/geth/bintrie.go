@@ -0,0 +1,214 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/overlay"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	bintrieCommand = &cli.Command{
+		Name:  "bintrie",
+		Usage: "A set of experimental binary trie management commands",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "convert",
+				Usage:  "Convert the merkle state of the head block into binary trie",
+				Action: convertBinaryTrie,
+				Flags:  slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth bintrie convert
+Converts the merkle state of the current head block into binary trie, by
+iterating the state snapshot. The conversion can be interrupted and will be
+resumed from where it stopped. If the binary trie is aligned with an older
+state, it's moved to the head state first, or converted from scratch if the
+mutations in between are not available anymore.
+
+The state snapshot must be fully generated and the preimages of all accounts
+and storage slots must be available in the database.
+`,
+			},
+			{
+				Name:   "verify",
+				Usage:  "Verify the converted binary trie against the merkle state",
+				Action: verifyBinaryTrie,
+				Flags:  slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth bintrie verify
+Checks that all the accounts and storage slots of the merkle state which the
+binary trie is aligned with are present in the binary trie.
+`,
+			},
+			{
+				Name:   "status",
+				Usage:  "Show the progress of the binary trie conversion",
+				Action: binaryTrieStatus,
+				Flags:  slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth bintrie status
+Shows the progress of the binary trie conversion, along with the time spent
+and the disk size of the converted binary trie.
+`,
+			},
+		},
+	}
+)
+
+// makeConverter opens the database and creates the binary trie converter. The
+// returned function must be called to release the resources.
+func makeConverter(ctx *cli.Context, readOnly bool) (*overlay.Converter, ethdb.Database, func(), error) {
+	stack, _ := makeConfigNode(ctx)
+	chaindb := utils.MakeChainDatabase(ctx, stack, readOnly)
+	triedb := utils.MakeTrieDatabase(ctx, stack, chaindb, true, readOnly, false)
+	release := func() {
+		triedb.Close()
+		chaindb.Close()
+		stack.Close()
+	}
+	converter, err := overlay.NewConverter(chaindb, triedb)
+	if err != nil {
+		release()
+		return nil, nil, nil, err
+	}
+	return converter, chaindb, release, nil
+}
+
+// abortOnInterrupt returns a channel which is closed if the process receives
+// an interrupt signal, along with the function to stop listening.
+func abortOnInterrupt(op string) (chan struct{}, func()) {
+	var (
+		interrupt = make(chan os.Signal, 1)
+		abort     = make(chan struct{})
+	)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info(fmt.Sprintf("Interrupted during %s, stopping at next batch", op))
+			close(abort)
+		}
+	}()
+	return abort, func() {
+		signal.Stop(interrupt)
+		close(interrupt)
+	}
+}
+
+func convertBinaryTrie(ctx *cli.Context) error {
+	if ctx.NArg() > 0 {
+		return errors.New("too many arguments")
+	}
+	converter, chaindb, release, err := makeConverter(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	head := rawdb.ReadHeadBlock(chaindb)
+	if head == nil {
+		return errors.New("no head block")
+	}
+	abort, stop := abortOnInterrupt("binary trie conversion")
+	defer stop()
+
+	start := time.Now()
+	if err := converter.Convert(head.Root(), head.NumberU64(), abort); err != nil {
+		return err
+	}
+	log.Info("Binary trie conversion finished", "number", head.NumberU64(), "root", head.Root(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return printBinaryTrieStatus(converter, chaindb)
+}
+
+func verifyBinaryTrie(ctx *cli.Context) error {
+	if ctx.NArg() > 0 {
+		return errors.New("too many arguments")
+	}
+	converter, _, release, err := makeConverter(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	abort, stop := abortOnInterrupt("binary trie verification")
+	defer stop()
+
+	return converter.Verify(abort)
+}
+
+func binaryTrieStatus(ctx *cli.Context) error {
+	if ctx.NArg() > 0 {
+		return errors.New("too many arguments")
+	}
+	converter, chaindb, release, err := makeConverter(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return printBinaryTrieStatus(converter, chaindb)
+}
+
+// printBinaryTrieStatus prints the conversion progress and the disk size of
+// the binary trie.
+func printBinaryTrieStatus(converter *overlay.Converter, db ethdb.Database) error {
+	status := converter.Status()
+	if status == nil {
+		fmt.Println("Binary trie conversion is not started")
+		return nil
+	}
+	var (
+		count uint64
+		size  common.StorageSize
+		it    = db.NewIterator(rawdb.BinaryTriePrefix, nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		if rawdb.IsAccountTrieNode(it.Key()[len(rawdb.BinaryTriePrefix):]) {
+			count++
+		}
+		size += common.StorageSize(len(it.Key()) + len(it.Value()))
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	fmt.Printf("Merkle state:      #%d (%#x)\n", status.Number, status.Root)
+	fmt.Printf("Binary trie root:  %#x\n", status.BinaryRoot)
+	fmt.Printf("Finished:          %t\n", status.Done)
+	if !status.Done {
+		fmt.Printf("Marker:            %#x\n", status.Marker)
+	}
+	fmt.Printf("Accounts:          %d\n", status.Accounts)
+	fmt.Printf("Storage slots:     %d\n", status.Slots)
+	fmt.Printf("Conversion time:   %v\n", common.PrettyDuration(status.Elapsed))
+	fmt.Printf("Binary trie nodes: %d\n", count)
+	fmt.Printf("Binary trie size:  %v\n", size)
+	return nil
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
@@ -83,6 +84,8 @@ Remove blockchain and state databases`,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
 			dbInspectHistoryCmd,
+			dbHistoryCmd,
+			dbMigrateAncientsCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -207,6 +210,69 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
 		Description: "This command queries the history of the account or storage slot within the specified block range",
 	}
+	dbHistoryCmd = &cli.Command{
+		Name:  "history",
+		Usage: "Verify and repair the state history and its index (path scheme only)",
+		Subcommands: []*cli.Command{
+			dbHistoryVerifyCmd,
+			dbHistoryReindexCmd,
+			dbHistoryTruncateCmd,
+		},
+	}
+	dbHistoryVerifyCmd = &cli.Command{
+		Action: verifyHistory,
+		Name:   "verify",
+		Usage:  "Verify the state history against the state history index",
+		Flags: slices.Concat([]cli.Flag{
+			&cli.Uint64Flag{
+				Name:  "start",
+				Usage: "block number of the range start, zero means earliest history",
+			},
+			&cli.Uint64Flag{
+				Name:  "end",
+				Usage: "block number of the range end(included), zero means latest history",
+			},
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command walks the state histories within the specified block range, checking
+their linkage and that every mutated state is tracked by the state history index. All
+the index records are also validated structurally. Mismatches are reported but not fixed.`,
+	}
+	dbHistoryReindexCmd = &cli.Command{
+		Action: reindexHistory,
+		Name:   "reindex",
+		Usage:  "Rebuild the state history index from the given block",
+		Flags: slices.Concat([]cli.Flag{
+			&cli.Uint64Flag{
+				Name:  "start",
+				Usage: "block number from which the index is rebuilt, zero means the entire index",
+			},
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command discards the state history index of the histories starting from the
+specified block and rebuilds it from the state history freezer. The entire index is
+rebuilt from scratch if no start block is specified.`,
+	}
+	dbHistoryTruncateCmd = &cli.Command{
+		Action:    truncateHistory,
+		Name:      "truncate",
+		Usage:     "Remove the state histories before the given block",
+		ArgsUsage: "<block number>",
+		Flags:     slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command removes the state histories older than the specified block from the
+state history freezer, the history of the given block is retained as the first one.
+WARNING: Historical states before the given block will no longer be accessible!`,
+	}
+	dbMigrateAncientsCmd = &cli.Command{
+		Action:    migrateAncients,
+		Name:      "migrate-ancients",
+		Usage:     "Convert the chain ancient store into the given storage engine",
+		ArgsUsage: "<file|pebble>",
+		Flags:     slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command copies the chain ancient data (headers, bodies, receipts and
+canonical hashes) into a fresh ancient store backed by the given engine, either
+flat files ('file') or a dedicated Pebble instance ('pebble'). The original ancient
+store is removed once the copy is completed. The migration can be interrupted and
+restarted from scratch. Era1 files are not affected.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -640,6 +706,34 @@ func importLDBdata(ctx *cli.Context) error {
 	return utils.ImportLDBData(db, fName, int64(start), stop)
 }
 
+func migrateAncients(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	engine := ctx.Args().Get(0)
+	if engine != rawdb.AncientEngineFile && engine != rawdb.AncientEnginePebble {
+		return fmt.Errorf("invalid ancient engine %q, allowed 'file' or 'pebble'", engine)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	abort, stop := abortOnInterrupt("ancient store migration")
+	defer stop()
+
+	var (
+		ancient = stack.ResolveAncient("chaindata", ctx.String(utils.AncientFlag.Name))
+		start   = time.Now()
+	)
+	open := func(path string) (ethdb.KeyValueStore, error) {
+		return node.OpenAncientStore(path, "", false)
+	}
+	if err := rawdb.MigrateChainAncients(ancient, engine, open, abort); err != nil {
+		return err
+	}
+	log.Info("Migrated chain ancient store", "engine", engine, "path", ancient, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
 type preimageIterator struct {
 	iter ethdb.Iterator
 }
@@ -862,48 +956,131 @@ func inspectHistory(ctx *cli.Context) error {
 	triedb := utils.MakeTrieDatabase(ctx, stack, db, false, false, false)
 	defer triedb.Close()
 
+	start, end, err := historyRange(ctx, db, triedb)
+	if err != nil {
+		return err
+	}
+	// Inspect the state history.
+	if slot == (common.Hash{}) {
+		return inspectAccount(triedb, start, end, address, ctx.Bool("raw"))
+	}
+	return inspectStorage(triedb, start, end, address, slot, ctx.Bool("raw"))
+}
+
+// historyBlockToID converts the block number to the ID of the associated state
+// history. State histories are identified by state ID rather than block number,
+// the conversion is done by loading the corresponding block header.
+func historyBlockToID(db ethdb.Database, triedb *triedb.Database, blockNumber uint64) (uint64, error) {
+	header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, blockNumber), blockNumber)
+	if header == nil {
+		return 0, fmt.Errorf("block #%d is not existent", blockNumber)
+	}
+	id := rawdb.ReadStateID(db, header.Root)
+	if id == nil {
+		first, last, err := triedb.HistoryRange()
+		if err == nil {
+			return 0, fmt.Errorf("history of block #%d is not existent, available history range: [#%d-#%d]", blockNumber, first, last)
+		}
+		return 0, fmt.Errorf("history of block #%d is not existent", blockNumber)
+	}
+	return *id, nil
+}
+
+// historyRange parses the optional start and end block numbers and converts
+// them into the state history IDs. Zero is returned if the boundary is not
+// specified.
+func historyRange(ctx *cli.Context, db ethdb.Database, triedb *triedb.Database) (uint64, uint64, error) {
 	var (
 		err   error
-		start uint64 // the id of first history object to query
-		end   uint64 // the id (included) of last history object to query
+		start uint64 // the id of first history object
+		end   uint64 // the id (included) of last history object
 	)
-	// State histories are identified by state ID rather than block number.
-	// To address this, load the corresponding block header and perform the
-	// conversion by this function.
-	blockToID := func(blockNumber uint64) (uint64, error) {
-		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, blockNumber), blockNumber)
-		if header == nil {
-			return 0, fmt.Errorf("block #%d is not existent", blockNumber)
-		}
-		id := rawdb.ReadStateID(db, header.Root)
-		if id == nil {
-			first, last, err := triedb.HistoryRange()
-			if err == nil {
-				return 0, fmt.Errorf("history of block #%d is not existent, available history range: [#%d-#%d]", blockNumber, first, last)
-			}
-			return 0, fmt.Errorf("history of block #%d is not existent", blockNumber)
-		}
-		return *id, nil
-	}
-	// Parse the starting block number for inspection.
-	startNumber := ctx.Uint64("start")
-	if startNumber != 0 {
-		start, err = blockToID(startNumber)
+	if number := ctx.Uint64("start"); number != 0 {
+		start, err = historyBlockToID(db, triedb, number)
 		if err != nil {
-			return err
+			return 0, 0, err
 		}
 	}
-	// Parse the ending block number for inspection.
-	endBlock := ctx.Uint64("end")
-	if endBlock != 0 {
-		end, err = blockToID(endBlock)
+	if number := ctx.Uint64("end"); number != 0 {
+		end, err = historyBlockToID(db, triedb, number)
 		if err != nil {
-			return err
+			return 0, 0, err
 		}
 	}
-	// Inspect the state history.
-	if slot == (common.Hash{}) {
-		return inspectAccount(triedb, start, end, address, ctx.Bool("raw"))
+	return start, end, nil
+}
+
+func verifyHistory(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	triedb := utils.MakeTrieDatabase(ctx, stack, db, false, true, false)
+	defer triedb.Close()
+
+	start, end, err := historyRange(ctx, db, triedb)
+	if err != nil {
+		return err
 	}
-	return inspectStorage(triedb, start, end, address, slot, ctx.Bool("raw"))
+	stats, err := triedb.VerifyHistory(start, end)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("State history:\n\trange: [%d-%d]\n\tindexed: %d\n\tindex records: %d\n", stats.First, stats.Last, stats.Indexed, stats.Records)
+	if len(stats.Issues) == 0 {
+		fmt.Println("No issue detected")
+		return nil
+	}
+	for _, issue := range stats.Issues {
+		fmt.Println(issue)
+	}
+	return fmt.Errorf("%d issues detected", len(stats.Issues))
+}
+
+func reindexHistory(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	triedb := utils.MakeTrieDatabase(ctx, stack, db, false, false, false)
+	defer triedb.Close()
+
+	start, _, err := historyRange(ctx, db, triedb)
+	if err != nil {
+		return err
+	}
+	return triedb.ReindexHistory(start)
+}
+
+func truncateHistory(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	number, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	triedb := utils.MakeTrieDatabase(ctx, stack, db, false, false, false)
+	defer triedb.Close()
+
+	id, err := historyBlockToID(db, triedb, number)
+	if err != nil {
+		return err
+	}
+	pruned, err := triedb.TruncateHistory(id)
+	if err != nil {
+		return err
+	}
+	log.Info("Truncated state history", "block", number, "id", id, "pruned", pruned)
+	return nil
 }
@@ -54,6 +54,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/syncer"
 	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/remotedb"
 	"github.com/ethereum/go-ethereum/ethstats"
@@ -68,6 +69,7 @@ import (
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/membership"
 	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
 	"github.com/ethereum/go-ethereum/params"
@@ -106,6 +108,12 @@ var (
 		Value:    node.DefaultConfig.DBEngine,
 		Category: flags.EthCategory,
 	}
+	AncientEngineFlag = &cli.StringFlag{
+		Name:     "db.engine.ancient",
+		Usage:    "Backing storage for the chain ancient data ('file' or 'pebble', default = the existing one or 'file')",
+		Value:    node.DefaultConfig.AncientEngine,
+		Category: flags.EthCategory,
+	}
 	AncientFlag = &flags.DirectoryFlag{
 		Name:     "datadir.ancient",
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
@@ -276,6 +284,17 @@ var (
 		Value:    ethconfig.Defaults.StateHistory,
 		Category: flags.StateCategory,
 	}
+	HistoricStateLimitFlag = &cli.Uint64Flag{
+		Name:     "history.state.serve",
+		Usage:    "Number of recent blocks to serve historical state for from the state history index, only relevant in state.scheme=path and gcmode=archive (0 = all indexed blocks)",
+		Value:    ethconfig.Defaults.HistoricStateLimit,
+		Category: flags.StateCategory,
+	}
+	BinaryConversionFlag = &cli.BoolFlag{
+		Name:     "bintrie.convert",
+		Usage:    "Convert the merkle state into binary trie in the background while following the chain (experimental, requires state.scheme=path)",
+		Category: flags.StateCategory,
+	}
 	TransactionHistoryFlag = &cli.Uint64Flag{
 		Name:     "history.transactions",
 		Usage:    "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
@@ -427,6 +446,11 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolStemFlag = &cli.BoolFlag{
+		Name:     "txpool.stem",
+		Usage:    "Relay locally submitted transactions privately along a random path of peers before broadcasting them",
+		Category: flags.TxPoolCategory,
+	}
 	// Blob transaction pool settings
 	BlobPoolDataDirFlag = &cli.StringFlag{
 		Name:     "blobpool.datadir",
@@ -465,6 +489,11 @@ var (
 		Value:    15,
 		Category: flags.PerfCategory,
 	}
+	CacheTriePersistFlag = &cli.BoolFlag{
+		Name:     "cache.trie.persist",
+		Usage:    "Persist the clean trie and state caches on shutdown and restore them on startup (path scheme only)",
+		Category: flags.PerfCategory,
+	}
 	CacheGCFlag = &cli.IntFlag{
 		Name:     "cache.gc",
 		Usage:    "Percentage of cache memory allowance to use for trie pruning (default = 25% full mode, 0% archive mode)",
@@ -493,6 +522,11 @@ var (
 		Category: flags.PerfCategory,
 		Value:    ethconfig.Defaults.FilterLogCacheSize,
 	}
+	CacheWitnessFlag = &cli.IntFlag{
+		Name:     "cache.witness",
+		Usage:    "Megabytes of memory allocated to witnesses of recent blocks served via the wit protocol (0 = disabled)",
+		Category: flags.PerfCategory,
+	}
 	FDLimitFlag = &cli.IntFlag{
 		Name:     "fdlimit",
 		Usage:    "Raise the open file descriptor resource limit (default = system fd limit)",
@@ -554,6 +588,11 @@ var (
 		Usage:    "Record information useful for VM and contract debugging",
 		Category: flags.VMCategory,
 	}
+	VMProfileFlag = &cli.BoolFlag{
+		Name:     "vmprofile",
+		Usage:    "Sample opcode and precompile execution times, exported as metrics and via debug_opcodeProfile",
+		Category: flags.VMCategory,
+	}
 	VMTraceFlag = &cli.StringFlag{
 		Name:     "vmtrace",
 		Usage:    "Name of tracer which should record internal VM operations (costly)",
@@ -798,7 +837,7 @@ var (
 	}
 	NATFlag = &cli.StringFlag{
 		Name:     "nat",
-		Usage:    "NAT port mapping mechanism (any|none|upnp|pmp|pmp:<IP>|extip:<IP>|stun:<IP:PORT>)",
+		Usage:    "NAT port mapping mechanism (any|none|upnp|pmp|pmp:<IP>|pcp|pcp:<IP>|extip:<IP>|stun:<IP:PORT>)",
 		Value:    "any",
 		Category: flags.NetworkingCategory,
 	}
@@ -837,6 +876,31 @@ var (
 		Value:    30303,
 		Category: flags.NetworkingCategory,
 	}
+	QUICPortFlag = &cli.IntFlag{
+		Name:     "quic.port",
+		Usage:    "UDP port of the experimental QUIC transport for peer connections (disabled if zero)",
+		Category: flags.NetworkingCategory,
+	}
+	NetCaptureFlag = &flags.DirectoryFlag{
+		Name:     "netcapture",
+		Usage:    "Records the message stream of every peer session into capture files in the given directory (for debugging)",
+		Category: flags.NetworkingCategory,
+	}
+	MembershipAdminFlag = &cli.StringFlag{
+		Name:     "membership.admin",
+		Usage:    "Address of the admin key signing the membership list of a permissioned network",
+		Category: flags.NetworkingCategory,
+	}
+	MembershipFileFlag = &cli.StringFlag{
+		Name:     "membership.file",
+		Usage:    "Loads the signed network membership list from the given JSON file",
+		Category: flags.NetworkingCategory,
+	}
+	MembershipContractFlag = &cli.StringFlag{
+		Name:     "membership.contract",
+		Usage:    "Loads the signed network membership list from the contract at the given address",
+		Category: flags.NetworkingCategory,
+	}
 
 	// Console
 	JSpathFlag = &flags.DirectoryFlag{
@@ -985,6 +1049,7 @@ var (
 		EraFlag,
 		RemoteDBFlag,
 		DBEngineFlag,
+		AncientEngineFlag,
 		StateSchemeFlag,
 		HttpHeaderFlag,
 	}
@@ -1125,6 +1190,9 @@ func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.IsSet(DiscoveryPortFlag.Name) {
 		cfg.DiscAddr = fmt.Sprintf(":%d", ctx.Int(DiscoveryPortFlag.Name))
 	}
+	if port := ctx.Int(QUICPortFlag.Name); port != 0 {
+		cfg.QUICListenAddr = fmt.Sprintf(":%d", port)
+	}
 }
 
 // setNAT creates a port mapper from command line flags.
@@ -1336,6 +1404,9 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		}
 		cfg.NetRestrict = list
 	}
+	if ctx.IsSet(NetCaptureFlag.Name) {
+		cfg.CaptureDir = ctx.String(NetCaptureFlag.Name)
+	}
 
 	if ctx.Bool(DeveloperFlag.Name) {
 		// --dev mode can't use p2p networking.
@@ -1395,6 +1466,13 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		log.Info(fmt.Sprintf("Using %s as db engine", dbEngine))
 		cfg.DBEngine = dbEngine
 	}
+	if ctx.IsSet(AncientEngineFlag.Name) {
+		engine := ctx.String(AncientEngineFlag.Name)
+		if engine != rawdb.AncientEngineFile && engine != rawdb.AncientEnginePebble {
+			Fatalf("Invalid choice for db.engine.ancient '%s', allowed 'file' or 'pebble'", engine)
+		}
+		cfg.AncientEngine = engine
+	}
 	// deprecation notice for log debug flags (TODO: find a more appropriate place to put these?)
 	if ctx.IsSet(LogBacktraceAtFlag.Name) {
 		log.Warn("Option --log.backtrace flag is deprecated")
@@ -1569,6 +1647,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setEtherbase(ctx, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
+	if ctx.IsSet(TxPoolStemFlag.Name) {
+		cfg.StemTxs = ctx.Bool(TxPoolStemFlag.Name)
+	}
 	setBlobPool(ctx, &cfg.BlobPool)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
@@ -1638,9 +1719,19 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		cfg.Preimages = true
 		log.Info("Enabling recording of key preimages since archive mode is used")
 	}
+	if ctx.IsSet(BinaryConversionFlag.Name) {
+		cfg.BinaryConversion = ctx.Bool(BinaryConversionFlag.Name)
+	}
+	if cfg.BinaryConversion && !cfg.Preimages {
+		cfg.Preimages = true
+		log.Info("Enabling recording of key preimages since binary trie conversion is enabled")
+	}
 	if ctx.IsSet(StateHistoryFlag.Name) {
 		cfg.StateHistory = ctx.Uint64(StateHistoryFlag.Name)
 	}
+	if ctx.IsSet(HistoricStateLimitFlag.Name) {
+		cfg.HistoricStateLimit = ctx.Uint64(HistoricStateLimitFlag.Name)
+	}
 	if ctx.IsSet(StateSchemeFlag.Name) {
 		cfg.StateScheme = ctx.String(StateSchemeFlag.Name)
 	}
@@ -1674,6 +1765,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheTrieFlag.Name) / 100
 	}
+	if ctx.IsSet(CacheTriePersistFlag.Name) {
+		cfg.TrieCleanPersist = ctx.Bool(CacheTriePersistFlag.Name)
+	}
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheGCFlag.Name) {
 		cfg.TrieDirtyCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheGCFlag.Name) / 100
 	}
@@ -1683,6 +1777,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheLogSizeFlag.Name) {
 		cfg.FilterLogCacheSize = ctx.Int(CacheLogSizeFlag.Name)
 	}
+	if ctx.IsSet(CacheWitnessFlag.Name) {
+		cfg.WitnessCache = ctx.Int(CacheWitnessFlag.Name)
+	}
 	if !ctx.Bool(SnapshotFlag.Name) || cfg.SnapshotCache == 0 {
 		// If snap-sync is requested, this flag is also required
 		if cfg.SyncMode == ethconfig.SnapSync {
@@ -1701,6 +1798,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(VMEnableDebugFlag.Name) {
 		cfg.EnablePreimageRecording = ctx.Bool(VMEnableDebugFlag.Name)
 	}
+	if ctx.IsSet(VMProfileFlag.Name) {
+		cfg.EnableOpcodeProfiling = ctx.Bool(VMProfileFlag.Name)
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -2007,6 +2107,51 @@ func RegisterSyncOverrideService(stack *node.Node, eth *eth.Ethereum, target com
 	syncer.Register(stack, eth, target, exitWhenSynced)
 }
 
+// RegisterMembershipService restricts the peers of the node to the members of
+// the signed network membership list, if configured.
+func RegisterMembershipService(ctx *cli.Context, stack *node.Node) {
+	if !ctx.IsSet(MembershipAdminFlag.Name) {
+		if ctx.IsSet(MembershipFileFlag.Name) || ctx.IsSet(MembershipContractFlag.Name) {
+			Fatalf("Option %q is required to verify the membership list", MembershipAdminFlag.Name)
+		}
+		return
+	}
+	admin := ctx.String(MembershipAdminFlag.Name)
+	if !common.IsHexAddress(admin) {
+		Fatalf("Option %q: invalid address %q", MembershipAdminFlag.Name, admin)
+	}
+	var source membership.Source
+	switch {
+	case ctx.IsSet(MembershipFileFlag.Name) && ctx.IsSet(MembershipContractFlag.Name):
+		Fatalf("Options %q and %q are mutually exclusive", MembershipFileFlag.Name, MembershipContractFlag.Name)
+	case ctx.IsSet(MembershipFileFlag.Name):
+		source = membership.FileSource(ctx.String(MembershipFileFlag.Name))
+	case ctx.IsSet(MembershipContractFlag.Name):
+		addr := ctx.String(MembershipContractFlag.Name)
+		if !common.IsHexAddress(addr) {
+			Fatalf("Option %q: invalid address %q", MembershipContractFlag.Name, addr)
+		}
+		source = membership.NewContractSource(ethclient.NewClient(stack.Attach()), common.HexToAddress(addr))
+	default:
+		Fatalf("Option %q requires %q or %q", MembershipAdminFlag.Name, MembershipFileFlag.Name, MembershipContractFlag.Name)
+	}
+	// Permit the static, trusted and boot nodes until the first list is loaded,
+	// the node can't sync the chain holding the list otherwise.
+	var (
+		srv       = stack.Server()
+		bootstrap []enode.ID
+	)
+	for _, nodes := range [][]*enode.Node{srv.StaticNodes, srv.TrustedNodes, srv.BootstrapNodes, srv.BootstrapNodesV5} {
+		for _, n := range nodes {
+			bootstrap = append(bootstrap, n.ID())
+		}
+	}
+	set := membership.NewSet(common.HexToAddress(admin), bootstrap)
+	srv.Membership = set
+	stack.RegisterLifecycle(membership.NewWatcher(set, source, membership.DefaultReloadInterval))
+	log.Info("Registered network membership service", "admin", admin, "source", source)
+}
+
 // SetupMetrics configures the metrics system.
 func SetupMetrics(cfg *metrics.Config) {
 	if !cfg.Enabled {
@@ -2231,6 +2376,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 	}
 	vmcfg := vm.Config{
 		EnablePreimageRecording: ctx.Bool(VMEnableDebugFlag.Name),
+		EnableOpcodeProfiling:   ctx.Bool(VMProfileFlag.Name),
 	}
 	if ctx.IsSet(VMTraceFlag.Name) {
 		if name := ctx.String(VMTraceFlag.Name); name != "" {
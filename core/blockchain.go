@@ -167,6 +167,7 @@ type BlockChainConfig struct {
 	TrieTimeLimit        time.Duration // Time limit after which to flush the current in-memory trie to disk
 	TrieNoAsyncFlush     bool          // Whether the asynchronous buffer flushing is disallowed
 	TrieJournalDirectory string        // Directory path to the journal used for persisting trie data across node restarts
+	TrieCleanPersist     bool          // Whether to persist the clean caches into the journal directory across node restarts
 
 	Preimages   bool   // Whether to store preimage of trie key to the disk
 	StateScheme string // Scheme used to store ethereum states and merkle tree nodes on top
@@ -196,6 +197,12 @@ type BlockChainConfig struct {
 	// If the value is zero, all transactions of the entire chain will be indexed.
 	// If the value is -1, indexing is disabled.
 	TxLookupLimit int64
+
+	// WitnessCacheLimit is the memory allowance (MB) for retaining the witnesses
+	// of recently imported blocks to serve them to other nodes. Witnesses are
+	// only created when blocks are imported one by one, i.e. when following the
+	// chain head. If set to 0, no witnesses are retained.
+	WitnessCacheLimit int
 }
 
 // DefaultConfig returns the default config.
@@ -251,6 +258,7 @@ func (cfg *BlockChainConfig) triedbConfig(isVerkle bool) *triedb.Config {
 			TrieCleanSize:       cfg.TrieCleanLimit * 1024 * 1024,
 			StateCleanSize:      cfg.SnapshotLimit * 1024 * 1024,
 			JournalDirectory:    cfg.TrieJournalDirectory,
+			CleanCacheJournal:   cfg.TrieCleanPersist,
 
 			// TODO(rjl493456442): The write buffer represents the memory limit used
 			// for flushing both trie data and state data to disk. The config name
@@ -325,6 +333,8 @@ type BlockChain struct {
 	txLookupLock  sync.RWMutex
 	txLookupCache *lru.Cache[common.Hash, txLookup]
 
+	witnessCache *lru.SizeConstrainedCache[common.Hash, []byte] // RLP encoded witnesses of recent blocks, nil if disabled
+
 	stopping      atomic.Bool // false if chain is running, true when stopped
 	procInterrupt atomic.Bool // interrupt signaler for block processing
 
@@ -360,6 +370,9 @@ func NewBlockChain(db ethdb.Database, genesis *Genesis, engine consensus.Engine,
 	if err != nil {
 		return nil, err
 	}
+	if err := vm.ValidatePrecompiles(chainConfig); err != nil {
+		return nil, err
+	}
 	log.Info("")
 	log.Info(strings.Repeat("-", 153))
 	for _, line := range strings.Split(chainConfig.Description(), "\n") {
@@ -383,6 +396,9 @@ func NewBlockChain(db ethdb.Database, genesis *Genesis, engine consensus.Engine,
 		engine:        engine,
 		logger:        cfg.VmConfig.Tracer,
 	}
+	if cfg.WitnessCacheLimit > 0 {
+		bc.witnessCache = lru.NewSizeConstrainedCache[common.Hash, []byte](uint64(cfg.WitnessCacheLimit) * 1024 * 1024)
+	}
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.insertStopped)
 	if err != nil {
 		return nil, err
@@ -1884,7 +1900,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 		}
 		// The traced section of block import.
 		start := time.Now()
-		res, err := bc.processBlock(parent.Root, block, setHead, makeWitness && len(chain) == 1)
+		res, err := bc.processBlock(parent.Root, block, setHead, (makeWitness || bc.witnessCache != nil) && len(chain) == 1)
 		if err != nil {
 			return nil, it.index, err
 		}
@@ -2132,6 +2148,14 @@ func (bc *BlockChain) processBlock(parentRoot common.Hash, block *types.Block, s
 	if witnessStats != nil {
 		witnessStats.ReportMetrics()
 	}
+	// Retain the witness for serving it to other nodes
+	if witness != nil && bc.witnessCache != nil {
+		if enc, err := rlp.EncodeToBytes(witness); err != nil {
+			log.Warn("Failed to encode block witness", "number", block.Number(), "hash", block.Hash(), "err", err)
+		} else {
+			bc.witnessCache.Add(block.Hash(), enc)
+		}
+	}
 
 	// Update the metrics touched during block commit
 	accountCommitTimer.Update(statedb.AccountCommits)   // Account commits are complete, we can mark them
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -143,6 +144,16 @@ func (bc *BlockChain) GetBodyRLP(hash common.Hash) rlp.RawValue {
 	return body
 }
 
+// GetWitness retrieves the RLP encoded execution witness of a recently imported
+// block from the witness cache, or nil if it's not retained.
+func (bc *BlockChain) GetWitness(hash common.Hash) []byte {
+	if bc.witnessCache == nil {
+		return nil
+	}
+	witness, _ := bc.witnessCache.Get(hash)
+	return witness
+}
+
 // HasBlock checks if a block is fully present in the database or not.
 func (bc *BlockChain) HasBlock(hash common.Hash, number uint64) bool {
 	if bc.blockCache.Contains(hash) {
@@ -487,6 +498,17 @@ func (bc *BlockChain) HistoryPruningCutoff() (uint64, common.Hash) {
 	return pt.BlockNumber, pt.BlockHash
 }
 
+// HistoryServingCutoff returns the first block whose bodies and receipts can be
+// retrieved locally. This is the history pruning point, unless the pruned chain
+// segment is fully covered by the era1 archives of the database.
+func (bc *BlockChain) HistoryServingCutoff() uint64 {
+	cutoff, _ := bc.HistoryPruningCutoff()
+	if db, ok := bc.db.(ethdb.EraReader); ok && cutoff > 0 && db.EraAvailable() >= cutoff {
+		return 0
+	}
+	return cutoff
+}
+
 // TrieDB retrieves the low level trie database used for data storage.
 func (bc *BlockChain) TrieDB() *triedb.Database {
 	return bc.triedb
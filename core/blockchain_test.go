@@ -25,6 +25,7 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"testing"
@@ -45,6 +46,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/tracers/logger"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/holiman/uint256"
@@ -4515,3 +4517,56 @@ func TestGetCanonicalReceipt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that history pruned from the ancient store is reported as servable if
+// the era1 archives of a node database cover it.
+func TestHistoryServingCutoffEra(t *testing.T) {
+	gspec := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}
+	ghash := gspec.ToBlock().Hash()
+	history.PrunePoints[ghash] = &history.PrunePoint{BlockNumber: 8192, BlockHash: common.Hash{0x01}}
+	defer delete(history.PrunePoints, ghash)
+
+	// The test era1 file covers the first 8192 blocks, which is exactly the
+	// pruned range of the chain.
+	era, err := os.ReadFile("rawdb/eradb/testdata/sepolia-00000-643a00f7.era1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, withEra := range []bool{false, true} {
+		stack, err := node.New(&node.Config{DataDir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		eraDir := filepath.Join(stack.Config().DataDir, "era")
+		if withEra {
+			if err := os.MkdirAll(eraDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(eraDir, "sepolia-00000-643a00f7.era1"), era, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		db, err := stack.OpenDatabaseWithOptions("chaindata", node.DatabaseOptions{EraDirectory: eraDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := DefaultConfig()
+		config.ChainHistoryMode = history.KeepPostMerge
+		chain, err := NewBlockChain(db, gspec, ethash.NewFaker(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := uint64(8192)
+		if withEra {
+			want = 0
+		}
+		if cutoff := chain.HistoryServingCutoff(); cutoff != want {
+			t.Errorf("era %v: serving cutoff mismatch: have %d, want %d", withEra, cutoff, want)
+		}
+		chain.Stop()
+		stack.Close()
+	}
+}
@@ -0,0 +1,795 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/bintrie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/database"
+	"github.com/holiman/uint256"
+)
+
+var (
+	// conversionBatchSize is the number of states converted before the binary
+	// trie is committed and the progress is persisted.
+	conversionBatchSize = 10000
+
+	// conversionRecheck is the time interval for checking the chain head once
+	// the initial conversion is finished, or for retrying a failed conversion.
+	conversionRecheck = 3 * time.Second
+
+	// errMissingPreimage is returned if the preimage of an account or storage
+	// slot is not available. The conversion relies on the preimages recorded
+	// since the state was created, as the flat states are keyed by hash.
+	errMissingPreimage = errors.New("missing preimage")
+
+	// errConversionAborted is returned if the conversion is aborted.
+	errConversionAborted = errors.New("conversion aborted")
+
+	// errConversionNotDone is returned if the conversion is verified before
+	// the initial conversion is finished.
+	errConversionNotDone = errors.New("conversion is not finished")
+)
+
+// ConversionStatus is the progress of the binary trie conversion.
+//
+// The conversion migrates the states in the order of account hash and storage
+// slot hash. All the states up to and including the marker are converted and
+// aligned with the merkle state specified by the root, while the remaining
+// ones are converted by iterating the state snapshot afterwards.
+type ConversionStatus struct {
+	Root       common.Hash // Merkle state root the binary trie is aligned with
+	Number     uint64      // Block number of the aligned merkle state
+	BinaryRoot common.Hash // Root hash of the binary trie
+	Marker     []byte      // Last converted key, account hash optionally followed by slot hash
+	Done       bool        // Flag whether the initial conversion is finished
+	Accounts   uint64      // Number of accounts converted in the initial conversion
+	Slots      uint64      // Number of storage slots converted in the initial conversion
+	Elapsed    uint64      // Time spent on the initial conversion, in nanoseconds
+}
+
+// converted reports whether the state identified by the given key, either the
+// account hash or the account hash followed by the slot hash, is converted.
+func (s *ConversionStatus) converted(key []byte) bool {
+	return s.Done || bytes.Compare(key, s.Marker) <= 0
+}
+
+// nodeDatabase is the path-based node store of the binary trie, implementing
+// the database.NodeDatabase interface. Only the latest version of the trie is
+// maintained, the node is always resolved regardless of the requested state.
+type nodeDatabase struct {
+	db ethdb.KeyValueReader
+}
+
+// NodeReader implements database.NodeDatabase, returning the reader of the
+// binary trie.
+func (db *nodeDatabase) NodeReader(root common.Hash) (database.NodeReader, error) {
+	return db, nil
+}
+
+// Node implements database.NodeReader, retrieving the node with the given path.
+func (db *nodeDatabase) Node(owner common.Hash, path []byte, hash common.Hash) ([]byte, error) {
+	return rawdb.ReadAccountTrieNode(db.db, path), nil
+}
+
+// Converter migrates the merkle state into a binary trie (EIP-7864). The initial
+// conversion is performed by iterating the state snapshot, and the states mutated
+// by the new blocks are applied afterwards, keeping the binary trie aligned with
+// the chain head.
+//
+// The binary trie is stored in a dedicated namespace of the key-value store along
+// with the conversion progress, allowing the conversion to be resumed across
+// restarts.
+type Converter struct {
+	disk   ethdb.KeyValueReader // Key-value store for resolving preimages and contract code
+	source *triedb.Database     // Path-based database holding the merkle states
+	db     ethdb.Database       // Key-value store holding the binary trie
+	nodes  *nodeDatabase        // Node store of the binary trie
+
+	status *ConversionStatus // Conversion progress, nil if not started yet
+	lock   sync.RWMutex      // Lock for protecting the status
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewConverter creates a converter for migrating the merkle state into a binary
+// trie, resuming the previous conversion if it's present in the database.
+func NewConverter(disk ethdb.Database, source *triedb.Database) (*Converter, error) {
+	if source.Scheme() != rawdb.PathScheme {
+		return nil, errors.New("binary trie conversion requires path-based state scheme")
+	}
+	if source.IsVerkle() {
+		return nil, errors.New("binary trie conversion requires merkle state")
+	}
+	db := rawdb.NewTable(disk, string(rawdb.BinaryTriePrefix))
+	c := &Converter{
+		disk:   disk,
+		source: source,
+		db:     db,
+		nodes:  &nodeDatabase{db: db},
+	}
+	if blob := rawdb.ReadBinaryConversionStatus(db); len(blob) > 0 {
+		var status ConversionStatus
+		if err := rlp.DecodeBytes(blob, &status); err != nil {
+			return nil, fmt.Errorf("failed to decode conversion status: %w", err)
+		}
+		c.status = &status
+	}
+	return c, nil
+}
+
+// Status returns the conversion progress, nil is returned if the conversion is
+// not started yet.
+func (c *Converter) Status() *ConversionStatus {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.status == nil {
+		return nil
+	}
+	status := *c.status
+	status.Marker = common.CopyBytes(c.status.Marker)
+	return &status
+}
+
+// Convert converts the specified merkle state into the binary trie, blocking
+// until the initial conversion is finished or aborted. If a previous conversion
+// is aligned with another state, it will be moved to the given one first.
+func (c *Converter) Convert(root common.Hash, number uint64, abort <-chan struct{}) error {
+	head := func() (common.Hash, uint64) { return root, number }
+	for {
+		select {
+		case <-abort:
+			return errConversionAborted
+		default:
+		}
+		done, err := c.step(head)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// Start launches the conversion in the background, following the chain head
+// returned by the given function. The conversion is retried if it fails,
+// e.g. the state being converted becomes unavailable.
+func (c *Converter) Start(head func() *types.Header) {
+	c.closeCh = make(chan struct{})
+	c.wg.Add(1)
+	go c.loop(func() (common.Hash, uint64) {
+		header := head()
+		return header.Root, header.Number.Uint64()
+	})
+}
+
+// Stop terminates the background conversion.
+func (c *Converter) Stop() {
+	if c.closeCh == nil {
+		return
+	}
+	close(c.closeCh)
+	c.wg.Wait()
+}
+
+// loop is the background conversion loop.
+func (c *Converter) loop(head func() (common.Hash, uint64)) {
+	defer c.wg.Done()
+
+	log.Info("Started binary trie conversion")
+	for {
+		done, err := c.step(head)
+		if err != nil {
+			log.Warn("Binary trie conversion failed, retrying", "err", err)
+		}
+		// Keep converting if the initial conversion is in progress, otherwise
+		// wait for a while before checking the chain head again.
+		if err == nil && !done {
+			select {
+			case <-c.closeCh:
+				return
+			default:
+			}
+			continue
+		}
+		select {
+		case <-c.closeCh:
+			return
+		case <-time.After(conversionRecheck):
+		}
+	}
+}
+
+// step performs a single conversion step: the binary trie is aligned with the
+// state returned by head first, and then a batch of states are converted if
+// the initial conversion is not finished. The flag whether the initial
+// conversion is finished is returned.
+func (c *Converter) step(head func() (common.Hash, uint64)) (bool, error) {
+	root, number := head()
+
+	// Ensure the specified state is available before the conversion, it might
+	// not be the case if the node is still syncing.
+	if _, err := c.source.StateReader(root); err != nil {
+		return false, err
+	}
+	if c.status == nil {
+		c.setStatus(&ConversionStatus{
+			Root:       root,
+			Number:     number,
+			BinaryRoot: types.EmptyBinaryHash,
+		})
+	}
+	if c.status.Root != root {
+		if err := c.advance(root, number); err != nil {
+			// The binary trie can't be aligned with the new state, e.g. the
+			// state histories have been pruned. Restart the conversion from
+			// scratch.
+			log.Warn("Restarting binary trie conversion", "root", root, "number", number, "err", err)
+			return false, c.reset()
+		}
+	}
+	if c.status.Done {
+		return true, nil
+	}
+	if err := c.convert(); err != nil {
+		return false, err
+	}
+	return c.status.Done, nil
+}
+
+// setStatus updates the conversion progress.
+func (c *Converter) setStatus(status *ConversionStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.status = status
+}
+
+// preimage resolves the preimage of the given hash. The preimages which are
+// not flushed yet are also resolved if the preimage recording is enabled.
+func (c *Converter) preimage(hash common.Hash) []byte {
+	if c.source.PreimageEnabled() {
+		return c.source.Preimage(hash)
+	}
+	return rawdb.ReadPreimage(c.disk, hash)
+}
+
+// address resolves the account address with the given hash.
+func (c *Converter) address(hash common.Hash) (common.Address, error) {
+	blob := c.preimage(hash)
+	if len(blob) != common.AddressLength {
+		return common.Address{}, fmt.Errorf("%w for account %#x", errMissingPreimage, hash)
+	}
+	return common.BytesToAddress(blob), nil
+}
+
+// slotKey resolves the storage slot key with the given hash.
+func (c *Converter) slotKey(hash common.Hash) (common.Hash, error) {
+	blob := c.preimage(hash)
+	if len(blob) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("%w for storage slot %#x", errMissingPreimage, hash)
+	}
+	return common.BytesToHash(blob), nil
+}
+
+// updateAccount writes the account into the binary trie, along with the
+// contract code if it's required.
+func (c *Converter) updateAccount(tr *bintrie.BinaryTrie, addr common.Address, account *types.StateAccount, withCode bool) error {
+	var code []byte
+	if !bytes.Equal(account.CodeHash, types.EmptyCodeHash.Bytes()) {
+		code = rawdb.ReadCode(c.disk, common.BytesToHash(account.CodeHash))
+		if len(code) == 0 {
+			return fmt.Errorf("missing code %#x of account %#x", account.CodeHash, addr)
+		}
+	}
+	if err := tr.UpdateAccount(addr, account, len(code)); err != nil {
+		return err
+	}
+	if withCode && len(code) != 0 {
+		return tr.UpdateContractCode(addr, common.BytesToHash(account.CodeHash), code)
+	}
+	return nil
+}
+
+// clearCode overwrites the code chunks of the given code with zeroes, as the
+// binary trie has no notion of deletion.
+func (c *Converter) clearCode(tr *bintrie.BinaryTrie, addr common.Address, codeHash []byte) error {
+	if len(codeHash) == 0 || bytes.Equal(codeHash, types.EmptyCodeHash.Bytes()) || common.BytesToHash(codeHash) == (common.Hash{}) {
+		return nil
+	}
+	code := rawdb.ReadCode(c.disk, common.BytesToHash(codeHash))
+	if len(code) == 0 {
+		return fmt.Errorf("missing code %#x of account %#x", codeHash, addr)
+	}
+	return tr.UpdateContractCode(addr, common.Hash{}, make([]byte, len(code)))
+}
+
+// deleteAccount marks the account as deleted in the binary trie by zeroing its
+// basic data, code hash and code chunks. The storage slots of deleted accounts
+// are part of the mutation set, they are zeroed separately.
+func (c *Converter) deleteAccount(tr *bintrie.BinaryTrie, addr common.Address) error {
+	prev, err := tr.GetAccount(addr)
+	if err != nil {
+		return err
+	}
+	if deletedAccount(prev) {
+		return nil
+	}
+	if err := c.clearCode(tr, addr, prev.CodeHash); err != nil {
+		return err
+	}
+	values := make([][]byte, bintrie.NodeWidth)
+	values[bintrie.BasicDataLeafKey] = make([]byte, common.HashLength)
+	values[bintrie.CodeHashLeafKey] = make([]byte, common.HashLength)
+	return tr.UpdateStem(bintrie.GetBinaryTreeKey(addr, make([]byte, common.HashLength))[:31], values)
+}
+
+// deletedAccount reports whether the account read from the binary trie is
+// absent or has been deleted. Deleted accounts are left with a zero code hash,
+// which no live account can have.
+func deletedAccount(account *types.StateAccount) bool {
+	return account == nil || common.BytesToHash(account.CodeHash) == (common.Hash{})
+}
+
+// updateStorage writes the storage slot into the binary trie. The slot value
+// is expected to be RLP-encoded as it's stored in the state snapshot.
+func (c *Converter) updateStorage(tr *bintrie.BinaryTrie, addr common.Address, key common.Hash, blob []byte) error {
+	if len(blob) == 0 {
+		return tr.DeleteStorage(addr, key.Bytes())
+	}
+	_, value, _, err := rlp.Split(blob)
+	if err != nil {
+		return err
+	}
+	return tr.UpdateStorage(addr, key.Bytes(), value)
+}
+
+// commit flushes the binary trie changes into the database along with the given
+// conversion progress atomically.
+func (c *Converter) commit(tr *bintrie.BinaryTrie, status *ConversionStatus) error {
+	root, nodes := tr.Commit(false)
+	status.BinaryRoot = root
+
+	batch := c.db.NewBatch()
+	for path, n := range nodes.Nodes {
+		if n.IsDeleted() {
+			rawdb.DeleteAccountTrieNode(batch, []byte(path))
+		} else {
+			rawdb.WriteAccountTrieNode(batch, []byte(path), n.Blob)
+		}
+	}
+	blob, err := rlp.EncodeToBytes(status)
+	if err != nil {
+		return err
+	}
+	rawdb.WriteBinaryConversionStatus(batch, blob)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	c.setStatus(status)
+	return nil
+}
+
+// convert iterates the state snapshot from the marker and converts a batch of
+// states into the binary trie.
+func (c *Converter) convert() error {
+	tr, err := bintrie.NewBinaryTrie(c.status.BinaryRoot, c.nodes)
+	if err != nil {
+		return err
+	}
+	var (
+		start  = time.Now()
+		status = c.Status()
+		count  int
+		seek   common.Hash
+	)
+	if len(status.Marker) >= common.HashLength {
+		seek = common.BytesToHash(status.Marker[:common.HashLength])
+	}
+	acctIt, err := c.source.AccountIterator(status.Root, seek)
+	if err != nil {
+		return err
+	}
+	defer acctIt.Release()
+
+	for acctIt.Next() {
+		hash := acctIt.Hash()
+		account, err := types.FullAccount(acctIt.Account())
+		if err != nil {
+			return err
+		}
+		addr, err := c.address(hash)
+		if err != nil {
+			return err
+		}
+		if !status.converted(hash.Bytes()) {
+			if err := c.updateAccount(tr, addr, account, true); err != nil {
+				return err
+			}
+			status.Marker = hash.Bytes()
+			status.Accounts++
+			count++
+		}
+		if account.Root != types.EmptyRootHash {
+			var slotSeek common.Hash
+			if len(status.Marker) == 2*common.HashLength && bytes.Equal(status.Marker[:common.HashLength], hash.Bytes()) {
+				slotSeek = common.BytesToHash(status.Marker[common.HashLength:])
+			}
+			storageIt, err := c.source.StorageIterator(status.Root, hash, slotSeek)
+			if err != nil {
+				return err
+			}
+			for storageIt.Next() {
+				key := append(hash.Bytes(), storageIt.Hash().Bytes()...)
+				if status.converted(key) {
+					continue
+				}
+				slot, err := c.slotKey(storageIt.Hash())
+				if err != nil {
+					storageIt.Release()
+					return err
+				}
+				if err := c.updateStorage(tr, addr, slot, storageIt.Slot()); err != nil {
+					storageIt.Release()
+					return err
+				}
+				status.Marker = key
+				status.Slots++
+				count++
+
+				if count >= conversionBatchSize {
+					break
+				}
+			}
+			err = storageIt.Error()
+			storageIt.Release()
+			if err != nil {
+				return err
+			}
+		}
+		if count >= conversionBatchSize {
+			status.Elapsed += uint64(time.Since(start))
+			if err := c.commit(tr, status); err != nil {
+				return err
+			}
+			log.Info("Converting state into binary trie", "accounts", status.Accounts, "slots", status.Slots,
+				"marker", common.Bytes2Hex(status.Marker), "elapsed", common.PrettyDuration(status.Elapsed))
+			return nil
+		}
+	}
+	if err := acctIt.Error(); err != nil {
+		return err
+	}
+	status.Done = true
+	status.Elapsed += uint64(time.Since(start))
+	if err := c.commit(tr, status); err != nil {
+		return err
+	}
+	log.Info("Converted state into binary trie", "root", status.BinaryRoot, "number", status.Number,
+		"accounts", status.Accounts, "slots", status.Slots, "elapsed", common.PrettyDuration(status.Elapsed))
+	return nil
+}
+
+// advance aligns the binary trie with the specified merkle state, by applying
+// the states mutated since the aligned one. Only the states that have been
+// converted are applied, the remaining ones will be converted from the new
+// state later.
+func (c *Converter) advance(root common.Hash, number uint64) error {
+	status := c.Status()
+	set, err := c.source.MutatedStates(status.Root, root)
+	if err != nil {
+		return err
+	}
+	reader, err := c.source.StateReader(root)
+	if err != nil {
+		return err
+	}
+	tr, err := bintrie.NewBinaryTrie(status.BinaryRoot, c.nodes)
+	if err != nil {
+		return err
+	}
+	for addr := range set.Accounts {
+		hash := crypto.Keccak256Hash(addr.Bytes())
+		if !status.converted(hash.Bytes()) {
+			continue
+		}
+		slim, err := reader.Account(hash)
+		if err != nil {
+			return err
+		}
+		if slim == nil {
+			if err := c.deleteAccount(tr, addr); err != nil {
+				return err
+			}
+			continue
+		}
+		account := fullAccount(slim)
+		prev, err := tr.GetAccount(addr)
+		if err != nil {
+			return err
+		}
+		// Wipe the stale code chunks if the code is replaced, the new code
+		// might be shorter than the old one.
+		codeChanged := deletedAccount(prev) || !bytes.Equal(prev.CodeHash, account.CodeHash)
+		if codeChanged && !deletedAccount(prev) {
+			if err := c.clearCode(tr, addr, prev.CodeHash); err != nil {
+				return err
+			}
+		}
+		if err := c.updateAccount(tr, addr, account, codeChanged); err != nil {
+			return err
+		}
+	}
+	update := func(addr common.Address, hash common.Hash, key common.Hash, slotHash common.Hash) error {
+		blob, err := reader.Storage(hash, slotHash)
+		if err != nil {
+			return err
+		}
+		return c.updateStorage(tr, addr, key, blob)
+	}
+	for addr, slots := range set.Storages {
+		hash := crypto.Keccak256Hash(addr.Bytes())
+		for key := range slots {
+			slotHash := crypto.Keccak256Hash(key.Bytes())
+			if !status.converted(append(hash.Bytes(), slotHash.Bytes()...)) {
+				continue
+			}
+			if err := update(addr, hash, key, slotHash); err != nil {
+				return err
+			}
+		}
+	}
+	for addr, slots := range set.HashedStorages {
+		hash := crypto.Keccak256Hash(addr.Bytes())
+		for slotHash := range slots {
+			if !status.converted(append(hash.Bytes(), slotHash.Bytes()...)) {
+				continue
+			}
+			key, err := c.slotKey(slotHash)
+			if err != nil {
+				return err
+			}
+			if err := update(addr, hash, key, slotHash); err != nil {
+				return err
+			}
+		}
+	}
+	status.Root, status.Number = root, number
+	if err := c.commit(tr, status); err != nil {
+		return err
+	}
+	log.Debug("Aligned binary trie with new state", "root", root, "number", number, "binary", status.BinaryRoot)
+	return nil
+}
+
+// reset wipes out the binary trie along with the conversion progress.
+func (c *Converter) reset() error {
+	var (
+		batch = c.db.NewBatch()
+		it    = c.db.NewIterator(rawdb.TrieNodeAccountPrefix, nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		if err := batch.Delete(it.Key()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	rawdb.DeleteBinaryConversionStatus(batch)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	c.setStatus(nil)
+	return nil
+}
+
+// Verify checks that all the states of the aligned merkle state are present in
+// the binary trie, and that the binary trie holds no other states. The initial
+// conversion must be finished before verifying.
+func (c *Converter) Verify(abort <-chan struct{}) error {
+	status := c.Status()
+	if status == nil || !status.Done {
+		return errConversionNotDone
+	}
+	tr, err := bintrie.NewBinaryTrie(status.BinaryRoot, c.nodes)
+	if err != nil {
+		return err
+	}
+	acctIt, err := c.source.AccountIterator(status.Root, common.Hash{})
+	if err != nil {
+		return err
+	}
+	defer acctIt.Release()
+
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		accounts uint64
+		slots    uint64
+		leaves   uint64                         // Number of non-zero leaves expected in the binary trie
+		chunks   = make(map[common.Hash]uint64) // Number of non-zero code chunks per code
+	)
+	for acctIt.Next() {
+		select {
+		case <-abort:
+			return errConversionAborted
+		default:
+		}
+		hash := acctIt.Hash()
+		account, err := types.FullAccount(acctIt.Account())
+		if err != nil {
+			return err
+		}
+		addr, err := c.address(hash)
+		if err != nil {
+			return err
+		}
+		got, err := tr.GetAccount(addr)
+		if err != nil {
+			return err
+		}
+		if got == nil || got.Nonce != account.Nonce || got.Balance.Cmp(account.Balance) != 0 || !bytes.Equal(got.CodeHash, account.CodeHash) {
+			return fmt.Errorf("account %#x mismatch, want %v, got %v", addr, account, got)
+		}
+		accounts++
+
+		// The code hash is never zero, the basic data only for empty accounts.
+		leaves++
+		hasCode := !bytes.Equal(account.CodeHash, types.EmptyCodeHash.Bytes())
+		if account.Nonce != 0 || !account.Balance.IsZero() || hasCode {
+			leaves++
+		}
+		if hasCode {
+			codeHash := common.BytesToHash(account.CodeHash)
+			n, ok := chunks[codeHash]
+			if !ok {
+				n = nonZeroChunks(rawdb.ReadCode(c.disk, codeHash))
+				chunks[codeHash] = n
+			}
+			leaves += n
+		}
+
+		if account.Root != types.EmptyRootHash {
+			storageIt, err := c.source.StorageIterator(status.Root, hash, common.Hash{})
+			if err != nil {
+				return err
+			}
+			for storageIt.Next() {
+				key, err := c.slotKey(storageIt.Hash())
+				if err != nil {
+					storageIt.Release()
+					return err
+				}
+				_, value, _, err := rlp.Split(storageIt.Slot())
+				if err != nil {
+					storageIt.Release()
+					return err
+				}
+				got, err := tr.GetStorage(addr, key.Bytes())
+				if err != nil {
+					storageIt.Release()
+					return err
+				}
+				if common.BytesToHash(got) != common.BytesToHash(value) {
+					storageIt.Release()
+					return fmt.Errorf("storage %#x:%#x mismatch, want %x, got %x", addr, key, value, got)
+				}
+				slots++
+				leaves++
+			}
+			err = storageIt.Error()
+			storageIt.Release()
+			if err != nil {
+				return err
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying binary trie", "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := acctIt.Error(); err != nil {
+		return err
+	}
+	// All the merkle states are present in the binary trie. Deleted states are
+	// zeroed out rather than removed, so the binary trie holds no other states
+	// if the number of its non-zero leaves matches.
+	nodeIt, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	var found uint64
+	for nodeIt.Next(true) {
+		select {
+		case <-abort:
+			return errConversionAborted
+		default:
+		}
+		if nodeIt.Leaf() && common.BytesToHash(nodeIt.LeafBlob()) != (common.Hash{}) {
+			found++
+		}
+	}
+	if err := nodeIt.Error(); err != nil {
+		return err
+	}
+	if found != leaves {
+		return fmt.Errorf("binary trie leaf count mismatch, want %d, got %d", leaves, found)
+	}
+	log.Info("Verified binary trie", "root", status.BinaryRoot, "accounts", accounts, "slots", slots, "leaves", leaves, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// nonZeroChunks returns the number of code chunks in the binary trie
+// representation of the code which are not all zeroes.
+func nonZeroChunks(code []byte) uint64 {
+	var (
+		n      uint64
+		chunks = trie.ChunkifyCode(code)
+	)
+	for i := 0; i < len(chunks); i += 32 {
+		if common.BytesToHash(chunks[i:i+32]) != (common.Hash{}) {
+			n++
+		}
+	}
+	return n
+}
+
+// fullAccount converts the slim account into the full format.
+func fullAccount(slim *types.SlimAccount) *types.StateAccount {
+	account := &types.StateAccount{
+		Nonce:    slim.Nonce,
+		Balance:  new(uint256.Int),
+		Root:     types.EmptyRootHash,
+		CodeHash: types.EmptyCodeHash.Bytes(),
+	}
+	if slim.Balance != nil {
+		account.Balance.Set(slim.Balance)
+	}
+	if len(slim.Root) != 0 {
+		account.Root = common.BytesToHash(slim.Root)
+	}
+	if len(slim.CodeHash) != 0 {
+		account.CodeHash = common.CopyBytes(slim.CodeHash)
+	}
+	return account
+}
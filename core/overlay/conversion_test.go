@@ -0,0 +1,281 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/overlay"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+// testChain is a sequence of merkle states for testing the conversion.
+type testChain struct {
+	disk  ethdb.Database
+	tdb   *triedb.Database
+	sdb   *state.CachingDB
+	roots []common.Hash
+}
+
+// newTestChain creates the genesis state with a set of accounts, contracts
+// and storage slots.
+func newTestChain(t *testing.T) *testChain {
+	disk, err := rawdb.Open(rawdb.NewMemoryDatabase(), rawdb.OpenOptions{Ancient: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	tdb := triedb.NewDatabase(disk, &triedb.Config{Preimages: true, PathDB: pathdb.Defaults})
+	chain := &testChain{
+		disk: disk,
+		tdb:  tdb,
+		sdb:  state.NewDatabase(tdb, nil),
+	}
+	t.Cleanup(func() {
+		tdb.Close()
+		disk.Close()
+	})
+	chain.commit(t, func(st *state.StateDB) {
+		for i := 1; i <= 64; i++ {
+			addr := common.BytesToAddress([]byte{byte(i)})
+			st.SetBalance(addr, uint256.NewInt(uint64(i)), tracing.BalanceChangeUnspecified)
+			st.SetNonce(addr, uint64(i), tracing.NonceChangeUnspecified)
+			if i%4 == 0 {
+				st.SetCode(addr, []byte{0x60, byte(i), 0x00}, tracing.CodeChangeUnspecified)
+				for j := 1; j <= 8; j++ {
+					st.SetState(addr, common.BytesToHash([]byte{byte(j)}), common.BytesToHash([]byte{byte(i), byte(j)}))
+				}
+			}
+		}
+	})
+	return chain
+}
+
+// commit applies the given mutations on top of the latest state.
+func (c *testChain) commit(t *testing.T, mutate func(st *state.StateDB)) common.Hash {
+	parent := types.EmptyRootHash
+	if len(c.roots) > 0 {
+		parent = c.roots[len(c.roots)-1]
+	}
+	st, err := state.New(parent, c.sdb)
+	if err != nil {
+		t.Fatalf("Failed to open state: %v", err)
+	}
+	mutate(st)
+	root, err := st.Commit(uint64(len(c.roots)), true, false)
+	if err != nil {
+		t.Fatalf("Failed to commit state: %v", err)
+	}
+	c.roots = append(c.roots, root)
+	return root
+}
+
+// grow applies a block updating the existing states and creating new ones.
+func (c *testChain) grow(t *testing.T) common.Hash {
+	n := len(c.roots)
+	return c.commit(t, func(st *state.StateDB) {
+		for i := 1; i <= 64; i++ {
+			addr := common.BytesToAddress([]byte{byte(i)})
+			if i%3 == n%3 {
+				st.AddBalance(addr, uint256.NewInt(1000), tracing.BalanceChangeUnspecified)
+			}
+			if i%4 == 0 {
+				st.SetState(addr, common.BytesToHash([]byte{byte(n)}), common.BytesToHash([]byte{byte(i), byte(n), 0xff}))
+			}
+		}
+		for i := 0; i < 4; i++ {
+			addr := common.BytesToAddress([]byte{0xaa, byte(n), byte(i)})
+			st.SetBalance(addr, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+			if i == 0 {
+				st.SetCode(addr, []byte{0x60, byte(n), 0x60, 0x00}, tracing.CodeChangeUnspecified)
+				st.SetState(addr, common.Hash{0x1}, common.Hash{0x2})
+			}
+		}
+	})
+}
+
+// shrink applies a block removing the existing accounts and storage slots.
+func (c *testChain) shrink(t *testing.T) common.Hash {
+	n := len(c.roots)
+	return c.commit(t, func(st *state.StateDB) {
+		for i := 1; i <= 64; i++ {
+			addr := common.BytesToAddress([]byte{byte(i)})
+			if i%4 == 0 {
+				st.SetState(addr, common.BytesToHash([]byte{byte(n % 8)}), common.Hash{})
+			} else if i%5 == n%5 {
+				st.SetNonce(addr, 0, tracing.NonceChangeUnspecified)
+				st.SetBalance(addr, new(uint256.Int), tracing.BalanceChangeUnspecified)
+			}
+		}
+	})
+}
+
+func newTestConverter(t *testing.T, chain *testChain) *overlay.Converter {
+	c, err := overlay.NewConverter(chain.disk, chain.tdb)
+	if err != nil {
+		t.Fatalf("Failed to create converter: %v", err)
+	}
+	return c
+}
+
+func TestConversion(t *testing.T) {
+	chain := newTestChain(t)
+	c := newTestConverter(t, chain)
+	if err := c.Verify(nil); err == nil {
+		t.Fatal("Expected error for verifying unfinished conversion")
+	}
+	if err := c.Convert(chain.roots[0], 0, nil); err != nil {
+		t.Fatalf("Failed to convert state: %v", err)
+	}
+	if err := c.Verify(nil); err != nil {
+		t.Fatalf("Failed to verify binary trie: %v", err)
+	}
+	status := c.Status()
+	if !status.Done || status.Accounts != 64 || status.Slots != 16*8 {
+		t.Fatalf("Unexpected conversion status: %+v", status)
+	}
+	// Follow the new states mutated by the subsequent blocks
+	for i := 0; i < 4; i++ {
+		chain.grow(t)
+		chain.shrink(t)
+	}
+	head := len(chain.roots) - 1
+	if err := c.Convert(chain.roots[head], uint64(head), nil); err != nil {
+		t.Fatalf("Failed to align binary trie: %v", err)
+	}
+	status = c.Status()
+	if status.Root != chain.roots[head] || status.Number != uint64(head) {
+		t.Fatalf("Unexpected aligned state, want %x(%d), got %x(%d)", chain.roots[head], head, status.Root, status.Number)
+	}
+	if err := c.Verify(nil); err != nil {
+		t.Fatalf("Failed to verify binary trie: %v", err)
+	}
+	// Ensure the conversion is resumed after restart
+	c = newTestConverter(t, chain)
+	if status := c.Status(); status == nil || status.Root != chain.roots[head] || !status.Done {
+		t.Fatalf("Unexpected conversion status after restart: %+v", status)
+	}
+	if err := c.Verify(nil); err != nil {
+		t.Fatalf("Failed to verify binary trie after restart: %v", err)
+	}
+}
+
+func TestConversionFollowChain(t *testing.T) {
+	defer overlay.SetConversionBatchSize(10)()
+
+	var (
+		chainA = newTestChain(t)
+		chainB = newTestChain(t)
+		c      = newTestConverter(t, chainA)
+	)
+	// Convert the states partially, and then move the conversion forward
+	// along with the chain, interleaved with restarts.
+	for i := 0; ; i++ {
+		head := len(chainA.roots) - 1
+		done, err := c.Step(chainA.roots[head], uint64(head))
+		if err != nil {
+			t.Fatalf("Failed to convert state: %v", err)
+		}
+		if done {
+			break
+		}
+		if i%3 == 0 {
+			chainA.grow(t)
+			chainB.grow(t)
+		}
+		if i%5 == 0 {
+			c = newTestConverter(t, chainA)
+		}
+	}
+	head := len(chainA.roots) - 1
+	if head == 0 {
+		t.Fatal("Chain is not extended during the conversion")
+	}
+	if err := c.Verify(nil); err != nil {
+		t.Fatalf("Failed to verify binary trie: %v", err)
+	}
+	// Ensure the binary trie is identical with the one converted from scratch
+	fresh := newTestConverter(t, chainB)
+	if err := fresh.Convert(chainB.roots[head], uint64(head), nil); err != nil {
+		t.Fatalf("Failed to convert state: %v", err)
+	}
+	if got, want := c.Status().BinaryRoot, fresh.Status().BinaryRoot; got != want {
+		t.Fatalf("Binary trie root mismatch, want %x, got %x", want, got)
+	}
+}
+
+func TestConversionDeleteAccount(t *testing.T) {
+	defer overlay.SetConversionBatchSize(10)()
+
+	var (
+		chain = newTestChain(t)
+		c     = newTestConverter(t, chain)
+	)
+	if done, err := c.Step(chain.roots[0], 0); err != nil || done {
+		t.Fatalf("Unexpected conversion step, done: %v, err: %v", done, err)
+	}
+	// Destruct the contracts with storage in the middle of the conversion,
+	// and resurrect some of them with different code in the next block.
+	chain.commit(t, func(st *state.StateDB) {
+		for i := 4; i <= 64; i += 4 {
+			st.SelfDestruct(common.BytesToAddress([]byte{byte(i)}))
+		}
+	})
+	chain.commit(t, func(st *state.StateDB) {
+		for i := 8; i <= 64; i += 8 {
+			addr := common.BytesToAddress([]byte{byte(i)})
+			st.CreateAccount(addr)
+			st.SetNonce(addr, 1, tracing.NonceChangeUnspecified)
+			st.SetCode(addr, []byte{0x60, byte(i)}, tracing.CodeChangeUnspecified)
+		}
+	})
+	for {
+		head := len(chain.roots) - 1
+		done, err := c.Step(chain.roots[head], uint64(head))
+		if err != nil {
+			t.Fatalf("Failed to convert state: %v", err)
+		}
+		if done {
+			break
+		}
+	}
+	if err := c.Verify(nil); err != nil {
+		t.Fatalf("Failed to verify binary trie: %v", err)
+	}
+	// Destruct the accounts again after the conversion is finished. Touch
+	// another account as well, the state would be identical to an ancestor
+	// otherwise.
+	head := chain.commit(t, func(st *state.StateDB) {
+		for i := 8; i <= 64; i += 8 {
+			st.SelfDestruct(common.BytesToAddress([]byte{byte(i)}))
+		}
+		st.AddBalance(common.BytesToAddress([]byte{1}), uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	})
+	if err := c.Convert(head, uint64(len(chain.roots)-1), nil); err != nil {
+		t.Fatalf("Failed to align binary trie: %v", err)
+	}
+	if err := c.Verify(nil); err != nil {
+		t.Fatalf("Failed to verify binary trie: %v", err)
+	}
+}
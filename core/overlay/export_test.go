@@ -0,0 +1,32 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package overlay
+
+import "github.com/ethereum/go-ethereum/common"
+
+// SetConversionBatchSize overrides the conversion batch size, returning the
+// function to restore the original one.
+func SetConversionBatchSize(size int) func() {
+	old := conversionBatchSize
+	conversionBatchSize = size
+	return func() { conversionBatchSize = old }
+}
+
+// Step performs a single conversion step against the specified state.
+func (c *Converter) Step(root common.Hash, number uint64) (bool, error) {
+	return c.step(func() (common.Hash, uint64) { return root, number })
+}
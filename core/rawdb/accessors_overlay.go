@@ -19,6 +19,7 @@ package rawdb
 import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 func ReadVerkleTransitionState(db ethdb.KeyValueReader, hash common.Hash) ([]byte, error) {
@@ -28,3 +29,26 @@ func ReadVerkleTransitionState(db ethdb.KeyValueReader, hash common.Hash) ([]byt
 func WriteVerkleTransitionState(db ethdb.KeyValueWriter, hash common.Hash, state []byte) error {
 	return db.Put(transitionStateKey(hash), state)
 }
+
+// ReadBinaryConversionStatus retrieves the serialized binary trie conversion
+// progress saved in the database.
+func ReadBinaryConversionStatus(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(binaryConversionKey)
+	return data
+}
+
+// WriteBinaryConversionStatus stores the serialized binary trie conversion
+// progress into the database.
+func WriteBinaryConversionStatus(db ethdb.KeyValueWriter, status []byte) {
+	if err := db.Put(binaryConversionKey, status); err != nil {
+		log.Crit("Failed to store binary conversion status", "err", err)
+	}
+}
+
+// DeleteBinaryConversionStatus deletes the binary trie conversion progress
+// from the database.
+func DeleteBinaryConversionStatus(db ethdb.KeyValueWriter) {
+	if err := db.Delete(binaryConversionKey); err != nil {
+		log.Crit("Failed to remove binary conversion status", "err", err)
+	}
+}
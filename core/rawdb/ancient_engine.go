@@ -0,0 +1,286 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// The list of supported engines of the chain ancient store.
+const (
+	AncientEngineFile   = "file"   // flat files along with index files
+	AncientEnginePebble = "pebble" // dedicated Pebble instance
+)
+
+// ChainPebbleFreezerName is the folder name of the Pebble based chain segment
+// ancient store.
+const ChainPebbleFreezerName = "chain_pebble"
+
+// migrateBatchBytes is the maximum size of the items per table copied in one
+// batch during the ancient store migration.
+const migrateBatchBytes = 64 * 1024 * 1024
+
+// PreexistingAncientEngine checks the given ancient root whether a chain ancient
+// store is already instantiated, and if so, returns the engine of the ancient
+// store (or the empty string).
+//
+// The Pebble based ancient store takes precedence, as the file-based one might be
+// left over by an interrupted migration.
+func PreexistingAncientEngine(ancient string) string {
+	if common.FileExist(filepath.Join(ancient, ChainPebbleFreezerName, "CURRENT")) {
+		return AncientEnginePebble
+	}
+	if len(chainFreezerFiles(filepath.Join(ancient, ChainFreezerName))) > 0 || len(chainFreezerFiles(ancient)) > 0 {
+		return AncientEngineFile
+	}
+	return ""
+}
+
+// ResolveAncientEngine resolves the engine of the chain ancient store in the given
+// ancient root. The requested engine is used if no ancient store is present yet,
+// otherwise it must match the existing one.
+func ResolveAncientEngine(ancient string, requested string) (string, error) {
+	if requested != "" && requested != AncientEngineFile && requested != AncientEnginePebble {
+		return "", fmt.Errorf("unknown ancient engine %v", requested)
+	}
+	existing := PreexistingAncientEngine(ancient)
+	switch {
+	case existing == "" && requested == "":
+		return AncientEngineFile, nil
+	case existing == "":
+		return requested, nil
+	case requested != "" && requested != existing:
+		return "", fmt.Errorf("ancient engine choice was %v but found pre-existing %v ancient store, run 'geth db migrate-ancients %v' to convert it", requested, existing, requested)
+	default:
+		return existing, nil
+	}
+}
+
+// chainFreezerFiles returns the data and index files of the file-based chain
+// ancient store in the given directory.
+func chainFreezerFiles(dir string) []string {
+	var files []string
+	for name := range chainFreezerTableConfigs {
+		for _, pattern := range []string{name + ".*idx", name + ".*dat", name + ".meta"} {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				panic(err) // only possible if the pattern is malformed
+			}
+			files = append(files, matches...)
+		}
+	}
+	return files
+}
+
+// MigrateChainAncients converts the chain ancient store in the given ancient root
+// into the specified engine. The key-value store backing the Pebble based ancient
+// store is opened with the supplied function.
+//
+// The data is copied into a fresh ancient store first, and the original one is
+// only removed once the new one is completed. The migration can be interrupted
+// and restarted from scratch.
+func MigrateChainAncients(ancient string, engine string, open func(path string) (ethdb.KeyValueStore, error), abort <-chan struct{}) error {
+	existing := PreexistingAncientEngine(ancient)
+	if existing == "" {
+		return errors.New("no chain ancient store found")
+	}
+	if existing == engine {
+		return fmt.Errorf("chain ancient store is already %v based", engine)
+	}
+	var (
+		pebbleDir = filepath.Join(ancient, ChainPebbleFreezerName)
+		fileDir   = filepath.Join(ancient, ChainFreezerName)
+	)
+	switch engine {
+	case AncientEnginePebble:
+		srcDir := resolveChainFreezerDir(ancient)
+		src, err := NewFreezer(srcDir, "", true, freezerTableSize, chainFreezerTableConfigs)
+		if err != nil {
+			return err
+		}
+		defer src.Close() // closing twice is permitted
+
+		// Copy the ancients into a temporary store, which is moved to the final
+		// location atomically once completed.
+		tmp := tmpName(pebbleDir)
+		if err := os.RemoveAll(tmp); err != nil {
+			return err
+		}
+		db, err := open(tmp)
+		if err != nil {
+			return err
+		}
+		dst, err := NewKeyValueFreezer(db, false, chainFreezerTableConfigs)
+		if err != nil {
+			db.Close()
+			return err
+		}
+		if err := copyAncients(src, dst, abort); err != nil {
+			dst.Close()
+			return err
+		}
+		if err := dst.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, pebbleDir); err != nil {
+			return err
+		}
+		src.Close()
+		for _, file := range chainFreezerFiles(srcDir) {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+		}
+		log.Info("Removed file-based ancient store", "path", srcDir)
+
+	case AncientEngineFile:
+		db, err := open(pebbleDir)
+		if err != nil {
+			return err
+		}
+		src, err := NewKeyValueFreezer(db, true, chainFreezerTableConfigs)
+		if err != nil {
+			db.Close()
+			return err
+		}
+		// The file-based ancient store left by an interrupted migration is
+		// discarded, as the Pebble based one takes precedence.
+		for _, dir := range []string{fileDir, ancient} {
+			for _, file := range chainFreezerFiles(dir) {
+				if err := os.Remove(file); err != nil {
+					src.Close()
+					return err
+				}
+			}
+		}
+		dst, err := NewFreezer(fileDir, "", false, freezerTableSize, chainFreezerTableConfigs)
+		if err != nil {
+			src.Close()
+			return err
+		}
+		err = copyAncients(src, dst, abort)
+		dst.Close()
+		src.Close()
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(pebbleDir); err != nil {
+			return err
+		}
+		log.Info("Removed Pebble based ancient store", "path", pebbleDir)
+
+	default:
+		return fmt.Errorf("unknown ancient engine %v", engine)
+	}
+	return nil
+}
+
+// copyAncients copies all the items of the chain ancient tables from the source
+// store into the empty destination store. The items pruned from the source are
+// filled with placeholders and truncated afterwards.
+func copyAncients(src ethdb.AncientReaderOp, dst ethdb.AncientStore, abort <-chan struct{}) error {
+	items, err := src.Ancients()
+	if err != nil {
+		return err
+	}
+	tail, err := src.Tail()
+	if err != nil {
+		return err
+	}
+	if n, err := dst.Ancients(); err != nil {
+		return err
+	} else if n != 0 {
+		return fmt.Errorf("destination ancient store is not empty, %d items", n)
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		tables = slices.Sorted(maps.Keys(chainFreezerTableConfigs))
+		next   uint64
+	)
+
+	for next < items {
+		select {
+		case <-abort:
+			return errors.New("migration aborted")
+		default:
+		}
+		// Read the items of all tables, the number of items in the batch is
+		// capped by the largest table.
+		var (
+			count = min(items-next, freezerBatchLimit)
+			batch = make(map[string][][]byte)
+		)
+		if next < tail {
+			count = min(count, tail-next)
+		}
+		for _, name := range tables {
+			if next < tail && chainFreezerTableConfigs[name].prunable {
+				continue
+			}
+			blobs, err := src.AncientRange(name, next, count, migrateBatchBytes)
+			if err != nil {
+				return err
+			}
+			count = min(count, uint64(len(blobs)))
+			batch[name] = blobs
+		}
+		_, err := dst.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+			for i := uint64(0); i < count; i++ {
+				for _, name := range tables {
+					var blob []byte
+					if blobs, ok := batch[name]; ok {
+						blob = blobs[i]
+					}
+					if err := op.AppendRaw(name, next+i, blob); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		next += count
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Migrating ancient store", "items", next, "total", items, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if tail > 0 {
+		if _, err := dst.TruncateTail(tail); err != nil {
+			return err
+		}
+	}
+	if err := dst.SyncAncient(); err != nil {
+		return err
+	}
+	log.Info("Migrated ancient store", "items", items, "tail", tail, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
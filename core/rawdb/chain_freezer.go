@@ -84,6 +84,27 @@ func newChainFreezer(datadir string, eraDir string, namespace string, readonly b
 	}, nil
 }
 
+// newKeyValueChainFreezer initializes the freezer for ancient chain segment on
+// top of the given key-value store, the ownership of the store is transferred
+// to the freezer. The store is left open on failure, it's up to the caller to
+// close it.
+func newKeyValueChainFreezer(db ethdb.KeyValueStore, eraDir string, readonly bool) (*chainFreezer, error) {
+	freezer, err := NewKeyValueFreezer(db, readonly, chainFreezerTableConfigs)
+	if err != nil {
+		return nil, err
+	}
+	edb, err := eradb.New(eraDir)
+	if err != nil {
+		return nil, err
+	}
+	return &chainFreezer{
+		ancients: freezer,
+		eradb:    edb,
+		quit:     make(chan struct{}),
+		trigger:  make(chan chan struct{}),
+	}, nil
+}
+
 // Close closes the chain freezer instance and terminates the background thread.
 func (f *chainFreezer) Close() error {
 	select {
@@ -375,10 +396,23 @@ func (f *chainFreezer) Ancient(kind string, number uint64) ([]byte, error) {
 	return nil, errUnknownTable
 }
 
+// EraAvailable returns the number of blocks, counting from genesis, whose bodies
+// and receipts can be retrieved from the era backend.
+func (f *chainFreezer) EraAvailable() uint64 {
+	if f.eradb == nil {
+		return 0
+	}
+	return f.eradb.Available()
+}
+
 // ReadAncients executes an operation while preventing mutations to the freezer,
 // i.e. if fn performs multiple reads, they will be consistent with each other.
 func (f *chainFreezer) ReadAncients(fn func(ethdb.AncientReaderOp) error) (err error) {
-	if store, ok := f.ancients.(*Freezer); ok {
+	switch store := f.ancients.(type) {
+	case *Freezer:
+		store.writeLock.Lock()
+		defer store.writeLock.Unlock()
+	case *KeyValueFreezer:
 		store.writeLock.Lock()
 		defer store.writeLock.Unlock()
 	}
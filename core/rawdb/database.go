@@ -216,21 +216,58 @@ type OpenOptions struct {
 	Era              string // era files directory
 	MetricsNamespace string // prefix added to freezer metric names
 	ReadOnly         bool
+
+	// AncientEngine is the engine of the chain ancient store, "file" or "pebble".
+	// If empty, the engine of the existing ancient store is used, and "file" for
+	// the new one.
+	AncientEngine string
+
+	// OpenAncientStore opens the key-value store at the given path for holding
+	// the chain ancients, required by the "pebble" engine.
+	OpenAncientStore func(path string) (ethdb.KeyValueStore, error)
 }
 
 // Open creates a high-level database wrapper for the given key-value store.
 func Open(db ethdb.KeyValueStore, opts OpenOptions) (ethdb.Database, error) {
 	// Create the idle freezer instance. If the given ancient directory is empty,
 	// in-memory chain freezer is used (e.g. dev mode); otherwise the regular
-	// file-based freezer is created.
-	chainFreezerDir := opts.Ancient
-	if chainFreezerDir != "" {
-		chainFreezerDir = resolveChainFreezerDir(chainFreezerDir)
+	// file-based freezer or the key-value based one is created.
+	engine := AncientEngineFile
+	if opts.Ancient != "" {
+		var err error
+		if engine, err = ResolveAncientEngine(opts.Ancient, opts.AncientEngine); err != nil {
+			return nil, err
+		}
 	}
-	frdb, err := newChainFreezer(chainFreezerDir, opts.Era, opts.MetricsNamespace, opts.ReadOnly)
-	if err != nil {
-		printChainMetadata(db)
-		return nil, err
+	var (
+		frdb *chainFreezer
+		err  error
+	)
+	if engine == AncientEnginePebble {
+		if opts.OpenAncientStore == nil {
+			return nil, errors.New("no key-value store for pebble ancient engine")
+		}
+		store, err := opts.OpenAncientStore(filepath.Join(opts.Ancient, ChainPebbleFreezerName))
+		if err != nil {
+			return nil, err
+		}
+		eraDir := resolveChainEraDir(filepath.Join(opts.Ancient, ChainFreezerName), opts.Era)
+		frdb, err = newKeyValueChainFreezer(store, eraDir, opts.ReadOnly)
+		if err != nil {
+			store.Close()
+			printChainMetadata(db)
+			return nil, err
+		}
+	} else {
+		chainFreezerDir := opts.Ancient
+		if chainFreezerDir != "" {
+			chainFreezerDir = resolveChainFreezerDir(chainFreezerDir)
+		}
+		frdb, err = newChainFreezer(chainFreezerDir, opts.Era, opts.MetricsNamespace, opts.ReadOnly)
+		if err != nil {
+			printChainMetadata(db)
+			return nil, err
+		}
 	}
 	// Since the freezer can be stored separately from the user's key-value database,
 	// there's a fairly high probability that the user requests invalid combinations
@@ -428,6 +465,9 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		verkleTries        stat
 		verkleStateLookups stat
 
+		// Binary trie statistics
+		binaryTries stat
+
 		// Meta- and unaccounted data
 		metadata    stat
 		unaccounted stat
@@ -538,6 +578,18 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 					unaccounted.add(size)
 				}
 
+			// Binary trie data converted from the merkle state is detected
+			case bytes.HasPrefix(key, BinaryTriePrefix):
+				remain := key[len(BinaryTriePrefix):]
+				switch {
+				case IsAccountTrieNode(remain):
+					binaryTries.add(size)
+				case bytes.Equal(remain, binaryConversionKey):
+					metadata.add(size)
+				default:
+					unaccounted.add(size)
+				}
+
 			// Metadata keys
 			case slices.ContainsFunc(knownMetadataKeys, func(x []byte) bool { return bytes.Equal(x, key) }):
 				metadata.add(size)
@@ -618,6 +670,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Path state history indexes", stateIndex.sizeString(), stateIndex.countString()},
 		{"Key-Value store", "Verkle trie nodes", verkleTries.sizeString(), verkleTries.countString()},
 		{"Key-Value store", "Verkle trie state lookups", verkleStateLookups.sizeString(), verkleStateLookups.countString()},
+		{"Key-Value store", "Binary trie nodes", binaryTries.sizeString(), binaryTries.countString()},
 		{"Key-Value store", "Trie preimages", preimages.sizeString(), preimages.countString()},
 		{"Key-Value store", "Account snapshot", accountSnaps.sizeString(), accountSnaps.countString()},
 		{"Key-Value store", "Storage snapshot", storageSnaps.sizeString(), storageSnaps.countString()},
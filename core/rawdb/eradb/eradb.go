@@ -23,7 +23,10 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/internal/era"
@@ -31,7 +34,13 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
-const openFileLimit = 64
+const (
+	openFileLimit = 64
+
+	// availableRecheck is the interval after which the store directory is scanned
+	// again for the range of available blocks, picking up newly added files.
+	availableRecheck = time.Minute
+)
 
 var errClosed = errors.New("era store is closed")
 
@@ -40,12 +49,19 @@ var errClosed = errors.New("era store is closed")
 type Store struct {
 	datadir string
 
+	// scanMu serializes directory scans in Available, so that concurrent callers
+	// hitting an expired result don't all re-read the directory at once.
+	scanMu sync.Mutex
+
 	// The mutex protects all remaining fields.
 	mu      sync.Mutex
 	cond    *sync.Cond
 	lru     lru.BasicLRU[uint64, *fileCacheEntry]
 	opening map[uint64]*fileCacheEntry
 	closing bool
+
+	available   uint64    // number of blocks covered by the files, see Available
+	availableAt time.Time // time of the last directory scan
 }
 
 type fileCacheEntry struct {
@@ -134,6 +150,74 @@ func (db *Store) GetRawReceipts(number uint64) ([]byte, error) {
 	return convertReceipts(data)
 }
 
+// Available returns the number of blocks, counting from genesis, which are covered
+// by a contiguous sequence of era1 files in the store directory. The result is
+// cached for a while, files added to the directory are picked up eventually.
+func (db *Store) Available() uint64 {
+	if available, ok := db.cachedAvailable(); ok {
+		return available
+	}
+	db.scanMu.Lock()
+	defer db.scanMu.Unlock()
+
+	// Another caller may have refreshed the result while we were waiting.
+	if available, ok := db.cachedAvailable(); ok {
+		return available
+	}
+	var available uint64
+	if epochs := db.contiguousEpochs(); epochs > 0 {
+		last := epochs - 1
+		entry := db.getEraByEpoch(last)
+		if entry.err == nil {
+			available = entry.file.Start() + entry.file.Count()
+			db.doneWithFile(last, entry)
+		} else {
+			log.Warn("Failed to open era1 file", "epoch", last, "err", entry.err)
+			available = last * uint64(era.MaxEra1Size)
+		}
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.available, db.availableAt = available, time.Now()
+	return available
+}
+
+// cachedAvailable returns the result of the last directory scan, if it is still
+// fresh enough to be used.
+func (db *Store) cachedAvailable() (uint64, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.availableAt.IsZero() || time.Since(db.availableAt) >= availableRecheck {
+		return 0, false
+	}
+	return db.available, true
+}
+
+// contiguousEpochs returns the number of era1 files in the store directory which
+// form a contiguous sequence starting at epoch zero.
+func (db *Store) contiguousEpochs() uint64 {
+	matches, err := filepath.Glob(filepath.Join(db.datadir, "*-*-*.era1"))
+	if err != nil {
+		return 0
+	}
+	epochs := make(map[uint64]bool)
+	for _, match := range matches {
+		// File name scheme is <network>-<epoch>-<root>.
+		parts := strings.Split(filepath.Base(match), "-")
+		epoch, err := strconv.ParseUint(parts[len(parts)-2], 10, 64)
+		if err != nil {
+			continue
+		}
+		epochs[epoch] = true
+	}
+	var n uint64
+	for epochs[n] {
+		n++
+	}
+	return n
+}
+
 // convertReceipts transforms an encoded block receipts list from the format
 // used by era1 into the 'storage' format used by the go-ethereum ancients database.
 func convertReceipts(input []byte) ([]byte, error) {
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -101,3 +102,36 @@ func TestEraDatabaseConcurrentOpenClose(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+func TestEraDatabaseAvailable(t *testing.T) {
+	// Only the first epoch is contiguous with genesis in the test directory.
+	db, err := New("testdata")
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, uint64(era.MaxEra1Size), db.Available())
+
+	// An empty directory covers no blocks.
+	empty, err := New(t.TempDir())
+	require.NoError(t, err)
+	defer empty.Close()
+	assert.Equal(t, uint64(0), empty.Available())
+}
+
+func TestEraDatabaseConcurrentAvailable(t *testing.T) {
+	db, err := New("testdata")
+	require.NoError(t, err)
+	defer db.Close()
+
+	const N = 25
+	var wg sync.WaitGroup
+	wg.Add(N)
+	for range N {
+		go func() {
+			defer wg.Done()
+			if n := db.Available(); n != uint64(era.MaxEra1Size) {
+				t.Error("wrong available count:", n)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,556 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// The key layout of the key-value based ancient store:
+//
+//   - kvFreezerItemsKey                                     => number of stored items
+//   - kvFreezerTailKey                                      => number of the first stored item
+//   - kvFreezerBeginPrefix + table name                     => table size at the tail
+//   - kvFreezerItemPrefix + table name + 0x00 + item number => cumulative table size + item
+var (
+	kvFreezerItemsKey    = []byte("items")
+	kvFreezerTailKey     = []byte("tail")
+	kvFreezerBeginPrefix = []byte("b")
+	kvFreezerItemPrefix  = []byte("i")
+)
+
+// kvFreezerItemKey = kvFreezerItemPrefix + table name + 0x00 + item number (uint64 big endian)
+func kvFreezerItemKey(kind string, number uint64) []byte {
+	return binary.BigEndian.AppendUint64(kvFreezerTablePrefix(kind), number)
+}
+
+// kvFreezerBeginKey = kvFreezerBeginPrefix + table name
+func kvFreezerBeginKey(kind string) []byte {
+	return append(append([]byte{}, kvFreezerBeginPrefix...), kind...)
+}
+
+// kvFreezerTablePrefix = kvFreezerItemPrefix + table name + 0x00
+func kvFreezerTablePrefix(kind string) []byte {
+	key := make([]byte, 0, len(kvFreezerItemPrefix)+len(kind)+1)
+	key = append(key, kvFreezerItemPrefix...)
+	key = append(key, kind...)
+	return append(key, 0x00)
+}
+
+// kvFreezerTableEnd returns the exclusive upper bound of the item keys of the
+// specified table.
+func kvFreezerTableEnd(kind string) []byte {
+	key := append([]byte{}, kvFreezerItemPrefix...)
+	key = append(key, kind...)
+	return append(key, 0x01)
+}
+
+// kvTable is the in-memory metadata of a table in the key-value based ancient
+// store. The stored items are prefixed with the cumulative table size, making
+// it possible to resolve the table size without iterating the items.
+type kvTable struct {
+	config freezerTableConfig
+	begin  uint64 // Cumulative size of the items deleted from the tail
+	end    uint64 // Cumulative size of all the items stored
+}
+
+// KeyValueFreezer is an ancient store backed by an ordered key-value store, e.g.
+// a dedicated Pebble instance. It implements the ethdb.AncientStore interface.
+//
+// Unlike the file-based freezer, all the items of a write operation are committed
+// atomically along with the item counter, which makes the store free of repairs
+// after an unclean shutdown. Compression is left to the underlying key-value store.
+type KeyValueFreezer struct {
+	db       ethdb.KeyValueStore // Key-value store holding the ancient items
+	items    atomic.Uint64       // Number of items stored
+	tail     atomic.Uint64       // Number of the first stored item in the freezer
+	readonly bool                // Flag if the freezer is only for reading
+	tables   map[string]*kvTable // Metadata of the tables
+
+	// This lock synchronizes writers and the truncate operation, as well as
+	// the "atomic" (batched) read operations.
+	writeLock sync.RWMutex
+}
+
+// NewKeyValueFreezer initializes the ancient store on top of the given key-value
+// store. The ownership of the key-value store is transferred to the freezer, it
+// will be closed along with the freezer.
+func NewKeyValueFreezer(db ethdb.KeyValueStore, readonly bool, tables map[string]freezerTableConfig) (*KeyValueFreezer, error) {
+	f := &KeyValueFreezer{
+		db:       db,
+		readonly: readonly,
+		tables:   make(map[string]*kvTable),
+	}
+	items, err := f.readCounter(kvFreezerItemsKey)
+	if err != nil {
+		return nil, err
+	}
+	tail, err := f.readCounter(kvFreezerTailKey)
+	if err != nil {
+		return nil, err
+	}
+	f.items.Store(items)
+	f.tail.Store(tail)
+	for name, config := range tables {
+		table := &kvTable{config: config}
+		if config.prunable {
+			if table.begin, err = f.readCounter(kvFreezerBeginKey(name)); err != nil {
+				return nil, err
+			}
+		}
+		if table.end, err = f.readEnd(name, table); err != nil {
+			return nil, err
+		}
+		f.tables[name] = table
+	}
+	// Clean up the dangling items left by an interrupted write or truncation,
+	// they are not referenced by the counters and can be safely removed.
+	if !readonly {
+		if err := f.deleteDangling(); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("Opened key-value ancient database", "items", items, "tail", tail, "readonly", readonly)
+	return f, nil
+}
+
+// readCounter reads the counter with the given key, 0 is returned if it's not
+// present.
+func (f *KeyValueFreezer) readCounter(key []byte) (uint64, error) {
+	if ok, err := f.db.Has(key); err != nil || !ok {
+		return 0, err
+	}
+	blob, err := f.db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(blob) != 8 {
+		return 0, fmt.Errorf("invalid ancient counter %q", key)
+	}
+	return binary.BigEndian.Uint64(blob), nil
+}
+
+// readEnd resolves the cumulative size of the table by reading the last item.
+func (f *KeyValueFreezer) readEnd(kind string, table *kvTable) (uint64, error) {
+	items := f.items.Load()
+	if items <= f.first(table) {
+		return table.begin, nil
+	}
+	blob, err := f.db.Get(kvFreezerItemKey(kind, items-1))
+	if err != nil {
+		return 0, fmt.Errorf("missing ancient item %s %d: %w", kind, items-1, err)
+	}
+	if len(blob) < 8 {
+		return 0, fmt.Errorf("corrupted ancient item %s %d", kind, items-1)
+	}
+	return binary.BigEndian.Uint64(blob), nil
+}
+
+// first returns the number of the first item stored in the table.
+func (f *KeyValueFreezer) first(table *kvTable) uint64 {
+	if table.config.prunable {
+		return f.tail.Load()
+	}
+	return 0
+}
+
+// deleteDangling removes the items above the head or below the tail.
+func (f *KeyValueFreezer) deleteDangling() error {
+	for name, table := range f.tables {
+		if err := f.deleteRange(kvFreezerItemKey(name, f.items.Load()), kvFreezerTableEnd(name)); err != nil {
+			return err
+		}
+		if first := f.first(table); first > 0 {
+			if err := f.deleteRange(kvFreezerItemKey(name, 0), kvFreezerItemKey(name, first)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteRange deletes all the keys in the range [start, end).
+func (f *KeyValueFreezer) deleteRange(start, end []byte) error {
+	for {
+		err := f.db.DeleteRange(start, end)
+		if !errors.Is(err, ethdb.ErrTooManyKeys) {
+			return err
+		}
+	}
+}
+
+// writeCounters persists the item counters along with the table sizes at the
+// tail in a single batch.
+func (f *KeyValueFreezer) writeCounters(batch ethdb.Batch, items uint64, tail uint64, begins map[string]uint64) error {
+	if err := batch.Put(kvFreezerItemsKey, binary.BigEndian.AppendUint64(nil, items)); err != nil {
+		return err
+	}
+	if err := batch.Put(kvFreezerTailKey, binary.BigEndian.AppendUint64(nil, tail)); err != nil {
+		return err
+	}
+	for name, begin := range begins {
+		if err := batch.Put(kvFreezerBeginKey(name), binary.BigEndian.AppendUint64(nil, begin)); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// retrieve retrieves multiple items in sequence, starting from the index 'start'.
+func (f *KeyValueFreezer) retrieve(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	table := f.tables[kind]
+	if table == nil {
+		return nil, errUnknownTable
+	}
+	// Ensure the start is written, not deleted from the tail, and that the
+	// caller actually wants something.
+	items := f.items.Load()
+	if items <= start || f.first(table) > start || count == 0 {
+		return nil, errOutOfBounds
+	}
+	// Cap the item count if the retrieval is out of bound.
+	if start+count > items {
+		count = items - start
+	}
+	var (
+		size  uint64
+		batch [][]byte
+		it    = f.db.NewIterator(kvFreezerTablePrefix(kind), binary.BigEndian.AppendUint64(nil, start))
+	)
+	defer it.Release()
+
+	for n := start; n < start+count; n++ {
+		if !it.Next() {
+			if err := it.Error(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("missing ancient item %s %d", kind, n)
+		}
+		blob := it.Value()
+		if len(blob) < 8 {
+			return nil, fmt.Errorf("corrupted ancient item %s %d", kind, n)
+		}
+		item := blob[8:]
+		if len(batch) != 0 && maxBytes != 0 && size+uint64(len(item)) > maxBytes {
+			return batch, nil
+		}
+		batch = append(batch, append([]byte{}, item...))
+		size += uint64(len(item))
+	}
+	return batch, nil
+}
+
+// Ancient retrieves an ancient binary blob from the key-value store.
+func (f *KeyValueFreezer) Ancient(kind string, number uint64) ([]byte, error) {
+	data, err := f.retrieve(kind, number, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	return data[0], nil
+}
+
+// AncientRange retrieves multiple items in sequence, starting from the index 'start'.
+// It will return
+//   - at most 'count' items,
+//   - if maxBytes is specified: at least 1 item (even if exceeding the maxByteSize),
+//     but will otherwise return as many items as fit into maxByteSize.
+//   - if maxBytes is not specified, 'count' items will be returned if they are present
+func (f *KeyValueFreezer) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	return f.retrieve(kind, start, count, maxBytes)
+}
+
+// Ancients returns the ancient item numbers in the freezer.
+func (f *KeyValueFreezer) Ancients() (uint64, error) {
+	return f.items.Load(), nil
+}
+
+// Tail returns the number of first stored item in the freezer.
+// This number can also be interpreted as the total deleted item numbers.
+func (f *KeyValueFreezer) Tail() (uint64, error) {
+	return f.tail.Load(), nil
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (f *KeyValueFreezer) AncientSize(kind string) (uint64, error) {
+	// This needs the write lock to avoid data races on table fields.
+	f.writeLock.RLock()
+	defer f.writeLock.RUnlock()
+
+	if table := f.tables[kind]; table != nil {
+		return table.end - table.begin, nil
+	}
+	return 0, errUnknownTable
+}
+
+// ReadAncients runs the given read operation while ensuring that no writes take place
+// on the underlying freezer.
+func (f *KeyValueFreezer) ReadAncients(fn func(ethdb.AncientReaderOp) error) (err error) {
+	f.writeLock.RLock()
+	defer f.writeLock.RUnlock()
+
+	return fn(f)
+}
+
+// kvFreezerBatch is the batch used for ancient write. The items are flushed
+// into the key-value store once the batch is large enough, but they are only
+// referenced after the item counter is updated at the end.
+type kvFreezerBatch struct {
+	f     *KeyValueFreezer
+	batch ethdb.Batch
+	next  map[string]uint64
+	end   map[string]uint64
+	size  int64
+}
+
+func newKVFreezerBatch(f *KeyValueFreezer) *kvFreezerBatch {
+	b := &kvFreezerBatch{
+		f:     f,
+		batch: f.db.NewBatch(),
+		next:  make(map[string]uint64),
+		end:   make(map[string]uint64),
+	}
+	for name, table := range f.tables {
+		b.next[name] = f.items.Load()
+		b.end[name] = table.end
+	}
+	return b
+}
+
+// Append adds an RLP-encoded item.
+func (b *kvFreezerBatch) Append(kind string, number uint64, item interface{}) error {
+	blob, err := rlp.EncodeToBytes(item)
+	if err != nil {
+		return err
+	}
+	return b.AppendRaw(kind, number, blob)
+}
+
+// AppendRaw adds an item without RLP-encoding it.
+func (b *kvFreezerBatch) AppendRaw(kind string, number uint64, blob []byte) error {
+	next, ok := b.next[kind]
+	if !ok {
+		return errUnknownTable
+	}
+	if next != number {
+		return errOutOrderInsertion
+	}
+	end := b.end[kind] + uint64(len(blob))
+	value := make([]byte, 8+len(blob))
+	binary.BigEndian.PutUint64(value, end)
+	copy(value[8:], blob)
+	if err := b.batch.Put(kvFreezerItemKey(kind, number), value); err != nil {
+		return err
+	}
+	b.next[kind]++
+	b.end[kind] = end
+	b.size += int64(len(blob))
+
+	if b.batch.ValueSize() >= ethdb.IdealBatchSize {
+		if err := b.batch.Write(); err != nil {
+			return err
+		}
+		b.batch.Reset()
+	}
+	return nil
+}
+
+// commit is called at the end of a write operation and writes all remaining
+// data along with the item counter.
+func (b *kvFreezerBatch) commit() (items uint64, writeSize int64, err error) {
+	// Check that count agrees on all batches.
+	items = math.MaxUint64
+	for name, next := range b.next {
+		if items < math.MaxUint64 && next != items {
+			return 0, 0, fmt.Errorf("table %s is at item %d, want %d", name, next, items)
+		}
+		items = next
+	}
+	if err := b.f.writeCounters(b.batch, items, b.f.tail.Load(), nil); err != nil {
+		return 0, 0, err
+	}
+	return items, b.size, nil
+}
+
+// ModifyAncients runs the given write operation.
+func (f *KeyValueFreezer) ModifyAncients(fn func(ethdb.AncientWriteOp) error) (writeSize int64, err error) {
+	if f.readonly {
+		return 0, errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	// Remove the flushed items in case of error.
+	prevItem := f.items.Load()
+	defer func() {
+		if err == nil {
+			return
+		}
+		for name := range f.tables {
+			if err := f.deleteRange(kvFreezerItemKey(name, prevItem), kvFreezerTableEnd(name)); err != nil {
+				log.Error("Freezer table roll-back failed", "table", name, "index", prevItem, "err", err)
+			}
+		}
+	}()
+
+	batch := newKVFreezerBatch(f)
+	if err := fn(batch); err != nil {
+		return 0, err
+	}
+	items, writeSize, err := batch.commit()
+	if err != nil {
+		return 0, err
+	}
+	for name, end := range batch.end {
+		f.tables[name].end = end
+	}
+	f.items.Store(items)
+	return writeSize, nil
+}
+
+// TruncateHead discards any recent data above the provided threshold number.
+// It returns the previous head number.
+func (f *KeyValueFreezer) TruncateHead(items uint64) (uint64, error) {
+	if f.readonly {
+		return 0, errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	old := f.items.Load()
+	if old <= items {
+		return old, nil
+	}
+	tail := f.tail.Load()
+	if items < tail {
+		return 0, errors.New("truncation below tail")
+	}
+	// Update the item counter first, the items above are dangling afterwards
+	// and will be removed.
+	if err := f.writeCounters(f.db.NewBatch(), items, tail, nil); err != nil {
+		return 0, err
+	}
+	f.items.Store(items)
+	for name, table := range f.tables {
+		end, err := f.readEnd(name, table)
+		if err != nil {
+			return 0, err
+		}
+		table.end = end
+		if err := f.deleteRange(kvFreezerItemKey(name, items), kvFreezerTableEnd(name)); err != nil {
+			return 0, err
+		}
+	}
+	return old, nil
+}
+
+// TruncateTail discards all data below the provided threshold number.
+// Note this will only truncate 'prunable' tables. Block headers and canonical
+// hashes cannot be truncated at this time.
+func (f *KeyValueFreezer) TruncateTail(tail uint64) (uint64, error) {
+	if f.readonly {
+		return 0, errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	old := f.tail.Load()
+	if old >= tail {
+		return old, nil
+	}
+	items := f.items.Load()
+	if tail > items {
+		return 0, errors.New("truncation above head")
+	}
+	// Resolve the table sizes at the new tail before deleting the items.
+	begins := make(map[string]uint64)
+	for name, table := range f.tables {
+		if !table.config.prunable {
+			continue
+		}
+		blob, err := f.db.Get(kvFreezerItemKey(name, tail-1))
+		if err != nil || len(blob) < 8 {
+			return 0, fmt.Errorf("missing ancient item %s %d", name, tail-1)
+		}
+		begins[name] = binary.BigEndian.Uint64(blob)
+	}
+	if err := f.writeCounters(f.db.NewBatch(), items, tail, begins); err != nil {
+		return 0, err
+	}
+	f.tail.Store(tail)
+	for name, begin := range begins {
+		f.tables[name].begin = begin
+		if err := f.deleteRange(kvFreezerItemKey(name, old), kvFreezerItemKey(name, tail)); err != nil {
+			return 0, err
+		}
+	}
+	return old, nil
+}
+
+// SyncAncient flushes all the pending writes to disk.
+func (f *KeyValueFreezer) SyncAncient() error {
+	return f.db.SyncKeyValue()
+}
+
+// Close releases all the sources held by the freezer, including the backing
+// key-value store.
+func (f *KeyValueFreezer) Close() error {
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	return f.db.Close()
+}
+
+// Reset drops all the data in the freezer and resets itself back to default
+// state.
+func (f *KeyValueFreezer) Reset() error {
+	if f.readonly {
+		return errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	if err := f.writeCounters(f.db.NewBatch(), 0, 0, map[string]uint64{}); err != nil {
+		return err
+	}
+	for name, table := range f.tables {
+		if err := f.db.Delete(kvFreezerBeginKey(name)); err != nil {
+			return err
+		}
+		if err := f.deleteRange(kvFreezerItemKey(name, 0), kvFreezerTableEnd(name)); err != nil {
+			return err
+		}
+		table.begin, table.end = 0, 0
+	}
+	f.items.Store(0)
+	f.tail.Store(0)
+	return nil
+}
+
+// AncientDatadir returns the path of the ancient store. The key-value based
+// freezer is not aware of its location, an empty string is returned.
+func (f *KeyValueFreezer) AncientDatadir() (string, error) {
+	return "", nil
+}
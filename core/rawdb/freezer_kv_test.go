@@ -0,0 +1,324 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb/ancienttest"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+)
+
+func TestKeyValueFreezer(t *testing.T) {
+	newTables := func(kinds []string) map[string]freezerTableConfig {
+		tables := make(map[string]freezerTableConfig)
+		for _, kind := range kinds {
+			tables[kind] = freezerTableConfig{
+				noSnappy: true,
+				prunable: true,
+			}
+		}
+		return tables
+	}
+	ancienttest.TestAncientSuite(t, func(kinds []string) ethdb.AncientStore {
+		f, err := NewKeyValueFreezer(memorydb.New(), false, newTables(kinds))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	})
+	ancienttest.TestResettableAncientSuite(t, func(kinds []string) ethdb.ResettableAncientStore {
+		f, err := NewKeyValueFreezer(memorydb.New(), false, newTables(kinds))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	})
+}
+
+// nopCloser wraps the key-value store, preventing it from being closed by the
+// freezer so that it can be reopened.
+type nopCloser struct {
+	ethdb.KeyValueStore
+}
+
+func (db nopCloser) Close() error { return nil }
+
+func TestKeyValueFreezerReopen(t *testing.T) {
+	var (
+		db     = nopCloser{memorydb.New()}
+		tables = map[string]freezerTableConfig{
+			"a": {prunable: false},
+			"b": {prunable: true},
+		}
+		item = func(kind string, i uint64) []byte { return []byte(fmt.Sprintf("%s-%d", kind, i)) }
+	)
+	f, err := NewKeyValueFreezer(db, false, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 10; i++ {
+			op.AppendRaw("a", i, item("a", i))
+			op.AppendRaw("b", i, item("b", i))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The failed write should be discarded entirely
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		op.AppendRaw("a", 10, item("a", 10))
+		return errors.New("failure")
+	})
+	if err == nil {
+		t.Fatal("Expected error for the failed write")
+	}
+	if _, err := f.TruncateTail(4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.TruncateHead(8); err != nil {
+		t.Fatal(err)
+	}
+	// Leave dangling items which are not referenced by the counters, e.g.
+	// an interrupted write.
+	db.Put(kvFreezerItemKey("a", 8), append(make([]byte, 8), item("a", 8)...))
+	db.Put(kvFreezerItemKey("b", 1), append(make([]byte, 8), item("b", 1)...))
+
+	f, err = NewKeyValueFreezer(db, false, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := f.Ancients(); items != 8 {
+		t.Fatalf("Unexpected item number, want 8, got %d", items)
+	}
+	if tail, _ := f.Tail(); tail != 4 {
+		t.Fatalf("Unexpected tail, want 4, got %d", tail)
+	}
+	for i := uint64(0); i < 10; i++ {
+		blob, err := f.Ancient("a", i)
+		if i < 8 {
+			if err != nil || !bytes.Equal(blob, item("a", i)) {
+				t.Fatalf("Unexpected item a %d: %v %q", i, err, blob)
+			}
+		} else if err == nil {
+			t.Fatalf("Expected error for truncated item a %d", i)
+		}
+		blob, err = f.Ancient("b", i)
+		if i >= 4 && i < 8 {
+			if err != nil || !bytes.Equal(blob, item("b", i)) {
+				t.Fatalf("Unexpected item b %d: %v %q", i, err, blob)
+			}
+		} else if err == nil {
+			t.Fatalf("Expected error for truncated item b %d", i)
+		}
+	}
+	if size, _ := f.AncientSize("a"); size != 8*3 {
+		t.Fatalf("Unexpected size of a, want %d, got %d", 8*3, size)
+	}
+	if size, _ := f.AncientSize("b"); size != 4*3 {
+		t.Fatalf("Unexpected size of b, want %d, got %d", 4*3, size)
+	}
+	// Ensure the dangling items are removed
+	for _, key := range [][]byte{kvFreezerItemKey("a", 8), kvFreezerItemKey("b", 1)} {
+		if ok, _ := db.Has(key); ok {
+			t.Fatalf("Dangling item %x is not removed", key)
+		}
+	}
+}
+
+func TestMigrateChainAncients(t *testing.T) {
+	var (
+		ancient = t.TempDir()
+		items   = uint64(100)
+		tail    = uint64(30)
+		item    = func(kind string, i uint64) []byte { return []byte(fmt.Sprintf("%s-%d", kind, i)) }
+		open    = func(path string) (ethdb.KeyValueStore, error) {
+			return pebble.New(path, 16, 16, "", false)
+		}
+	)
+	f, err := NewFreezer(filepath.Join(ancient, ChainFreezerName), "", false, freezerTableSize, chainFreezerTableConfigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < items; i++ {
+			for kind := range chainFreezerTableConfigs {
+				if err := op.AppendRaw(kind, i, item(kind, i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.TruncateTail(tail); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	check := func(f ethdb.AncientStore) {
+		t.Helper()
+		if n, _ := f.Ancients(); n != items {
+			t.Fatalf("Unexpected item number, want %d, got %d", items, n)
+		}
+		if n, _ := f.Tail(); n != tail {
+			t.Fatalf("Unexpected tail, want %d, got %d", tail, n)
+		}
+		for kind, config := range chainFreezerTableConfigs {
+			for i := uint64(0); i < items; i++ {
+				blob, err := f.Ancient(kind, i)
+				if config.prunable && i < tail {
+					if err == nil {
+						t.Fatalf("Expected error for pruned item %s %d", kind, i)
+					}
+					continue
+				}
+				if err != nil || !bytes.Equal(blob, item(kind, i)) {
+					t.Fatalf("Unexpected item %s %d: %v %q", kind, i, err, blob)
+				}
+			}
+		}
+	}
+	if engine := PreexistingAncientEngine(ancient); engine != AncientEngineFile {
+		t.Fatalf("Unexpected ancient engine, want %s, got %s", AncientEngineFile, engine)
+	}
+	if _, err := ResolveAncientEngine(ancient, AncientEnginePebble); err == nil {
+		t.Fatal("Expected error for mismatched ancient engine")
+	}
+	// Migrate the file-based ancient store into pebble
+	if err := MigrateChainAncients(ancient, AncientEnginePebble, open, nil); err != nil {
+		t.Fatalf("Failed to migrate ancient store: %v", err)
+	}
+	if engine := PreexistingAncientEngine(ancient); engine != AncientEnginePebble {
+		t.Fatalf("Unexpected ancient engine, want %s, got %s", AncientEnginePebble, engine)
+	}
+	if files := chainFreezerFiles(filepath.Join(ancient, ChainFreezerName)); len(files) != 0 {
+		t.Fatalf("File-based ancient store is not removed: %v", files)
+	}
+	db, err := open(filepath.Join(ancient, ChainPebbleFreezerName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kf, err := NewKeyValueFreezer(db, true, chainFreezerTableConfigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(kf)
+	kf.Close()
+
+	// Migrate it back into flat files
+	if err := MigrateChainAncients(ancient, AncientEngineFile, open, nil); err != nil {
+		t.Fatalf("Failed to migrate ancient store: %v", err)
+	}
+	if engine := PreexistingAncientEngine(ancient); engine != AncientEngineFile {
+		t.Fatalf("Unexpected ancient engine, want %s, got %s", AncientEngineFile, engine)
+	}
+	f, err = NewFreezer(filepath.Join(ancient, ChainFreezerName), "", true, freezerTableSize, chainFreezerTableConfigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	check(f)
+}
+
+func TestOpenPebbleAncients(t *testing.T) {
+	var (
+		ancient = t.TempDir()
+		kvdb    = nopCloser{memorydb.New()}
+		opts    = OpenOptions{
+			Ancient:       ancient,
+			AncientEngine: AncientEnginePebble,
+			OpenAncientStore: func(path string) (ethdb.KeyValueStore, error) {
+				return pebble.New(path, 16, 16, "", false)
+			},
+		}
+	)
+	db, err := Open(kvdb, opts)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for kind := range chainFreezerTableConfigs {
+			if err := op.AppendRaw(kind, 0, []byte(kind)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// The existing engine should be picked if it's not specified
+	opts.AncientEngine = ""
+	db, err = Open(kvdb, opts)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if blob, err := db.Ancient(ChainFreezerHeaderTable, 0); err != nil || string(blob) != ChainFreezerHeaderTable {
+		t.Fatalf("Unexpected ancient item: %v %q", err, blob)
+	}
+	db.Close()
+
+	// The mismatched engine should be rejected
+	opts.AncientEngine = AncientEngineFile
+	if _, err := Open(kvdb, opts); err == nil {
+		t.Fatal("Expected error for mismatched ancient engine")
+	}
+}
+
+// closeTracker is a key-value store recording whether it has been closed.
+type closeTracker struct {
+	ethdb.KeyValueStore
+	closed bool
+}
+
+func (db *closeTracker) Close() error {
+	db.closed = true
+	return nil
+}
+
+func TestOpenPebbleAncientsFailure(t *testing.T) {
+	// Corrupt the counter of the ancient store, failing the freezer creation
+	store := &closeTracker{KeyValueStore: memorydb.New()}
+	store.Put(kvFreezerItemsKey, []byte{0x1})
+
+	opts := OpenOptions{
+		Ancient:       t.TempDir(),
+		AncientEngine: AncientEnginePebble,
+		OpenAncientStore: func(path string) (ethdb.KeyValueStore, error) {
+			return store, nil
+		},
+	}
+	if _, err := Open(nopCloser{memorydb.New()}, opts); err == nil {
+		t.Fatal("Expected error for corrupted ancient store")
+	}
+	if !store.closed {
+		t.Fatal("Ancient store is not closed on failure")
+	}
+}
@@ -100,6 +100,10 @@ var (
 	// snapSyncStatusFlagKey flags that status of snap sync.
 	snapSyncStatusFlagKey = []byte("SnapSyncStatus")
 
+	// binaryConversionKey tracks the binary trie conversion progress across restarts,
+	// it's stored within the BinaryTriePrefix namespace.
+	binaryConversionKey = []byte("BinaryConversion")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td (deprecated)
@@ -135,6 +139,11 @@ var (
 	// (d) State ID lookups, etc.
 	VerklePrefix = []byte("v")
 
+	// BinaryTriePrefix is the database prefix for the binary trie converted
+	// from the merkle state, which includes the trie nodes and the conversion
+	// progress.
+	BinaryTriePrefix = []byte("y")
+
 	PreimagePrefix = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-")  // config prefix for the db
 	genesisPrefix  = []byte("ethereum-genesis-") // genesis state prefix for the db
@@ -74,6 +74,15 @@ func (t *table) Tail() (uint64, error) {
 	return t.db.Tail()
 }
 
+// EraAvailable is a noop passthrough that just forwards the request to the
+// underlying database, if it is backed by era1 archives.
+func (t *table) EraAvailable() uint64 {
+	if db, ok := t.db.(ethdb.EraReader); ok {
+		return db.EraAvailable()
+	}
+	return 0
+}
+
 // AncientSize is a noop passthrough that just forwards the request to the underlying
 // database.
 func (t *table) AncientSize(kind string) (uint64, error) {
@@ -58,8 +58,8 @@ type txIndexer struct {
 	// progress queries.
 	tail atomic.Pointer[uint64]
 
-	// cutoff denotes the block number before which the chain segment should
-	// be pruned and not available locally.
+	// cutoff denotes the block number before which the chain segment is
+	// pruned and not available locally, not even from the era1 archives.
 	cutoff uint64
 	db     ethdb.Database
 	term   chan chan struct{}
@@ -68,7 +68,7 @@ type txIndexer struct {
 
 // newTxIndexer initializes the transaction indexer.
 func newTxIndexer(limit uint64, chain *BlockChain) *txIndexer {
-	cutoff, _ := chain.HistoryPruningCutoff()
+	cutoff := chain.HistoryServingCutoff()
 	indexer := &txIndexer{
 		limit:  limit,
 		cutoff: cutoff,
@@ -0,0 +1,253 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// blockExecution enables running legacy code in basic blocks. It's only turned
+// off in benchmarks, to compare against per-instruction execution.
+var blockExecution = true
+
+// BlockCache represents the cache of basic-block analysis results.
+type BlockCache interface {
+	// Load retrieves the cached block analysis for the given code hash.
+	// Returns the analysis and true if found, or nil and false if not cached.
+	Load(codeHash common.Hash) (*CodeBlocks, bool)
+
+	// Store saves the block analysis for the given code hash.
+	Store(codeHash common.Hash, blocks *CodeBlocks)
+}
+
+// sharedBlockCacheSize is the number of contracts whose basic-block analysis is
+// retained across EVM instances.
+const sharedBlockCacheSize = 1024
+
+// sharedBlocks is the default BlockCache, shared by all EVM instances. Unlike
+// the JUMPDEST analysis, the block analysis is too expensive to be redone for
+// each block: short calls into large contracts, e.g. token transfers, would
+// spend more time analysing the code than running it.
+var sharedBlocks = &lruBlocks{cache: lru.NewCache[common.Hash, *CodeBlocks](sharedBlockCacheSize)}
+
+// lruBlocks is a BlockCache retaining the analysis of the most recently used
+// contracts. It's safe for concurrent use, the analysis is never modified once
+// created.
+type lruBlocks struct {
+	cache *lru.Cache[common.Hash, *CodeBlocks]
+}
+
+func (b *lruBlocks) Load(codeHash common.Hash) (*CodeBlocks, bool) {
+	return b.cache.Get(codeHash)
+}
+
+func (b *lruBlocks) Store(codeHash common.Hash, blocks *CodeBlocks) {
+	b.cache.Add(codeHash, blocks)
+}
+
+// codeBlock is a straight-line run of instructions which only have a constant
+// gas cost. The gas and stack requirements of all of them are validated once
+// on entry instead of for each instruction.
+type codeBlock struct {
+	gas      uint64          // Sum of the constant gas of all instructions
+	minStack int             // Minimum stack height on entry to not underflow
+	maxStack int             // Maximum stack height on entry to not overflow
+	ops      []executionFunc // Instructions to run, with common pairs fused
+}
+
+// CodeBlocks is the basic-block analysis of a piece of legacy code, specific
+// to the instruction set it was created for.
+type CodeBlocks struct {
+	table  *JumpTable
+	index  []uint32 // 1-based index of the block starting at each code offset
+	blocks []codeBlock
+}
+
+// at returns the block starting at the given program counter, if any.
+func (c *CodeBlocks) at(pc uint64) *codeBlock {
+	if pc < uint64(len(c.index)) {
+		if idx := c.index[pc]; idx != 0 {
+			return &c.blocks[idx-1]
+		}
+	}
+	return nil
+}
+
+// blockable returns whether an instruction may be part of a basic block.
+// Instructions with dynamic gas costs or memory expansion, those inspecting the
+// remaining gas and undefined ones are executed individually.
+func blockable(op OpCode, operation *operation) bool {
+	return !operation.undefined && operation.dynamicGas == nil && operation.memorySize == nil && op != GAS
+}
+
+// analyseBlocks splits legacy code into basic blocks for the given instruction
+// set. Blocks start at JUMPDESTs and after any instruction which can not be
+// part of a block, and end after JUMP, JUMPI and STOP.
+func analyseBlocks(code []byte, table *JumpTable) *CodeBlocks {
+	var (
+		blocks = &CodeBlocks{table: table, index: make([]uint32, len(code))}
+		start  = -1     // Code offset of the block being built, -1 if none
+		ops    []OpCode // Instructions of the block being built
+		block  codeBlock
+		height int // Stack height relative to the block entry
+	)
+	flush := func() {
+		if start >= 0 {
+			block.ops = fuseOps(ops, table)
+			blocks.blocks = append(blocks.blocks, block)
+			blocks.index[start] = uint32(len(blocks.blocks))
+		}
+		start, ops = -1, ops[:0]
+	}
+	for pc := 0; pc < len(code); {
+		op := OpCode(code[pc])
+		operation := table[op]
+
+		next := pc + 1
+		if op >= PUSH1 && op <= PUSH32 {
+			next += int(op - PUSH0)
+		}
+		if op == JUMPDEST || !blockable(op, operation) {
+			flush()
+		}
+		if blockable(op, operation) {
+			if start < 0 {
+				start, height = pc, 0
+				block = codeBlock{maxStack: int(params.StackLimit)}
+			}
+			block.gas += operation.constantGas
+			block.minStack = max(block.minStack, operation.minStack-height)
+			block.maxStack = min(block.maxStack, operation.maxStack-height)
+			height += int(params.StackLimit) - operation.maxStack
+			ops = append(ops, op)
+
+			if op == JUMP || op == JUMPI || op == STOP {
+				flush()
+			}
+		}
+		pc = next
+	}
+	flush()
+	return blocks
+}
+
+// fuseOps returns the execution functions of a block's instructions, replacing
+// common pairs with superinstructions.
+func fuseOps(ops []OpCode, table *JumpTable) []executionFunc {
+	fns := make([]executionFunc, 0, len(ops))
+	for i := 0; i < len(ops); i++ {
+		if i+1 < len(ops) {
+			if fn := fuse(ops[i], ops[i+1]); fn != nil {
+				fns = append(fns, fn)
+				i++
+				continue
+			}
+		}
+		fns = append(fns, table[ops[i]].execute)
+	}
+	return fns
+}
+
+// fuse returns the superinstruction executing a followed by b, if there is one.
+// Superinstructions are entered at the first instruction and must leave the
+// program counter at the last byte of the second.
+func fuse(a, b OpCode) executionFunc {
+	switch {
+	case a == PUSH1 && b == JUMP:
+		return opPush1Jump
+	case a == PUSH1 && b == JUMPI:
+		return opPush1Jumpi
+	case a == SWAP1 && b == POP:
+		return opSwap1Pop
+	case a == SWAP2 && b == POP:
+		return opSwap2Pop
+	case a == DUP2 && b == DUP2:
+		return opDup2Dup2
+	}
+	return nil
+}
+
+func opPush1Jump(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	if evm.abort.Load() {
+		return nil, errStopToken
+	}
+	var pos uint256.Int
+	pos.SetUint64(uint64(scope.Contract.Code[*pc+1]))
+	if !scope.Contract.validJumpdest(&pos) {
+		return nil, ErrInvalidJump
+	}
+	*pc = pos.Uint64() - 1 // pc will be increased by the interpreter loop
+	return nil, nil
+}
+
+func opPush1Jumpi(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	if evm.abort.Load() {
+		return nil, errStopToken
+	}
+	if cond := scope.Stack.pop(); !cond.IsZero() {
+		var pos uint256.Int
+		pos.SetUint64(uint64(scope.Contract.Code[*pc+1]))
+		if !scope.Contract.validJumpdest(&pos) {
+			return nil, ErrInvalidJump
+		}
+		*pc = pos.Uint64() - 1 // pc will be increased by the interpreter loop
+	} else {
+		*pc += 2
+	}
+	return nil, nil
+}
+
+func opSwap1Pop(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	st := scope.Stack
+	st.data[st.len()-2] = st.data[st.len()-1]
+	st.data = st.data[:st.len()-1]
+	*pc += 1
+	return nil, nil
+}
+
+func opSwap2Pop(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	st := scope.Stack
+	st.data[st.len()-3] = st.data[st.len()-1]
+	st.data = st.data[:st.len()-1]
+	*pc += 1
+	return nil, nil
+}
+
+func opDup2Dup2(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	st := scope.Stack
+	st.data = append(st.data, st.data[st.len()-2], st.data[st.len()-1])
+	*pc += 1
+	return nil, nil
+}
+
+// codeBlocks returns the basic-block analysis of the contract's code for the
+// active instruction set. Initcode is not analysed, as it is mostly executed
+// only once.
+func (evm *EVM) codeBlocks(contract *Contract) *CodeBlocks {
+	if contract.CodeHash == (common.Hash{}) {
+		return nil
+	}
+	if blocks, ok := evm.blocks.Load(contract.CodeHash); ok && blocks.table == evm.table {
+		return blocks
+	}
+	blocks := analyseBlocks(contract.Code, evm.table)
+	evm.blocks.Store(contract.CodeHash, blocks)
+	return blocks
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestAnalyseBlocks(t *testing.T) {
+	code := []byte{
+		byte(PUSH1), 1, byte(PUSH1), 2, byte(ADD), // 0: plain block
+		byte(JUMPDEST), byte(SWAP1), byte(POP), // 5: starts at a jumpdest, fuses SWAP1 POP
+		byte(GAS),                  // 8: executed on its own
+		byte(PUSH1), 0, byte(JUMP), // 9: fuses PUSH1 JUMP
+		byte(STOP), // 12: block after a jump
+	}
+	evm := NewEVM(BlockContext{BlockNumber: big.NewInt(1), Time: 1, Random: &common.Hash{}}, nil, params.MergedTestChainConfig, Config{})
+	blocks := analyseBlocks(code, evm.table)
+
+	tests := []struct {
+		pc       uint64
+		gas      uint64
+		minStack int
+		maxStack int
+		ops      int
+	}{
+		{pc: 0, gas: 9, minStack: 0, maxStack: 1022, ops: 3},
+		{pc: 5, gas: 6, minStack: 2, maxStack: 1024, ops: 2},
+		{pc: 9, gas: 11, minStack: 0, maxStack: 1023, ops: 1},
+		{pc: 12, gas: 0, minStack: 0, maxStack: 1024, ops: 1},
+	}
+	starts := make(map[uint64]bool)
+	for _, tt := range tests {
+		starts[tt.pc] = true
+		block := blocks.at(tt.pc)
+		if block == nil {
+			t.Fatalf("pc %d: missing block", tt.pc)
+		}
+		if block.gas != tt.gas || block.minStack != tt.minStack || block.maxStack != tt.maxStack || len(block.ops) != tt.ops {
+			t.Errorf("pc %d: have gas %d, stack [%d, %d], %d ops, want gas %d, stack [%d, %d], %d ops",
+				tt.pc, block.gas, block.minStack, block.maxStack, len(block.ops), tt.gas, tt.minStack, tt.maxStack, tt.ops)
+		}
+	}
+	for pc := uint64(0); pc < uint64(len(code))+1; pc++ {
+		if !starts[pc] && blocks.at(pc) != nil {
+			t.Errorf("pc %d: unexpected block", pc)
+		}
+	}
+}
+
+// runBlockTest executes code and returns the outcome in a comparable form.
+// A non-nil tracer forces per-instruction execution.
+func runBlockTest(code []byte, gas uint64, tracer *tracing.Hooks) string {
+	var (
+		address    = common.BytesToAddress([]byte("contract"))
+		statedb, _ = state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+		vmctx      = BlockContext{
+			CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+			BlockNumber: big.NewInt(1),
+			Time:        1,
+			Random:      &common.Hash{},
+		}
+	)
+	statedb.CreateAccount(address)
+	statedb.SetCode(address, code, tracing.CodeChangeUnspecified)
+	statedb.Finalise(true)
+
+	evm := NewEVM(vmctx, statedb, params.MergedTestChainConfig, Config{Tracer: tracer})
+	ret, left, err := evm.Call(common.Address{}, address, nil, gas, new(uint256.Int))
+	return fmt.Sprintf("ret %x, gas %d, err %v, root %x", ret, left, err, statedb.IntermediateRoot(true))
+}
+
+func TestBlockExecution(t *testing.T) {
+	tests := []struct {
+		code string
+		gas  uint64
+	}{
+		{"6001600201600055", 100000},             // PUSH1 1 PUSH1 2 ADD PUSH1 0 SSTORE
+		{"6001600201600055", 22000},              // out of gas in SSTORE
+		{"600160020160005500", 8},                // out of gas within the first block
+		{"5b600060005600", 100000},               // stack overflow in a loop
+		{"6001900001", 100000},                   // stack underflow within a block
+		{"600456", 100000},                       // invalid jump
+		{"6000600757fe5b600160025b9050", 100000}, // JUMPI fallthrough, SWAP1 POP
+		{"600160025b818101600a5700", 100000},     // DUP2 DUP2 in a loop
+		{"6001600260039150600055", 100000},       // SWAP2 POP
+		{"5a600055", 100000},                     // GAS
+		{"60016000fd", 100000},                   // revert keeps gas
+		{"600160005d60005c600055", 100000},       // transient storage
+	}
+	for i, tt := range tests {
+		code := common.FromHex(tt.code)
+		want := runBlockTest(code, tt.gas, &tracing.Hooks{})
+		if have := runBlockTest(code, tt.gas, nil); have != want {
+			t.Errorf("test %d: block execution mismatch\nhave %s\nwant %s", i, have, want)
+		}
+	}
+}
+
+// TestBlockExecutionRandom compares block and per-instruction execution of
+// random programs made of blockable and non-blockable instructions.
+func TestBlockExecutionRandom(t *testing.T) {
+	ops := []OpCode{
+		PUSH1, PUSH1, PUSH1, PUSH2, PUSH0, ADD, SUB, MUL, LT, ISZERO, DUP1, DUP2, DUP3,
+		SWAP1, SWAP2, POP, JUMPDEST, JUMPDEST, JUMP, JUMPI, GAS, PC, MSTORE, MLOAD,
+		SSTORE, SLOAD, TSTORE, TLOAD, CALLVALUE, STOP, INVALID, RETURN,
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		var code []byte
+		for n := rng.Intn(64) + 1; n > 0; n-- {
+			op := ops[rng.Intn(len(ops))]
+			code = append(code, byte(op))
+			switch op {
+			case PUSH1:
+				code = append(code, byte(rng.Intn(64)))
+			case PUSH2:
+				code = append(code, 0, byte(rng.Intn(64)))
+			}
+		}
+		gas := uint64(rng.Intn(30000))
+		want := runBlockTest(code, gas, &tracing.Hooks{})
+		if have := runBlockTest(code, gas, nil); have != want {
+			t.Fatalf("program %x with gas %d: block execution mismatch\nhave %s\nwant %s", code, gas, have, want)
+		}
+	}
+}
+
+// BenchmarkBlockInterpreter compares block execution against per-instruction
+// execution on contracts of different shapes. Every iteration runs the code on
+// a new EVM, like the transactions of separate blocks, so the cost of the block
+// analysis is included unless its result is cached across EVMs.
+func BenchmarkBlockInterpreter(b *testing.B) {
+	contracts := []struct {
+		name string
+		code string
+	}{
+		// Tight arithmetic loop made of blockable instructions only:
+		//   PUSH2 0x1000 JUMPDEST PUSH1 1 SWAP1 SUB DUP1 PUSH1 3 JUMPI STOP
+		{"loop", "6110005b6001900380600357" + "00"},
+		// Loop mixing arithmetic with memory access, which splits the blocks:
+		//   PUSH2 0x1000 JUMPDEST DUP1 DUP1 MSTORE PUSH1 0 MLOAD ADD PUSH1 1
+		//   SWAP1 SUB DUP1 PUSH1 3 JUMPI STOP
+		{"memory", "6110005b8080526000510160019003806003570" + "0"},
+		// Loop hashing memory, dominated by the cost of the instruction itself:
+		//   PUSH2 0x1000 JUMPDEST PUSH1 0x40 PUSH1 0 SHA3 POP PUSH1 1 SWAP1 SUB
+		//   DUP1 PUSH1 3 JUMPI STOP
+		{"keccak", "6110005b60406000205060019003806003570" + "0"},
+		// Dispatcher of a typical contract, comparing the selector against a
+		// number of candidates before jumping to the matching function.
+		{"dispatch", dispatcherCode(64)},
+	}
+	var (
+		address = common.BytesToAddress([]byte("contract"))
+		vmctx   = BlockContext{
+			CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+			BlockNumber: big.NewInt(1),
+			Time:        1,
+			Random:      &common.Hash{},
+		}
+	)
+	for _, c := range contracts {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, common.FromHex(c.code), tracing.CodeChangeUnspecified)
+		statedb.Finalise(true)
+
+		for _, blocks := range []bool{false, true} {
+			name := c.name + "/plain"
+			if blocks {
+				name = c.name + "/blocks"
+			}
+			b.Run(name, func(b *testing.B) {
+				defer func(enabled bool) { blockExecution = enabled }(blockExecution)
+				blockExecution = blocks
+
+				input := common.FromHex("ffffffff")
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					evm := NewEVM(vmctx, statedb, params.MergedTestChainConfig, Config{})
+					if _, _, err := evm.Call(common.Address{}, address, input, 10_000_000, new(uint256.Int)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// dispatcherCode returns the code of a contract dispatching calls by function
+// selector between n functions, matching none of the given selector 0xffffffff.
+func dispatcherCode(n int) string {
+	// PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR
+	code := []byte{byte(PUSH1), 0, byte(CALLDATALOAD), byte(PUSH1), 0xe0, byte(SHR)}
+	for i := 0; i < n; i++ {
+		// DUP1 PUSH4 selector EQ PUSH2 target JUMPI
+		code = append(code, byte(DUP1), byte(PUSH4), 0x12, 0x34, byte(i>>8), byte(i), byte(EQ), byte(PUSH2), 0, 0, byte(JUMPI))
+	}
+	// STOP, and the functions jumped to by no selector
+	code = append(code, byte(STOP), byte(JUMPDEST), byte(STOP))
+	return common.Bytes2Hex(code)
+}
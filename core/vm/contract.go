@@ -38,6 +38,12 @@ type Contract struct {
 	CodeHash common.Hash
 	Input    []byte
 
+	// Container is the parsed EOF container if the executed code is EOF, in
+	// which case Code holds the currently executed code section.
+	Container   *Container
+	codeSection int
+	returnStack []returnFrame // EOF return stack of CALLF/RETF
+
 	// is the execution frame represented by this object a contract deployment
 	IsDeployment bool
 	IsSystemCall bool
@@ -158,6 +164,13 @@ func (c *Contract) Value() *uint256.Int {
 	return c.value
 }
 
+// setCodeSection switches the executed code to the given code section of the
+// EOF container.
+func (c *Contract) setCodeSection(section int) {
+	c.Code = c.Container.codeSections[section]
+	c.codeSection = section
+}
+
 // SetCallCode sets the code of the contract,
 func (c *Contract) SetCallCode(hash common.Hash, code []byte) {
 	c.Code = code
@@ -24,6 +24,7 @@ import (
 	"maps"
 	"math"
 	"math/big"
+	"slices"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
@@ -209,6 +210,12 @@ func init() {
 }
 
 func activePrecompiledContracts(rules params.Rules) PrecompiledContracts {
+	return withCustomPrecompiles(standardPrecompiledContracts(rules), rules)
+}
+
+// standardPrecompiledContracts returns the precompiled contracts of the fork
+// active with the current configuration.
+func standardPrecompiledContracts(rules params.Rules) PrecompiledContracts {
 	switch {
 	case rules.IsVerkle:
 		return PrecompiledContractsVerkle
@@ -236,6 +243,20 @@ func ActivePrecompiledContracts(rules params.Rules) PrecompiledContracts {
 
 // ActivePrecompiles returns the precompile addresses enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
+	addrs := standardPrecompiles(rules)
+	if len(rules.Precompiles) == 0 {
+		return addrs
+	}
+	addrs = slices.Clone(addrs)
+	for _, p := range rules.Precompiles {
+		addrs = append(addrs, p.Address)
+	}
+	return addrs
+}
+
+// standardPrecompiles returns the addresses of the precompiled contracts of the
+// fork active with the current configuration.
+func standardPrecompiles(rules params.Rules) []common.Address {
 	switch {
 	case rules.IsOsaka:
 		return PrecompiledAddressesOsaka
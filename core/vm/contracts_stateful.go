@@ -0,0 +1,368 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// errStatefulPrecompile is returned if a stateful precompiled contract is run
+// without the context of a call.
+var errStatefulPrecompile = errors.New("stateful precompile requires call context")
+
+// StatefulPrecompiledContract is a native Go contract with access to the call
+// context and a restricted view of the state. It allows private networks to
+// add native contracts via the chain configuration, without modifying the
+// standard set of precompiles.
+type StatefulPrecompiledContract interface {
+	RequiredGas(input []byte) uint64                          // RequiredGas calculates the contract gas use
+	Run(ctx *PrecompileContext, input []byte) ([]byte, error) // Run runs the precompiled contract
+	Name() string
+}
+
+// PrecompileFactory creates a stateful precompiled contract from its module
+// specific configuration in the chain config.
+type PrecompileFactory func(config json.RawMessage) (StatefulPrecompiledContract, error)
+
+var (
+	precompileModules   = make(map[string]PrecompileFactory)
+	precompileModulesMu sync.RWMutex
+
+	// precompileInstances caches the contracts created from the precompile
+	// configs of the chain config, so they're not recreated for each EVM. They
+	// are keyed by the content of the configs, so the copies of a chain config
+	// share the instances rather than each adding new ones.
+	precompileInstances sync.Map // precompileKey -> PrecompiledContract
+)
+
+// precompileKey identifies the contract created from a precompile config.
+type precompileKey struct {
+	module string
+	config string
+}
+
+// RegisterPrecompile makes a stateful precompiled contract implementation
+// available under the given module name, to be referenced by the precompiles
+// of the chain config. It panics if the module is registered twice.
+func RegisterPrecompile(module string, factory PrecompileFactory) {
+	precompileModulesMu.Lock()
+	defer precompileModulesMu.Unlock()
+
+	if _, ok := precompileModules[module]; ok {
+		panic(fmt.Sprintf("precompile module %q already registered", module))
+	}
+	precompileModules[module] = factory
+}
+
+// ValidatePrecompiles checks that the custom precompiled contracts of the chain
+// config reference registered modules with valid configurations, and don't
+// collide with each other or the standard precompiles.
+func ValidatePrecompiles(config *params.ChainConfig) error {
+	seen := make(map[common.Address]bool)
+	for _, p := range config.Precompiles {
+		if seen[p.Address] {
+			return fmt.Errorf("duplicate precompile at %v", p.Address)
+		}
+		seen[p.Address] = true
+
+		if _, ok := PrecompiledContractsVerkle[p.Address]; ok {
+			return fmt.Errorf("precompile %s collides with standard precompile at %v", p.Module, p.Address)
+		}
+		if _, ok := PrecompiledContractsOsaka[p.Address]; ok {
+			return fmt.Errorf("precompile %s collides with standard precompile at %v", p.Module, p.Address)
+		}
+		if _, err := loadPrecompile(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPrecompile returns the contract configured by the given precompile
+// config, creating it on first use.
+func loadPrecompile(config *params.PrecompileConfig) (PrecompiledContract, error) {
+	key := precompileKey{module: config.Module, config: string(config.Config)}
+	if p, ok := precompileInstances.Load(key); ok {
+		return p.(PrecompiledContract), nil
+	}
+	precompileModulesMu.RLock()
+	factory, ok := precompileModules[config.Module]
+	precompileModulesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown precompile module %q", config.Module)
+	}
+	contract, err := factory(config.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config of precompile %s: %w", config.Module, err)
+	}
+	p, _ := precompileInstances.LoadOrStore(key, &statefulPrecompile{contract: contract})
+	return p.(PrecompiledContract), nil
+}
+
+// withCustomPrecompiles extends the given precompiled contracts with the custom
+// ones active with the current configuration.
+func withCustomPrecompiles(contracts PrecompiledContracts, rules params.Rules) PrecompiledContracts {
+	if len(rules.Precompiles) == 0 {
+		return contracts
+	}
+	extended := make(PrecompiledContracts, len(contracts)+len(rules.Precompiles))
+	for addr, p := range contracts {
+		extended[addr] = p
+	}
+	for _, config := range rules.Precompiles {
+		p, err := loadPrecompile(config)
+		if err != nil {
+			// Configs are validated on chain setup, this should never happen
+			log.Error("Failed to load precompile", "module", config.Module, "address", config.Address, "err", err)
+			continue
+		}
+		extended[config.Address] = p
+	}
+	return extended
+}
+
+// statefulPrecompile adapts a stateful precompiled contract to the interface
+// of the stateless ones, so it can be installed along them.
+type statefulPrecompile struct {
+	contract StatefulPrecompiledContract
+}
+
+func (p *statefulPrecompile) RequiredGas(input []byte) uint64 {
+	return p.contract.RequiredGas(input)
+}
+
+func (p *statefulPrecompile) Run(input []byte) ([]byte, error) {
+	return nil, errStatefulPrecompile
+}
+
+func (p *statefulPrecompile) Name() string {
+	return p.contract.Name()
+}
+
+// runPrecompiledContract runs a precompiled contract as part of a call, passing
+// stateful contracts the context of the call.
+func (evm *EVM) runPrecompiledContract(p PrecompiledContract, caller common.Address, addr common.Address, input []byte, gas uint64, value *uint256.Int, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if evm.Config.EnableOpcodeProfiling {
+		defer func(start time.Time) { ProfilePrecompile(p.Name(), time.Since(start)) }(time.Now())
+	}
+	sp, ok := p.(*statefulPrecompile)
+	if !ok {
+		return RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+	}
+	gasCost := sp.RequiredGas(input)
+	if gas < gasCost {
+		return nil, 0, ErrOutOfGas
+	}
+	if logger := evm.Config.Tracer; logger != nil && logger.OnGasChange != nil {
+		logger.OnGasChange(gas, gas-gasCost, tracing.GasChangeCallPrecompiledContract)
+	}
+	ctx := &PrecompileContext{
+		evm:      evm,
+		address:  addr,
+		caller:   caller,
+		value:    value,
+		readOnly: readOnly || evm.readOnly,
+		gas:      gas - gasCost,
+	}
+	output, err := sp.contract.Run(ctx, input)
+	return output, ctx.gas, err
+}
+
+// PrecompileContext is the context a stateful precompiled contract is called
+// in. Its state access is restricted to reading accounts, and modifying the
+// storage of the precompile itself.
+type PrecompileContext struct {
+	evm      *EVM
+	address  common.Address
+	caller   common.Address
+	value    *uint256.Int
+	readOnly bool
+	gas      uint64 // Gas left for storage access, after the required gas
+}
+
+// Address returns the address the precompiled contract is installed at.
+func (ctx *PrecompileContext) Address() common.Address {
+	return ctx.address
+}
+
+// Caller returns the address of the caller.
+func (ctx *PrecompileContext) Caller() common.Address {
+	return ctx.caller
+}
+
+// Origin returns the sender of the transaction.
+func (ctx *PrecompileContext) Origin() common.Address {
+	return ctx.evm.Origin
+}
+
+// Value returns the value transferred with the call.
+func (ctx *PrecompileContext) Value() *uint256.Int {
+	return new(uint256.Int).Set(ctx.value)
+}
+
+// ReadOnly returns whether the call is made in a static context, in which the
+// state can't be modified.
+func (ctx *PrecompileContext) ReadOnly() bool {
+	return ctx.readOnly
+}
+
+// BlockNumber returns the number of the block being executed.
+func (ctx *PrecompileContext) BlockNumber() *big.Int {
+	return new(big.Int).Set(ctx.evm.Context.BlockNumber)
+}
+
+// Time returns the timestamp of the block being executed.
+func (ctx *PrecompileContext) Time() uint64 {
+	return ctx.evm.Context.Time
+}
+
+// Gas returns the gas left to the call, after the required gas of the contract
+// and the storage accessed so far are paid for.
+func (ctx *PrecompileContext) Gas() uint64 {
+	return ctx.gas
+}
+
+// StateDB returns the restricted state access of the precompiled contract.
+func (ctx *PrecompileContext) StateDB() PrecompileStateDB {
+	return &precompileStateDB{ctx: ctx}
+}
+
+// useStorageGas charges the gas of a storage opcode from the gas left to the
+// call, as if the contract at addr executed it with the given stack arguments,
+// top first. The storage access of precompiles thus costs the same as the one
+// of regular contracts in the active fork, including the access list and the
+// refund accounting.
+func (ctx *PrecompileContext) useStorageGas(op OpCode, addr common.Address, args ...common.Hash) error {
+	var (
+		operation = ctx.evm.table[op]
+		contract  = &Contract{address: addr, Gas: ctx.gas}
+		stack     = newstack()
+	)
+	defer returnStack(stack)
+
+	for i := len(args) - 1; i >= 0; i-- {
+		stack.push(new(uint256.Int).SetBytes(args[i][:]))
+	}
+	cost := operation.constantGas
+	if operation.dynamicGas != nil {
+		dynamicCost, err := operation.dynamicGas(ctx.evm, contract, stack, nil, 0)
+		if err != nil {
+			return err
+		}
+		if cost += dynamicCost; cost < dynamicCost {
+			return ErrGasUintOverflow
+		}
+	}
+	if ctx.gas < cost {
+		return ErrOutOfGas
+	}
+	ctx.gas -= cost
+	return nil
+}
+
+// PrecompileStateDB is the state access of stateful precompiled contracts. Any
+// account can be read, but only the storage of the precompile written to.
+type PrecompileStateDB interface {
+	GetBalance(addr common.Address) *uint256.Int
+	GetNonce(addr common.Address) uint64
+	GetCodeHash(addr common.Address) common.Hash
+
+	// GetState reads a storage slot of any account, charging the gas of an
+	// SLOAD. It fails if the call runs out of gas.
+	GetState(addr common.Address, key common.Hash) (common.Hash, error)
+
+	// SetState sets a storage slot of the precompile, charging the gas of an
+	// SSTORE. It fails if the call is made in a static context or runs out of
+	// gas.
+	SetState(key common.Hash, value common.Hash) error
+
+	// AddLog emits a log from the precompile. It fails if the call is made
+	// in a static context.
+	AddLog(topics []common.Hash, data []byte) error
+}
+
+type precompileStateDB struct {
+	ctx *PrecompileContext
+}
+
+func (db *precompileStateDB) GetBalance(addr common.Address) *uint256.Int {
+	return db.ctx.evm.StateDB.GetBalance(addr)
+}
+
+func (db *precompileStateDB) GetNonce(addr common.Address) uint64 {
+	return db.ctx.evm.StateDB.GetNonce(addr)
+}
+
+func (db *precompileStateDB) GetCodeHash(addr common.Address) common.Hash {
+	return db.ctx.evm.StateDB.GetCodeHash(addr)
+}
+
+func (db *precompileStateDB) GetState(addr common.Address, key common.Hash) (common.Hash, error) {
+	if err := db.ctx.useStorageGas(SLOAD, addr, key); err != nil {
+		return common.Hash{}, err
+	}
+	return db.ctx.evm.StateDB.GetState(addr, key), nil
+}
+
+func (db *precompileStateDB) SetState(key common.Hash, value common.Hash) error {
+	if db.ctx.readOnly {
+		return ErrWriteProtection
+	}
+	var (
+		state = db.ctx.evm.StateDB
+		addr  = db.ctx.address
+	)
+	if err := db.ctx.useStorageGas(SSTORE, addr, key, value); err != nil {
+		return err
+	}
+	// Accounts without nonce, balance and code are deleted as empty at the end
+	// of the transaction along with their storage, so mark the precompile as
+	// non-empty the same way contracts are on creation.
+	if state.GetNonce(addr) == 0 {
+		state.SetNonce(addr, 1, tracing.NonceChangeNewContract)
+	}
+	state.SetState(addr, key, value)
+	return nil
+}
+
+func (db *precompileStateDB) AddLog(topics []common.Hash, data []byte) error {
+	if db.ctx.readOnly {
+		return ErrWriteProtection
+	}
+	db.ctx.evm.StateDB.AddLog(&types.Log{
+		Address: db.ctx.address,
+		Topics:  topics,
+		Data:    common.CopyBytes(data),
+		// This is a non-consensus field, but assigned here because
+		// core/state doesn't know the current block number.
+		BlockNumber: db.ctx.evm.Context.BlockNumber.Uint64(),
+	})
+	return nil
+}
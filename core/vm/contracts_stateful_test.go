@@ -0,0 +1,195 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// counterPrecompile is a stateful precompile incrementing a counter in its own
+// storage by a configured step on each call, returning the caller.
+type counterPrecompile struct {
+	Step uint64 `json:"step"`
+}
+
+func (c *counterPrecompile) RequiredGas(input []byte) uint64 { return 100 }
+func (c *counterPrecompile) Name() string                    { return "COUNTER" }
+
+func (c *counterPrecompile) Run(ctx *PrecompileContext, input []byte) ([]byte, error) {
+	db := ctx.StateDB()
+
+	value, err := db.GetState(ctx.Address(), common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	count := new(uint256.Int).SetBytes(value.Bytes())
+	count.AddUint64(count, c.Step)
+	if err := db.SetState(common.Hash{}, count.Bytes32()); err != nil {
+		return nil, err
+	}
+	if err := db.AddLog([]common.Hash{count.Bytes32()}, ctx.Value().Bytes()); err != nil {
+		return nil, err
+	}
+	return common.LeftPadBytes(ctx.Caller().Bytes(), 32), nil
+}
+
+func init() {
+	RegisterPrecompile("test-counter", func(config json.RawMessage) (StatefulPrecompiledContract, error) {
+		c := new(counterPrecompile)
+		if err := json.Unmarshal(config, c); err != nil {
+			return nil, err
+		}
+		if c.Step == 0 {
+			return nil, errors.New("zero step")
+		}
+		return c, nil
+	})
+}
+
+func newUint64(val uint64) *uint64 { return &val }
+
+func TestStatefulPrecompile(t *testing.T) {
+	var (
+		addr   = common.HexToAddress("0x0300000000000000000000000000000000000001")
+		caller = common.HexToAddress("0xca11e4")
+		config = *params.MergedTestChainConfig
+	)
+	config.Precompiles = []*params.PrecompileConfig{{
+		Module:  "test-counter",
+		Address: addr,
+		Time:    newUint64(10),
+		Config:  json.RawMessage(`{"step": 2}`),
+	}}
+	if err := ValidatePrecompiles(&config); err != nil {
+		t.Fatalf("failed to validate precompiles: %v", err)
+	}
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+
+	// Before the activation, the address is a plain empty account
+	vmctx := eofTestBlockContext
+	vmctx.Time = 9
+	evm := NewEVM(vmctx, statedb, &config, Config{})
+	if _, ok := evm.precompile(addr); ok {
+		t.Fatal("precompile active before activation time")
+	}
+	if slices.Contains(ActivePrecompiles(evm.chainRules), addr) {
+		t.Fatal("precompile address active before activation time")
+	}
+
+	// After the activation, calls increment the counter
+	vmctx.Time = 10
+	evm = NewEVM(vmctx, statedb, &config, Config{})
+	if !slices.Contains(ActivePrecompiles(evm.chainRules), addr) {
+		t.Fatal("precompile address missing after activation time")
+	}
+	// The storage access is charged like the one of contracts: the first call
+	// pays for the cold read and the new slot, the second one for warm access.
+	if _, _, err := evm.Call(caller, addr, nil, 2000, uint256.NewInt(7)); !errors.Is(err, ErrOutOfGas) {
+		t.Fatalf("call without storage gas error mismatch: have %v, want %v", err, ErrOutOfGas)
+	}
+	wantGas := []uint64{
+		30000 - 100 - params.ColdSloadCostEIP2929 - params.SstoreSetGasEIP2200,
+		30000 - 100 - 2*params.WarmStorageReadCostEIP2929,
+	}
+	for i := 1; i <= 2; i++ {
+		ret, gas, err := evm.Call(caller, addr, nil, 30000, uint256.NewInt(7))
+		if err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+		if gas != wantGas[i-1] {
+			t.Errorf("call %d: gas mismatch: have %d, want %d", i, gas, wantGas[i-1])
+		}
+		if !bytes.Equal(ret, common.LeftPadBytes(caller.Bytes(), 32)) {
+			t.Errorf("call %d: return mismatch: have %x", i, ret)
+		}
+		if have, want := statedb.GetState(addr, common.Hash{}), common.BigToHash(common.Big2); i == 1 && have != want {
+			t.Errorf("call %d: counter mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	if have := statedb.GetState(addr, common.Hash{}).Big().Uint64(); have != 4 {
+		t.Errorf("counter mismatch: have %d, want %d", have, 4)
+	}
+	if logs := statedb.Logs(); len(logs) != 2 || logs[0].Address != addr || !bytes.Equal(logs[0].Data, []byte{7}) {
+		t.Errorf("unexpected logs: %v", logs)
+	}
+	// The precompile must not be deleted as an empty account with its storage
+	statedb.Finalise(true)
+	if have := statedb.GetState(addr, common.Hash{}).Big().Uint64(); have != 4 {
+		t.Errorf("counter mismatch after finalisation: have %d, want %d", have, 4)
+	}
+
+	// Static calls can't modify the state
+	if _, _, err := evm.StaticCall(caller, addr, nil, 30000); !errors.Is(err, ErrWriteProtection) {
+		t.Errorf("static call error mismatch: have %v, want %v", err, ErrWriteProtection)
+	}
+	// Copies of the chain config share the contract instances
+	copied := *config.Precompiles[0]
+	p1, _ := loadPrecompile(config.Precompiles[0])
+	p2, _ := loadPrecompile(&copied)
+	if p1 != p2 {
+		t.Error("precompile instance not shared between identical configs")
+	}
+}
+
+func TestValidatePrecompiles(t *testing.T) {
+	for i, test := range []struct {
+		precompiles []*params.PrecompileConfig
+		fail        bool
+	}{
+		{
+			precompiles: []*params.PrecompileConfig{{Module: "test-counter", Address: common.Address{0xaa}, Config: json.RawMessage(`{"step":1}`)}},
+		},
+		{
+			// Unknown module
+			precompiles: []*params.PrecompileConfig{{Module: "test-unknown", Address: common.Address{0xaa}}},
+			fail:        true,
+		},
+		{
+			// Rejected module config
+			precompiles: []*params.PrecompileConfig{{Module: "test-counter", Address: common.Address{0xaa}, Config: json.RawMessage(`{"step":0}`)}},
+			fail:        true,
+		},
+		{
+			// Collision with the standard precompiles
+			precompiles: []*params.PrecompileConfig{{Module: "test-counter", Address: common.BytesToAddress([]byte{0x1}), Config: json.RawMessage(`{"step":1}`)}},
+			fail:        true,
+		},
+		{
+			// Duplicate addresses
+			precompiles: []*params.PrecompileConfig{
+				{Module: "test-counter", Address: common.Address{0xaa}, Config: json.RawMessage(`{"step":1}`)},
+				{Module: "test-counter", Address: common.Address{0xaa}, Config: json.RawMessage(`{"step":2}`)},
+			},
+			fail: true,
+		},
+	} {
+		err := ValidatePrecompiles(&params.ChainConfig{Precompiles: test.precompiles})
+		if fail := err != nil; fail != test.fail {
+			t.Errorf("test %d: validation mismatch: have %v, want failure %v", i, err, test.fail)
+		}
+	}
+}
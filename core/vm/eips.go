@@ -42,6 +42,7 @@ var activators = map[int]func(*JumpTable){
 	4762: enable4762,
 	7702: enable7702,
 	7939: enable7939,
+	7692: enable7692,
 }
 
 // EnableEIP enables the given EIP on the config.
@@ -556,3 +557,12 @@ func enable7702(jt *JumpTable) {
 	jt[STATICCALL].dynamicGas = gasStaticCallEIP7702
 	jt[DELEGATECALL].dynamicGas = gasDelegateCallEIP7702
 }
+
+// enable7692 applies the legacy code changes of EIP-7692 (EOFv1 meta), which
+// hide the contents of EOF contracts from code introspection. The EOF code
+// itself runs on a separate instruction set derived from this one.
+func enable7692(jt *JumpTable) {
+	jt[EXTCODESIZE].execute = opExtCodeSizeEOF
+	jt[EXTCODECOPY].execute = opExtCodeCopyEOF
+	jt[EXTCODEHASH].execute = opExtCodeHashEOF
+}
@@ -0,0 +1,383 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	offsetVersion   = 2
+	offsetTypesKind = 3
+	offsetCodeKind  = 6
+
+	kindTypes     = 1
+	kindCode      = 2
+	kindContainer = 3
+	kindData      = 0xff
+
+	eofFormatByte = 0xef
+	eof1Version   = 1
+
+	maxInputItems        = 127
+	maxOutputItems       = 127
+	nonReturningFunction = 0x80
+	maxStackHeight       = 1023
+	maxCodeSections      = 1024
+	maxContainerSections = 256
+	maxReturnStackHeight = 1024
+)
+
+var eofMagic = []byte{eofFormatByte, 0x00}
+
+var (
+	errInvalidMagic                = errors.New("invalid magic")
+	errInvalidVersion              = errors.New("invalid version")
+	errMissingTypeHeader           = errors.New("missing type header")
+	errInvalidTypeSize             = errors.New("invalid type section size")
+	errMissingCodeHeader           = errors.New("missing code header")
+	errInvalidCodeSize             = errors.New("invalid code size")
+	errInvalidContainerSectionSize = errors.New("invalid container section size")
+	errMissingDataHeader           = errors.New("missing data header")
+	errMissingTerminator           = errors.New("missing header terminator")
+	errTooManyInputs               = errors.New("invalid type content, too many inputs")
+	errTooManyOutputs              = errors.New("invalid type content, too many outputs")
+	errInvalidFirstSectionType     = errors.New("invalid section 0 type, input should be zero and section non-returning")
+	errTooLargeMaxStackHeight      = errors.New("invalid type content, max stack height exceeds limit")
+	errInvalidContainerSize        = errors.New("invalid container size")
+	errTruncatedTopLevelContainer  = errors.New("truncated top level container")
+)
+
+// HasEOFMagic returns whether the code starts with the EOF magic bytes.
+func HasEOFMagic(code []byte) bool {
+	return len(code) >= len(eofMagic) && code[0] == eofMagic[0] && code[1] == eofMagic[1]
+}
+
+// functionMetadata is the type section entry of a single code section.
+type functionMetadata struct {
+	inputs           uint8
+	outputs          uint8
+	maxStackIncrease uint16
+}
+
+// returning reports whether the code section returns to its caller.
+func (meta *functionMetadata) returning() bool {
+	return meta.outputs != nonReturningFunction
+}
+
+// Container is a parsed EOF version 1 container, as defined by EIP-3540.
+type Container struct {
+	types             []*functionMetadata
+	codeSections      [][]byte
+	subContainers     []*Container
+	subContainerCodes [][]byte
+	data              []byte
+	dataSize          int // declared size, might exceed len(data) in subcontainers
+}
+
+// CodeSections returns the code sections of the container.
+func (c *Container) CodeSections() [][]byte {
+	return c.codeSections
+}
+
+// MarshalBinary encodes the container into its binary EOF representation.
+func (c *Container) MarshalBinary() []byte {
+	b := make([]byte, 0, c.size())
+	b = append(b, eofMagic...)
+	b = append(b, eof1Version)
+
+	// Write the section headers
+	b = append(b, kindTypes)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.types)*4))
+	b = append(b, kindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.codeSections)))
+	for _, code := range c.codeSections {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(code)))
+	}
+	if len(c.subContainerCodes) > 0 {
+		b = append(b, kindContainer)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(c.subContainerCodes)))
+		for _, code := range c.subContainerCodes {
+			b = binary.BigEndian.AppendUint32(b, uint32(len(code)))
+		}
+	}
+	b = append(b, kindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(c.dataSize))
+	b = append(b, 0) // terminator
+
+	// Write the section contents
+	for _, ty := range c.types {
+		b = append(b, ty.inputs, ty.outputs)
+		b = binary.BigEndian.AppendUint16(b, ty.maxStackIncrease)
+	}
+	for _, code := range c.codeSections {
+		b = append(b, code...)
+	}
+	for _, code := range c.subContainerCodes {
+		b = append(b, code...)
+	}
+	return append(b, c.data...)
+}
+
+// size returns the length of the binary representation of the container.
+func (c *Container) size() int {
+	size := 3 + 3 + 3 + 2*len(c.codeSections) + 3 + 1 + 4*len(c.types)
+	if len(c.subContainerCodes) > 0 {
+		size += 3 + 4*len(c.subContainerCodes)
+	}
+	for _, code := range c.codeSections {
+		size += len(code)
+	}
+	for _, code := range c.subContainerCodes {
+		size += len(code)
+	}
+	return size + len(c.data)
+}
+
+// UnmarshalBinary decodes a top level EOF container, which must be complete
+// without any trailing bytes. The code sections are not validated.
+func (c *Container) UnmarshalBinary(b []byte) error {
+	size, err := c.unmarshal(b, true)
+	if err != nil {
+		return err
+	}
+	if size != len(b) {
+		return fmt.Errorf("%w: have %d, want %d", errInvalidContainerSize, len(b), size)
+	}
+	return nil
+}
+
+// unmarshal decodes an EOF container from the start of b, returning the size
+// the container declares for itself. Top level containers must contain the
+// whole declared data section, whereas subcontainers may end prematurely.
+func (c *Container) unmarshal(b []byte, topLevel bool) (int, error) {
+	if !HasEOFMagic(b) {
+		return 0, fmt.Errorf("%w: want %x", errInvalidMagic, eofMagic)
+	}
+	if len(b) < 14 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if b[offsetVersion] != eof1Version {
+		return 0, fmt.Errorf("%w: have %d, want %d", errInvalidVersion, b[offsetVersion], eof1Version)
+	}
+	// Parse the type section header
+	kind, typesSize, err := parseSection(b, offsetTypesKind)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindTypes {
+		return 0, fmt.Errorf("%w: found section kind %x instead", errMissingTypeHeader, kind)
+	}
+	if typesSize < 4 || typesSize%4 != 0 {
+		return 0, fmt.Errorf("%w: type section size must be divisible by 4, have %d", errInvalidTypeSize, typesSize)
+	}
+	if typesSize/4 > maxCodeSections {
+		return 0, fmt.Errorf("%w: type section must not exceed 4*%d, have %d", errInvalidTypeSize, maxCodeSections, typesSize)
+	}
+	// Parse the code section header
+	kind, codeSizes, err := parseSectionList(b, offsetCodeKind, 2)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindCode {
+		return 0, fmt.Errorf("%w: found section kind %x instead", errMissingCodeHeader, kind)
+	}
+	if len(codeSizes) != typesSize/4 {
+		return 0, fmt.Errorf("%w: mismatch of code sections found and type signatures, types %d, code %d", errInvalidCodeSize, typesSize/4, len(codeSizes))
+	}
+	offset := offsetCodeKind + 3 + 2*len(codeSizes)
+
+	// Parse the optional container section header
+	var containerSizes []int
+	if offset < len(b) && b[offset] == kindContainer {
+		if _, containerSizes, err = parseSectionList(b, offset, 4); err != nil {
+			return 0, err
+		}
+		if len(containerSizes) == 0 || len(containerSizes) > maxContainerSections {
+			return 0, fmt.Errorf("%w: number of container sections must be within 1..%d, have %d", errInvalidContainerSectionSize, maxContainerSections, len(containerSizes))
+		}
+		offset += 3 + 4*len(containerSizes)
+	}
+	// Parse the data section header
+	kind, dataSize, err := parseSection(b, offset)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindData {
+		return 0, fmt.Errorf("%w: found section kind %x instead", errMissingDataHeader, kind)
+	}
+	offset += 3
+	if offset >= len(b) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if b[offset] != 0 {
+		return 0, fmt.Errorf("%w: have %x", errMissingTerminator, b[offset])
+	}
+	offset++
+
+	// Check that the body fits the declared section sizes. Only the data section
+	// of subcontainers might be truncated.
+	expected := offset + typesSize + sum(codeSizes) + sum(containerSizes) + dataSize
+	if len(b) < expected-dataSize {
+		return 0, fmt.Errorf("%w: have %d, want %d", errInvalidContainerSize, len(b), expected)
+	}
+	if topLevel && len(b) < expected {
+		return 0, fmt.Errorf("%w: have %d, want %d", errTruncatedTopLevelContainer, len(b), expected)
+	}
+	// Parse the types section
+	types := make([]*functionMetadata, 0, typesSize/4)
+	for i := 0; i < typesSize/4; i++ {
+		sig := &functionMetadata{
+			inputs:           b[offset+i*4],
+			outputs:          b[offset+i*4+1],
+			maxStackIncrease: binary.BigEndian.Uint16(b[offset+i*4+2:]),
+		}
+		if sig.inputs > maxInputItems {
+			return 0, fmt.Errorf("%w for section %d: have %d", errTooManyInputs, i, sig.inputs)
+		}
+		if sig.outputs > maxOutputItems && sig.outputs != nonReturningFunction {
+			return 0, fmt.Errorf("%w for section %d: have %d", errTooManyOutputs, i, sig.outputs)
+		}
+		if sig.maxStackIncrease > maxStackHeight {
+			return 0, fmt.Errorf("%w for section %d: have %d", errTooLargeMaxStackHeight, i, sig.maxStackIncrease)
+		}
+		types = append(types, sig)
+	}
+	if types[0].inputs != 0 || types[0].returning() {
+		return 0, fmt.Errorf("%w: have %d, %d", errInvalidFirstSectionType, types[0].inputs, types[0].outputs)
+	}
+	offset += typesSize
+
+	// Parse the code sections
+	codeSections := make([][]byte, len(codeSizes))
+	for i, size := range codeSizes {
+		if size == 0 {
+			return 0, fmt.Errorf("%w for section %d: size must not be 0", errInvalidCodeSize, i)
+		}
+		codeSections[i] = b[offset : offset+size]
+		offset += size
+	}
+	// Parse the subcontainers
+	var (
+		subContainers     []*Container
+		subContainerCodes [][]byte
+	)
+	for i, size := range containerSizes {
+		if size == 0 {
+			return 0, fmt.Errorf("%w for subcontainer %d: size must not be 0", errInvalidContainerSectionSize, i)
+		}
+		code := b[offset : offset+size]
+
+		sub := new(Container)
+		subSize, err := sub.unmarshal(code, false)
+		if err != nil {
+			return 0, fmt.Errorf("subcontainer %d: %w", i, err)
+		}
+		if subSize < len(code) {
+			return 0, fmt.Errorf("subcontainer %d: %w: have %d, want %d", i, errInvalidContainerSize, len(code), subSize)
+		}
+		subContainers = append(subContainers, sub)
+		subContainerCodes = append(subContainerCodes, code)
+		offset += size
+	}
+	// Parse the data section, tolerating truncation as checked above
+	end := min(offset+dataSize, len(b))
+
+	c.types = types
+	c.codeSections = codeSections
+	c.subContainers = subContainers
+	c.subContainerCodes = subContainerCodes
+	c.data = b[offset:end]
+	c.dataSize = dataSize
+	return expected, nil
+}
+
+// String implements fmt.Stringer, returning a human readable description of
+// the container layout.
+func (c *Container) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "Header\n")
+	fmt.Fprintf(&out, "  - EOFMagic: %02x\n", eofMagic)
+	fmt.Fprintf(&out, "  - EOFVersion: %02x\n", eof1Version)
+	fmt.Fprintf(&out, "  - KindType: %02x\n", kindTypes)
+	fmt.Fprintf(&out, "  - TypesSize: %04x\n", len(c.types)*4)
+	fmt.Fprintf(&out, "  - KindCode: %02x\n", kindCode)
+	fmt.Fprintf(&out, "  - KindData: %02x\n", kindData)
+	fmt.Fprintf(&out, "  - DataSize: %04x\n", c.dataSize)
+	fmt.Fprintf(&out, "  - Number of code sections: %d\n", len(c.codeSections))
+	for i, code := range c.codeSections {
+		fmt.Fprintf(&out, "    - Code section %d length: %04x\n", i, len(code))
+	}
+	fmt.Fprintf(&out, "  - Number of subcontainers: %d\n", len(c.subContainers))
+	for i, code := range c.subContainerCodes {
+		fmt.Fprintf(&out, "    - Subcontainer %d length: %04x\n", i, len(code))
+	}
+	fmt.Fprintf(&out, "Body\n")
+	for i, ty := range c.types {
+		fmt.Fprintf(&out, "  - Type %d: inputs %d, outputs %d, max stack increase %d\n", i, ty.inputs, ty.outputs, ty.maxStackIncrease)
+	}
+	for i, code := range c.codeSections {
+		fmt.Fprintf(&out, "  - Code section %d: %#x\n", i, code)
+	}
+	for i, code := range c.subContainerCodes {
+		fmt.Fprintf(&out, "  - Subcontainer %d: %#x\n", i, code)
+	}
+	fmt.Fprintf(&out, "  - Data: %#x\n", c.data)
+	return out.String()
+}
+
+// parseSection decodes a (kind, size) pair from an EOF header.
+func parseSection(b []byte, idx int) (kind, size int, err error) {
+	if idx+3 > len(b) {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	return int(b[idx]), int(binary.BigEndian.Uint16(b[idx+1:])), nil
+}
+
+// parseSectionList decodes a (kind, len, []size) section list from an EOF
+// header, where each size is encoded on the given number of bytes.
+func parseSectionList(b []byte, idx int, width int) (kind int, sizes []int, err error) {
+	if idx+3 > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	kind, count := int(b[idx]), int(binary.BigEndian.Uint16(b[idx+1:]))
+	if idx+3+count*width > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	sizes = make([]int, count)
+	for i := range sizes {
+		pos := idx + 3 + i*width
+		if width == 2 {
+			sizes[i] = int(binary.BigEndian.Uint16(b[pos:]))
+		} else {
+			sizes[i] = int(binary.BigEndian.Uint32(b[pos:]))
+		}
+	}
+	return kind, sizes, nil
+}
+
+// sum returns the sum of the given integers.
+func sum(list []int) (s int) {
+	for _, n := range list {
+		s += n
+	}
+	return s
+}
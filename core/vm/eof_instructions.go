@@ -0,0 +1,452 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// returnFrame is an entry of the EOF return stack, pushed by CALLF and popped
+// by RETF.
+type returnFrame struct {
+	section int    // code section to return to
+	pc      uint64 // program counter of the instruction after the CALLF
+}
+
+// Status codes pushed by EXTCALL, EXTDELEGATECALL and EXTSTATICCALL.
+const (
+	extCallSuccess = 0
+	extCallRevert  = 1 // also pushed for light failures, when the call was not made
+	extCallFailure = 2
+)
+
+// eofMagicHash is the code hash reported for EOF contracts to legacy code.
+var eofMagicHash = crypto.Keccak256Hash(eofMagic)
+
+// jumpTo moves the program counter to the given position of the current code
+// section, accounting for the increment of the interpreter loop.
+func jumpTo(pc *uint64, dest uint64) {
+	*pc = dest - 1 // wraps around for zero, pc will be increased by the interpreter loop
+}
+
+// opRjump implements the RJUMP opcode.
+func opRjump(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	if evm.abort.Load() {
+		return nil, errStopToken
+	}
+	offset := int16(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	jumpTo(pc, uint64(int64(*pc)+3+int64(offset)))
+	return nil, nil
+}
+
+// opRjumpi implements the RJUMPI opcode.
+func opRjumpi(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	cond := scope.Stack.pop()
+	if cond.IsZero() {
+		*pc += 2
+		return nil, nil
+	}
+	return opRjump(pc, evm, scope)
+}
+
+// opRjumpv implements the RJUMPV opcode.
+func opRjumpv(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	if evm.abort.Load() {
+		return nil, errStopToken
+	}
+	var (
+		code  = scope.Contract.Code
+		count = uint64(code[*pc+1]) + 1
+		next  = *pc + 2 + 2*count
+		index = scope.Stack.pop()
+	)
+	if !index.IsUint64() || index.Uint64() >= count {
+		jumpTo(pc, next)
+		return nil, nil
+	}
+	offset := int16(binary.BigEndian.Uint16(code[*pc+2+2*index.Uint64():]))
+	jumpTo(pc, uint64(int64(next)+int64(offset)))
+	return nil, nil
+}
+
+// opCallf implements the CALLF opcode.
+func opCallf(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	var (
+		contract = scope.Contract
+		section  = int(binary.BigEndian.Uint16(contract.Code[*pc+1:]))
+		typ      = contract.Container.types[section]
+	)
+	if limit := int(params.StackLimit) - int(typ.maxStackIncrease); scope.Stack.len() > limit {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.len(), limit: limit}
+	}
+	if len(contract.returnStack) >= maxReturnStackHeight {
+		return nil, ErrReturnStackExceeded
+	}
+	contract.returnStack = append(contract.returnStack, returnFrame{
+		section: contract.codeSection,
+		pc:      *pc + 3,
+	})
+	contract.setCodeSection(section)
+	jumpTo(pc, 0)
+	return nil, nil
+}
+
+// opRetf implements the RETF opcode.
+func opRetf(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	contract := scope.Contract
+
+	frame := contract.returnStack[len(contract.returnStack)-1]
+	contract.returnStack = contract.returnStack[:len(contract.returnStack)-1]
+
+	contract.setCodeSection(frame.section)
+	jumpTo(pc, frame.pc)
+	return nil, nil
+}
+
+// opJumpf implements the JUMPF opcode.
+func opJumpf(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	var (
+		contract = scope.Contract
+		section  = int(binary.BigEndian.Uint16(contract.Code[*pc+1:]))
+		typ      = contract.Container.types[section]
+	)
+	if limit := int(params.StackLimit) - int(typ.maxStackIncrease); scope.Stack.len() > limit {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.len(), limit: limit}
+	}
+	contract.setCodeSection(section)
+	jumpTo(pc, 0)
+	return nil, nil
+}
+
+// opDataLoad implements the DATALOAD opcode.
+func opDataLoad(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	offset := scope.Stack.peek()
+	start, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		start = math.MaxUint64
+	}
+	offset.SetBytes(getData(scope.Contract.Container.data, start, 32))
+	return nil, nil
+}
+
+// opDataLoadN implements the DATALOADN opcode.
+func opDataLoadN(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	offset := uint64(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	scope.Stack.push(new(uint256.Int).SetBytes(scope.Contract.Container.data[offset : offset+32]))
+	*pc += 2
+	return nil, nil
+}
+
+// opDataSize implements the DATASIZE opcode.
+func opDataSize(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	scope.Stack.push(new(uint256.Int).SetUint64(uint64(len(scope.Contract.Container.data))))
+	return nil, nil
+}
+
+// opDataCopy implements the DATACOPY opcode.
+func opDataCopy(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	var (
+		memOffset = scope.Stack.pop()
+		offset    = scope.Stack.pop()
+		size      = scope.Stack.pop()
+	)
+	start, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		start = math.MaxUint64
+	}
+	scope.Memory.Set(memOffset.Uint64(), size.Uint64(), getData(scope.Contract.Container.data, start, size.Uint64()))
+	return nil, nil
+}
+
+// opDupN implements the DUPN opcode.
+func opDupN(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	n := int(scope.Contract.Code[*pc+1]) + 1
+	scope.Stack.dup(n)
+	*pc += 1
+	return nil, nil
+}
+
+// opSwapN implements the SWAPN opcode.
+func opSwapN(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	var (
+		n    = int(scope.Contract.Code[*pc+1]) + 1
+		data = scope.Stack.data
+		top  = len(data) - 1
+	)
+	data[top], data[top-n] = data[top-n], data[top]
+	*pc += 1
+	return nil, nil
+}
+
+// opExchange implements the EXCHANGE opcode.
+func opExchange(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	var (
+		imm  = scope.Contract.Code[*pc+1]
+		n    = int(imm>>4) + 1
+		m    = int(imm&0x0f) + 1
+		data = scope.Stack.data
+		top  = len(data) - 1
+	)
+	data[top-n], data[top-n-m] = data[top-n-m], data[top-n]
+	*pc += 1
+	return nil, nil
+}
+
+// opReturnDataLoad implements the RETURNDATALOAD opcode.
+func opReturnDataLoad(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	offset := scope.Stack.peek()
+	start, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		start = math.MaxUint64
+	}
+	offset.SetBytes(getData(evm.returnData, start, 32))
+	return nil, nil
+}
+
+// opReturnDataCopyEOF implements the RETURNDATACOPY opcode in EOF code, which
+// pads out of bounds reads with zeroes instead of failing.
+func opReturnDataCopyEOF(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	var (
+		memOffset = scope.Stack.pop()
+		offset    = scope.Stack.pop()
+		size      = scope.Stack.pop()
+	)
+	start, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		start = math.MaxUint64
+	}
+	scope.Memory.Set(memOffset.Uint64(), size.Uint64(), getData(evm.returnData, start, size.Uint64()))
+	return nil, nil
+}
+
+// opEOFCreate implements the EOFCREATE opcode.
+func opEOFCreate(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	if evm.readOnly {
+		return nil, ErrWriteProtection
+	}
+	var (
+		index        = int(scope.Contract.Code[*pc+1])
+		value        = scope.Stack.pop()
+		salt         = scope.Stack.pop()
+		offset, size = scope.Stack.pop(), scope.Stack.pop()
+		input        = scope.Memory.GetCopy(offset.Uint64(), size.Uint64())
+		gas          = scope.Contract.Gas
+	)
+	gas -= gas / 64
+	scope.Contract.UseGas(gas, evm.Config.Tracer, tracing.GasChangeCallContractCreation)
+
+	// reuse size int for stackvalue
+	stackvalue := size
+	res, addr, returnGas, suberr := evm.EOFCreate(scope.Contract.Address(), scope.Contract.Container, index, input, gas, &value, &salt)
+	if suberr != nil {
+		stackvalue.Clear()
+	} else {
+		stackvalue.SetBytes(addr.Bytes())
+	}
+	scope.Stack.push(&stackvalue)
+	scope.Contract.RefundGas(returnGas, evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	if suberr == ErrExecutionReverted {
+		evm.returnData = res // set REVERT data to return data buffer
+	} else {
+		evm.returnData = nil // clear dirty return data buffer
+	}
+	*pc += 1
+	return nil, nil
+}
+
+// opReturnContract implements the RETURNCONTRACT opcode, terminating the
+// initcode and returning the subcontainer to deploy with the auxiliary data
+// appended to its data section.
+func opReturnContract(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	var (
+		index        = int(scope.Contract.Code[*pc+1])
+		offset, size = scope.Stack.pop(), scope.Stack.pop()
+		aux          = scope.Memory.GetPtr(offset.Uint64(), size.Uint64())
+		deploy       = *scope.Contract.Container.subContainers[index]
+	)
+	dataSize := len(deploy.data) + len(aux)
+	if dataSize > math.MaxUint16 || dataSize < deploy.dataSize {
+		return nil, ErrInvalidEOFAuxData
+	}
+	deploy.data = append(append(make([]byte, 0, dataSize), deploy.data...), aux...)
+	deploy.dataSize = dataSize
+
+	return deploy.MarshalBinary(), errStopToken
+}
+
+// extCallStatus maps the error of a call made by EXTCALL, EXTDELEGATECALL or
+// EXTSTATICCALL to the status code pushed onto the stack.
+func extCallStatus(err error) uint64 {
+	switch {
+	case err == nil:
+		return extCallSuccess
+	case errors.Is(err, ErrExecutionReverted), errors.Is(err, ErrDepth), errors.Is(err, ErrInsufficientBalance):
+		return extCallRevert
+	default:
+		return extCallFailure
+	}
+}
+
+// opExtCall implements the EXTCALL opcode.
+func opExtCall(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	// Pop the call parameters, the gas to forward is in evm.callGasTemp
+	addr, inOffset, inSize, value := stack.pop(), stack.pop(), stack.pop(), stack.pop()
+	toAddr := common.Address(addr.Bytes20())
+
+	if evm.readOnly && !value.IsZero() {
+		return nil, ErrWriteProtection
+	}
+	var (
+		ret       []byte
+		returnGas uint64
+		status    uint64 = extCallRevert // light failure, not enough gas to make the call
+	)
+	if gas := evm.callGasTemp; gas != 0 {
+		var err error
+		args := scope.Memory.GetPtr(inOffset.Uint64(), inSize.Uint64())
+		ret, returnGas, err = evm.Call(scope.Contract.Address(), toAddr, args, gas, &value)
+		status = extCallStatus(err)
+	}
+	// reuse addr int for stackvalue
+	addr.SetUint64(status)
+	stack.push(&addr)
+	scope.Contract.RefundGas(returnGas, evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	evm.returnData = ret
+	return nil, nil
+}
+
+// opExtDelegateCall implements the EXTDELEGATECALL opcode.
+func opExtDelegateCall(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	// Pop the call parameters, the gas to forward is in evm.callGasTemp
+	addr, inOffset, inSize := stack.pop(), stack.pop(), stack.pop()
+	toAddr := common.Address(addr.Bytes20())
+
+	var (
+		ret       []byte
+		returnGas uint64
+		status    uint64 = extCallRevert // light failure, not enough gas to make the call
+	)
+	if gas := evm.callGasTemp; gas != 0 {
+		if HasEOFMagic(evm.resolveCode(toAddr)) {
+			var err error
+			args := scope.Memory.GetPtr(inOffset.Uint64(), inSize.Uint64())
+			ret, returnGas, err = evm.DelegateCall(scope.Contract.Caller(), scope.Contract.Address(), toAddr, args, gas, scope.Contract.value)
+			status = extCallStatus(err)
+		} else {
+			returnGas = gas // light failure, legacy code cannot be delegated to
+		}
+	}
+	// reuse addr int for stackvalue
+	addr.SetUint64(status)
+	stack.push(&addr)
+	scope.Contract.RefundGas(returnGas, evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	evm.returnData = ret
+	return nil, nil
+}
+
+// opExtStaticCall implements the EXTSTATICCALL opcode.
+func opExtStaticCall(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	// Pop the call parameters, the gas to forward is in evm.callGasTemp
+	addr, inOffset, inSize := stack.pop(), stack.pop(), stack.pop()
+	toAddr := common.Address(addr.Bytes20())
+
+	var (
+		ret       []byte
+		returnGas uint64
+		status    uint64 = extCallRevert // light failure, not enough gas to make the call
+	)
+	if gas := evm.callGasTemp; gas != 0 {
+		var err error
+		args := scope.Memory.GetPtr(inOffset.Uint64(), inSize.Uint64())
+		ret, returnGas, err = evm.StaticCall(scope.Contract.Address(), toAddr, args, gas)
+		status = extCallStatus(err)
+	}
+	// reuse addr int for stackvalue
+	addr.SetUint64(status)
+	stack.push(&addr)
+	scope.Contract.RefundGas(returnGas, evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	evm.returnData = ret
+	return nil, nil
+}
+
+// opExtCodeSizeEOF implements EXTCODESIZE for legacy code once EOF is enabled,
+// reporting the size of the EOF magic for EOF contracts.
+func opExtCodeSizeEOF(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	slot := scope.Stack.peek()
+	addr := common.Address(slot.Bytes20())
+
+	size := evm.StateDB.GetCodeSize(addr)
+	if size >= len(eofMagic) && HasEOFMagic(evm.StateDB.GetCode(addr)) {
+		size = len(eofMagic)
+	}
+	slot.SetUint64(uint64(size))
+	return nil, nil
+}
+
+// opExtCodeCopyEOF implements EXTCODECOPY for legacy code once EOF is enabled,
+// copying only the EOF magic of EOF contracts.
+func opExtCodeCopyEOF(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	var (
+		stack      = scope.Stack
+		a          = stack.pop()
+		memOffset  = stack.pop()
+		codeOffset = stack.pop()
+		length     = stack.pop()
+	)
+	uint64CodeOffset, overflow := codeOffset.Uint64WithOverflow()
+	if overflow {
+		uint64CodeOffset = math.MaxUint64
+	}
+	code := evm.StateDB.GetCode(common.Address(a.Bytes20()))
+	if HasEOFMagic(code) {
+		code = eofMagic
+	}
+	codeCopy := getData(code, uint64CodeOffset, length.Uint64())
+	scope.Memory.Set(memOffset.Uint64(), length.Uint64(), codeCopy)
+	return nil, nil
+}
+
+// opExtCodeHashEOF implements EXTCODEHASH for legacy code once EOF is enabled,
+// reporting the hash of the EOF magic for EOF contracts.
+func opExtCodeHashEOF(pc *uint64, evm *EVM, scope *ScopeContext) ([]byte, error) {
+	slot := scope.Stack.peek()
+	address := common.Address(slot.Bytes20())
+	switch {
+	case evm.StateDB.Empty(address):
+		slot.Clear()
+	case HasEOFMagic(evm.StateDB.GetCode(address)):
+		slot.SetBytes(eofMagicHash.Bytes())
+	default:
+		slot.SetBytes(evm.StateDB.GetCodeHash(address).Bytes())
+	}
+	return nil, nil
+}
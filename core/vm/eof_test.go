@@ -0,0 +1,264 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// makeContainer assembles the binary representation of an EOF container.
+func makeContainer(types []*functionMetadata, code [][]byte, subContainers [][]byte, data []byte) []byte {
+	c := &Container{
+		types:             types,
+		codeSections:      code,
+		subContainerCodes: subContainers,
+		data:              data,
+		dataSize:          len(data),
+	}
+	return c.MarshalBinary()
+}
+
+func TestEOFMarshaling(t *testing.T) {
+	for i, test := range []struct {
+		want Container
+		err  error
+	}{
+		{
+			want: Container{
+				types:        []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+				codeSections: [][]byte{common.Hex2Bytes("604200")},
+				data:         []byte{},
+			},
+		},
+		{
+			want: Container{
+				types: []*functionMetadata{
+					{inputs: 0, outputs: 0x80, maxStackIncrease: 1},
+					{inputs: 2, outputs: 3, maxStackIncrease: 4},
+					{inputs: 1, outputs: 1, maxStackIncrease: 1},
+				},
+				codeSections: [][]byte{
+					common.Hex2Bytes("604200"),
+					common.Hex2Bytes("6042604200"),
+					common.Hex2Bytes("00"),
+				},
+				data: common.Hex2Bytes("deadbeef"),
+			},
+		},
+	} {
+		test.want.dataSize = len(test.want.data)
+
+		var (
+			b   = test.want.MarshalBinary()
+			got Container
+		)
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Fatalf("test %d: failed to unmarshal: %v", i, err)
+		}
+		if have := got.MarshalBinary(); !bytes.Equal(have, b) {
+			t.Fatalf("test %d: roundtrip mismatch: have %x, want %x", i, have, b)
+		}
+		if len(got.types) != len(test.want.types) || len(got.codeSections) != len(test.want.codeSections) {
+			t.Fatalf("test %d: section count mismatch", i)
+		}
+	}
+}
+
+func TestEOFParseErrors(t *testing.T) {
+	for i, test := range []struct {
+		code string
+		err  error
+	}{
+		{"", errInvalidMagic},
+		{"ef01", errInvalidMagic},
+		{"ef00020100040200010001ff00000000800000fe", errInvalidVersion},
+		{"ef0001", io.ErrUnexpectedEOF},
+		// Missing code section header
+		{"ef00010100040300010001ff00000000800000fe", errMissingCodeHeader},
+		// Zero code section size
+		{"ef00010100040200010000ff00000000800000", errInvalidCodeSize},
+		// Section 0 must be non-returning without inputs
+		{"ef00010100040200010001ff00000000000000fe", errInvalidFirstSectionType},
+		// Declared data exceeds the top level container
+		{"ef00010100040200010001ff00020000800000fe", errTruncatedTopLevelContainer},
+		// Trailing bytes after the top level container
+		{"ef00010100040200010001ff00000000800000fe00", errInvalidContainerSize},
+	} {
+		var c Container
+		err := c.UnmarshalBinary(common.FromHex(test.code))
+		if !errors.Is(err, test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+var eofTestBlockContext = BlockContext{
+	CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+	Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+	BlockNumber: common.Big0,
+	Random:      &common.Hash{},
+}
+
+// newEOFTestEVM creates an EVM running on a fork with EOF enabled.
+func newEOFTestEVM(t *testing.T) (*EVM, *state.StateDB) {
+	t.Helper()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	return NewEVM(eofTestBlockContext, statedb, params.MergedTestChainConfig, Config{ExtraEips: []int{7692}}), statedb
+}
+
+func TestEOFExecution(t *testing.T) {
+	evm, statedb := newEOFTestEVM(t)
+
+	// Section 0 adds two numbers via CALLF into section 1 and returns the
+	// result, then appends the word loaded from the data section.
+	code := makeContainer(
+		[]*functionMetadata{
+			{inputs: 0, outputs: 0x80, maxStackIncrease: 2},
+			{inputs: 2, outputs: 1, maxStackIncrease: 0},
+		},
+		[][]byte{
+			{
+				byte(PUSH1), 2, byte(PUSH1), 3, byte(CALLF), 0, 1,
+				byte(PUSH0), byte(MSTORE),
+				byte(DATALOADN), 0, 0, byte(PUSH1), 32, byte(MSTORE),
+				byte(PUSH1), 64, byte(PUSH0), byte(RETURN),
+			},
+			{byte(ADD), byte(RETF)},
+		},
+		nil,
+		common.LeftPadBytes([]byte{0x2a}, 32),
+	)
+	address := common.HexToAddress("0xc0de")
+	statedb.SetCode(address, code, tracing.CodeChangeUnspecified)
+
+	ret, _, err := evm.Call(common.Address{}, address, nil, 100000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	want := append(common.LeftPadBytes([]byte{5}, 32), common.LeftPadBytes([]byte{0x2a}, 32)...)
+	if !bytes.Equal(ret, want) {
+		t.Fatalf("return mismatch: have %x, want %x", ret, want)
+	}
+}
+
+func TestEOFExecutionDisabled(t *testing.T) {
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	evm := NewEVM(eofTestBlockContext, statedb, params.MergedTestChainConfig, Config{})
+
+	code := makeContainer([]*functionMetadata{{inputs: 0, outputs: 0x80}}, [][]byte{{byte(STOP)}}, nil, nil)
+	address := common.HexToAddress("0xc0de")
+	statedb.SetCode(address, code, tracing.CodeChangeUnspecified)
+
+	// Without EOF, the container is executed as legacy code, hitting 0xEF
+	if _, _, err := evm.Call(common.Address{}, address, nil, 100000, new(uint256.Int)); !errors.As(err, new(*ErrInvalidOpCode)) {
+		t.Fatalf("expected invalid opcode error, have %v", err)
+	}
+}
+
+func TestEOFCreate(t *testing.T) {
+	evm, statedb := newEOFTestEVM(t)
+
+	var (
+		// Deployed code returns the word stored in its data section
+		runtime = makeContainer(
+			[]*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 2}},
+			[][]byte{{
+				byte(DATALOADN), 0, 0, byte(PUSH0), byte(MSTORE),
+				byte(PUSH1), 32, byte(PUSH0), byte(RETURN),
+			}},
+			nil,
+			common.LeftPadBytes([]byte{0x2a}, 32),
+		)
+		// Initcode deploys the runtime container as is
+		initcode = makeContainer(
+			[]*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 2}},
+			[][]byte{{byte(PUSH0), byte(PUSH0), byte(RETURNCONTRACT), 0}},
+			[][]byte{runtime},
+			nil,
+		)
+		// Factory creates the initcode container and returns the new address
+		factory = makeContainer(
+			[]*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 4}},
+			[][]byte{{
+				byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(EOFCREATE), 0,
+				byte(PUSH0), byte(MSTORE),
+				byte(PUSH1), 32, byte(PUSH0), byte(RETURN),
+			}},
+			[][]byte{initcode},
+			nil,
+		)
+		factoryAddr = common.HexToAddress("0xfac7")
+		sender      = common.HexToAddress("0x5e4d")
+	)
+	statedb.SetCode(factoryAddr, factory, tracing.CodeChangeUnspecified)
+
+	// Create a contract through EOFCREATE, the address only depends on the salt
+	ret, _, err := evm.Call(sender, factoryAddr, nil, 1000000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("factory call failed: %v", err)
+	}
+	want := common.BytesToAddress(crypto.Keccak256([]byte{0xff}, common.LeftPadBytes(factoryAddr.Bytes(), 32), make([]byte, 32))[12:])
+	if have := common.BytesToAddress(ret); have != want {
+		t.Fatalf("created address mismatch: have %v, want %v", have, want)
+	}
+	if have := statedb.GetCode(want); !bytes.Equal(have, runtime) {
+		t.Fatalf("deployed code mismatch: have %x, want %x", have, runtime)
+	}
+	ret, _, err = evm.Call(sender, want, nil, 100000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if !bytes.Equal(ret, common.LeftPadBytes([]byte{0x2a}, 32)) {
+		t.Fatalf("return mismatch: have %x", ret)
+	}
+
+	// Create a contract through a creation transaction, passing calldata after
+	// the initcode container
+	_, addr, _, err := evm.Create(sender, append(initcode, 0xca, 0x11), 1000000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if have := statedb.GetCode(addr); !bytes.Equal(have, runtime) {
+		t.Fatalf("deployed code mismatch: have %x, want %x", have, runtime)
+	}
+
+	// Invalid initcode fails without consuming gas but still bumps the nonce
+	nonce := statedb.GetNonce(sender)
+	invalid := makeContainer([]*functionMetadata{{inputs: 0, outputs: 0x80}}, [][]byte{{byte(STOP)}}, nil, nil)
+	_, _, left, err := evm.Create(sender, invalid, 1000000, new(uint256.Int))
+	if !errors.Is(err, ErrInvalidEOFInitcode) {
+		t.Fatalf("expected invalid initcode error, have %v", err)
+	}
+	if left != 1000000 {
+		t.Fatalf("gas consumed by invalid initcode: %d", 1000000-left)
+	}
+	if have := statedb.GetNonce(sender); have != nonce+1 {
+		t.Fatalf("nonce mismatch: have %d, want %d", have, nonce+1)
+	}
+}
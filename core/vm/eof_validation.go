@@ -0,0 +1,377 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	errUndefinedInstruction          = errors.New("undefined instruction")
+	errTruncatedImmediate            = errors.New("truncated immediate")
+	errInvalidSectionArgument        = errors.New("invalid section argument")
+	errInvalidCallArgument           = errors.New("callf into non-returning section")
+	errInvalidDataloadNArgument      = errors.New("invalid dataloadN argument")
+	errInvalidJumpDest               = errors.New("invalid jump destination")
+	errInvalidBackwardJump           = errors.New("invalid backward jump")
+	errInvalidOutputs                = errors.New("invalid number of outputs")
+	errInvalidMaxStackHeight         = errors.New("invalid max stack height")
+	errInvalidCodeTermination        = errors.New("invalid code termination")
+	errEOFCreateWithTruncatedSection = errors.New("eofcreate with truncated section")
+	errOrphanedSubcontainer          = errors.New("subcontainer not referenced at all")
+	errIncompatibleContainerKind     = errors.New("incompatible container kind")
+	errStopInInitCode                = errors.New("initcode contains a RETURN or STOP opcode")
+	errReturnContractInRuntime       = errors.New("runtime code contains a RETURNCONTRACT opcode")
+	errUnreachableCode               = errors.New("unreachable code")
+	errUnreachableCodeSection        = errors.New("unreachable code section")
+	errInvalidNonReturningFlag       = errors.New("invalid non-returning flag")
+	errEOFStackUnderflow             = errors.New("stack underflow")
+	errEOFStackOverflow              = errors.New("stack overflow")
+)
+
+// Container kinds, determining the instructions allowed to terminate the code.
+const (
+	containerKindRuntime  = iota + 1 // deployed code, terminated by STOP or RETURN
+	containerKindInitcode            // initcode, terminated by RETURNCONTRACT
+)
+
+// codeInfo collects the cross references found while validating a single code
+// section, which are checked in the context of the whole container.
+type codeInfo struct {
+	sections   []int       // code sections referenced by CALLF and JUMPF
+	containers map[int]int // subcontainers referenced by EOFCREATE and RETURNCONTRACT
+	returning  bool        // whether the section returns via RETF or a returning JUMPF
+	stops      bool        // whether the section contains STOP or RETURN
+	returns    bool        // whether the section contains RETURNCONTRACT
+}
+
+// ValidateCode validates the code sections of the container and all of its
+// subcontainers against the given EOF instruction set, in either initcode or
+// runtime mode.
+func (c *Container) ValidateCode(jt *JumpTable, isInitcode bool) error {
+	kind := containerKindRuntime
+	if isInitcode {
+		kind = containerKindInitcode
+	}
+	return c.validate(jt, kind)
+}
+
+// validate checks the code sections of the container as the given kind, then
+// recursively validates the referenced subcontainers.
+func (c *Container) validate(jt *JumpTable, kind int) error {
+	var (
+		visited    = make(map[int]bool)
+		queue      = []int{0}
+		containers = make(map[int]int)
+	)
+	visited[0] = true
+	for len(queue) > 0 {
+		section := queue[0]
+		queue = queue[1:]
+
+		info, err := validateCode(c.codeSections[section], section, c, jt)
+		if err != nil {
+			return fmt.Errorf("code section %d: %w", section, err)
+		}
+		if info.returning != c.types[section].returning() {
+			return fmt.Errorf("%w: section %d", errInvalidNonReturningFlag, section)
+		}
+		if kind == containerKindInitcode && info.stops {
+			return errStopInInitCode
+		}
+		if kind == containerKindRuntime && info.returns {
+			return errReturnContractInRuntime
+		}
+		for _, target := range info.sections {
+			if !visited[target] {
+				visited[target] = true
+				queue = append(queue, target)
+			}
+		}
+		for index, subkind := range info.containers {
+			if prev, ok := containers[index]; ok && prev != subkind {
+				return fmt.Errorf("%w: subcontainer %d", errIncompatibleContainerKind, index)
+			}
+			containers[index] = subkind
+		}
+	}
+	if len(visited) != len(c.codeSections) {
+		return fmt.Errorf("%w: reached %d of %d", errUnreachableCodeSection, len(visited), len(c.codeSections))
+	}
+	// Validate all the subcontainers in the mode they are referenced by
+	for i, sub := range c.subContainers {
+		subkind, ok := containers[i]
+		if !ok {
+			return fmt.Errorf("%w: subcontainer %d", errOrphanedSubcontainer, i)
+		}
+		if subkind == containerKindInitcode && len(sub.data) < sub.dataSize {
+			return fmt.Errorf("%w: subcontainer %d", errEOFCreateWithTruncatedSection, i)
+		}
+		if err := sub.validate(jt, subkind); err != nil {
+			return fmt.Errorf("subcontainer %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// immediateSize returns the number of immediate bytes following the opcode at
+// the given position. The returned size is bogus for truncated RJUMPV tables,
+// which are caught by the bound checks of the caller.
+func immediateSize(code []byte, pos int) int {
+	op := OpCode(code[pos])
+	switch {
+	case op >= PUSH1 && op <= PUSH32:
+		return int(op-PUSH1) + 1
+	case op == RJUMP, op == RJUMPI, op == CALLF, op == JUMPF, op == DATALOADN:
+		return 2
+	case op == RJUMPV:
+		if pos+1 >= len(code) {
+			return 1
+		}
+		return 1 + 2*(int(code[pos+1])+1)
+	case op == DUPN, op == SWAPN, op == EXCHANGE, op == EOFCREATE, op == RETURNCONTRACT:
+		return 1
+	}
+	return 0
+}
+
+// isTerminal returns whether the opcode ends the execution of the section,
+// without any fallthrough to the next instruction.
+func isTerminal(op OpCode) bool {
+	switch op {
+	case STOP, RETURN, REVERT, INVALID, RETF, JUMPF, RETURNCONTRACT:
+		return true
+	}
+	return false
+}
+
+// stackRange is the range of possible operand stack heights at an instruction.
+type stackRange struct {
+	min, max int
+	visited  bool
+}
+
+// validateCode checks the instructions, the relative jumps and the operand stack
+// usage of a single code section, as specified by EIP-3670, EIP-4200, EIP-4750
+// and EIP-5450.
+func validateCode(code []byte, section int, container *Container, jt *JumpTable) (*codeInfo, error) {
+	var (
+		info       = &codeInfo{containers: make(map[int]int)}
+		boundaries = make([]bool, len(code))
+		types      = container.types
+	)
+	// Check the instructions and their immediates in a linear scan
+	for pos := 0; pos < len(code); {
+		op := OpCode(code[pos])
+		if jt[op].undefined {
+			return nil, fmt.Errorf("%w: op %s, pos %d", errUndefinedInstruction, op, pos)
+		}
+		size := immediateSize(code, pos)
+		if pos+size >= len(code) && size > 0 {
+			return nil, fmt.Errorf("%w: op %s, pos %d", errTruncatedImmediate, op, pos)
+		}
+		boundaries[pos] = true
+
+		switch op {
+		case CALLF:
+			target := int(binary.BigEndian.Uint16(code[pos+1:]))
+			if target >= len(types) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, target, len(types), pos)
+			}
+			if !types[target].returning() {
+				return nil, fmt.Errorf("%w: section %d, pos %d", errInvalidCallArgument, target, pos)
+			}
+			info.sections = append(info.sections, target)
+
+		case JUMPF:
+			target := int(binary.BigEndian.Uint16(code[pos+1:]))
+			if target >= len(types) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, target, len(types), pos)
+			}
+			if types[target].returning() {
+				info.returning = true
+			}
+			info.sections = append(info.sections, target)
+
+		case RETF:
+			info.returning = true
+
+		case DATALOADN:
+			arg := int(binary.BigEndian.Uint16(code[pos+1:]))
+			if arg+32 > container.dataSize {
+				return nil, fmt.Errorf("%w: arg %d, data size %d, pos %d", errInvalidDataloadNArgument, arg, container.dataSize, pos)
+			}
+
+		case EOFCREATE, RETURNCONTRACT:
+			arg := int(code[pos+1])
+			if arg >= len(container.subContainers) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, arg, len(container.subContainers), pos)
+			}
+			kind := containerKindInitcode
+			if op == RETURNCONTRACT {
+				kind = containerKindRuntime
+				info.returns = true
+			}
+			if prev, ok := info.containers[arg]; ok && prev != kind {
+				return nil, fmt.Errorf("%w: subcontainer %d, pos %d", errIncompatibleContainerKind, arg, pos)
+			}
+			info.containers[arg] = kind
+
+		case STOP, RETURN:
+			info.stops = true
+		}
+		pos += 1 + size
+	}
+	// Check that all relative jumps land on instruction boundaries
+	for pos := 0; pos < len(code); pos += 1 + immediateSize(code, pos) {
+		for _, target := range jumpTargets(code, pos) {
+			if target < 0 || target >= len(code) || !boundaries[target] {
+				return nil, fmt.Errorf("%w: op %s, target %d, pos %d", errInvalidJumpDest, OpCode(code[pos]), target, pos)
+			}
+		}
+	}
+	// Validate the operand stack heights in a single forward pass
+	if err := validateStack(code, section, types, jt); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// jumpTargets returns the destinations of the relative jump at the given
+// position, or nil if the instruction is not a relative jump.
+func jumpTargets(code []byte, pos int) []int {
+	switch OpCode(code[pos]) {
+	case RJUMP, RJUMPI:
+		return []int{pos + 3 + int(int16(binary.BigEndian.Uint16(code[pos+1:])))}
+	case RJUMPV:
+		var (
+			count   = int(code[pos+1]) + 1
+			next    = pos + 2 + 2*count
+			targets = make([]int, count)
+		)
+		for i := range targets {
+			targets[i] = next + int(int16(binary.BigEndian.Uint16(code[pos+2+2*i:])))
+		}
+		return targets
+	}
+	return nil
+}
+
+// validateStack computes the operand stack height ranges of all instructions,
+// checking for underflows, unreachable instructions, consistent backward jumps,
+// matching section outputs and the declared maximum stack increase.
+func validateStack(code []byte, section int, types []*functionMetadata, jt *JumpTable) error {
+	var (
+		heights = make([]stackRange, len(code))
+		inputs  = int(types[section].inputs)
+		highest = inputs
+	)
+	heights[0] = stackRange{min: inputs, max: inputs, visited: true}
+
+	for pos := 0; pos < len(code); {
+		var (
+			op   = OpCode(code[pos])
+			size = immediateSize(code, pos)
+			cur  = heights[pos]
+			next = pos + 1 + size
+		)
+		if !cur.visited {
+			return fmt.Errorf("%w: pos %d", errUnreachableCode, pos)
+		}
+		// Determine the stack effect of the instruction
+		var pops, pushes int
+		switch op {
+		case CALLF, JUMPF:
+			target := types[binary.BigEndian.Uint16(code[pos+1:])]
+			if cur.max+int(target.maxStackIncrease) > int(params.StackLimit) {
+				return fmt.Errorf("%w: op %s, pos %d", errEOFStackOverflow, op, pos)
+			}
+			pops, pushes = int(target.inputs), int(target.outputs)
+			if op == JUMPF {
+				if target.returning() {
+					// Tail calls must leave exactly the outputs of the current section
+					if target.outputs > types[section].outputs {
+						return fmt.Errorf("%w: jumpf to section with more outputs, pos %d", errInvalidOutputs, pos)
+					}
+					want := int(types[section].outputs) + int(target.inputs) - int(target.outputs)
+					if cur.min != want || cur.max != want {
+						return fmt.Errorf("%w: have %d..%d, want %d, pos %d", errInvalidOutputs, cur.min, cur.max, want, pos)
+					}
+				}
+				pushes = 0
+			}
+		case RETF:
+			want := int(types[section].outputs)
+			if cur.min != want || cur.max != want {
+				return fmt.Errorf("%w: have %d..%d, want %d, pos %d", errInvalidOutputs, cur.min, cur.max, want, pos)
+			}
+		case DUPN:
+			pops, pushes = int(code[pos+1])+1, int(code[pos+1])+2
+		case SWAPN:
+			pops, pushes = int(code[pos+1])+2, int(code[pos+1])+2
+		case EXCHANGE:
+			n, m := int(code[pos+1]>>4)+1, int(code[pos+1]&0x0f)+1
+			pops, pushes = n+m+1, n+m+1
+		default:
+			pops = jt[op].minStack
+			pushes = int(params.StackLimit) + jt[op].minStack - jt[op].maxStack
+		}
+		if cur.min < pops {
+			return fmt.Errorf("%w: op %s, have %d, want %d, pos %d", errEOFStackUnderflow, op, cur.min, pops, pos)
+		}
+		after := stackRange{min: cur.min - pops + pushes, max: cur.max - pops + pushes, visited: true}
+		highest = max(highest, after.max)
+
+		// Propagate the stack heights to the successors
+		var successors []int
+		if !isTerminal(op) && op != RJUMP {
+			if next >= len(code) {
+				return fmt.Errorf("%w: pos %d", errInvalidCodeTermination, pos)
+			}
+			successors = append(successors, next)
+		}
+		successors = append(successors, jumpTargets(code, pos)...)
+
+		for _, succ := range successors {
+			if succ > pos {
+				// Forward jump or fallthrough, extend the range of the successor
+				if prev := heights[succ]; prev.visited {
+					heights[succ] = stackRange{min: min(prev.min, after.min), max: max(prev.max, after.max), visited: true}
+				} else {
+					heights[succ] = after
+				}
+				continue
+			}
+			// Backward jumps must target an instruction with identical heights
+			if prev := heights[succ]; prev.min != after.min || prev.max != after.max {
+				return fmt.Errorf("%w: have %d..%d, want %d..%d, pos %d", errInvalidBackwardJump, after.min, after.max, prev.min, prev.max, pos)
+			}
+		}
+		pos = next
+	}
+	if highest > maxStackHeight {
+		return fmt.Errorf("%w: have %d, pos %d", errTooLargeMaxStackHeight, highest, len(code))
+	}
+	if want := int(types[section].maxStackIncrease); highest-inputs != want {
+		return fmt.Errorf("%w: have %d, want %d", errInvalidMaxStackHeight, highest-inputs, want)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateCode(t *testing.T) {
+	jt := NewEOFInstructionSetForTesting()
+	for i, test := range []struct {
+		code     []byte
+		section  int
+		types    []*functionMetadata
+		data     []byte
+		err      error
+		initcode bool
+	}{
+		{
+			code:  []byte{byte(CALLER), byte(POP), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+		},
+		{
+			code:  []byte{byte(RJUMP), 0x00, 0x02, byte(INVALID), byte(INVALID), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 0}},
+			err:   errUnreachableCode,
+		},
+		{
+			code:  []byte{byte(PUSH0), byte(RJUMPI), 0x00, 0x01, byte(INVALID), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+		},
+		{
+			code:  []byte{byte(PUSH0), byte(RJUMPV), 0x01, 0x00, 0x00, 0x00, 0x01, byte(STOP), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+		},
+		{
+			code:  []byte{byte(RJUMP), 0xff, 0xfc},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 0}},
+			err:   errInvalidJumpDest,
+		},
+		{
+			code:  []byte{byte(CALLER), byte(RJUMP), 0xff, 0xfc},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+			err:   errInvalidBackwardJump,
+		},
+		{
+			code:  []byte{byte(CALLER), byte(POP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+			err:   errInvalidCodeTermination,
+		},
+		{
+			code:  []byte{byte(POP), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 0}},
+			err:   errEOFStackUnderflow,
+		},
+		{
+			code:  []byte{byte(CALLER), byte(POP), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 2}},
+			err:   errInvalidMaxStackHeight,
+		},
+		{
+			code:  []byte{byte(JUMP), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 0}},
+			err:   errUndefinedInstruction,
+		},
+		{
+			code:  []byte{byte(PUSH2), 0x00},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+			err:   errTruncatedImmediate,
+		},
+		{
+			code:  []byte{byte(DATALOADN), 0x00, 0x00, byte(POP), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+			data:  make([]byte, 32),
+		},
+		{
+			code:  []byte{byte(DATALOADN), 0x00, 0x01, byte(POP), byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 1}},
+			data:  make([]byte, 32),
+			err:   errInvalidDataloadNArgument,
+		},
+		{
+			code:  []byte{byte(CALLF), 0x00, 0x01, byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 0}},
+			err:   errInvalidSectionArgument,
+		},
+		{
+			code:  []byte{byte(CALLF), 0x00, 0x00, byte(STOP)},
+			types: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 0}},
+			err:   errInvalidCallArgument,
+		},
+		{
+			code:    []byte{byte(ADD), byte(RETF)},
+			section: 1,
+			types: []*functionMetadata{
+				{inputs: 0, outputs: 0x80, maxStackIncrease: 0},
+				{inputs: 2, outputs: 1, maxStackIncrease: 0},
+			},
+		},
+		{
+			code:    []byte{byte(ADD), byte(RETF)},
+			section: 1,
+			types: []*functionMetadata{
+				{inputs: 0, outputs: 0x80, maxStackIncrease: 0},
+				{inputs: 2, outputs: 2, maxStackIncrease: 0},
+			},
+			err: errInvalidOutputs,
+		},
+		{
+			code:     []byte{byte(STOP)},
+			types:    []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 0}},
+			initcode: true,
+			err:      errStopInInitCode,
+		},
+	} {
+		container := &Container{
+			types:    test.types,
+			data:     test.data,
+			dataSize: len(test.data),
+		}
+		_, err := validateCode(test.code, test.section, container, jt)
+		if test.initcode {
+			// Opcode restrictions of the container kind are checked on the
+			// whole container
+			container.codeSections = [][]byte{test.code}
+			err = container.ValidateCode(jt, true)
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("test %d (%x): error mismatch: have %v, want %v", i, test.code, err, test.err)
+		}
+	}
+}
+
+func TestValidateContainer(t *testing.T) {
+	jt := NewEOFInstructionSetForTesting()
+
+	var (
+		sub      = makeContainer([]*functionMetadata{{inputs: 0, outputs: 0x80}}, [][]byte{{byte(INVALID)}}, nil, nil)
+		subTypes = []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 2}}
+	)
+	for i, test := range []struct {
+		code     []byte
+		initcode bool
+		err      error
+	}{
+		// Runtime code deploying a subcontainer through EOFCREATE
+		{
+			code: makeContainer(
+				[]*functionMetadata{{inputs: 0, outputs: 0x80, maxStackIncrease: 4}},
+				[][]byte{{byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(EOFCREATE), 0, byte(POP), byte(STOP)}},
+				[][]byte{makeContainer(subTypes, [][]byte{{byte(PUSH0), byte(PUSH0), byte(RETURNCONTRACT), 0}}, [][]byte{sub}, nil)},
+				nil,
+			),
+		},
+		// Initcode returning its subcontainer
+		{
+			code:     makeContainer(subTypes, [][]byte{{byte(PUSH0), byte(PUSH0), byte(RETURNCONTRACT), 0}}, [][]byte{sub}, nil),
+			initcode: true,
+		},
+		// RETURNCONTRACT is not allowed in runtime code
+		{
+			code: makeContainer(subTypes, [][]byte{{byte(PUSH0), byte(PUSH0), byte(RETURNCONTRACT), 0}}, [][]byte{sub}, nil),
+			err:  errReturnContractInRuntime,
+		},
+		// Subcontainers must be referenced
+		{
+			code: makeContainer([]*functionMetadata{{inputs: 0, outputs: 0x80}}, [][]byte{{byte(STOP)}}, [][]byte{sub}, nil),
+			err:  errOrphanedSubcontainer,
+		},
+		// Code sections must be reachable from the first one
+		{
+			code: makeContainer(
+				[]*functionMetadata{{inputs: 0, outputs: 0x80}, {inputs: 0, outputs: 0x80}},
+				[][]byte{{byte(STOP)}, {byte(STOP)}},
+				nil, nil,
+			),
+			err: errUnreachableCodeSection,
+		},
+	} {
+		var c Container
+		if err := c.UnmarshalBinary(test.code); err != nil {
+			t.Fatalf("test %d: failed to unmarshal: %v", i, err)
+		}
+		if err := c.ValidateCode(jt, test.initcode); !errors.Is(err, test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
@@ -38,6 +38,10 @@ var (
 	ErrGasUintOverflow          = errors.New("gas uint64 overflow")
 	ErrInvalidCode              = errors.New("invalid code: must not begin with 0xef")
 	ErrNonceUintOverflow        = errors.New("nonce uint64 overflow")
+	ErrInvalidEOFInitcode       = errors.New("invalid eof initcode")
+	ErrInvalidEOFAuxData        = errors.New("invalid eof auxiliary data size")
+	ErrReturnStackExceeded      = errors.New("return stack limit reached")
+	ErrInvalidCallTarget        = errors.New("invalid call target: address exceeds 20 bytes")
 
 	// errStopToken is an internal token indicating interpreter loop termination,
 	// never returned to outside callers.
@@ -147,6 +151,10 @@ const (
 	VMErrorCodeStackUnderflow
 	VMErrorCodeStackOverflow
 	VMErrorCodeInvalidOpCode
+	VMErrorCodeInvalidEOFInitcode
+	VMErrorCodeInvalidEOFAuxData
+	VMErrorCodeReturnStackExceeded
+	VMErrorCodeInvalidCallTarget
 
 	// VMErrorCodeUnknown explicitly marks an error as unknown, this is useful when error is converted
 	// from an actual `error` in which case if the mapping is not known, we can use this value to indicate that.
@@ -181,6 +189,14 @@ func vmErrorCodeFromErr(err error) int {
 		return VMErrorCodeInvalidCode
 	case errors.Is(err, ErrNonceUintOverflow):
 		return VMErrorCodeNonceUintOverflow
+	case errors.Is(err, ErrInvalidEOFInitcode):
+		return VMErrorCodeInvalidEOFInitcode
+	case errors.Is(err, ErrInvalidEOFAuxData):
+		return VMErrorCodeInvalidEOFAuxData
+	case errors.Is(err, ErrReturnStackExceeded):
+		return VMErrorCodeReturnStackExceeded
+	case errors.Is(err, ErrInvalidCallTarget):
+		return VMErrorCodeInvalidCallTarget
 
 	default:
 		// Dynamic errors
@@ -18,7 +18,9 @@ package vm
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
+	"slices"
 	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -99,6 +101,12 @@ type EVM struct {
 	// table holds the opcode specific handlers
 	table *JumpTable
 
+	// eofTable holds the opcode handlers of EOF code, nil if EOF is disabled
+	eofTable *JumpTable
+
+	// containers caches the parsed EOF containers of deployed code
+	containers map[common.Hash]*Container
+
 	// depth is the current call stack
 	depth int
 
@@ -125,6 +133,9 @@ type EVM struct {
 	// jumpDests stores results of JUMPDEST analysis.
 	jumpDests JumpDestCache
 
+	// blocks stores results of basic-block analysis.
+	blocks BlockCache
+
 	hasher    crypto.KeccakState // Keccak256 hasher instance shared across opcodes
 	hasherBuf common.Hash        // Keccak256 hasher result array shared across opcodes
 
@@ -144,6 +155,7 @@ func NewEVM(blockCtx BlockContext, statedb StateDB, chainConfig *params.ChainCon
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(blockCtx.BlockNumber, blockCtx.Random != nil, blockCtx.Time),
 		jumpDests:   newMapJumpDests(),
+		blocks:      sharedBlocks,
 		hasher:      crypto.NewKeccakState(),
 	}
 	evm.precompiles = activePrecompiledContracts(evm.chainRules)
@@ -195,6 +207,11 @@ func NewEVM(blockCtx BlockContext, statedb StateDB, chainConfig *params.ChainCon
 		}
 	}
 	evm.Config.ExtraEips = extraEips
+
+	// EOF code runs on its own instruction set, derived from the legacy one
+	if slices.Contains(extraEips, 7692) {
+		evm.eofTable = newEOFInstructionSet(evm.table)
+	}
 	return evm
 }
 
@@ -210,6 +227,11 @@ func (evm *EVM) SetJumpDestCache(jumpDests JumpDestCache) {
 	evm.jumpDests = jumpDests
 }
 
+// SetBlockCache configures the basic-block analysis cache.
+func (evm *EVM) SetBlockCache(blocks BlockCache) {
+	evm.blocks = blocks
+}
+
 // SetTxContext resets the EVM with a new transaction context.
 // This is not threadsafe and should only be done very cautiously.
 func (evm *EVM) SetTxContext(txCtx TxContext) {
@@ -283,7 +305,7 @@ func (evm *EVM) Call(caller common.Address, addr common.Address, input []byte, g
 	evm.Context.Transfer(evm.StateDB, caller, addr, value)
 
 	if isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+		ret, gas, err = evm.runPrecompiledContract(p, caller, addr, input, gas, value, false)
 	} else {
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		code := evm.resolveCode(addr)
@@ -347,7 +369,8 @@ func (evm *EVM) CallCode(caller common.Address, addr common.Address, input []byt
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+		// The value is transferred to the caller itself, not the precompile
+		ret, gas, err = evm.runPrecompiledContract(p, caller, addr, input, gas, new(uint256.Int), false)
 	} else {
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		// The contract is a scoped environment for this execution context only.
@@ -390,7 +413,9 @@ func (evm *EVM) DelegateCall(originCaller common.Address, caller common.Address,
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+		// The precompile sees the delegating contract as its caller, as it
+		// never executes in the context of the caller's caller.
+		ret, gas, err = evm.runPrecompiledContract(p, caller, addr, input, gas, new(uint256.Int), false)
 	} else {
 		// Initialise a new contract and make initialise the delegate values
 		//
@@ -442,7 +467,7 @@ func (evm *EVM) StaticCall(caller common.Address, addr common.Address, input []b
 	evm.StateDB.AddBalance(addr, new(uint256.Int), tracing.BalanceChangeTouchAccount)
 
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+		ret, gas, err = evm.runPrecompiledContract(p, caller, addr, input, gas, new(uint256.Int), true)
 	} else {
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		// The contract is a scoped environment for this execution context only.
@@ -468,8 +493,9 @@ func (evm *EVM) StaticCall(caller common.Address, addr common.Address, input []b
 	return ret, gas, err
 }
 
-// create creates a new contract using code as deployment code.
-func (evm *EVM) create(caller common.Address, code []byte, gas uint64, value *uint256.Int, address common.Address, typ OpCode) (ret []byte, createAddress common.Address, leftOverGas uint64, err error) {
+// create creates a new contract using code as deployment code. If the code is
+// an EOF initcode container, it's passed pre-parsed along with its input.
+func (evm *EVM) create(caller common.Address, code []byte, container *Container, input []byte, gas uint64, value *uint256.Int, address common.Address, typ OpCode) (ret []byte, createAddress common.Address, leftOverGas uint64, err error) {
 	if evm.Config.Tracer != nil {
 		evm.captureBegin(evm.depth, typ, caller, address, code, gas, value.ToBig())
 		defer func(startGas uint64) {
@@ -559,8 +585,9 @@ func (evm *EVM) create(caller common.Address, code []byte, gas uint64, value *ui
 	// for the initialization code.
 	contract.SetCallCode(common.Hash{}, code)
 	contract.IsDeployment = true
+	contract.Container = container
 
-	ret, err = evm.initNewContract(contract, address)
+	ret, err = evm.initNewContract(contract, address, input)
 	if err != nil && (evm.chainRules.IsHomestead || err != ErrCodeStoreOutOfGas) {
 		evm.StateDB.RevertToSnapshot(snapshot)
 		if err != ErrExecutionReverted {
@@ -572,8 +599,11 @@ func (evm *EVM) create(caller common.Address, code []byte, gas uint64, value *ui
 
 // initNewContract runs a new contract's creation code, performs checks on the
 // resulting code that is to be deployed, and consumes necessary gas.
-func (evm *EVM) initNewContract(contract *Contract, address common.Address) ([]byte, error) {
-	ret, err := evm.Run(contract, nil, false)
+func (evm *EVM) initNewContract(contract *Contract, address common.Address, input []byte) ([]byte, error) {
+	// EOF initcode can only deploy a validated container via RETURNCONTRACT
+	isEOF := contract.Container != nil
+
+	ret, err := evm.Run(contract, input, false)
 	if err != nil {
 		return ret, err
 	}
@@ -584,7 +614,7 @@ func (evm *EVM) initNewContract(contract *Contract, address common.Address) ([]b
 	}
 
 	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
+	if !isEOF && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
 		return ret, ErrInvalidCode
 	}
 
@@ -606,9 +636,75 @@ func (evm *EVM) initNewContract(contract *Contract, address common.Address) ([]b
 }
 
 // Create creates a new contract using code as deployment code.
+//
+// If EOF is enabled and a creation transaction carries an EOF container, the
+// data is split into the initcode container and its calldata as per EIP-7698.
 func (evm *EVM) Create(caller common.Address, code []byte, gas uint64, value *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller, evm.StateDB.GetNonce(caller))
-	return evm.create(caller, code, gas, value, contractAddr, CREATE)
+	if evm.eofTable != nil && evm.depth == 0 && HasEOFMagic(code) {
+		container, size, err := evm.parseInitcode(code)
+		if err != nil {
+			// Invalid initcode fails the transaction without consuming the
+			// execution gas, but the sender nonce is still bumped.
+			nonce := evm.StateDB.GetNonce(caller)
+			if nonce+1 < nonce {
+				return nil, common.Address{}, gas, ErrNonceUintOverflow
+			}
+			evm.StateDB.SetNonce(caller, nonce+1, tracing.NonceChangeContractCreator)
+			return nil, common.Address{}, gas, err
+		}
+		return evm.create(caller, code[:size], container, code[size:], gas, value, contractAddr, CREATE)
+	}
+	return evm.create(caller, code, nil, nil, gas, value, contractAddr, CREATE)
+}
+
+// EOFCreate creates a new contract from the given subcontainer of an EOF
+// container, executing it as initcode with the given input.
+//
+// The address is derived from the caller and the salt only, as per EIP-7620:
+// keccak256(0xff ++ caller ++ salt)[12:].
+func (evm *EVM) EOFCreate(caller common.Address, container *Container, index int, input []byte, gas uint64, endowment *uint256.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	var (
+		sender = common.LeftPadBytes(caller.Bytes(), 32)
+		hash   = salt.Bytes32()
+	)
+	contractAddr = common.BytesToAddress(crypto.Keccak256([]byte{0xff}, sender, hash[:])[12:])
+	return evm.create(caller, container.subContainerCodes[index], container.subContainers[index], input, gas, endowment, contractAddr, EOFCREATE)
+}
+
+// parseInitcode decodes and validates the EOF initcode container at the start
+// of a creation transaction's data, returning the container and its size.
+func (evm *EVM) parseInitcode(data []byte) (*Container, int, error) {
+	container := new(Container)
+	size, err := container.unmarshal(data, true)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidEOFInitcode, err)
+	}
+	if err := container.ValidateCode(evm.eofTable, true); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidEOFInitcode, err)
+	}
+	return container, size, nil
+}
+
+// loadContainer returns the parsed EOF container of the given deployed code,
+// caching the result by code hash. Code deployed before EIP-3541 may carry the
+// EOF magic without being a valid container, so it's validated before use.
+func (evm *EVM) loadContainer(hash common.Hash, code []byte) (*Container, error) {
+	if container, ok := evm.containers[hash]; ok {
+		return container, nil
+	}
+	container := new(Container)
+	if err := container.UnmarshalBinary(code); err != nil {
+		return nil, err
+	}
+	if err := container.ValidateCode(evm.eofTable, false); err != nil {
+		return nil, err
+	}
+	if evm.containers == nil {
+		evm.containers = make(map[common.Hash]*Container)
+	}
+	evm.containers[hash] = container
+	return container, nil
 }
 
 // Create2 creates a new contract using code as deployment code.
@@ -618,7 +714,7 @@ func (evm *EVM) Create(caller common.Address, code []byte, gas uint64, value *ui
 func (evm *EVM) Create2(caller common.Address, code []byte, gas uint64, endowment *uint256.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	inithash := crypto.HashData(evm.hasher, code)
 	contractAddr = crypto.CreateAddress2(caller, salt.Bytes32(), inithash[:])
-	return evm.create(caller, code, gas, endowment, contractAddr, CREATE2)
+	return evm.create(caller, code, nil, nil, gas, endowment, contractAddr, CREATE2)
 }
 
 // resolveCode returns the code associated with the provided account. After
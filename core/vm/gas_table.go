@@ -461,6 +461,58 @@ func gasStaticCall(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memo
 	return gas, nil
 }
 
+func gasExtCall(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return gasExtCallVariant(evm, contract, stack, mem, memorySize, !stack.Back(3).IsZero())
+}
+
+func gasExtDelegateCall(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return gasExtCallVariant(evm, contract, stack, mem, memorySize, false)
+}
+
+func gasExtStaticCall(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return gasExtCallVariant(evm, contract, stack, mem, memorySize, false)
+}
+
+// gasExtCallVariant calculates the dynamic gas of the EOF call instructions,
+// as defined by EIP-7069, and the gas to forward to the callee. The forwarded
+// gas is set to zero if the callee would receive less than the minimum, which
+// makes the instruction fail without making the call.
+func gasExtCallVariant(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64, transfersValue bool) (uint64, error) {
+	target := stack.Back(0)
+	if target.BitLen() > 8*common.AddressLength {
+		return 0, ErrInvalidCallTarget
+	}
+	address := common.Address(target.Bytes20())
+
+	gas, err := memoryGasCost(mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	// The warm access cost is already charged as constant gas
+	if !evm.StateDB.AddressInAccessList(address) {
+		evm.StateDB.AddAddressToAccessList(address)
+		gas += params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929
+	}
+	if transfersValue {
+		gas += params.CallValueTransferGas
+		if evm.StateDB.Empty(address) {
+			gas += params.CallNewAccountGas
+		}
+	}
+	// Forward all but max(1/64th, minimum retained) of the remaining gas
+	evm.callGasTemp = 0
+	if contract.Gas < gas {
+		return gas, nil
+	}
+	available := contract.Gas - gas
+	if retained := max(available/64, params.ExtCallMinRetainedGas); available > retained {
+		if callee := available - retained; callee >= params.ExtCallMinCalleeGas {
+			evm.callGasTemp = callee
+		}
+	}
+	return gas + evm.callGasTemp, nil
+}
+
 func gasSelfdestruct(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 	var gas uint64
 	// EIP150 homestead gas reprice fork:
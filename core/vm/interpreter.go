@@ -18,6 +18,7 @@ package vm
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -34,6 +35,7 @@ type Config struct {
 
 	StatelessSelfValidation bool // Generate execution witnesses and self-check against them (testing purpose)
 	EnableWitnessStats      bool // Whether trie access statistics collection is enabled
+	EnableOpcodeProfiling   bool // Samples opcode and precompile execution times, see OpcodeProfile
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -114,13 +116,26 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	// Switch to the EOF instruction set for EOF code, which starts executing
+	// the first code section. Invalid containers and EOF initcode passed to the
+	// legacy CREATE opcodes fall back to legacy execution, aborting on the 0xEF
+	// opcode.
+	jumpTable := evm.table
+	if evm.eofTable != nil {
+		if contract.Container == nil && !contract.IsDeployment && HasEOFMagic(contract.Code) {
+			contract.Container, _ = evm.loadContainer(contract.CodeHash, contract.Code)
+		}
+		if contract.Container != nil {
+			jumpTable = evm.eofTable
+			contract.setCodeSection(0)
+		}
+	}
 
 	var (
-		op          OpCode     // current opcode
-		jumpTable   *JumpTable = evm.table
-		mem                    = NewMemory() // bound memory
-		stack                  = newstack()  // local stack
-		callContext            = &ScopeContext{
+		op          OpCode        // current opcode
+		mem         = NewMemory() // bound memory
+		stack       = newstack()  // local stack
+		callContext = &ScopeContext{
 			Memory:   mem,
 			Stack:    stack,
 			Contract: contract,
@@ -136,8 +151,15 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 		logged    bool   // deferred EVMLogger should ignore already logged steps
 		res       []byte // result of the opcode execution function
 		debug     = evm.Config.Tracer != nil
+		profile   = evm.Config.EnableOpcodeProfiling
 		isEIP4762 = evm.chainRules.IsEIP4762
+		blocks    *CodeBlocks // basic blocks of the code, nil if executing per instruction
 	)
+	// Basic blocks are only used where charging gas per block is indistinguishable
+	// from per instruction: not when tracing, profiling or with per-chunk code gas.
+	if blockExecution && !debug && !profile && !isEIP4762 && contract.Container == nil {
+		blocks = evm.codeBlocks(contract)
+	}
 	// Don't move this deferred function, it's placed before the OnOpcode-deferred method,
 	// so that it gets executed _after_: the OnOpcode needs the stacks before
 	// they are returned to the pools
@@ -166,6 +188,26 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 	// parent context.
 	_ = jumpTable[0] // nil-check the jumpTable out of the loop
 	for {
+		// Run a whole basic block if one starts here and its gas and stack
+		// requirements are met. Otherwise step through it instruction by
+		// instruction, so any error surfaces at the exact opcode.
+		if blocks != nil {
+			if block := blocks.at(pc); block != nil && contract.Gas >= block.gas {
+				if sLen := stack.len(); sLen >= block.minStack && sLen <= block.maxStack {
+					contract.Gas -= block.gas
+					for _, execute := range block.ops {
+						if res, err = execute(&pc, evm, callContext); err != nil {
+							break
+						}
+						pc++
+					}
+					if err != nil {
+						break
+					}
+					continue
+				}
+			}
+		}
 		if debug {
 			// Capture pre-execution values for tracing.
 			logged, pcCopy, gasCopy = false, pc, contract.Gas
@@ -249,7 +291,13 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 		}
 
 		// execute the operation
-		res, err = operation.execute(&pc, evm, callContext)
+		if profile && !isCallOpcode(op) && sampleOpcode() {
+			start := time.Now()
+			res, err = operation.execute(&pc, evm, callContext)
+			ProfileOpcode(op, time.Since(start))
+		} else {
+			res, err = operation.execute(&pc, evm, callContext)
+		}
 		if err != nil {
 			break
 		}
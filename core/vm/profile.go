@@ -0,0 +1,172 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// opcodeProfileSampleRate is the average number of executed instructions per
+// timed one when profiling is enabled through the VM config. Timing every
+// instruction would make the measurement overhead dominate the results.
+const opcodeProfileSampleRate = 32
+
+// profileStat aggregates the execution times of a single opcode or precompile.
+type profileStat struct {
+	count atomic.Uint64
+	total atomic.Uint64 // Nanoseconds
+	max   atomic.Uint64 // Nanoseconds
+
+	once sync.Once
+	hist metrics.Histogram
+}
+
+func (s *profileStat) record(name string, elapsed time.Duration) {
+	ns := uint64(elapsed.Nanoseconds())
+	s.count.Add(1)
+	s.total.Add(ns)
+	for {
+		old := s.max.Load()
+		if ns <= old || s.max.CompareAndSwap(old, ns) {
+			break
+		}
+	}
+	s.once.Do(func() {
+		s.hist = metrics.GetOrRegisterHistogram(name, nil, metrics.NewExpDecaySample(1028, 0.015))
+	})
+	s.hist.Update(int64(ns))
+}
+
+var (
+	opcodeStats     [256]profileStat
+	precompileStats sync.Map // name -> *profileStat
+)
+
+// ProfileOpcode records the execution time of an opcode, exporting it as the
+// vm/opcode/<name> histogram.
+func ProfileOpcode(op OpCode, elapsed time.Duration) {
+	opcodeStats[op].record("vm/opcode/"+op.String(), elapsed)
+}
+
+// ProfilePrecompile records the execution time of a precompiled contract,
+// exporting it as the vm/precompile/<name> histogram.
+func ProfilePrecompile(name string, elapsed time.Duration) {
+	stat, ok := precompileStats.Load(name)
+	if !ok {
+		stat, _ = precompileStats.LoadOrStore(name, new(profileStat))
+	}
+	stat.(*profileStat).record("vm/precompile/"+name, elapsed)
+}
+
+// sampleOpcode reports whether the next instruction should be timed.
+func sampleOpcode() bool {
+	return rand.Uint32N(opcodeProfileSampleRate) == 0
+}
+
+// isCallOpcode reports whether the opcode executes other code as part of its
+// own execution. Their timings would include the callee, so they are not
+// profiled by the interpreter.
+func isCallOpcode(op OpCode) bool {
+	switch op {
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE, CREATE2, EXTCALL, EXTDELEGATECALL, EXTSTATICCALL, EOFCREATE:
+		return true
+	}
+	return false
+}
+
+// ProfileEntry is the aggregated execution time of an opcode or precompile.
+type ProfileEntry struct {
+	Name  string        `json:"name"`
+	Count uint64        `json:"count"`
+	Total time.Duration `json:"total"`
+	Mean  time.Duration `json:"mean"`
+	Max   time.Duration `json:"max"`
+}
+
+// Profile is a snapshot of the collected execution times, ordered by the
+// total time spent, descending.
+type Profile struct {
+	Opcodes     []ProfileEntry `json:"opcodes"`
+	Precompiles []ProfileEntry `json:"precompiles"`
+}
+
+func (s *profileStat) entry(name string) (ProfileEntry, bool) {
+	count := s.count.Load()
+	if count == 0 {
+		return ProfileEntry{}, false
+	}
+	total := s.total.Load()
+	return ProfileEntry{
+		Name:  name,
+		Count: count,
+		Total: time.Duration(total),
+		Mean:  time.Duration(total / count),
+		Max:   time.Duration(s.max.Load()),
+	}, true
+}
+
+// OpcodeProfile returns the execution times collected since startup or the
+// last reset. The histograms exported via metrics are not affected by resets.
+func OpcodeProfile() *Profile {
+	profile := &Profile{
+		Opcodes:     []ProfileEntry{},
+		Precompiles: []ProfileEntry{},
+	}
+	for op := range opcodeStats {
+		if entry, ok := opcodeStats[op].entry(OpCode(op).String()); ok {
+			profile.Opcodes = append(profile.Opcodes, entry)
+		}
+	}
+	precompileStats.Range(func(name, stat any) bool {
+		if entry, ok := stat.(*profileStat).entry(name.(string)); ok {
+			profile.Precompiles = append(profile.Precompiles, entry)
+		}
+		return true
+	})
+	byTotal := func(a, b ProfileEntry) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	}
+	slices.SortFunc(profile.Opcodes, byTotal)
+	slices.SortFunc(profile.Precompiles, byTotal)
+	return profile
+}
+
+// ResetOpcodeProfile clears the execution times returned by OpcodeProfile.
+func ResetOpcodeProfile() {
+	for op := range opcodeStats {
+		opcodeStats[op].count.Store(0)
+		opcodeStats[op].total.Store(0)
+		opcodeStats[op].max.Store(0)
+	}
+	precompileStats.Range(func(_, stat any) bool {
+		s := stat.(*profileStat)
+		s.count.Store(0)
+		s.total.Store(0)
+		s.max.Store(0)
+		return true
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestOpcodeProfiling(t *testing.T) {
+	ResetOpcodeProfile()
+	defer ResetOpcodeProfile()
+
+	var (
+		statedb, _ = state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+		contract   = common.Address{0xc0}
+		evm        = NewEVM(eofTestBlockContext, statedb, params.MergedTestChainConfig, Config{EnableOpcodeProfiling: true})
+	)
+	// Loop 1000 times decrementing a counter, then call the identity precompile.
+	statedb.SetCode(contract, []byte{
+		byte(PUSH2), 0x03, 0xe8, // counter
+		byte(JUMPDEST), // pc 3
+		byte(PUSH1), 0x1, byte(SWAP1), byte(SUB),
+		byte(DUP1), byte(PUSH1), 0x3, byte(JUMPI),
+		byte(PUSH1), 0x0, byte(PUSH1), 0x0, byte(PUSH1), 0x0, byte(PUSH1), 0x0,
+		byte(PUSH1), 0x4, byte(GAS), byte(STATICCALL),
+		byte(STOP),
+	}, tracing.CodeChangeUnspecified)
+
+	if _, _, err := evm.Call(common.Address{}, contract, nil, 10_000_000, new(uint256.Int)); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	profile := OpcodeProfile()
+
+	opcodes := make(map[string]ProfileEntry)
+	for _, entry := range profile.Opcodes {
+		opcodes[entry.Name] = entry
+	}
+	if _, ok := opcodes["STATICCALL"]; ok {
+		t.Error("call opcodes should not be profiled")
+	}
+	// 6000 loop instructions are executed, sampling has to pick up some
+	if entry := opcodes["JUMPI"]; entry.Count == 0 || entry.Count >= 1000 {
+		t.Errorf("unexpected JUMPI sample count %d", entry.Count)
+	}
+	if len(profile.Precompiles) != 1 || profile.Precompiles[0].Name != "ID" || profile.Precompiles[0].Count != 1 {
+		t.Errorf("unexpected precompile profile: %+v", profile.Precompiles)
+	}
+	ResetOpcodeProfile()
+	if profile := OpcodeProfile(); len(profile.Opcodes) != 0 || len(profile.Precompiles) != 0 {
+		t.Errorf("profile not reset: %+v", profile)
+	}
+}
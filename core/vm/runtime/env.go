@@ -18,6 +18,7 @@ package runtime
 
 import (
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
 )
 
@@ -42,7 +43,11 @@ func NewEnv(cfg *Config) *vm.EVM {
 		Random:      cfg.Random,
 	}
 
-	evm := vm.NewEVM(blockContext, cfg.State, cfg.ChainConfig, cfg.EVMConfig)
+	var tracingStateDB = vm.StateDB(cfg.State)
+	if hooks := cfg.EVMConfig.Tracer; hooks != nil {
+		tracingStateDB = state.NewHookedState(cfg.State, hooks)
+	}
+	evm := vm.NewEVM(blockContext, tracingStateDB, cfg.ChainConfig, cfg.EVMConfig)
 	evm.SetTxContext(txContext)
 	return evm
 }
@@ -236,12 +236,9 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.B
 	if header == nil {
 		return nil, nil, errors.New("header not found")
 	}
-	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	stateDb, err := b.stateAt(header)
 	if err != nil {
-		stateDb, err = b.eth.BlockChain().HistoricState(header.Root)
-		if err != nil {
-			return nil, nil, err
-		}
+		return nil, nil, err
 	}
 	return stateDb, header, nil
 }
@@ -261,21 +258,33 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
 			return nil, nil, errors.New("hash is not currently canonical")
 		}
-		stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+		stateDb, err := b.stateAt(header)
 		if err != nil {
-			stateDb, err = b.eth.BlockChain().HistoricState(header.Root)
-			if err != nil {
-				return nil, nil, err
-			}
+			return nil, nil, err
 		}
 		return stateDb, header, nil
 	}
 	return nil, nil, errors.New("invalid arguments; neither block nor hash specified")
 }
 
+// stateAt returns the state associated with the given header. The live state
+// is preferred, falling back to the indexed state histories if the trie is no
+// longer available (path scheme only).
+func (b *EthAPIBackend) stateAt(header *types.Header) (*state.StateDB, error) {
+	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	if err == nil {
+		return stateDb, nil
+	}
+	if b.eth.BlockChain().TrieDB().Scheme() != rawdb.PathScheme {
+		return nil, err
+	}
+	return b.eth.historicState(header)
+}
+
+// HistoryPruningCutoff returns the first block whose history is available locally,
+// either from the database or from era1 archives.
 func (b *EthAPIBackend) HistoryPruningCutoff() uint64 {
-	bn, _ := b.eth.blockchain.HistoryPruningCutoff()
-	return bn
+	return b.eth.blockchain.HistoryServingCutoff()
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
@@ -320,7 +329,15 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	err := b.eth.txPool.Add([]*types.Transaction{signedTx}, false)[0]
+	var err error
+	if h := b.eth.handler; h != nil && h.stem != nil {
+		// Relay the transaction privately along a stem before broadcasting it.
+		// If it's only accepted later, the local tracker resubmits it and it's
+		// broadcast as usual.
+		err = h.stem.addLocal(signedTx)
+	} else {
+		err = b.eth.txPool.Add([]*types.Transaction{signedTx}, false)[0]
+	}
 
 	// If the local transaction tracker is not configured, returns whatever
 	// returned from the txpool.
@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
@@ -95,6 +96,17 @@ func (api *DebugAPI) Preimage(ctx context.Context, hash common.Hash) (hexutil.By
 	return nil, errors.New("unknown preimage")
 }
 
+// FetchWitness retrieves the execution witness of a block from the peers on the
+// `wit` protocol and returns it RLP encoded. The witness is checked to belong to
+// the parent of the block, but it is not executed.
+func (api *DebugAPI) FetchWitness(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
+	witness, err := api.eth.handler.fetchWitness(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(witness)
+}
+
 // BadBlockArgs represents the entries in the list returned when bad blocks are queried.
 type BadBlockArgs struct {
 	Hash  common.Hash            `json:"hash"`
@@ -443,3 +455,15 @@ func (api *DebugAPI) GetTrieFlushInterval() (string, error) {
 	}
 	return api.eth.blockchain.GetTrieFlushInterval().String(), nil
 }
+
+// OpcodeProfile returns the execution times of opcodes and precompiled
+// contracts collected since startup or the last reset. Profiling has to be
+// enabled with --vmprofile or the opcodeProfiler live tracer. If reset is
+// set, the collected times are cleared after being returned.
+func (api *DebugAPI) OpcodeProfile(reset *bool) *vm.Profile {
+	profile := vm.OpcodeProfile()
+	if reset != nil && *reset {
+		vm.ResetOpcodeProfile()
+	}
+	return profile
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/filtermaps"
+	"github.com/ethereum/go-ethereum/core/overlay"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/txpool"
@@ -46,6 +47,8 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/eth/protocols/stem"
+	"github.com/ethereum/go-ethereum/eth/protocols/wit"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -111,6 +114,8 @@ type Ethereum struct {
 	filterMaps      *filtermaps.FilterMaps
 	closeFilterMaps chan chan struct{}
 
+	converter *overlay.Converter // Binary trie converter, nil if not enabled
+
 	APIBackend *EthAPIBackend
 
 	miner    *miner.Miner
@@ -222,19 +227,22 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	}
 	var (
 		options = &core.BlockChainConfig{
-			TrieCleanLimit:   config.TrieCleanCache,
-			NoPrefetch:       config.NoPrefetch,
-			TrieDirtyLimit:   config.TrieDirtyCache,
-			ArchiveMode:      config.NoPruning,
-			TrieTimeLimit:    config.TrieTimeout,
-			SnapshotLimit:    config.SnapshotCache,
-			Preimages:        config.Preimages,
-			StateHistory:     config.StateHistory,
-			StateScheme:      scheme,
-			ChainHistoryMode: config.HistoryMode,
-			TxLookupLimit:    int64(min(config.TransactionHistory, math.MaxInt64)),
+			TrieCleanLimit:    config.TrieCleanCache,
+			TrieCleanPersist:  config.TrieCleanPersist,
+			NoPrefetch:        config.NoPrefetch,
+			TrieDirtyLimit:    config.TrieDirtyCache,
+			ArchiveMode:       config.NoPruning,
+			TrieTimeLimit:     config.TrieTimeout,
+			SnapshotLimit:     config.SnapshotCache,
+			Preimages:         config.Preimages,
+			StateHistory:      config.StateHistory,
+			StateScheme:       scheme,
+			ChainHistoryMode:  config.HistoryMode,
+			TxLookupLimit:     int64(min(config.TransactionHistory, math.MaxInt64)),
+			WitnessCacheLimit: config.WitnessCache,
 			VmConfig: vm.Config{
 				EnablePreimageRecording: config.EnablePreimageRecording,
+				EnableOpcodeProfiling:   config.EnableOpcodeProfiling,
 			},
 			// Enables file journaling for the trie database. The journal files will be stored
 			// within the data directory. The corresponding paths will be either:
@@ -277,7 +285,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		HashScheme:     scheme == rawdb.HashScheme,
 	}
 	chainView := eth.newChainView(eth.blockchain.CurrentBlock())
-	historyCutoff, _ := eth.blockchain.HistoryPruningCutoff()
+	historyCutoff := eth.blockchain.HistoryServingCutoff()
 	var finalBlock uint64
 	if fb := eth.blockchain.CurrentFinalBlock(); fb != nil {
 		finalBlock = fb.Number.Uint64()
@@ -289,6 +297,14 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	eth.filterMaps = filterMaps
 	eth.closeFilterMaps = make(chan chan struct{})
 
+	// Initialize the binary trie converter if requested
+	if config.BinaryConversion {
+		eth.converter, err = overlay.NewConverter(chainDb, eth.blockchain.TrieDB())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize binary trie conversion: %v", err)
+		}
+	}
+
 	// TxPool
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
@@ -327,6 +343,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		BloomCache:     uint64(cacheLimit),
 		EventMux:       eth.eventMux,
 		RequiredBlocks: config.RequiredBlocks,
+		StemTxs:        config.StemTxs,
+		Witnesses:      config.WitnessCache > 0,
 	}); err != nil {
 		return nil, err
 	}
@@ -425,6 +443,12 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler))...)
 	}
+	if s.config.StemTxs {
+		protos = append(protos, stem.MakeProtocols((*stemHandler)(s.handler))...)
+	}
+	if s.config.WitnessCache > 0 {
+		protos = append(protos, wit.MakeProtocols((*witHandler)(s.handler))...)
+	}
 	return protos
 }
 
@@ -447,6 +471,11 @@ func (s *Ethereum) Start() error {
 	// start log indexer
 	s.filterMaps.Start()
 	go s.updateFilterMapsHeads()
+
+	// start binary trie conversion
+	if s.converter != nil {
+		s.converter.Start(s.blockchain.CurrentBlock)
+	}
 	return nil
 }
 
@@ -483,7 +512,7 @@ func (s *Ethereum) updateFilterMapsHeads() {
 		if head == nil || newHead.Hash() != head.Hash() {
 			head = newHead
 			chainView := s.newChainView(head)
-			historyCutoff, _ := s.blockchain.HistoryPruningCutoff()
+			historyCutoff := s.blockchain.HistoryServingCutoff()
 			var finalBlock uint64
 			if fb := s.blockchain.CurrentFinalBlock(); fb != nil {
 				finalBlock = fb.Number.Uint64()
@@ -570,6 +599,9 @@ func (s *Ethereum) Stop() error {
 	s.closeFilterMaps <- ch
 	<-ch
 	s.filterMaps.Stop()
+	if s.converter != nil {
+		s.converter.Stop()
+	}
 	s.txPool.Close()
 	s.blockchain.Stop()
 	s.engine.Close()
@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
 )
 
 // timeoutGracePeriod is the amount of time to allow for a peer to deliver a
@@ -295,6 +296,10 @@ func (d *Downloader) concurrentFetch(queue typedQueue) error {
 				log.Error("Delivery timeout from unknown peer", "peer", req.Peer)
 				continue
 			}
+			// Timeouts don't get the peer dropped, but they do hurt its reputation
+			if scorer, ok := peer.peer.(p2p.Scorer); ok {
+				scorer.Score(p2p.ScoreTimeout)
+			}
 			if fails > 2 {
 				queue.updateCapacity(peer, 0, 0)
 			} else {
@@ -51,8 +51,8 @@ var (
 
 // dropper monitors the state of the peer pool and makes changes as follows:
 //   - during sync the Downloader handles peer connections, so dropper is disabled
-//   - if not syncing and the peer count is close to the limit, it drops peers
-//     randomly every peerDropInterval to make space for new peers
+//   - if not syncing and the peer count is close to the limit, it drops the peer
+//     with the worst reputation every peerDropInterval to make space for new peers
 //   - peers are dropped separately from the inboud pool and from the dialed pool
 type dropper struct {
 	maxDialPeers    int // maximum number of dialed peers
@@ -103,8 +103,9 @@ func (cm *dropper) Stop() {
 	cm.wg.Wait()
 }
 
-// dropRandomPeer selects one of the peers randomly and drops it from the peer pool.
-func (cm *dropper) dropRandomPeer() bool {
+// dropWorstPeer selects the peer with the lowest reputation score and drops it
+// from the peer pool. Peers with equal scores are selected randomly.
+func (cm *dropper) dropWorstPeer() bool {
 	peers := cm.peersFunc()
 	var numInbound int
 	for _, p := range peers {
@@ -126,9 +127,20 @@ func (cm *dropper) dropRandomPeer() bool {
 
 	droppable := slices.DeleteFunc(peers, selectDoNotDrop)
 	if len(droppable) > 0 {
-		p := droppable[mrand.Intn(len(droppable))]
-		log.Debug("Dropping random peer", "inbound", p.Inbound(),
-			"id", p.ID(), "duration", common.PrettyDuration(p.Lifetime()), "peercountbefore", len(peers))
+		mrand.Shuffle(len(droppable), func(i, j int) {
+			droppable[i], droppable[j] = droppable[j], droppable[i]
+		})
+		var (
+			p     = droppable[0]
+			score = p.Reputation()
+		)
+		for _, candidate := range droppable[1:] {
+			if s := candidate.Reputation(); s < score {
+				p, score = candidate, s
+			}
+		}
+		log.Debug("Dropping worst peer", "inbound", p.Inbound(), "id", p.ID(), "score", score,
+			"duration", common.PrettyDuration(p.Lifetime()), "peercountbefore", len(peers))
 		p.Disconnect(p2p.DiscUselessPeer)
 		if p.Inbound() {
 			droppedInbound.Mark(1)
@@ -155,9 +167,9 @@ func (cm *dropper) loop() {
 	for {
 		select {
 		case <-cm.peerDropTimer.C:
-			// Drop a random peer if we are not syncing and the peer count is close to the limit.
+			// Drop the worst peer if we are not syncing and the peer count is close to the limit.
 			if !cm.syncingFunc() {
-				cm.dropRandomPeer()
+				cm.dropWorstPeer()
 			}
 			cm.peerDropTimer.Reset(randomDuration(peerDropIntervalMin, peerDropIntervalMax))
 		case <-cm.shutdownCh:
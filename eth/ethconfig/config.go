@@ -105,11 +105,20 @@ type Config struct {
 	LogExportCheckpoints string // export log index checkpoints to file
 	StateHistory         uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved.
 
+	// HistoricStateLimit is the maximum number of blocks from head whose state
+	// can be served from the indexed state histories (path scheme archive mode
+	// only). If set to 0, all the indexed historical states will be served.
+	HistoricStateLimit uint64 `toml:",omitempty"`
+
 	// State scheme represents the scheme used to store ethereum states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
 	// consistent with persistent state.
 	StateScheme string `toml:",omitempty"`
 
+	// BinaryConversion enables converting the merkle state into a binary trie
+	// in the background while following the chain (path scheme only).
+	BinaryConversion bool `toml:",omitempty"`
+
 	// RequiredBlocks is a set of block number -> hash mappings which must be in the
 	// canonical chain of all remote peers. Setting the option makes geth verify the
 	// presence of these blocks for every new peer connection.
@@ -122,11 +131,12 @@ type Config struct {
 	DatabaseFreezer    string
 	DatabaseEra        string
 
-	TrieCleanCache int
-	TrieDirtyCache int
-	TrieTimeout    time.Duration
-	SnapshotCache  int
-	Preimages      bool
+	TrieCleanCache   int
+	TrieCleanPersist bool `toml:",omitempty"` // Whether to persist the clean caches across restarts (path scheme only)
+	TrieDirtyCache   int
+	TrieTimeout      time.Duration
+	SnapshotCache    int
+	Preimages        bool
 
 	// This is the number of blocks for which logs will be cached in the filter system.
 	FilterLogCacheSize int
@@ -138,12 +148,24 @@ type Config struct {
 	TxPool   legacypool.Config
 	BlobPool blobpool.Config
 
+	// StemTxs enables relaying the locally submitted transactions privately
+	// along a random path of peers before broadcasting them (Dandelion++).
+	StemTxs bool `toml:",omitempty"`
+
+	// WitnessCache is the memory allowance (MB) for the witnesses of recently
+	// imported blocks, served to other nodes over the `wit` protocol. The
+	// protocol is disabled if zero.
+	WitnessCache int `toml:",omitempty"`
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables sampling of opcode and precompile execution times in the VM
+	EnableOpcodeProfiling bool
+
 	// Enables VM tracing
 	VMTrace           string
 	VMTraceJsonConfig string
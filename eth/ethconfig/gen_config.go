@@ -31,7 +31,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		LogNoHistory            bool   `toml:",omitempty"`
 		LogExportCheckpoints    string
 		StateHistory            uint64                 `toml:",omitempty"`
+		HistoricStateLimit      uint64                 `toml:",omitempty"`
 		StateScheme             string                 `toml:",omitempty"`
+		BinaryConversion        bool                   `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
 		SkipBcVersionCheck      bool                   `toml:"-"`
 		DatabaseHandles         int                    `toml:"-"`
@@ -39,6 +41,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		DatabaseFreezer         string
 		DatabaseEra             string
 		TrieCleanCache          int
+		TrieCleanPersist        bool `toml:",omitempty"`
 		TrieDirtyCache          int
 		TrieTimeout             time.Duration
 		SnapshotCache           int
@@ -47,8 +50,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Miner                   miner.Config
 		TxPool                  legacypool.Config
 		BlobPool                blobpool.Config
+		StemTxs                 bool `toml:",omitempty"`
+		WitnessCache            int  `toml:",omitempty"`
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
+		EnableOpcodeProfiling   bool
 		VMTrace                 string
 		VMTraceJsonConfig       string
 		RPCGasCap               uint64
@@ -72,7 +78,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.LogNoHistory = c.LogNoHistory
 	enc.LogExportCheckpoints = c.LogExportCheckpoints
 	enc.StateHistory = c.StateHistory
+	enc.HistoricStateLimit = c.HistoricStateLimit
 	enc.StateScheme = c.StateScheme
+	enc.BinaryConversion = c.BinaryConversion
 	enc.RequiredBlocks = c.RequiredBlocks
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
@@ -80,6 +88,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DatabaseFreezer = c.DatabaseFreezer
 	enc.DatabaseEra = c.DatabaseEra
 	enc.TrieCleanCache = c.TrieCleanCache
+	enc.TrieCleanPersist = c.TrieCleanPersist
 	enc.TrieDirtyCache = c.TrieDirtyCache
 	enc.TrieTimeout = c.TrieTimeout
 	enc.SnapshotCache = c.SnapshotCache
@@ -88,8 +97,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Miner = c.Miner
 	enc.TxPool = c.TxPool
 	enc.BlobPool = c.BlobPool
+	enc.StemTxs = c.StemTxs
+	enc.WitnessCache = c.WitnessCache
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.EnableOpcodeProfiling = c.EnableOpcodeProfiling
 	enc.VMTrace = c.VMTrace
 	enc.VMTraceJsonConfig = c.VMTraceJsonConfig
 	enc.RPCGasCap = c.RPCGasCap
@@ -117,7 +129,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		LogNoHistory            *bool   `toml:",omitempty"`
 		LogExportCheckpoints    *string
 		StateHistory            *uint64                `toml:",omitempty"`
+		HistoricStateLimit      *uint64                `toml:",omitempty"`
 		StateScheme             *string                `toml:",omitempty"`
+		BinaryConversion        *bool                  `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
 		SkipBcVersionCheck      *bool                  `toml:"-"`
 		DatabaseHandles         *int                   `toml:"-"`
@@ -125,6 +139,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		DatabaseFreezer         *string
 		DatabaseEra             *string
 		TrieCleanCache          *int
+		TrieCleanPersist        *bool `toml:",omitempty"`
 		TrieDirtyCache          *int
 		TrieTimeout             *time.Duration
 		SnapshotCache           *int
@@ -133,8 +148,11 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Miner                   *miner.Config
 		TxPool                  *legacypool.Config
 		BlobPool                *blobpool.Config
+		StemTxs                 *bool `toml:",omitempty"`
+		WitnessCache            *int  `toml:",omitempty"`
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
+		EnableOpcodeProfiling   *bool
 		VMTrace                 *string
 		VMTraceJsonConfig       *string
 		RPCGasCap               *uint64
@@ -189,9 +207,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.StateHistory != nil {
 		c.StateHistory = *dec.StateHistory
 	}
+	if dec.HistoricStateLimit != nil {
+		c.HistoricStateLimit = *dec.HistoricStateLimit
+	}
 	if dec.StateScheme != nil {
 		c.StateScheme = *dec.StateScheme
 	}
+	if dec.BinaryConversion != nil {
+		c.BinaryConversion = *dec.BinaryConversion
+	}
 	if dec.RequiredBlocks != nil {
 		c.RequiredBlocks = dec.RequiredBlocks
 	}
@@ -213,6 +237,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TrieCleanCache != nil {
 		c.TrieCleanCache = *dec.TrieCleanCache
 	}
+	if dec.TrieCleanPersist != nil {
+		c.TrieCleanPersist = *dec.TrieCleanPersist
+	}
 	if dec.TrieDirtyCache != nil {
 		c.TrieDirtyCache = *dec.TrieDirtyCache
 	}
@@ -237,12 +264,21 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.BlobPool != nil {
 		c.BlobPool = *dec.BlobPool
 	}
+	if dec.StemTxs != nil {
+		c.StemTxs = *dec.StemTxs
+	}
+	if dec.WitnessCache != nil {
+		c.WitnessCache = *dec.WitnessCache
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.EnableOpcodeProfiling != nil {
+		c.EnableOpcodeProfiling = *dec.EnableOpcodeProfiling
+	}
 	if dec.VMTrace != nil {
 		c.VMTrace = *dec.VMTrace
 	}
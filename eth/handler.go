@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/eth/protocols/wit"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -106,6 +107,8 @@ type handlerConfig struct {
 	BloomCache     uint64                 // Megabytes to alloc for snap sync bloom
 	EventMux       *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
+	StemTxs        bool                   // Whether to relay local transactions along a stem before broadcasting
+	Witnesses      bool                   // Whether to exchange block witnesses over the `wit` protocol
 }
 
 type handler struct {
@@ -124,6 +127,8 @@ type handler struct {
 	txFetcher      *fetcher.TxFetcher
 	peers          *peerSet
 	txBroadcastKey [16]byte
+	stem           *stemRelay   // Private transaction relaying, nil if disabled
+	witFetcher     *wit.Fetcher // Block witness retrieval, nil if disabled
 
 	eventMux   *event.TypeMux
 	txsCh      chan core.NewTxsEvent
@@ -207,6 +212,13 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		return h.txpool.Add(txs, false)
 	}
 	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, h.removePeer)
+
+	if config.StemTxs {
+		h.stem = newStemRelay(h.txpool, h.BroadcastTransactions)
+	}
+	if config.Witnesses {
+		h.witFetcher = wit.NewFetcher()
+	}
 	return h, nil
 }
 
@@ -443,6 +455,9 @@ func (h *handler) Start(maxPeers int) {
 
 	// start sync handlers
 	h.txFetcher.Start()
+	if h.stem != nil {
+		h.stem.start()
+	}
 
 	// start peer handler tracker
 	h.wg.Add(1)
@@ -453,6 +468,9 @@ func (h *handler) Stop() {
 	h.txsSub.Unsubscribe() // quits txBroadcastLoop
 	h.blockRange.stop()
 	h.txFetcher.Stop()
+	if h.stem != nil {
+		h.stem.stop()
+	}
 	h.downloader.Terminate()
 
 	// Quit chainSync and txsync64.
@@ -490,6 +508,10 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	)
 
 	for _, tx := range txs {
+		// Withhold the transactions in the stem phase of private relaying
+		if h.stem != nil && h.stem.embargoed(tx.Hash()) {
+			continue
+		}
 		var directSet map[*ethPeer]struct{}
 		switch {
 		case tx.Type() == types.BlobTxType:
@@ -653,7 +675,7 @@ func (h *handler) broadcastBlockRange(state *blockRangeState) {
 
 // update assigns the values of the next block range update from the chain.
 func (st *blockRangeState) update(chain *core.BlockChain, latest *types.Header) {
-	earliest, _ := chain.HistoryPruningCutoff()
+	earliest := chain.HistoryServingCutoff()
 	st.next.Store(&eth.BlockRangeUpdatePacket{
 		EarliestBlock:   min(latest.Number.Uint64(), earliest),
 		LatestBlock:     latest.Number.Uint64(),
@@ -20,9 +20,11 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 )
 
@@ -58,11 +60,18 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 	// Consume any broadcasts and announces, forwarding the rest to the downloader
 	switch packet := packet.(type) {
 	case *eth.NewPooledTransactionHashesPacket:
+		if h.stem != nil {
+			h.stem.seen(packet.Hashes)
+		}
 		return h.txFetcher.Notify(peer.ID(), packet.Types, packet.Sizes, packet.Hashes)
 
 	case *eth.TransactionsPacket:
+		if h.stem != nil {
+			h.stem.seen(transactionHashes(*packet))
+		}
 		for _, tx := range *packet {
 			if tx.Type() == types.BlobTxType {
+				peer.Score(p2p.ScoreInvalid)
 				return errors.New("disallowed broadcast blob transaction")
 			}
 		}
@@ -75,16 +84,52 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		for _, tx := range *packet {
 			if tx.Type() == types.BlobTxType {
 				if tx.BlobTxSidecar() == nil {
+					peer.Score(p2p.ScoreInvalid)
 					return errors.New("received sidecar-less blob transaction")
 				}
 				if err := tx.BlobTxSidecar().ValidateBlobCommitmentHashes(tx.BlobHashes()); err != nil {
+					peer.Score(p2p.ScoreInvalid)
 					return err
 				}
 			}
 		}
-		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
+		if h.stem != nil {
+			h.stem.seen(transactionHashes(*packet))
+		}
+		return h.enqueuePooledTxs(peer, *packet)
 
 	default:
 		return fmt.Errorf("unexpected eth packet type: %T", packet)
 	}
 }
+
+// enqueuePooledTxs schedules the transactions retrieved from the peer after its
+// announcements for import, rewarding the peer if any of them was new to us and
+// got accepted into the pool.
+func (h *ethHandler) enqueuePooledTxs(peer *eth.Peer, txs []*types.Transaction) error {
+	var fresh []common.Hash
+	for _, tx := range txs {
+		if !h.txpool.Has(tx.Hash()) {
+			fresh = append(fresh, tx.Hash())
+		}
+	}
+	if err := h.txFetcher.Enqueue(peer.ID(), txs, true); err != nil {
+		return err
+	}
+	for _, hash := range fresh {
+		if h.txpool.Has(hash) {
+			peer.Score(p2p.ScoreUseful)
+			break
+		}
+	}
+	return nil
+}
+
+// transactionHashes returns the hashes of the given transactions.
+func transactionHashes(txs []*types.Transaction) []common.Hash {
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	return hashes
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/stem"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// stemHandler implements the stem.Backend interface to handle the transactions
+// relayed privately by the remote peers.
+type stemHandler handler
+
+// RunPeer is invoked when a peer joins on the `stem` protocol.
+func (h *stemHandler) RunPeer(peer *stem.Peer, hand stem.Handler) error {
+	if !(*handler)(h).incHandlers() {
+		return p2p.DiscQuitting
+	}
+	defer (*handler)(h).decHandlers()
+
+	h.stem.registerPeer(peer)
+	defer h.stem.unregisterPeer(peer.ID())
+
+	return hand(peer)
+}
+
+// PeerInfo retrieves all known `stem` information about a peer.
+func (h *stemHandler) PeerInfo(id enode.ID) interface{} {
+	if p := h.stem.peer(id.String()); p != nil {
+		return &stemPeerInfo{Version: p.Version()}
+	}
+	return nil
+}
+
+// Handle is invoked from a peer's message handler when it receives a new remote
+// message that the handler couldn't consume and serve itself.
+func (h *stemHandler) Handle(peer *stem.Peer, packet stem.Packet) error {
+	switch packet := packet.(type) {
+	case *stem.TransactionsPacket:
+		// Stem transactions are only accepted once the node is synced, the
+		// same as the transactions broadcast via `eth`.
+		if h.synced.Load() {
+			h.stem.handleTransactions(peer, types.Transactions(*packet))
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unexpected stem packet type: %T", packet)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/eth/protocols/wit"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// errWitnessesDisabled is returned when fetching a witness while the `wit`
+// protocol is disabled.
+var errWitnessesDisabled = errors.New("witness protocol disabled")
+
+// fetchWitness retrieves the execution witness of a known block from the peers
+// on the `wit` protocol. The witness is checked to be built on top of the parent
+// of the block, but it is not executed.
+func (h *handler) fetchWitness(ctx context.Context, hash common.Hash) (*stateless.Witness, error) {
+	if h.witFetcher == nil {
+		return nil, errWitnessesDisabled
+	}
+	header := h.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, fmt.Errorf("unknown block %x", hash)
+	}
+	return h.witFetcher.Fetch(ctx, header)
+}
+
+// witHandler implements the wit.Backend interface to handle the witnesses
+// delivered by the remote peers.
+type witHandler handler
+
+func (h *witHandler) Chain() *core.BlockChain { return h.chain }
+
+// RunPeer is invoked when a peer joins on the `wit` protocol.
+func (h *witHandler) RunPeer(peer *wit.Peer, hand wit.Handler) error {
+	if !(*handler)(h).incHandlers() {
+		return p2p.DiscQuitting
+	}
+	defer (*handler)(h).decHandlers()
+
+	if err := h.witFetcher.Register(peer); err != nil {
+		return err
+	}
+	defer h.witFetcher.Unregister(peer.ID())
+
+	return hand(peer)
+}
+
+// PeerInfo retrieves all known `wit` information about a peer.
+func (h *witHandler) PeerInfo(id enode.ID) interface{} {
+	if p := h.witFetcher.Peer(id.String()); p != nil {
+		return &witPeerInfo{Version: p.Version()}
+	}
+	return nil
+}
+
+// Handle is invoked from a peer's message handler when it receives a new remote
+// message that the handler couldn't consume and serve itself.
+func (h *witHandler) Handle(peer *wit.Peer, packet wit.Packet) error {
+	switch packet := packet.(type) {
+	case *wit.WitnessPacket:
+		// Any failure of the delivery means the peer sent unrequested data,
+		// penalize it before the disconnect.
+		if err := h.witFetcher.Deliver(peer, packet); err != nil {
+			peer.Score(p2p.ScoreInvalid)
+			return err
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unexpected wit packet type: %T", packet)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/protocols/wit"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newTestWitHandler creates a handler with the `wit` protocol enabled, importing
+// the given blocks one by one. If cache is set, the witnesses of the imported
+// blocks are retained to be served.
+func newTestWitHandler(t *testing.T, gspec *core.Genesis, blocks []*types.Block, cache bool) *handler {
+	t.Helper()
+
+	config := core.DefaultConfig()
+	if cache {
+		config.WitnessCacheLimit = 16
+	}
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, gspec, ethash.NewFaker(), config)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	for _, block := range blocks {
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("failed to import block %d: %v", block.NumberU64(), err)
+		}
+	}
+	handler, err := newHandler(&handlerConfig{
+		Database:   db,
+		Chain:      chain,
+		TxPool:     newTestTxPool(),
+		Network:    1,
+		Sync:       ethconfig.SnapSync,
+		BloomCache: 1,
+		Witnesses:  true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	handler.Start(1000)
+	t.Cleanup(func() {
+		handler.Stop()
+		chain.Stop()
+	})
+	return handler
+}
+
+// Tests that the handler fetches the witnesses of known blocks from its `wit`
+// peers.
+func TestWitnessFetch(t *testing.T) {
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{testAddr: {Balance: big.NewInt(params.Ether)}},
+	}
+	signer := types.LatestSigner(gspec.Config)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(testAddr), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, testKey)
+		b.AddTx(tx)
+	})
+	var (
+		server = newTestWitHandler(t, gspec, blocks, true)
+		client = newTestWitHandler(t, gspec, blocks, false)
+	)
+	// Connect the client to the server, serving the requests of the client
+	app, net := p2p.MsgPipe()
+	t.Cleanup(func() { app.Close(); net.Close() })
+
+	var (
+		local  = wit.NewPeer(wit.WIT1, p2p.NewPeer(enode.ID{1}, "", nil), app)
+		remote = wit.NewPeer(wit.WIT1, p2p.NewPeer(enode.ID{2}, "", nil), net)
+	)
+	go (*witHandler)(server).RunPeer(remote, func(peer *wit.Peer) error {
+		return wit.Handle((*witHandler)(server), peer)
+	})
+	go (*witHandler)(client).RunPeer(local, func(peer *wit.Peer) error {
+		return wit.Handle((*witHandler)(client), peer)
+	})
+	for start := time.Now(); client.witFetcher.Peer(local.ID()) == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatal("peer not registered with the witness fetcher")
+		}
+	}
+	witness, err := client.fetchWitness(context.Background(), blocks[2].Hash())
+	if err != nil {
+		t.Fatalf("failed to fetch witness: %v", err)
+	}
+	if witness.Headers[0].Hash() != blocks[1].Hash() {
+		t.Fatalf("witness parent mismatch: have %x, want %x", witness.Headers[0].Hash(), blocks[1].Hash())
+	}
+	// Witnesses of unknown blocks can't be verified, so they must not be fetched
+	if _, err := client.fetchWitness(context.Background(), common.Hash{0xde, 0xad}); err == nil {
+		t.Fatal("witness of unknown block fetched")
+	}
+	// Handlers without the `wit` protocol must refuse fetching
+	disabled := newTestHandlerWithBlocks(0)
+	defer disabled.close()
+	if _, err := disabled.handler.fetchWitness(context.Background(), blocks[2].Hash()); err != errWitnessesDisabled {
+		t.Fatalf("disabled fetch error mismatch: have %v, want %v", err, errWitnessesDisabled)
+	}
+}
@@ -71,3 +71,15 @@ func (p *snapPeer) info() *snapPeerInfo {
 		Version: p.Version(),
 	}
 }
+
+// stemPeerInfo represents a short summary of the `stem` sub-protocol metadata known
+// about a connected peer.
+type stemPeerInfo struct {
+	Version uint `json:"version"` // Stem protocol version negotiated
+}
+
+// witPeerInfo represents a short summary of the `wit` sub-protocol metadata known
+// about a connected peer.
+type witPeerInfo struct {
+	Version uint `json:"version"` // Witness protocol version negotiated
+}
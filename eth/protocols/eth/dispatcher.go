@@ -228,6 +228,7 @@ func (p *Peer) dispatcher() {
 				// Response arrived with an untracked ID. Since even cancelled
 				// requests are tracked until fulfillment, a dangling response
 				// means the remote peer implements the protocol badly.
+				p.Peer.Score(p2p.ScoreInvalid)
 				resOp.fail <- errDanglingResponse
 
 			case res.Req.want != res.code:
@@ -235,6 +236,7 @@ func (p *Peer) dispatcher() {
 				// one expected by the requester. Either the local code is bad,
 				// or the remote peer send junk. In neither cases can we handle
 				// the packet.
+				p.Peer.Score(p2p.ScoreInvalid)
 				resOp.fail <- fmt.Errorf("%w: have %d, want %d", errMismatchingResponseType, res.code, res.Req.want)
 
 			default:
@@ -242,6 +244,7 @@ func (p *Peer) dispatcher() {
 				// with the matching request. Signal to the delivery routine that
 				// it can wait for a handler response and dispatch the data.
 				res.Time = res.recv.Sub(res.Req.Sent)
+				p.Peer.ScoreResponse(res.Time)
 				resOp.fail <- nil
 
 				// Stop tracking the request, the response dispatcher will deliver
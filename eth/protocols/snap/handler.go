@@ -277,15 +277,24 @@ func ServiceGetAccountRangeQuery(chain *core.BlockChain, req *GetAccountRangePac
 	if req.Bytes > softResponseLimit {
 		req.Bytes = softResponseLimit
 	}
-	// Retrieve the requested state and bail out if non existent
+	// Retrieve the requested state and bail out if non existent. States which
+	// have left the layer tree are reconstructed from the state histories.
+	var historic database.NodeDatabase
 	tr, err := trie.New(trie.StateTrieID(req.Root), chain.TrieDB())
 	if err != nil {
-		return nil, nil
+		if historic = openHistoricState(chain, req.Root); historic == nil {
+			return nil, nil
+		}
+		if tr, err = trie.New(trie.StateTrieID(req.Root), historic); err != nil {
+			return nil, nil
+		}
 	}
 	// Temporary solution: using the snapshot interface for both cases.
 	// This can be removed once the hash scheme is deprecated.
 	var it snapshot.AccountIterator
-	if chain.TrieDB().Scheme() == rawdb.HashScheme {
+	if historic != nil {
+		it, err = newTrieAccountIterator(tr.Copy(), req.Origin)
+	} else if chain.TrieDB().Scheme() == rawdb.HashScheme {
 		// The snapshot is assumed to be available in hash mode if
 		// the SNAP protocol is enabled.
 		it, err = chain.Snapshots().AccountIterator(req.Root, req.Origin)
@@ -349,6 +358,17 @@ func ServiceGetStorageRangesQuery(chain *core.BlockChain, req *GetStorageRangesP
 	// Calculate the hard limit at which to abort, even if mid storage trie
 	hardLimit := uint64(float64(req.Bytes) * (1 + stateLookupSlack))
 
+	// States which have left the layer tree are reconstructed from the state
+	// histories, open them through the reconstructed trie nodes.
+	var nodedb database.NodeDatabase = chain.TrieDB()
+	var historic bool
+	if chain.TrieDB().Scheme() == rawdb.PathScheme {
+		if _, err := chain.TrieDB().StateReader(req.Root); err != nil {
+			if db := openHistoricState(chain, req.Root); db != nil {
+				nodedb, historic = db, true
+			}
+		}
+	}
 	// Retrieve storage ranges until the packet limit is reached
 	var (
 		slots  [][]*StorageData
@@ -377,7 +397,9 @@ func ServiceGetStorageRangesQuery(chain *core.BlockChain, req *GetStorageRangesP
 		)
 		// Temporary solution: using the snapshot interface for both cases.
 		// This can be removed once the hash scheme is deprecated.
-		if chain.TrieDB().Scheme() == rawdb.HashScheme {
+		if historic {
+			it, err = newTrieStorageIterator(nodedb, req.Root, account, origin)
+		} else if chain.TrieDB().Scheme() == rawdb.HashScheme {
 			// The snapshot is assumed to be available in hash mode if
 			// the SNAP protocol is enabled.
 			it, err = chain.Snapshots().StorageIterator(req.Root, account, origin)
@@ -425,7 +447,7 @@ func ServiceGetStorageRangesQuery(chain *core.BlockChain, req *GetStorageRangesP
 		if origin != (common.Hash{}) || (abort && len(storage) > 0) {
 			// Request started at a non-zero hash or was capped prematurely, add
 			// the endpoint Merkle proofs
-			accTrie, err := trie.NewStateTrie(trie.StateTrieID(req.Root), chain.TrieDB())
+			accTrie, err := trie.NewStateTrie(trie.StateTrieID(req.Root), nodedb)
 			if err != nil {
 				return nil, nil
 			}
@@ -434,7 +456,7 @@ func ServiceGetStorageRangesQuery(chain *core.BlockChain, req *GetStorageRangesP
 				return nil, nil
 			}
 			id := trie.StorageTrieID(req.Root, account, acc.Root)
-			stTrie, err := trie.NewStateTrie(id, chain.TrieDB())
+			stTrie, err := trie.NewStateTrie(id, nodedb)
 			if err != nil {
 				return nil, nil
 			}
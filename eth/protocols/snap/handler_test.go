@@ -0,0 +1,166 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"golang.org/x/time/rate"
+)
+
+// This test checks that account and storage ranges of states which have left the
+// pathdb layer tree are served from the state histories, with valid proofs.
+func TestServeHistoricRanges(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		gspec    = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				// SSTORE(NUMBER, NUMBER)
+				contract: {Code: []byte{byte(0x43), byte(0x43), byte(0x55), byte(0x00)}},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 160, func(i int, b *core.BlockGen) {
+		transfer, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(transfer)
+		call, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), contract, common.Big0, 50000, b.BaseFee(), nil), signer, key)
+		b.AddTx(call)
+	})
+	db, err := rawdb.Open(rawdb.NewMemoryDatabase(), rawdb.OpenOptions{Ancient: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	chain, err := core.NewBlockChain(db, gspec, ethash.NewFaker(), core.DefaultConfig().WithStateScheme(rawdb.PathScheme))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	root := blocks[9].Root()
+	if _, err := chain.TrieDB().StateReader(root); err == nil {
+		t.Fatal("state is still in the layer tree")
+	}
+
+	// Request a partial account range, starting after the first account.
+	origin := common.Hash{0x01}
+	accounts, proof := ServiceGetAccountRangeQuery(chain, &GetAccountRangePacket{
+		Root:   root,
+		Origin: origin,
+		Limit:  common.MaxHash,
+		Bytes:  softResponseLimit,
+	})
+	if len(accounts) == 0 {
+		t.Fatal("historic account range not served")
+	}
+	var (
+		keys        [][]byte
+		vals        [][]byte
+		storageRoot common.Hash
+		contractKey = crypto.Keccak256Hash(contract.Bytes())
+	)
+	for _, acc := range accounts {
+		full, err := types.FullAccountRLP(acc.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, common.CopyBytes(acc.Hash[:]))
+		vals = append(vals, full)
+		if acc.Hash == contractKey {
+			account, _ := types.FullAccount(acc.Body)
+			storageRoot = account.Root
+		}
+	}
+	if _, err := trie.VerifyRangeProof(root, origin[:], keys, vals, proofSet(proof)); err != nil {
+		t.Fatalf("invalid account range proof: %v", err)
+	}
+	if storageRoot == (common.Hash{}) {
+		t.Fatalf("contract %x not in account range", contractKey)
+	}
+
+	// Request the entire storage of the contract, which is served without proofs.
+	slots, proof := ServiceGetStorageRangesQuery(chain, &GetStorageRangesPacket{
+		Root:     root,
+		Accounts: []common.Hash{contractKey},
+		Bytes:    softResponseLimit,
+	})
+	if len(slots) != 1 || len(slots[0]) != 10 || len(proof) != 0 {
+		t.Fatalf("wrong storage response: %d accounts, %d proof nodes", len(slots), len(proof))
+	}
+	keys, vals = nil, nil
+	for _, slot := range slots[0] {
+		keys = append(keys, common.CopyBytes(slot.Hash[:]))
+		vals = append(vals, slot.Body)
+	}
+	if _, err := trie.VerifyRangeProof(storageRoot, nil, keys, vals, nil); err != nil {
+		t.Fatalf("invalid storage range: %v", err)
+	}
+
+	// Request a partial storage range, which is proven.
+	slots, proof = ServiceGetStorageRangesQuery(chain, &GetStorageRangesPacket{
+		Root:     root,
+		Accounts: []common.Hash{contractKey},
+		Origin:   keys[1],
+		Bytes:    softResponseLimit,
+	})
+	if len(slots) != 1 || len(slots[0]) != 9 || len(proof) == 0 {
+		t.Fatalf("wrong partial storage response: %d accounts, %d proof nodes", len(slots), len(proof))
+	}
+	if _, err := trie.VerifyRangeProof(storageRoot, keys[1], keys[1:], vals[1:], proofSet(proof)); err != nil {
+		t.Fatalf("invalid partial storage range proof: %v", err)
+	}
+
+	// Once the reconstructions are throttled, only the already reconstructed
+	// state is served.
+	defer func(limiter *rate.Limiter) { historicRebuilds = limiter }(historicRebuilds)
+	historicRebuilds = rate.NewLimiter(0, 0)
+
+	request := &GetAccountRangePacket{Limit: common.MaxHash, Bytes: softResponseLimit}
+	request.Root = root
+	if accounts, _ := ServiceGetAccountRangeQuery(chain, request); len(accounts) == 0 {
+		t.Fatal("reconstructed historic state not served")
+	}
+	request.Root = blocks[10].Root()
+	if accounts, _ := ServiceGetAccountRangeQuery(chain, request); len(accounts) != 0 {
+		t.Fatal("throttled historic state served")
+	}
+}
+
+func proofSet(proof [][]byte) *trienode.ProofSet {
+	nodes := make(trienode.ProofList, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	return nodes.Set()
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb/database"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// historicRebuildRate is the number of historical states reconstructed per
+	// second at most for serving remote peers. The reconstruction reverts up to
+	// thousands of state changes, so it's limited across all peers to prevent
+	// them from exhausting the local resources by requesting many old states.
+	historicRebuildRate = 1
+
+	// historicRebuildBurst is the number of historical states reconstructed at
+	// once, before the rate limit applies.
+	historicRebuildBurst = 4
+)
+
+// historicRebuilds limits the reconstructions of historical states across all
+// peers. Requests for states which are already reconstructed are not limited.
+var historicRebuilds = rate.NewLimiter(historicRebuildRate, historicRebuildBurst)
+
+// openHistoricState returns a node database for serving a state which has left
+// the pathdb layer tree, reconstructed from the retained state histories. Nil is
+// returned if the state is unavailable, or if too many states were reconstructed
+// recently.
+func openHistoricState(chain *core.BlockChain, root common.Hash) database.NodeDatabase {
+	if chain.TrieDB().Scheme() != rawdb.PathScheme {
+		return nil
+	}
+	if db := chain.TrieDB().CachedHistoricNodeDatabase(root); db != nil {
+		return db
+	}
+	if !historicRebuilds.Allow() {
+		log.Debug("Historic state reconstruction throttled", "root", root)
+		return nil
+	}
+	db, err := chain.TrieDB().HistoricNodeDatabase(root)
+	if err != nil {
+		log.Debug("Historic state unavailable for serving", "root", root, "err", err)
+		return nil
+	}
+	return db
+}
+
+// trieAccountIterator is an account iterator stepping over the leaves of an
+// account trie, converting the accounts into the slim format.
+type trieAccountIterator struct {
+	it      *trie.Iterator
+	account []byte
+	err     error
+}
+
+// newTrieAccountIterator creates an account iterator over the given trie,
+// starting at the seek position.
+func newTrieAccountIterator(tr *trie.Trie, seek common.Hash) (*trieAccountIterator, error) {
+	nodeIt, err := tr.NodeIterator(seek[:])
+	if err != nil {
+		return nil, err
+	}
+	return &trieAccountIterator{it: trie.NewIterator(nodeIt)}, nil
+}
+
+func (it *trieAccountIterator) Next() bool {
+	if it.err != nil || !it.it.Next() {
+		return false
+	}
+	var account types.StateAccount
+	if err := rlp.DecodeBytes(it.it.Value, &account); err != nil {
+		it.err = err
+		return false
+	}
+	it.account = types.SlimAccountRLP(account)
+	return true
+}
+
+func (it *trieAccountIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.it.Err
+}
+
+func (it *trieAccountIterator) Hash() common.Hash { return common.BytesToHash(it.it.Key) }
+func (it *trieAccountIterator) Account() []byte   { return it.account }
+func (it *trieAccountIterator) Release()          {}
+
+// trieStorageIterator is a storage iterator stepping over the leaves of a
+// storage trie.
+type trieStorageIterator struct {
+	it *trie.Iterator
+}
+
+// newTrieStorageIterator creates an iterator over the storage trie of the given
+// account, starting at the seek position.
+func newTrieStorageIterator(db database.NodeDatabase, root common.Hash, account common.Hash, seek common.Hash) (*trieStorageIterator, error) {
+	accTrie, err := trie.NewStateTrie(trie.StateTrieID(root), db)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := accTrie.GetAccountByHash(account)
+	if err != nil {
+		return nil, err
+	}
+	storageRoot := types.EmptyRootHash
+	if acc != nil {
+		storageRoot = acc.Root
+	}
+	stTrie, err := trie.New(trie.StorageTrieID(root, account, storageRoot), db)
+	if err != nil {
+		return nil, err
+	}
+	nodeIt, err := stTrie.NodeIterator(seek[:])
+	if err != nil {
+		return nil, err
+	}
+	return &trieStorageIterator{it: trie.NewIterator(nodeIt)}, nil
+}
+
+func (it *trieStorageIterator) Next() bool        { return it.it.Next() }
+func (it *trieStorageIterator) Error() error      { return it.it.Err }
+func (it *trieStorageIterator) Hash() common.Hash { return common.BytesToHash(it.it.Key) }
+func (it *trieStorageIterator) Slot() []byte      { return it.it.Value }
+func (it *trieStorageIterator) Release()          {}
@@ -0,0 +1,71 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package stem implements the `stem` protocol, relaying transactions privately
+// along a single-peer path before they are diffused via the `eth` protocol, in
+// the style of Dandelion++.
+package stem
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	STEM1 = 1
+)
+
+// ProtocolName is the official short name of the `stem` protocol used during
+// devp2p capability negotiation.
+const ProtocolName = "stem"
+
+// ProtocolVersions are the supported versions of the `stem` protocol (first
+// is primary).
+var ProtocolVersions = []uint{STEM1}
+
+// protocolLengths are the number of implemented message corresponding to
+// different protocol versions.
+var protocolLengths = map[uint]uint64{STEM1: 1}
+
+// maxMessageSize is the maximum cap on the size of a protocol message.
+const maxMessageSize = 1024 * 1024
+
+const (
+	TransactionsMsg = 0x00
+)
+
+var (
+	errMsgTooLarge    = errors.New("message too long")
+	errDecode         = errors.New("invalid message")
+	errInvalidMsgCode = errors.New("invalid message code")
+	errBlobTx         = errors.New("blob transaction in stem phase")
+)
+
+// Packet represents a p2p message in the `stem` protocol.
+type Packet interface {
+	Name() string // Name returns a string corresponding to the message type.
+	Kind() byte   // Kind returns the message type.
+}
+
+// TransactionsPacket is the network packet for relaying transactions in their
+// stem phase. Blob transactions are never relayed along the stem, as they are
+// only announced by the `eth` protocol.
+type TransactionsPacket []*types.Transaction
+
+func (*TransactionsPacket) Name() string { return "Transactions" }
+func (*TransactionsPacket) Kind() byte   { return TransactionsMsg }
@@ -0,0 +1,246 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// requestTimeout is the maximum time to wait for a peer to deliver a witness.
+const requestTimeout = 10 * time.Second
+
+var (
+	errNoPeers         = errors.New("no peers to fetch the witness from")
+	errUnavailable     = errors.New("witness unavailable from all peers")
+	errUnknownPeer     = errors.New("unknown peer")
+	errAlreadyRunning  = errors.New("peer already registered")
+	errUnrequested     = errors.New("unrequested witness delivery")
+	errResponseTooLong = errors.New("witness response with more items than requested")
+	errTimeout         = errors.New("witness request timed out")
+)
+
+// witnessRequest tracks an in-flight witness request.
+type witnessRequest struct {
+	peer    string
+	deliver chan []byte
+	fail    chan error
+}
+
+// Fetcher retrieves the execution witnesses of blocks from remote peers, trying
+// the connected peers one by one until one delivers a valid witness.
+type Fetcher struct {
+	peers   map[string]*Peer
+	pending map[uint64]*witnessRequest
+	lock    sync.Mutex
+}
+
+// NewFetcher creates a witness fetcher without any peers.
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		peers:   make(map[string]*Peer),
+		pending: make(map[uint64]*witnessRequest),
+	}
+}
+
+// Register injects a new peer into the set of witness sources.
+func (f *Fetcher) Register(peer *Peer) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.peers[peer.id]; ok {
+		return errAlreadyRunning
+	}
+	f.peers[peer.id] = peer
+	return nil
+}
+
+// Unregister removes a peer from the set of witness sources, failing all of its
+// in-flight requests.
+func (f *Fetcher) Unregister(id string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.peers[id]; !ok {
+		return errUnknownPeer
+	}
+	delete(f.peers, id)
+	for reqid, req := range f.pending {
+		if req.peer == id {
+			delete(f.pending, reqid)
+			req.fail <- errUnknownPeer
+		}
+	}
+	return nil
+}
+
+// Peer retrieves the registered peer with the given id.
+func (f *Fetcher) Peer(id string) *Peer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.peers[id]
+}
+
+// Deliver injects a witness response received from a remote peer. An error is
+// returned if the response was not requested from the peer.
+func (f *Fetcher) Deliver(peer *Peer, packet *WitnessPacket) error {
+	f.lock.Lock()
+	req, ok := f.pending[packet.ID]
+	if !ok || req.peer != peer.id {
+		f.lock.Unlock()
+		return errUnrequested
+	}
+	delete(f.pending, packet.ID)
+	f.lock.Unlock()
+
+	switch len(packet.Witnesses) {
+	case 0:
+		req.deliver <- nil
+	case 1:
+		req.deliver <- packet.Witnesses[0]
+	default:
+		req.fail <- errResponseTooLong
+		return errResponseTooLong
+	}
+	return nil
+}
+
+// Fetch retrieves the execution witness of the given block from the network.
+// The witness is checked to be built on top of the block's parent, but it is
+// not executed.
+func (f *Fetcher) Fetch(ctx context.Context, header *types.Header) (*stateless.Witness, error) {
+	hash := header.Hash()
+	tried := make(map[string]struct{})
+	for {
+		peer := f.pickPeer(tried)
+		if peer == nil {
+			if len(tried) == 0 {
+				return nil, errNoPeers
+			}
+			return nil, errUnavailable
+		}
+		tried[peer.id] = struct{}{}
+
+		blob, err := f.request(ctx, peer, hash)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			peer.Log().Debug("Witness request failed", "hash", hash, "err", err)
+			continue
+		}
+		if len(blob) == 0 {
+			continue // peer doesn't have the witness
+		}
+		witness := new(stateless.Witness)
+		if err := rlp.DecodeBytes(blob, witness); err != nil {
+			peer.Log().Debug("Peer delivered invalid witness", "hash", hash, "err", err)
+			continue
+		}
+		if err := verifyWitness(header, witness); err != nil {
+			peer.Log().Debug("Peer delivered mismatching witness", "hash", hash, "err", err)
+			continue
+		}
+		return witness, nil
+	}
+}
+
+// pickPeer selects a random peer which wasn't tried yet.
+func (f *Fetcher) pickPeer(tried map[string]struct{}) *Peer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var candidates []*Peer
+	for id, peer := range f.peers {
+		if _, ok := tried[id]; !ok {
+			candidates = append(candidates, peer)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// request sends a witness query to a peer and waits for the response.
+func (f *Fetcher) request(ctx context.Context, peer *Peer, hash common.Hash) ([]byte, error) {
+	req := &witnessRequest{
+		peer:    peer.id,
+		deliver: make(chan []byte, 1),
+		fail:    make(chan error, 1),
+	}
+	f.lock.Lock()
+	var id uint64
+	for {
+		id = rand.Uint64()
+		if _, ok := f.pending[id]; !ok {
+			break
+		}
+	}
+	f.pending[id] = req
+	f.lock.Unlock()
+
+	defer func() {
+		f.lock.Lock()
+		delete(f.pending, id)
+		f.lock.Unlock()
+	}()
+	if err := peer.RequestWitnesses(id, []common.Hash{hash}); err != nil {
+		return nil, err
+	}
+	timeout := time.NewTimer(requestTimeout)
+	defer timeout.Stop()
+
+	select {
+	case blob := <-req.deliver:
+		return blob, nil
+	case err := <-req.fail:
+		return nil, err
+	case <-timeout.C:
+		return nil, errTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// verifyWitness checks that the witness belongs to the parent of the given block.
+func verifyWitness(header *types.Header, witness *stateless.Witness) error {
+	if len(witness.Headers) == 0 {
+		return errors.New("witness without parent header")
+	}
+	parent := witness.Headers[0]
+	if parent.Hash() != header.ParentHash {
+		return fmt.Errorf("witness parent %x, want %x", parent.Hash(), header.ParentHash)
+	}
+	// The ancestor headers must form a chain.
+	for i := 1; i < len(witness.Headers); i++ {
+		if witness.Headers[i-1].ParentHash != witness.Headers[i].Hash() {
+			return fmt.Errorf("witness header %d is not the parent of header %d", i, i-1)
+		}
+	}
+	return nil
+}
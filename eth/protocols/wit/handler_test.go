@@ -0,0 +1,151 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr   = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// testBackend is a mock implementation of the witness protocol backend, serving
+// from a local chain and delivering responses into a fetcher.
+type testBackend struct {
+	chain   *core.BlockChain
+	fetcher *Fetcher
+}
+
+func (b *testBackend) Chain() *core.BlockChain                   { return b.chain }
+func (b *testBackend) RunPeer(peer *Peer, handler Handler) error { return nil }
+func (b *testBackend) PeerInfo(id enode.ID) interface{}          { return nil }
+
+func (b *testBackend) Handle(peer *Peer, packet Packet) error {
+	return b.fetcher.Deliver(peer, packet.(*WitnessPacket))
+}
+
+// newTestChain creates a chain with the witness cache enabled, importing the
+// blocks one by one so that all of their witnesses are retained.
+func newTestChain(t *testing.T, n int) (*core.BlockChain, []*types.Block) {
+	t.Helper()
+
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{testAddr: {Balance: big.NewInt(params.Ether)}},
+	}
+	signer := types.LatestSigner(gspec.Config)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), n, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(testAddr), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, testKey)
+		b.AddTx(tx)
+	})
+	config := core.DefaultConfig()
+	config.WitnessCacheLimit = 16
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), gspec, ethash.NewFaker(), config)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	for _, block := range blocks {
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("failed to import block %d: %v", block.NumberU64(), err)
+		}
+	}
+	return chain, blocks
+}
+
+// Tests that witness queries are served from the recent witness cache, with
+// empty entries for the unknown blocks.
+func TestServiceGetWitnessQuery(t *testing.T) {
+	chain, blocks := newTestChain(t, 4)
+
+	hashes := []common.Hash{blocks[0].Hash(), {0xde, 0xad}, blocks[3].Hash()}
+	res := ServiceGetWitnessQuery(chain, &GetWitnessPacket{ID: 1, Hashes: hashes})
+	if len(res) != len(hashes) {
+		t.Fatalf("response length mismatch: have %d, want %d", len(res), len(hashes))
+	}
+	if len(res[0]) == 0 || len(res[2]) == 0 {
+		t.Fatalf("missing witness for known block")
+	}
+	if len(res[1]) != 0 {
+		t.Fatalf("witness served for unknown block")
+	}
+	// Oversized queries must be capped
+	hashes = make([]common.Hash, 2*maxWitnessLookups)
+	if res := ServiceGetWitnessQuery(chain, &GetWitnessPacket{Hashes: hashes}); len(res) != maxWitnessLookups {
+		t.Fatalf("oversized query not capped: have %d, want %d", len(res), maxWitnessLookups)
+	}
+}
+
+// Tests that the fetcher retrieves a witness over the network, skipping peers
+// which don't have it.
+func TestFetcher(t *testing.T) {
+	chain, blocks := newTestChain(t, 4)
+
+	// Create an empty and a full server, both connected to the same client
+	empty, _ := newTestChain(t, 0)
+	fetcher := NewFetcher()
+	client := &testBackend{fetcher: fetcher}
+
+	for i, server := range []*core.BlockChain{empty, chain} {
+		app, net := p2p.MsgPipe()
+		t.Cleanup(func() { app.Close(); net.Close() })
+
+		id := string(rune('a'+i)) + "0000000"
+		local := NewFakePeer(WIT1, id, app)
+		remote := NewFakePeer(WIT1, id, net)
+
+		go Handle(client, local)
+		go Handle(&testBackend{chain: server}, remote)
+
+		if err := fetcher.Register(local); err != nil {
+			t.Fatalf("failed to register peer: %v", err)
+		}
+	}
+	witness, err := fetcher.Fetch(context.Background(), blocks[2].Header())
+	if err != nil {
+		t.Fatalf("failed to fetch witness: %v", err)
+	}
+	if witness.Headers[0].Hash() != blocks[1].Hash() {
+		t.Fatalf("witness parent mismatch: have %x, want %x", witness.Headers[0].Hash(), blocks[1].Hash())
+	}
+	// Witnesses of unknown blocks must fail once all peers are exhausted
+	header := types.CopyHeader(blocks[2].Header())
+	header.Extra = []byte("unknown")
+	if _, err := fetcher.Fetch(context.Background(), header); err != errUnavailable {
+		t.Fatalf("unknown witness fetch error mismatch: have %v, want %v", err, errUnavailable)
+	}
+	// Unrequested deliveries must be rejected
+	if err := fetcher.Deliver(fetcher.Peer("a0000000"), &WitnessPacket{ID: 1}); err != errUnrequested {
+		t.Fatalf("unrequested delivery error mismatch: have %v, want %v", err, errUnrequested)
+	}
+}
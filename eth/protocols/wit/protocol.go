@@ -0,0 +1,79 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	WIT1 = 1
+)
+
+// ProtocolName is the official short name of the `wit` protocol used during
+// devp2p capability negotiation.
+const ProtocolName = "wit"
+
+// ProtocolVersions are the supported versions of the `wit` protocol (first
+// is primary).
+var ProtocolVersions = []uint{WIT1}
+
+// protocolLengths are the number of implemented message corresponding to
+// different protocol versions.
+var protocolLengths = map[uint]uint64{WIT1: 2}
+
+// maxMessageSize is the maximum cap on the size of a protocol message. It is the
+// largest message RLPx is able to transfer, witnesses are big.
+const maxMessageSize = 16*1024*1024 - 1
+
+const (
+	GetWitnessMsg = 0x00
+	WitnessMsg    = 0x01
+)
+
+var (
+	errMsgTooLarge    = errors.New("message too long")
+	errDecode         = errors.New("invalid message")
+	errInvalidMsgCode = errors.New("invalid message code")
+)
+
+// Packet represents a p2p message in the `wit` protocol.
+type Packet interface {
+	Name() string // Name returns a string corresponding to the message type.
+	Kind() byte   // Kind returns the message type.
+}
+
+// GetWitnessPacket represents a block witness query.
+type GetWitnessPacket struct {
+	ID     uint64        // Request ID to match up responses with
+	Hashes []common.Hash // Block hashes to retrieve the witnesses for
+}
+
+// WitnessPacket represents a block witness query response.
+type WitnessPacket struct {
+	ID        uint64   // ID of the request this is a response for
+	Witnesses [][]byte // RLP encoded witnesses in request order, empty if unavailable
+}
+
+func (*GetWitnessPacket) Name() string { return "GetWitness" }
+func (*GetWitnessPacket) Kind() byte   { return GetWitnessMsg }
+
+func (*WitnessPacket) Name() string { return "Witness" }
+func (*WitnessPacket) Kind() byte   { return WitnessMsg }
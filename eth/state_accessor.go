@@ -182,13 +182,26 @@ func (eth *Ethereum) pathState(block *types.Block) (*state.StateDB, func(), erro
 	if err == nil {
 		return statedb, noopReleaser, nil
 	}
-	statedb, err = eth.blockchain.HistoricState(block.Root())
+	statedb, err = eth.historicState(block.Header())
 	if err == nil {
 		return statedb, noopReleaser, nil
 	}
 	return nil, nil, errors.New("historical state is not available")
 }
 
+// historicState retrieves the historical state associated with the given header
+// from the indexed state histories of the path database. The request is rejected
+// if the block is beyond the configured serving depth.
+func (eth *Ethereum) historicState(header *types.Header) (*state.StateDB, error) {
+	if limit := eth.config.HistoricStateLimit; limit != 0 {
+		head := eth.blockchain.CurrentBlock().Number.Uint64()
+		if number := header.Number.Uint64(); number+limit < head {
+			return nil, fmt.Errorf("historical state %d is beyond the serving limit, head: %d, limit: %d", number, head, limit)
+		}
+	}
+	return eth.blockchain.HistoricState(header.Root)
+}
+
 // stateAtBlock retrieves the state database associated with a certain block.
 // If no state is locally available for the given block, a number of blocks
 // are attempted to be reexecuted to generate the desired state. The optional
@@ -0,0 +1,348 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/stem"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// stemEpoch is the time after which a new stem relay is selected. Keeping
+	// the relay fixed for a while prevents an adversary from learning about the
+	// origin of the transactions by observing multiple stem paths.
+	stemEpoch = 10 * time.Minute
+
+	// stemFluffProbability is the chance of ending the stem phase at each hop
+	// and diffusing the transactions instead of relaying them further.
+	stemFluffProbability = 0.25
+
+	// Transactions are withheld from the regular broadcasts while in the stem
+	// phase, at most for the embargo time (uniform between min and max). If no
+	// peer announces them in the meantime, they are diffused by the local node
+	// in case the stem path was broken.
+	stemEmbargoMin = 30 * time.Second
+	stemEmbargoMax = 60 * time.Second
+
+	// stemCheckInterval is the interval for checking expired embargoes.
+	stemCheckInterval = time.Second
+)
+
+var (
+	stemRelayMeter  = metrics.NewRegisteredMeter("eth/stem/relay", nil)  // Transactions relayed along the stem
+	stemFluffMeter  = metrics.NewRegisteredMeter("eth/stem/fluff", nil)  // Transactions diffused at the end of the stem
+	stemExpireMeter = metrics.NewRegisteredMeter("eth/stem/expire", nil) // Transactions diffused after the embargo expired
+)
+
+// stemRelay implements the stem phase of the Dandelion++ transaction relaying.
+// Locally submitted transactions are first relayed along a random single-peer
+// path of `stem` peers for a few hops, and each hop decides randomly whether to
+// relay further or to diffuse them via the regular `eth` broadcasts. While in
+// the stem phase, transactions are withheld from the broadcasts by every hop
+// until their embargo expires.
+type stemRelay struct {
+	txpool    txPool
+	broadcast func(types.Transactions) // Diffuses the transactions via `eth`
+	fluffProb float64                  // Chance of diffusing at each hop, configurable for tests
+	now       func() time.Time
+
+	lock    sync.Mutex
+	peers   map[string]*stem.Peer     // Connected `stem` peers
+	relay   *stem.Peer                // Relay selected for the current epoch
+	epoch   time.Time                 // Expiration time of the current relay selection
+	embargo map[common.Hash]time.Time // Transactions in stem phase and their embargo expiration
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newStemRelay(txpool txPool, broadcast func(types.Transactions)) *stemRelay {
+	return &stemRelay{
+		txpool:    txpool,
+		broadcast: broadcast,
+		fluffProb: stemFluffProbability,
+		now:       time.Now,
+		peers:     make(map[string]*stem.Peer),
+		embargo:   make(map[common.Hash]time.Time),
+		quit:      make(chan struct{}),
+	}
+}
+
+// start launches the embargo expiration loop.
+func (s *stemRelay) start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// stop terminates the embargo expiration loop.
+func (s *stemRelay) stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+func (s *stemRelay) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(stemCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.expire()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// registerPeer adds a `stem` peer as a potential relay.
+func (s *stemRelay) registerPeer(peer *stem.Peer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.peers[peer.ID()] = peer
+}
+
+// unregisterPeer removes a `stem` peer, selecting a new relay next time if it
+// was the current one.
+func (s *stemRelay) unregisterPeer(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.peers, id)
+	if s.relay != nil && s.relay.ID() == id {
+		s.relay = nil
+	}
+}
+
+// peer retrieves the registered `stem` peer with the given id.
+func (s *stemRelay) peer(id string) *stem.Peer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.peers[id]
+}
+
+// selectRelay returns the relay of the current epoch, selecting a new one if
+// the epoch is over. Outbound peers are preferred, as inbound connections are
+// cheap for an adversary to create. The peer the transactions were received
+// from is never selected, in which case a random other peer is returned without
+// replacing the relay of the epoch.
+func (s *stemRelay) selectRelay(exclude string) *stem.Peer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	pick := func() *stem.Peer {
+		var inbound, outbound []*stem.Peer
+		for id, peer := range s.peers {
+			if id == exclude {
+				continue
+			}
+			if peer.Inbound() {
+				inbound = append(inbound, peer)
+			} else {
+				outbound = append(outbound, peer)
+			}
+		}
+		if len(outbound) > 0 {
+			return outbound[mrand.Intn(len(outbound))]
+		}
+		if len(inbound) > 0 {
+			return inbound[mrand.Intn(len(inbound))]
+		}
+		return nil
+	}
+	now := s.now()
+	if s.relay == nil || now.After(s.epoch) {
+		s.relay, s.epoch = pick(), now.Add(stemEpoch)
+	}
+	if s.relay != nil && s.relay.ID() == exclude {
+		return pick()
+	}
+	return s.relay
+}
+
+// hold withholds the given transactions from the regular broadcasts until their
+// embargo expires.
+func (s *stemRelay) hold(txs types.Transactions) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	for _, tx := range txs {
+		jitter := time.Duration(mrand.Int63n(int64(stemEmbargoMax - stemEmbargoMin)))
+		s.embargo[tx.Hash()] = now.Add(stemEmbargoMin + jitter)
+	}
+}
+
+// release lifts the embargo of the given transactions.
+func (s *stemRelay) release(hashes []common.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, hash := range hashes {
+		delete(s.embargo, hash)
+	}
+}
+
+// embargoed reports whether the transaction is in the stem phase and should be
+// withheld from the broadcasts.
+func (s *stemRelay) embargoed(hash common.Hash) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, ok := s.embargo[hash]
+	return ok
+}
+
+// seen is invoked when transactions are announced or delivered by `eth` peers,
+// meaning they are diffused by someone already and the embargo can be lifted.
+func (s *stemRelay) seen(hashes []common.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.embargo) == 0 {
+		return
+	}
+	for _, hash := range hashes {
+		delete(s.embargo, hash)
+	}
+}
+
+// add inserts the transactions into the pool while withholding them from the
+// broadcasts, returning the accepted ones along with the errors of the pool.
+func (s *stemRelay) add(txs types.Transactions) (types.Transactions, []error) {
+	s.hold(txs)
+
+	var (
+		errs     = s.txpool.Add(txs, false)
+		accepted types.Transactions
+		rejected []common.Hash
+	)
+	for i, err := range errs {
+		if err != nil {
+			rejected = append(rejected, txs[i].Hash())
+		} else {
+			accepted = append(accepted, txs[i])
+		}
+	}
+	s.release(rejected)
+	return accepted, errs
+}
+
+// addLocal inserts a locally submitted transaction into the pool and starts its
+// stem phase. Blob transactions are not relayed privately, as they are only
+// announced to peers anyway.
+func (s *stemRelay) addLocal(tx *types.Transaction) error {
+	if tx.Type() == types.BlobTxType {
+		return s.txpool.Add([]*types.Transaction{tx}, false)[0]
+	}
+	accepted, errs := s.add(types.Transactions{tx})
+	if len(accepted) > 0 {
+		s.forward(accepted, "")
+	}
+	return errs[0]
+}
+
+// handleTransactions processes the transactions relayed by a `stem` peer, either
+// relaying them further along the stem or diffusing them.
+func (s *stemRelay) handleTransactions(peer *stem.Peer, txs types.Transactions) {
+	// Skip the transactions already known, they are either diffused already
+	// or loop along the stem.
+	var fresh types.Transactions
+	for _, tx := range txs {
+		if !s.txpool.Has(tx.Hash()) {
+			fresh = append(fresh, tx)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	accepted, _ := s.add(fresh)
+	if len(accepted) == 0 {
+		return
+	}
+	if mrand.Float64() < s.fluffProb {
+		s.fluff(accepted)
+		return
+	}
+	s.forward(accepted, peer.ID())
+}
+
+// forward relays the transactions to the stem relay, or diffuses them if no
+// relay is available.
+func (s *stemRelay) forward(txs types.Transactions, from string) {
+	relay := s.selectRelay(from)
+	if relay == nil {
+		s.fluff(txs)
+		return
+	}
+	if err := relay.SendTransactions(txs); err != nil {
+		relay.Log().Debug("Failed to relay stem transactions", "err", err)
+		s.fluff(txs)
+		return
+	}
+	stemRelayMeter.Mark(int64(len(txs)))
+}
+
+// fluff ends the stem phase of the transactions and diffuses them.
+func (s *stemRelay) fluff(txs types.Transactions) {
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	s.release(hashes)
+	stemFluffMeter.Mark(int64(len(txs)))
+	s.broadcast(txs)
+}
+
+// expire diffuses the transactions whose embargo expired without them being
+// diffused by anyone else, which means the stem path was broken.
+func (s *stemRelay) expire() {
+	s.lock.Lock()
+	var (
+		now     = s.now()
+		expired []common.Hash
+	)
+	for hash, deadline := range s.embargo {
+		if now.After(deadline) {
+			expired = append(expired, hash)
+			delete(s.embargo, hash)
+		}
+	}
+	s.lock.Unlock()
+
+	var txs types.Transactions
+	for _, hash := range expired {
+		if tx := s.txpool.Get(hash); tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	if len(txs) > 0 {
+		log.Debug("Stem transaction embargo expired", "count", len(txs))
+		stemExpireMeter.Mark(int64(len(txs)))
+		s.broadcast(txs)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/stem"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// testStemRelay is a stem relay on top of a mock transaction pool, collecting
+// the transactions broadcast via `eth`.
+type testStemRelay struct {
+	*stemRelay
+	pool *testTxPool
+
+	lock      sync.Mutex
+	broadcast []common.Hash
+}
+
+func newTestStemRelay(fluffProb float64) *testStemRelay {
+	r := &testStemRelay{pool: newTestTxPool()}
+	r.stemRelay = newStemRelay(r.pool, func(txs types.Transactions) {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		for _, tx := range txs {
+			r.broadcast = append(r.broadcast, tx.Hash())
+		}
+	})
+	r.fluffProb = fluffProb
+	return r
+}
+
+func (r *testStemRelay) broadcasts() []common.Hash {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]common.Hash{}, r.broadcast...)
+}
+
+// newTestStemPeer creates a `stem` peer along with the remote end of its pipe.
+func newTestStemPeer(t *testing.T, id byte) (*stem.Peer, *p2p.MsgPipeRW) {
+	local, remote := p2p.MsgPipe()
+	t.Cleanup(func() {
+		local.Close()
+		remote.Close()
+	})
+	return stem.NewPeer(stem.STEM1, p2p.NewPeer(enode.ID{id}, "", nil), local), remote
+}
+
+func newTestStemTx(nonce uint64) *types.Transaction {
+	tx := types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 100000, big.NewInt(0), nil)
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, testKey)
+	return tx
+}
+
+// expectStemTxs waits for the stem transactions relayed over the pipe.
+func expectStemTxs(t *testing.T, remote *p2p.MsgPipeRW, want ...*types.Transaction) {
+	t.Helper()
+
+	msg, err := remote.ReadMsg()
+	if err != nil {
+		t.Fatalf("failed to read stem message: %v", err)
+	}
+	var txs stem.TransactionsPacket
+	if err := msg.Decode(&txs); err != nil {
+		t.Fatalf("failed to decode stem message: %v", err)
+	}
+	if len(txs) != len(want) {
+		t.Fatalf("relayed transaction count mismatch: have %d, want %d", len(txs), len(want))
+	}
+	for i, tx := range txs {
+		if tx.Hash() != want[i].Hash() {
+			t.Fatalf("relayed transaction %d mismatch: have %x, want %x", i, tx.Hash(), want[i].Hash())
+		}
+	}
+}
+
+// Tests that local transactions are relayed along the stem and withheld from the
+// broadcasts until they are seen from the network.
+func TestStemRelayLocal(t *testing.T) {
+	r := newTestStemRelay(0)
+	peer, remote := newTestStemPeer(t, 1)
+	r.registerPeer(peer)
+
+	tx := newTestStemTx(0)
+	errc := make(chan error, 1)
+	go func() { errc <- r.addLocal(tx) }()
+
+	expectStemTxs(t, remote, tx)
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if !r.pool.Has(tx.Hash()) {
+		t.Fatal("local transaction is not added into the pool")
+	}
+	if !r.embargoed(tx.Hash()) {
+		t.Fatal("local transaction is not withheld from broadcasts")
+	}
+	if len(r.broadcasts()) != 0 {
+		t.Fatal("local transaction is broadcast in stem phase")
+	}
+	// Once announced by someone else, the embargo should be lifted
+	r.seen([]common.Hash{tx.Hash()})
+	if r.embargoed(tx.Hash()) {
+		t.Fatal("transaction is still withheld after being seen")
+	}
+}
+
+// Tests that transactions are diffused right away if there are no stem peers,
+// or if the only stem peer is the one they were received from.
+func TestStemRelayNoPeers(t *testing.T) {
+	r := newTestStemRelay(0)
+
+	tx := newTestStemTx(0)
+	if err := r.addLocal(tx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if have := r.broadcasts(); len(have) != 1 || have[0] != tx.Hash() {
+		t.Fatalf("transaction is not broadcast without stem peers: %v", have)
+	}
+	if r.embargoed(tx.Hash()) {
+		t.Fatal("diffused transaction is still withheld")
+	}
+	// Relaying back to the originating peer is not permitted
+	peer, _ := newTestStemPeer(t, 1)
+	r.registerPeer(peer)
+
+	tx = newTestStemTx(1)
+	r.handleTransactions(peer, types.Transactions{tx})
+	if have := r.broadcasts(); len(have) != 2 || have[1] != tx.Hash() {
+		t.Fatalf("transaction is not broadcast without other stem peers: %v", have)
+	}
+}
+
+// Tests that the stem transactions of remote peers are either relayed further
+// or diffused, and known transactions are ignored.
+func TestStemRelayRemote(t *testing.T) {
+	var (
+		relaying = newTestStemRelay(0)
+		fluffing = newTestStemRelay(1)
+		from, _  = newTestStemPeer(t, 1)
+		to, pipe = newTestStemPeer(t, 2)
+		tx       = newTestStemTx(0)
+	)
+	relaying.registerPeer(from)
+	relaying.registerPeer(to)
+
+	done := make(chan struct{})
+	go func() {
+		relaying.handleTransactions(from, types.Transactions{tx})
+		close(done)
+	}()
+	expectStemTxs(t, pipe, tx)
+	<-done
+	if !relaying.embargoed(tx.Hash()) || len(relaying.broadcasts()) != 0 {
+		t.Fatal("relayed transaction is not withheld from broadcasts")
+	}
+	// The same transaction should not be relayed again
+	relaying.handleTransactions(from, types.Transactions{tx})
+
+	fluffing.handleTransactions(from, types.Transactions{tx})
+	if have := fluffing.broadcasts(); len(have) != 1 || have[0] != tx.Hash() {
+		t.Fatalf("transaction is not diffused at the end of the stem: %v", have)
+	}
+	if fluffing.embargoed(tx.Hash()) {
+		t.Fatal("diffused transaction is still withheld")
+	}
+}
+
+// Tests that the transactions are diffused if their embargo expires, e.g. if
+// the stem path is broken.
+func TestStemRelayEmbargoExpiry(t *testing.T) {
+	r := newTestStemRelay(0)
+	peer, pipe := newTestStemPeer(t, 1)
+	r.registerPeer(peer)
+
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	tx := newTestStemTx(0)
+	go r.addLocal(tx)
+	expectStemTxs(t, pipe, tx)
+
+	r.expire()
+	if len(r.broadcasts()) != 0 {
+		t.Fatal("transaction is diffused before the embargo expired")
+	}
+	now = now.Add(stemEmbargoMax + time.Second)
+	r.expire()
+	if have := r.broadcasts(); len(have) != 1 || have[0] != tx.Hash() {
+		t.Fatalf("transaction is not diffused after the embargo expired: %v", have)
+	}
+	if r.embargoed(tx.Hash()) {
+		t.Fatal("expired transaction is still withheld")
+	}
+}
+
+// Tests that the stem relay is kept for an epoch, and reselected if it's gone.
+func TestStemRelaySelection(t *testing.T) {
+	r := newTestStemRelay(0)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	for i := 1; i <= 8; i++ {
+		peer, _ := newTestStemPeer(t, byte(i))
+		r.registerPeer(peer)
+	}
+	relay := r.selectRelay("")
+	for i := 0; i < 16; i++ {
+		if r.selectRelay("") != relay {
+			t.Fatal("stem relay changed within the epoch")
+		}
+	}
+	if other := r.selectRelay(relay.ID()); other == nil || other == relay {
+		t.Fatalf("excluded stem relay selected: %v", other)
+	}
+	if r.selectRelay("") != relay {
+		t.Fatal("stem relay replaced by excluding it")
+	}
+	r.unregisterPeer(relay.ID())
+	if next := r.selectRelay(""); next == nil || next == relay {
+		t.Fatalf("disconnected stem relay selected: %v", next)
+	}
+}
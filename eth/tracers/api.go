@@ -1038,6 +1038,7 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 	// Define a meaningful timeout of a single transaction trace
 	if config.Timeout != nil {
 		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			tracer.Stop(err)
 			return nil, err
 		}
 	}
@@ -1056,6 +1057,9 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 	statedb.SetTxContext(txctx.TxHash, txctx.TxIndex)
 	_, err = core.ApplyTransactionWithEVM(message, new(core.GasPool).AddGas(message.GasLimit), statedb, vmctx.BlockNumber, txctx.BlockHash, vmctx.Time, tx, &usedGas, evm)
 	if err != nil {
+		// Stop the tracer to release its resources, the result is never
+		// retrieved.
+		tracer.Stop(err)
 		return nil, fmt.Errorf("tracing failed: %w", err)
 	}
 	return tracer.GetResult()
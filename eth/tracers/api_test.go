@@ -280,6 +280,53 @@ func TestStateHooks(t *testing.T) {
 	}
 }
 
+func TestTraceCallStopOnError(t *testing.T) {
+	t.Parallel()
+
+	var (
+		accounts = newAccounts(1)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		backend = newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+		stopped atomic.Int32
+	)
+	defer backend.teardown()
+
+	// The tracer must be stopped to release its resources if the trace fails
+	// before retrieving the result.
+	DefaultDirectory.Register("stopTracer", func(ctx *Context, cfg json.RawMessage, chainCfg *params.ChainConfig) (*Tracer, error) {
+		return &Tracer{
+			Hooks:     &tracing.Hooks{},
+			GetResult: func() (json.RawMessage, error) { return json.RawMessage(`{}`), nil },
+			Stop:      func(err error) { stopped.Add(1) },
+		}, nil
+	}, false)
+	var (
+		api     = NewAPI(backend)
+		tracer  = "stopTracer"
+		timeout = "invalid"
+		value   = (*hexutil.Big)(big.NewInt(2 * params.Ether))
+	)
+	configs := []*TraceCallConfig{
+		{TraceConfig: TraceConfig{Tracer: &tracer}},
+		{TraceConfig: TraceConfig{Tracer: &tracer, Timeout: &timeout}},
+	}
+	for i, config := range configs {
+		stopped.Store(0)
+		_, err := api.TraceCall(context.Background(), ethapi.TransactionArgs{From: &accounts[0].addr, To: &accounts[0].addr, Value: value}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+		if err == nil {
+			t.Fatalf("test %d: expect error, got nothing", i)
+		}
+		if n := stopped.Load(); n != 1 {
+			t.Fatalf("test %d: tracer stopped %d times, want 1", i, n)
+		}
+	}
+}
+
 func TestTraceCall(t *testing.T) {
 	t.Parallel()
 
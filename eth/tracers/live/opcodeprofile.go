@@ -0,0 +1,129 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.LiveDirectory.Register("opcodeProfiler", newOpcodeProfiler)
+}
+
+// opcodeProfileFrame tracks the instruction being executed in a call frame.
+type opcodeProfileFrame struct {
+	op         vm.OpCode
+	start      time.Time
+	pending    bool   // Whether op is executing and not yet recorded
+	precompile string // Name of the precompile executed by the frame, if any
+}
+
+// opcodeProfiler measures the execution time of every instruction as the time
+// between consecutive tracing hooks, and the time spent in precompiled
+// contracts. The measurements are fed into the same profile as the sampling
+// enabled by vm.Config.EnableOpcodeProfiling, which has a much lower overhead
+// and should not be enabled together with this tracer. The timings recorded
+// here include the tracing overhead, making them mainly useful for relative
+// comparisons.
+type opcodeProfiler struct {
+	chainConfig *params.ChainConfig
+	precompiles vm.PrecompiledContracts
+	frames      []opcodeProfileFrame
+}
+
+func newOpcodeProfiler(_ json.RawMessage) (*tracing.Hooks, error) {
+	t := &opcodeProfiler{}
+	return &tracing.Hooks{
+		OnBlockchainInit: t.onBlockchainInit,
+		OnTxStart:        t.onTxStart,
+		OnEnter:          t.onEnter,
+		OnExit:           t.onExit,
+		OnOpcode:         t.onOpcode,
+	}, nil
+}
+
+func (t *opcodeProfiler) onBlockchainInit(chainConfig *params.ChainConfig) {
+	t.chainConfig = chainConfig
+}
+
+func (t *opcodeProfiler) onTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.frames = t.frames[:0]
+	t.precompiles = nil
+	if t.chainConfig != nil {
+		rules := t.chainConfig.Rules(env.BlockNumber, env.Random != nil, env.Time)
+		t.precompiles = vm.ActivePrecompiledContracts(rules)
+	}
+}
+
+// finish records the instruction pending in the innermost frame.
+func (t *opcodeProfiler) finish(now time.Time) {
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := &t.frames[len(t.frames)-1]
+	if frame.pending {
+		vm.ProfileOpcode(frame.op, now.Sub(frame.start))
+		frame.pending = false
+	}
+}
+
+func (t *opcodeProfiler) onEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	now := time.Now()
+
+	// Calls are only measured up to entering the callee.
+	t.finish(now)
+
+	frame := opcodeProfileFrame{start: now}
+	if p, ok := t.precompiles[to]; ok && vm.OpCode(typ) != vm.SELFDESTRUCT {
+		frame.precompile = p.Name()
+	}
+	t.frames = append(t.frames, frame)
+}
+
+func (t *opcodeProfiler) onExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(t.frames) == 0 {
+		return
+	}
+	now := time.Now()
+	t.finish(now)
+
+	frame := t.frames[len(t.frames)-1]
+	if frame.precompile != "" {
+		vm.ProfilePrecompile(frame.precompile, now.Sub(frame.start))
+	}
+	t.frames = t.frames[:len(t.frames)-1]
+}
+
+func (t *opcodeProfiler) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	now := time.Now()
+	t.finish(now)
+
+	frame := &t.frames[len(t.frames)-1]
+	frame.op, frame.start, frame.pending = vm.OpCode(op), now, true
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestOpcodeProfiler(t *testing.T) {
+	vm.ResetOpcodeProfile()
+	defer vm.ResetOpcodeProfile()
+
+	hooks, err := tracers.LiveDirectory.New("opcodeProfiler", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	var (
+		from     = common.Address{0xa}
+		to       = common.Address{0xb}
+		identity = common.BytesToAddress([]byte{0x4})
+	)
+	hooks.OnBlockchainInit(params.MergedTestChainConfig)
+	hooks.OnTxStart(&tracing.VMContext{BlockNumber: big.NewInt(1), Random: &common.Hash{}}, types.NewTx(&types.LegacyTx{To: &to}), from)
+	hooks.OnEnter(0, byte(vm.CALL), from, to, nil, 100000, new(big.Int))
+	hooks.OnOpcode(0, byte(vm.PUSH1), 100000, 3, nil, nil, 1, nil)
+	hooks.OnOpcode(2, byte(vm.STATICCALL), 99997, 100, nil, nil, 1, nil)
+	hooks.OnEnter(1, byte(vm.STATICCALL), to, identity, nil, 1000, nil)
+	hooks.OnExit(1, nil, 15, nil, false)
+	hooks.OnOpcode(3, byte(vm.STOP), 99000, 0, nil, nil, 1, nil)
+	hooks.OnExit(0, nil, 1000, nil, false)
+
+	profile := vm.OpcodeProfile()
+	have := make(map[string]uint64)
+	for _, entry := range profile.Opcodes {
+		have[entry.Name] = entry.Count
+	}
+	for _, op := range []string{"PUSH1", "STATICCALL", "STOP"} {
+		if have[op] != 1 {
+			t.Errorf("opcode %s count mismatch: have %d, want 1", op, have[op])
+		}
+	}
+	if len(profile.Precompiles) != 1 || profile.Precompiles[0].Name != "ID" {
+		t.Errorf("unexpected precompile profile: %+v", profile.Precompiles)
+	}
+}
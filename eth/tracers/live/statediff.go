@@ -0,0 +1,430 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func init() {
+	tracers.LiveDirectory.Register("statediff", newStateDiffTracer)
+}
+
+// defaultStateDiffBlocksPerFile is the number of consecutive blocks stored in
+// a single compressed output file if not configured otherwise.
+const defaultStateDiffBlocksPerFile = 10000
+
+// stateDiffBalance is the balance transition of an account.
+type stateDiffBalance struct {
+	From *hexutil.Big `json:"from"`
+	To   *hexutil.Big `json:"to"`
+}
+
+// stateDiffNonce is the nonce transition of an account.
+type stateDiffNonce struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+// stateDiffCode is the code transition of an account. Only the new code is
+// included, the previous one can be looked up by its hash.
+type stateDiffCode struct {
+	From common.Hash   `json:"from"`
+	To   common.Hash   `json:"to"`
+	Code hexutil.Bytes `json:"code,omitempty"`
+}
+
+// stateDiffSlot is the value transition of a storage slot.
+type stateDiffSlot struct {
+	From common.Hash `json:"from"`
+	To   common.Hash `json:"to"`
+}
+
+// stateDiffAccount contains all changes made to a single account.
+type stateDiffAccount struct {
+	Balance *stateDiffBalance              `json:"balance,omitempty"`
+	Nonce   *stateDiffNonce                `json:"nonce,omitempty"`
+	Code    *stateDiffCode                 `json:"code,omitempty"`
+	Storage map[common.Hash]*stateDiffSlot `json:"storage,omitempty"`
+}
+
+// stateDiff maps accounts to their changes.
+type stateDiff map[common.Address]*stateDiffAccount
+
+// stateDiffTx is the diff produced by a single transaction.
+type stateDiffTx struct {
+	Index int         `json:"index"`
+	Hash  common.Hash `json:"hash"`
+	Diff  stateDiff   `json:"diff"`
+}
+
+// stateDiffRecord is a single line of the output files. It is one of:
+//
+//   - a block containing the diffs of all its transactions.
+//   - a fork marker, preceding a block which does not extend the previously
+//     written one. Blocks are written as they are processed, including side
+//     branches which might never become canonical, so the marker does not
+//     imply that any written block was dropped. Consumers resolve the canonical
+//     chain by following the parent hashes from the head.
+//   - a gap marker, telling that the blocks in range [Number, Last] were
+//     processed but failed to be written.
+type stateDiffRecord struct {
+	Type       string      `json:"type"` // "block", "fork" or "gap"
+	Number     uint64      `json:"blockNumber"`
+	Hash       common.Hash `json:"hash"`
+	ParentHash common.Hash `json:"parentHash"`
+
+	// Block-only fields. System contains changes made outside of transactions,
+	// e.g. system calls, withdrawals and mining rewards.
+	Txs    []*stateDiffTx `json:"txs,omitempty"`
+	System stateDiff      `json:"system,omitempty"`
+
+	// Fork-only field, the hash of the previously written block.
+	Previous common.Hash `json:"previous,omitempty"`
+
+	// Gap-only field, the number of the last block failed to be written.
+	Last uint64 `json:"last,omitempty"`
+}
+
+type stateDiffTracerConfig struct {
+	Path          string `json:"path"`          // Path to the directory where the diff files will be stored
+	BlocksPerFile uint64 `json:"blocksPerFile"` // BlocksPerFile is the number of blocks stored in one file. It defaults to 10000.
+}
+
+type stateDiffTracer struct {
+	dir    string
+	span   uint64
+	bucket uint64
+	file   *os.File
+	gz     *gzip.Writer
+
+	block *stateDiffRecord // Block currently being processed, nil outside of blocks
+	tx    *stateDiffTx     // Transaction currently being processed, nil outside of transactions
+
+	// Hash of the last processed block, used to detect forks.
+	processed bool
+	lastHash  common.Hash
+
+	gap *stateDiffRecord // Blocks failed to be written, reported with the next written block
+}
+
+func newStateDiffTracer(cfg json.RawMessage) (*tracing.Hooks, error) {
+	var config stateDiffTracerConfig
+	if err := json.Unmarshal(cfg, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	if config.Path == "" {
+		return nil, errors.New("statediff tracer output path is required")
+	}
+	if err := os.MkdirAll(config.Path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	t := &stateDiffTracer{
+		dir:  config.Path,
+		span: config.BlocksPerFile,
+	}
+	if t.span == 0 {
+		t.span = defaultStateDiffBlocksPerFile
+	}
+	// The journal reports changes of reverted call frames in reverse, so they
+	// cancel out.
+	return tracing.WrapWithJournal(&tracing.Hooks{
+		OnBlockStart:    t.onBlockStart,
+		OnBlockEnd:      t.onBlockEnd,
+		OnGenesisBlock:  t.onGenesisBlock,
+		OnTxStart:       t.onTxStart,
+		OnTxEnd:         t.onTxEnd,
+		OnBalanceChange: t.onBalanceChange,
+		OnNonceChangeV2: t.onNonceChange,
+		OnCodeChangeV2:  t.onCodeChange,
+		OnStorageChange: t.onStorageChange,
+		OnClose:         t.onClose,
+	})
+}
+
+// stateDiffFileName returns the name of the file storing the blocks starting
+// at the given number.
+func stateDiffFileName(start uint64) string {
+	return fmt.Sprintf("statediff-%012d.jsonl.gz", start)
+}
+
+func (t *stateDiffTracer) onBlockStart(ev tracing.BlockEvent) {
+	t.startBlock(ev.Block.NumberU64(), ev.Block.Hash(), ev.Block.ParentHash())
+}
+
+func (t *stateDiffTracer) startBlock(number uint64, hash, parent common.Hash) {
+	t.block = &stateDiffRecord{
+		Type:       "block",
+		Number:     number,
+		Hash:       hash,
+		ParentHash: parent,
+		Txs:        []*stateDiffTx{},
+	}
+	t.tx = nil
+}
+
+func (t *stateDiffTracer) onBlockEnd(err error) {
+	block := t.block
+	t.block, t.tx = nil, nil
+
+	// Blocks failing to process never become part of the chain, drop them.
+	if block == nil || err != nil {
+		return
+	}
+	if block.System != nil {
+		block.System.prune()
+		if len(block.System) == 0 {
+			block.System = nil
+		}
+	}
+	var records []*stateDiffRecord
+	if t.gap != nil {
+		records = append(records, t.gap)
+	}
+	// If the new block doesn't extend the last one, mark the fork so consumers
+	// can tell the branches apart.
+	if t.processed && block.ParentHash != t.lastHash {
+		records = append(records, &stateDiffRecord{
+			Type:       "fork",
+			Number:     block.Number,
+			Hash:       block.Hash,
+			ParentHash: block.ParentHash,
+			Previous:   t.lastHash,
+		})
+	}
+	records = append(records, block)
+	t.processed, t.lastHash = true, block.Hash
+
+	// Failed blocks are not retried, but the next written block is preceded
+	// by a gap marker so consumers are aware of the missing ones.
+	if err := t.write(block.Number, records); err != nil {
+		log.Warn("Failed to write statediff record", "number", block.Number, "hash", block.Hash, "err", err)
+		if t.gap == nil {
+			t.gap = &stateDiffRecord{Type: "gap", Number: block.Number}
+		}
+		t.gap.Last = block.Number
+		return
+	}
+	t.gap = nil
+}
+
+func (t *stateDiffTracer) onGenesisBlock(b *types.Block, alloc types.GenesisAlloc) {
+	t.startBlock(b.NumberU64(), b.Hash(), b.ParentHash())
+
+	// The state hooks are not invoked while committing the genesis, so build
+	// the diff from the allocation directly.
+	for addr, account := range alloc {
+		if account.Balance != nil && account.Balance.Sign() > 0 {
+			t.onBalanceChange(addr, new(big.Int), account.Balance, tracing.BalanceIncreaseGenesisBalance)
+		}
+		if account.Nonce > 0 {
+			t.onNonceChange(addr, 0, account.Nonce, tracing.NonceChangeGenesis)
+		}
+		if len(account.Code) > 0 {
+			t.onCodeChange(addr, types.EmptyCodeHash, nil, crypto.Keccak256Hash(account.Code), account.Code, tracing.CodeChangeGenesis)
+		}
+		for slot, value := range account.Storage {
+			t.onStorageChange(addr, slot, common.Hash{}, value)
+		}
+	}
+	t.onBlockEnd(nil)
+}
+
+func (t *stateDiffTracer) onTxStart(vm *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	if t.block == nil {
+		return
+	}
+	t.tx = &stateDiffTx{
+		Index: len(t.block.Txs),
+		Hash:  tx.Hash(),
+		Diff:  make(stateDiff),
+	}
+}
+
+func (t *stateDiffTracer) onTxEnd(receipt *types.Receipt, err error) {
+	tx := t.tx
+	t.tx = nil
+
+	// Transactions failing validation are not included in the block.
+	if t.block == nil || tx == nil || err != nil {
+		return
+	}
+	tx.Diff.prune()
+	t.block.Txs = append(t.block.Txs, tx)
+}
+
+// account returns the diff entry of the given address in the current scope,
+// or nil if no block is being processed.
+func (t *stateDiffTracer) account(addr common.Address) *stateDiffAccount {
+	var diff stateDiff
+	switch {
+	case t.tx != nil:
+		diff = t.tx.Diff
+	case t.block != nil:
+		if t.block.System == nil {
+			t.block.System = make(stateDiff)
+		}
+		diff = t.block.System
+	default:
+		return nil
+	}
+	acc := diff[addr]
+	if acc == nil {
+		acc = new(stateDiffAccount)
+		diff[addr] = acc
+	}
+	return acc
+}
+
+// The change hooks below only record the first previous and the last new
+// value within a scope. Reverted changes are reported by the journal with
+// swapped values, so they cancel out and get pruned at the end.
+
+func (t *stateDiffTracer) onBalanceChange(addr common.Address, prevBalance, newBalance *big.Int, reason tracing.BalanceChangeReason) {
+	acc := t.account(addr)
+	if acc == nil {
+		return
+	}
+	if acc.Balance == nil {
+		acc.Balance = &stateDiffBalance{From: (*hexutil.Big)(new(big.Int).Set(prevBalance))}
+	}
+	acc.Balance.To = (*hexutil.Big)(new(big.Int).Set(newBalance))
+}
+
+func (t *stateDiffTracer) onNonceChange(addr common.Address, prevNonce, newNonce uint64, reason tracing.NonceChangeReason) {
+	acc := t.account(addr)
+	if acc == nil {
+		return
+	}
+	if acc.Nonce == nil {
+		acc.Nonce = &stateDiffNonce{From: hexutil.Uint64(prevNonce)}
+	}
+	acc.Nonce.To = hexutil.Uint64(newNonce)
+}
+
+func (t *stateDiffTracer) onCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte, reason tracing.CodeChangeReason) {
+	acc := t.account(addr)
+	if acc == nil {
+		return
+	}
+	if acc.Code == nil {
+		acc.Code = &stateDiffCode{From: prevCodeHash}
+	}
+	acc.Code.To = codeHash
+	acc.Code.Code = common.CopyBytes(code)
+}
+
+func (t *stateDiffTracer) onStorageChange(addr common.Address, slot common.Hash, prev, value common.Hash) {
+	acc := t.account(addr)
+	if acc == nil {
+		return
+	}
+	if acc.Storage == nil {
+		acc.Storage = make(map[common.Hash]*stateDiffSlot)
+	}
+	entry := acc.Storage[slot]
+	if entry == nil {
+		entry = &stateDiffSlot{From: prev}
+		acc.Storage[slot] = entry
+	}
+	entry.To = value
+}
+
+// prune removes all changes which ended up restoring the original value.
+func (d stateDiff) prune() {
+	for addr, acc := range d {
+		if acc.Balance != nil && (*big.Int)(acc.Balance.From).Cmp((*big.Int)(acc.Balance.To)) == 0 {
+			acc.Balance = nil
+		}
+		if acc.Nonce != nil && acc.Nonce.From == acc.Nonce.To {
+			acc.Nonce = nil
+		}
+		if acc.Code != nil && acc.Code.From == acc.Code.To {
+			acc.Code = nil
+		}
+		for slot, entry := range acc.Storage {
+			if entry.From == entry.To {
+				delete(acc.Storage, slot)
+			}
+		}
+		if acc.Balance == nil && acc.Nonce == nil && acc.Code == nil && len(acc.Storage) == 0 {
+			delete(d, addr)
+		}
+	}
+}
+
+// write appends the records to the file responsible for the given block number,
+// switching files if needed. Files of earlier ranges are reopened on forks,
+// which results in multi-member gzip streams that standard readers handle
+// transparently.
+func (t *stateDiffTracer) write(number uint64, records []*stateDiffRecord) error {
+	bucket := number - number%t.span
+	if t.file == nil || bucket != t.bucket {
+		t.closeFile()
+		f, err := os.OpenFile(filepath.Join(t.dir, stateDiffFileName(bucket)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		t.file, t.gz, t.bucket = f, gzip.NewWriter(f), bucket
+	}
+	for _, rec := range records {
+		out, _ := json.Marshal(rec)
+		out = append(out, '\n')
+		if _, err := t.gz.Write(out); err != nil {
+			t.closeFile()
+			return err
+		}
+	}
+	// Flush after every block so that consumers tailing the file and crashes
+	// never observe partially written blocks.
+	if err := t.gz.Flush(); err != nil {
+		t.closeFile()
+		return err
+	}
+	return nil
+}
+
+func (t *stateDiffTracer) closeFile() {
+	if t.file == nil {
+		return
+	}
+	if err := t.gz.Close(); err != nil {
+		log.Warn("failed to finalize statediff tracer file", "error", err)
+	}
+	if err := t.file.Close(); err != nil {
+		log.Warn("failed to close statediff tracer file", "error", err)
+	}
+	t.file, t.gz = nil, nil
+}
+
+func (t *stateDiffTracer) onClose() {
+	t.closeFile()
+}
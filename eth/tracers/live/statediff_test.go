@@ -0,0 +1,228 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// readStateDiffFile decodes all records of a compressed statediff file.
+func readStateDiffFile(t *testing.T, path string) []stateDiffRecord {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("failed to open gzip stream: %v", err)
+	}
+	var (
+		records []stateDiffRecord
+		scanner = bufio.NewScanner(gz)
+	)
+	for scanner.Scan() {
+		var rec stateDiffRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("failed to decode record: %v", err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	return records
+}
+
+func TestStateDiffTracer(t *testing.T) {
+	dir := t.TempDir()
+	hooks, err := tracers.LiveDirectory.New("statediff", json.RawMessage(`{"path": "`+dir+`", "blocksPerFile": 2}`))
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	var (
+		alice = common.Address{0xa}
+		bob   = common.Address{0xb}
+		child = common.Address{0xc}
+		tx    = types.NewTx(&types.LegacyTx{Nonce: 0, Gas: 21000})
+	)
+	// Bob calls a child contract which writes slot 1 and reverts, then writes
+	// slot 2 itself.
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.SetCode(child, []byte{
+		byte(vm.PUSH1), 0x2, byte(vm.PUSH1), 0x1, byte(vm.SSTORE), byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.REVERT),
+	}, tracing.CodeChangeUnspecified)
+	code := []byte{
+		byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH20),
+	}
+	code = append(code, child.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.PUSH1), 0x3, byte(vm.PUSH1), 0x2, byte(vm.SSTORE), byte(vm.STOP))
+	statedb.SetCode(bob, code, tracing.CodeChangeUnspecified)
+	statedb.Finalise(true)
+
+	vmctx := vm.BlockContext{
+		CanTransfer: func(vm.StateDB, common.Address, *uint256.Int) bool { return true },
+		Transfer:    func(vm.StateDB, common.Address, common.Address, *uint256.Int) {},
+		BlockNumber: big.NewInt(1),
+		Time:        1,
+		Random:      &common.Hash{},
+	}
+	evm := vm.NewEVM(vmctx, state.NewHookedState(statedb, hooks), params.MergedTestChainConfig, vm.Config{Tracer: hooks})
+	block := func(number int64, parent common.Hash, extra byte) *types.Block {
+		return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number), ParentHash: parent, Difficulty: big.NewInt(0), Extra: []byte{extra}})
+	}
+	process := func(blocks ...*types.Block) {
+		for _, b := range blocks {
+			hooks.OnBlockStart(tracing.BlockEvent{Block: b})
+			hooks.OnBlockEnd(nil)
+		}
+	}
+	// Block 1: a transfer with a reverted storage write and a mining reward
+	b1 := block(1, common.Hash{}, 0)
+	hooks.OnBlockStart(tracing.BlockEvent{Block: b1})
+	hooks.OnTxStart(nil, tx, alice)
+	hooks.OnNonceChangeV2(alice, 0, 1, tracing.NonceChangeEoACall)
+	hooks.OnBalanceChange(alice, big.NewInt(10), big.NewInt(7), tracing.BalanceChangeTransfer)
+	hooks.OnBalanceChange(bob, big.NewInt(0), big.NewInt(3), tracing.BalanceChangeTransfer)
+	if _, _, err := evm.Call(alice, bob, nil, 100000, new(uint256.Int)); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	hooks.OnTxEnd(&types.Receipt{}, nil)
+	hooks.OnBalanceChange(bob, big.NewInt(3), big.NewInt(5), tracing.BalanceIncreaseRewardMineBlock)
+	hooks.OnBlockEnd(nil)
+
+	// Block 2 fails processing and must not be written
+	hooks.OnBlockStart(tracing.BlockEvent{Block: block(2, b1.Hash(), 0)})
+	hooks.OnBalanceChange(bob, big.NewInt(5), big.NewInt(6), tracing.BalanceIncreaseRewardMineBlock)
+	hooks.OnBlockEnd(errors.New("invalid block"))
+
+	// Blocks 2 and 3, followed by a side block 3 and a block 4 extending the
+	// original block 3, both being forks.
+	var (
+		b2  = block(2, b1.Hash(), 1)
+		b3  = block(3, b2.Hash(), 0)
+		b3s = block(3, b2.Hash(), 1)
+		b4  = block(4, b3.Hash(), 0)
+		b5  = block(5, b4.Hash(), 0)
+		b6  = block(6, b5.Hash(), 0)
+		b7  = block(7, b6.Hash(), 0)
+		b8  = block(8, b7.Hash(), 0)
+	)
+	process(b2, b3, b3s, b4, b5)
+
+	// Blocks 6 and 7 fail to be written, a gap is reported before block 8
+	if err := os.Mkdir(filepath.Join(dir, stateDiffFileName(6)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	process(b6, b7, b8)
+	hooks.OnClose()
+
+	first := readStateDiffFile(t, filepath.Join(dir, stateDiffFileName(0)))
+	if len(first) != 1 {
+		t.Fatalf("first file record count mismatch: have %d, want 1", len(first))
+	}
+	rec := first[0]
+	if rec.Type != "block" || rec.Number != 1 || rec.Hash != b1.Hash() {
+		t.Fatalf("unexpected block record: %+v", rec)
+	}
+	if len(rec.Txs) != 1 || rec.Txs[0].Hash != tx.Hash() {
+		t.Fatalf("unexpected transactions: %+v", rec.Txs)
+	}
+	diff := rec.Txs[0].Diff
+	if len(diff) != 2 {
+		t.Fatalf("tx diff account count mismatch: have %d, want 2", len(diff))
+	}
+	if acc := diff[alice]; acc.Nonce == nil || acc.Nonce.To != 1 || acc.Balance == nil || (*big.Int)(acc.Balance.To).Int64() != 7 {
+		t.Fatalf("unexpected sender diff: %+v", acc)
+	}
+	if acc := diff[bob]; acc.Balance == nil || (*big.Int)(acc.Balance.From).Sign() != 0 {
+		t.Fatalf("unexpected recipient diff: %+v", acc)
+	}
+	// Only the storage write outside of the reverted call frame is reported
+	wantStorage := map[common.Hash]*stateDiffSlot{
+		common.BigToHash(big.NewInt(2)): {To: common.BigToHash(big.NewInt(3))},
+	}
+	if storage := diff[bob].Storage; !reflect.DeepEqual(storage, wantStorage) {
+		t.Fatalf("unexpected recipient storage: %v", storage)
+	}
+	if acc := rec.System[bob]; acc == nil || (*big.Int)(acc.Balance.From).Int64() != 3 || (*big.Int)(acc.Balance.To).Int64() != 5 {
+		t.Fatalf("unexpected system diff: %+v", rec.System)
+	}
+
+	type record struct {
+		typ    string
+		number uint64
+		hash   common.Hash
+	}
+	check := func(file uint64, want []record) []stateDiffRecord {
+		t.Helper()
+		have := readStateDiffFile(t, filepath.Join(dir, stateDiffFileName(file)))
+		if len(have) != len(want) {
+			t.Fatalf("file %d record count mismatch: have %d, want %d", file, len(have), len(want))
+		}
+		for i, w := range want {
+			if have[i].Type != w.typ || have[i].Number != w.number || have[i].Hash != w.hash {
+				t.Errorf("file %d record %d mismatch: have %s %d %x, want %s %d %x", file, i, have[i].Type, have[i].Number, have[i].Hash, w.typ, w.number, w.hash)
+			}
+		}
+		return have
+	}
+	second := check(2, []record{
+		{"block", 2, b2.Hash()},
+		{"block", 3, b3.Hash()},
+		{"fork", 3, b3s.Hash()},
+		{"block", 3, b3s.Hash()},
+	})
+	if second[2].Previous != b3.Hash() {
+		t.Errorf("fork previous mismatch: have %x, want %x", second[2].Previous, b3.Hash())
+	}
+	third := check(4, []record{
+		{"fork", 4, b4.Hash()},
+		{"block", 4, b4.Hash()},
+		{"block", 5, b5.Hash()},
+	})
+	if third[0].Previous != b3s.Hash() {
+		t.Errorf("fork previous mismatch: have %x, want %x", third[0].Previous, b3s.Hash())
+	}
+	last := check(8, []record{
+		{"gap", 6, common.Hash{}},
+		{"block", 8, b8.Hash()},
+	})
+	if last[0].Last != 7 {
+		t.Errorf("gap end mismatch: have %d, want 7", last[0].Last)
+	}
+}
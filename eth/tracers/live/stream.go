@@ -0,0 +1,412 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/live/stream"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.LiveDirectory.Register("stream", newStreamTracer)
+}
+
+const (
+	// streamBufferSize is the default number of frames buffered before events
+	// are dropped or block processing is paused, depending on the overflow
+	// policy.
+	streamBufferSize = 4096
+
+	// streamOverflowWait is the default time waited for buffer space before
+	// events are dropped.
+	streamOverflowWait = 100 * time.Millisecond
+
+	// streamRetryInterval is the time waited between attempts to reconnect to
+	// the consumer.
+	streamRetryInterval = time.Second
+)
+
+type streamTracerConfig struct {
+	Socket       string `json:"socket"`       // Unix socket of the consumer to stream the events to
+	Path         string `json:"path"`         // File to append the events to, if no socket is configured
+	BufferSize   int    `json:"bufferSize"`   // Number of events buffered before the overflow policy applies
+	Overflow     string `json:"overflow"`     // Overflow policy: "drop" (default) or "block"
+	OverflowWait string `json:"overflowWait"` // Time waited for buffer space before dropping events
+}
+
+// streamTracer serialises the live tracing events into the binary protocol of
+// package stream, forwarding them to an external consumer over a Unix socket
+// or into a file.
+//
+// Events are buffered and written in the background. Once the buffer fills up,
+// e.g. because the consumer is slow or unreachable, the overflow policy applies.
+// By default, the tracer waits a short while for space and then drops events,
+// replacing them with a gap marker once the consumer catches up, so a stalled
+// consumer can't stop block processing. With the "block" policy, processing is
+// paused until the consumer catches up instead, so no events are ever dropped.
+type streamTracer struct {
+	open    func() (io.WriteCloser, error) // Opens the connection to the consumer
+	frames  chan []byte                    // Encoded events waiting to be written
+	closing chan struct{}                  // Closed when the tracer is shutting down
+	done    chan struct{}                  // Closed when all frames are written
+
+	block   bool          // Whether to pause processing instead of dropping events
+	wait    time.Duration // Time waited for buffer space before dropping events
+	dropped uint64        // Number of events dropped since the last gap marker
+}
+
+func newStreamTracer(cfg json.RawMessage) (*tracing.Hooks, error) {
+	var config streamTracerConfig
+	if err := json.Unmarshal(cfg, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = streamBufferSize
+	}
+	t := &streamTracer{
+		frames:  make(chan []byte, config.BufferSize),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+		wait:    streamOverflowWait,
+	}
+	switch config.Overflow {
+	case "", "drop":
+	case "block":
+		t.block = true
+	default:
+		return nil, fmt.Errorf("invalid overflow policy %q", config.Overflow)
+	}
+	if config.OverflowWait != "" {
+		wait, err := time.ParseDuration(config.OverflowWait)
+		if err != nil {
+			return nil, fmt.Errorf("invalid overflow wait: %v", err)
+		}
+		t.wait = wait
+	}
+	switch {
+	case config.Socket != "":
+		t.open = func() (io.WriteCloser, error) {
+			return net.Dial("unix", config.Socket)
+		}
+	case config.Path != "":
+		t.open = func() (io.WriteCloser, error) {
+			return os.OpenFile(config.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		}
+	default:
+		return nil, errors.New("stream tracer requires a socket or an output path")
+	}
+	go t.loop()
+
+	return &tracing.Hooks{
+		OnBlockchainInit:  t.onBlockchainInit,
+		OnBlockStart:      t.onBlockStart,
+		OnBlockEnd:        t.onBlockEnd,
+		OnSkippedBlock:    t.onSkippedBlock,
+		OnGenesisBlock:    t.onGenesisBlock,
+		OnTxStart:         t.onTxStart,
+		OnTxEnd:           t.onTxEnd,
+		OnSystemCallStart: t.onSystemCallStart,
+		OnSystemCallEnd:   t.onSystemCallEnd,
+		OnEnter:           t.onEnter,
+		OnExit:            t.onExit,
+		OnBalanceChange:   t.onBalanceChange,
+		OnNonceChangeV2:   t.onNonceChange,
+		OnCodeChangeV2:    t.onCodeChange,
+		OnStorageChange:   t.onStorageChange,
+		OnLog:             t.onLog,
+		OnClose:           t.onClose,
+	}, nil
+}
+
+// loop writes the queued frames to the consumer, reconnecting on failure. The
+// frames which are not flushed out yet are retained and resent on the new
+// connection, so a consumer may receive the frames around a failure twice, but
+// none of them is lost.
+func (t *streamTracer) loop() {
+	defer close(t.done)
+
+	var (
+		out     io.WriteCloser
+		w       *bufio.Writer
+		pending [][]byte // Frames not flushed out to the consumer yet
+		written int      // Number of pending frames written into the current buffer
+	)
+	for frame := range t.frames {
+		pending = append(pending, frame)
+		for {
+			if out == nil {
+				var err error
+				if out, w, err = t.connect(); err != nil {
+					log.Warn("Failed to connect to stream tracer consumer", "err", err)
+					select {
+					case <-time.After(streamRetryInterval):
+						continue
+					case <-t.closing:
+						// Shutting down without a consumer, drop the pending events
+						log.Warn("Dropping stream tracer events", "count", len(pending)+len(t.frames))
+						for range t.frames {
+						}
+						return
+					}
+				}
+				written = 0
+			}
+			var err error
+			for err == nil && written < len(pending) {
+				// Flush the buffer explicitly before it overflows, the frames
+				// are known to be delivered only after a successful flush.
+				if w.Buffered() > 0 && w.Available() < len(pending[written]) {
+					if err = w.Flush(); err != nil {
+						break
+					}
+					pending, written = append(pending[:0], pending[written:]...), 0
+				}
+				if _, err = w.Write(pending[written]); err == nil {
+					written++
+				}
+			}
+			if err == nil && len(t.frames) == 0 {
+				// Flush when caught up, to not delay the events of idle periods
+				if err = w.Flush(); err == nil {
+					pending, written = pending[:0], 0
+				}
+			}
+			if err == nil {
+				break
+			}
+			log.Warn("Stream tracer consumer failed", "err", err, "pending", len(pending))
+			out.Close()
+			out = nil
+		}
+	}
+	if out != nil {
+		if err := w.Flush(); err != nil {
+			log.Warn("Failed to flush stream tracer events", "count", len(pending), "err", err)
+		}
+		out.Close()
+	}
+}
+
+// connect opens a new connection to the consumer and writes the stream header.
+func (t *streamTracer) connect() (io.WriteCloser, *bufio.Writer, error) {
+	out, err := t.open()
+	if err != nil {
+		return nil, nil, err
+	}
+	header, err := stream.AppendFrame(nil, &stream.Header{Version: stream.Version})
+	if err != nil {
+		out.Close()
+		return nil, nil, err
+	}
+	w := bufio.NewWriter(out)
+	if _, err := w.Write(header); err != nil {
+		out.Close()
+		return nil, nil, err
+	}
+	return out, w, nil
+}
+
+// emit encodes an event and queues it for writing, applying the overflow
+// policy if the buffer is full. If events were dropped before, a gap marker is
+// queued ahead of the event.
+func (t *streamTracer) emit(ev stream.Event) {
+	frame, err := stream.AppendFrame(nil, ev)
+	if err != nil {
+		log.Error("Failed to encode stream tracer event", "kind", ev.Kind(), "err", err)
+		return
+	}
+	if t.dropped > 0 {
+		gap, _ := stream.AppendFrame(nil, &stream.Gap{Dropped: t.dropped})
+		if !t.queue(gap) {
+			t.dropped++
+			return
+		}
+		log.Info("Stream tracer consumer caught up", "dropped", t.dropped)
+		t.dropped = 0
+	}
+	if !t.queue(frame) {
+		if t.dropped == 0 {
+			log.Warn("Stream tracer consumer fell behind, dropping events")
+		}
+		t.dropped++
+	}
+}
+
+// queue adds a frame to the write buffer, reporting whether it was added. If
+// the buffer is full, it either blocks until there's space or waits a limited
+// time for it, depending on the overflow policy. While events are already being
+// dropped, it doesn't wait at all, to not slow down processing any further.
+func (t *streamTracer) queue(frame []byte) bool {
+	if t.block {
+		t.frames <- frame
+		return true
+	}
+	select {
+	case t.frames <- frame:
+		return true
+	default:
+	}
+	if t.dropped > 0 || t.wait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(t.wait)
+	defer timer.Stop()
+
+	select {
+	case t.frames <- frame:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (t *streamTracer) onBlockchainInit(chainConfig *params.ChainConfig) {
+	config, err := json.Marshal(chainConfig)
+	if err != nil {
+		log.Error("Failed to encode chain config", "err", err)
+		return
+	}
+	t.emit(&stream.Init{ChainConfig: config})
+}
+
+func (t *streamTracer) onBlockStart(ev tracing.BlockEvent) {
+	start := &stream.BlockStart{Header: ev.Block.Header()}
+	if ev.Finalized != nil {
+		start.Finalized = ev.Finalized.Number.Uint64()
+	}
+	if ev.Safe != nil {
+		start.Safe = ev.Safe.Number.Uint64()
+	}
+	t.emit(start)
+}
+
+func (t *streamTracer) onBlockEnd(err error) {
+	t.emit(&stream.BlockEnd{Error: errorString(err)})
+}
+
+func (t *streamTracer) onSkippedBlock(ev tracing.BlockEvent) {
+	t.emit(&stream.SkippedBlock{Header: ev.Block.Header()})
+}
+
+func (t *streamTracer) onGenesisBlock(b *types.Block, alloc types.GenesisAlloc) {
+	blob, err := json.Marshal(alloc)
+	if err != nil {
+		log.Error("Failed to encode genesis alloc", "err", err)
+		return
+	}
+	t.emit(&stream.Genesis{Header: b.Header(), Alloc: blob})
+}
+
+func (t *streamTracer) onTxStart(vm *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	blob, err := tx.MarshalBinary()
+	if err != nil {
+		log.Error("Failed to encode transaction", "hash", tx.Hash(), "err", err)
+		return
+	}
+	t.emit(&stream.TxStart{Tx: blob, From: from})
+}
+
+func (t *streamTracer) onTxEnd(receipt *types.Receipt, err error) {
+	end := &stream.TxEnd{Error: errorString(err)}
+	if receipt != nil {
+		end.Status = receipt.Status
+		end.GasUsed = receipt.GasUsed
+		end.CumulativeGasUsed = receipt.CumulativeGasUsed
+		end.ContractAddress = receipt.ContractAddress
+	}
+	t.emit(end)
+}
+
+func (t *streamTracer) onSystemCallStart() {
+	t.emit(&stream.SystemCallStart{})
+}
+
+func (t *streamTracer) onSystemCallEnd() {
+	t.emit(&stream.SystemCallEnd{})
+}
+
+func (t *streamTracer) onEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.emit(&stream.Enter{
+		Depth: uint64(depth),
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Input: input,
+		Gas:   gas,
+		Value: value,
+	})
+}
+
+func (t *streamTracer) onExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	t.emit(&stream.Exit{
+		Depth:    uint64(depth),
+		Output:   output,
+		GasUsed:  gasUsed,
+		Error:    errorString(err),
+		Reverted: reverted,
+	})
+}
+
+func (t *streamTracer) onBalanceChange(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+	t.emit(&stream.BalanceChange{Address: addr, Prev: prev, New: new, Reason: byte(reason)})
+}
+
+func (t *streamTracer) onNonceChange(addr common.Address, prev, new uint64, reason tracing.NonceChangeReason) {
+	t.emit(&stream.NonceChange{Address: addr, Prev: prev, New: new, Reason: byte(reason)})
+}
+
+func (t *streamTracer) onCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte, reason tracing.CodeChangeReason) {
+	t.emit(&stream.CodeChange{Address: addr, PrevCodeHash: prevCodeHash, CodeHash: codeHash, Code: code, Reason: byte(reason)})
+}
+
+func (t *streamTracer) onStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+	t.emit(&stream.StorageChange{Address: addr, Slot: slot, Prev: prev, New: new})
+}
+
+func (t *streamTracer) onLog(l *types.Log) {
+	t.emit(&stream.Log{Address: l.Address, Topics: l.Topics, Data: l.Data, Index: uint64(l.Index)})
+}
+
+func (t *streamTracer) onClose() {
+	t.emit(&stream.Close{})
+	close(t.closing)
+	close(t.frames)
+	<-t.done
+}
+
+// errorString returns the message of err, or an empty string if it's nil.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package stream implements the wire protocol of the streaming live tracer,
+// which forwards the execution events of block processing to an external
+// consumer, along with a reader to decode the stream on the consumer side.
+//
+// A stream is a sequence of frames, each made of a 4 byte big endian length,
+// followed by that many bytes of payload: a single byte event kind and the RLP
+// encoding of the event. Every stream, and every reconnection of the tracer to
+// its consumer, starts with a [Header] frame.
+package stream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Version is the version of the stream protocol.
+const Version = 2
+
+// MaxFrameSize is the maximum size of a frame payload accepted by the reader.
+const MaxFrameSize = 64 * 1024 * 1024
+
+// Kind identifies the type of an event in the stream.
+type Kind byte
+
+const (
+	KindHeader Kind = iota
+	KindInit
+	KindBlockStart
+	KindBlockEnd
+	KindSkippedBlock
+	KindGenesis
+	KindTxStart
+	KindTxEnd
+	KindSystemCallStart
+	KindSystemCallEnd
+	KindEnter
+	KindExit
+	KindBalanceChange
+	KindNonceChange
+	KindCodeChange
+	KindStorageChange
+	KindLog
+	KindClose
+	KindGap
+)
+
+// Event is a single event of the stream.
+type Event interface {
+	Kind() Kind
+}
+
+// Header starts every stream. Consumers receiving a header in the middle of a
+// stream must assume that the tracer reconnected and events might be lost, so
+// any partially received block is to be discarded.
+type Header struct {
+	Version uint64
+}
+
+// Init is sent when the blockchain is initialized.
+type Init struct {
+	ChainConfig []byte // JSON encoded chain config
+}
+
+// BlockStart is sent before a block is processed.
+type BlockStart struct {
+	Header    *types.Header
+	Finalized uint64 // Number of the finalized block, zero if unknown
+	Safe      uint64 // Number of the safe block, zero if unknown
+}
+
+// BlockEnd is sent after a block is processed.
+type BlockEnd struct {
+	Error string
+}
+
+// SkippedBlock is sent for blocks which are not processed as they're already
+// known.
+type SkippedBlock struct {
+	Header *types.Header
+}
+
+// Genesis is sent when the genesis block is processed.
+type Genesis struct {
+	Header *types.Header
+	Alloc  []byte // JSON encoded genesis allocation
+}
+
+// TxStart is sent before a transaction is executed.
+type TxStart struct {
+	Tx   []byte // Binary encoded transaction
+	From common.Address
+}
+
+// TxEnd is sent after a transaction is executed.
+type TxEnd struct {
+	Status            uint64
+	GasUsed           uint64
+	CumulativeGasUsed uint64
+	ContractAddress   common.Address
+	Error             string
+}
+
+// SystemCallStart is sent before a system call is executed.
+type SystemCallStart struct{}
+
+// SystemCallEnd is sent after a system call is executed.
+type SystemCallEnd struct{}
+
+// Enter is sent when a call frame is entered.
+type Enter struct {
+	Depth uint64
+	Type  byte // Opcode of the call, e.g. CALL or CREATE2
+	From  common.Address
+	To    common.Address
+	Input []byte
+	Gas   uint64
+	Value *big.Int
+}
+
+// Exit is sent when a call frame is exited.
+type Exit struct {
+	Depth    uint64
+	Output   []byte
+	GasUsed  uint64
+	Error    string
+	Reverted bool
+}
+
+// BalanceChange is sent when the balance of an account changes.
+type BalanceChange struct {
+	Address common.Address
+	Prev    *big.Int
+	New     *big.Int
+	Reason  byte
+}
+
+// NonceChange is sent when the nonce of an account changes.
+type NonceChange struct {
+	Address common.Address
+	Prev    uint64
+	New     uint64
+	Reason  byte
+}
+
+// CodeChange is sent when the code of an account changes.
+type CodeChange struct {
+	Address      common.Address
+	PrevCodeHash common.Hash
+	CodeHash     common.Hash
+	Code         []byte
+	Reason       byte
+}
+
+// StorageChange is sent when a storage slot changes.
+type StorageChange struct {
+	Address common.Address
+	Slot    common.Hash
+	Prev    common.Hash
+	New     common.Hash
+}
+
+// Log is sent when a log is emitted.
+type Log struct {
+	Address common.Address
+	Topics  []common.Hash
+	Data    []byte
+	Index   uint64 // Index of the log in the block
+}
+
+// Close is sent when the blockchain is closed.
+type Close struct{}
+
+// Gap is sent when events were dropped, because the consumer didn't keep up
+// with block processing. Consumers must discard any partially received block.
+type Gap struct {
+	Dropped uint64 // Number of dropped events
+}
+
+func (*Header) Kind() Kind          { return KindHeader }
+func (*Init) Kind() Kind            { return KindInit }
+func (*BlockStart) Kind() Kind      { return KindBlockStart }
+func (*BlockEnd) Kind() Kind        { return KindBlockEnd }
+func (*SkippedBlock) Kind() Kind    { return KindSkippedBlock }
+func (*Genesis) Kind() Kind         { return KindGenesis }
+func (*TxStart) Kind() Kind         { return KindTxStart }
+func (*TxEnd) Kind() Kind           { return KindTxEnd }
+func (*SystemCallStart) Kind() Kind { return KindSystemCallStart }
+func (*SystemCallEnd) Kind() Kind   { return KindSystemCallEnd }
+func (*Enter) Kind() Kind           { return KindEnter }
+func (*Exit) Kind() Kind            { return KindExit }
+func (*BalanceChange) Kind() Kind   { return KindBalanceChange }
+func (*NonceChange) Kind() Kind     { return KindNonceChange }
+func (*CodeChange) Kind() Kind      { return KindCodeChange }
+func (*StorageChange) Kind() Kind   { return KindStorageChange }
+func (*Log) Kind() Kind             { return KindLog }
+func (*Close) Kind() Kind           { return KindClose }
+func (*Gap) Kind() Kind             { return KindGap }
+
+// newEvent creates an empty event of the given kind to decode into.
+func newEvent(kind Kind) (Event, error) {
+	switch kind {
+	case KindHeader:
+		return new(Header), nil
+	case KindInit:
+		return new(Init), nil
+	case KindBlockStart:
+		return new(BlockStart), nil
+	case KindBlockEnd:
+		return new(BlockEnd), nil
+	case KindSkippedBlock:
+		return new(SkippedBlock), nil
+	case KindGenesis:
+		return new(Genesis), nil
+	case KindTxStart:
+		return new(TxStart), nil
+	case KindTxEnd:
+		return new(TxEnd), nil
+	case KindSystemCallStart:
+		return new(SystemCallStart), nil
+	case KindSystemCallEnd:
+		return new(SystemCallEnd), nil
+	case KindEnter:
+		return new(Enter), nil
+	case KindExit:
+		return new(Exit), nil
+	case KindBalanceChange:
+		return new(BalanceChange), nil
+	case KindNonceChange:
+		return new(NonceChange), nil
+	case KindCodeChange:
+		return new(CodeChange), nil
+	case KindStorageChange:
+		return new(StorageChange), nil
+	case KindLog:
+		return new(Log), nil
+	case KindClose:
+		return new(Close), nil
+	case KindGap:
+		return new(Gap), nil
+	default:
+		return nil, fmt.Errorf("unknown event kind %d", kind)
+	}
+}
+
+// AppendFrame appends the frame of the given event to buf.
+func AppendFrame(buf []byte, ev Event) ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(ev)
+	if err != nil {
+		return buf, err
+	}
+	if len(payload)+1 > MaxFrameSize {
+		return buf, fmt.Errorf("event too large: %d bytes", len(payload)+1)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)+1))
+	buf = append(buf, byte(ev.Kind()))
+	return append(buf, payload...), nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	errMissingHeader      = errors.New("stream does not start with a header")
+	errUnsupportedVersion = errors.New("unsupported stream version")
+)
+
+// Reader decodes the events of a stream.
+type Reader struct {
+	r       *bufio.Reader
+	buf     []byte
+	started bool
+}
+
+// NewReader creates a reader decoding the stream of r, which is usually a
+// connection accepted on the Unix socket the tracer is configured with, or the
+// file it writes to.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next decodes the next event of the stream. It returns io.EOF once the stream
+// ends, and io.ErrUnexpectedEOF if it ends in the middle of a frame.
+func (r *Reader) Next() (Event, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r.r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n == 0 || n > MaxFrameSize {
+		return nil, fmt.Errorf("invalid frame size %d", n)
+	}
+	if cap(r.buf) < int(n) {
+		r.buf = make([]byte, n)
+	}
+	frame := r.buf[:n]
+	if _, err := io.ReadFull(r.r, frame); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	ev, err := newEvent(Kind(frame[0]))
+	if err != nil {
+		return nil, err
+	}
+	if err := rlp.DecodeBytes(frame[1:], ev); err != nil {
+		return nil, fmt.Errorf("invalid %T event: %w", ev, err)
+	}
+	// Ensure the stream is one of a supported version
+	if header, ok := ev.(*Header); ok {
+		if header.Version != Version {
+			return nil, fmt.Errorf("%w: %d", errUnsupportedVersion, header.Version)
+		}
+		r.started = true
+	} else if !r.started {
+		return nil, errMissingHeader
+	}
+	return ev, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestStreamRoundtrip(t *testing.T) {
+	events := []Event{
+		&Header{Version: Version},
+		&Init{ChainConfig: []byte(`{"chainId":1}`)},
+		&BlockStart{Header: &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0), BaseFee: big.NewInt(7)}, Finalized: 1},
+		&TxStart{Tx: []byte{0x02, 0xc0}, From: common.Address{0x1}},
+		&Enter{Depth: 0, Type: 0xf1, From: common.Address{0x1}, To: common.Address{0x2}, Input: []byte{0xca, 0xfe}, Gas: 21000, Value: big.NewInt(1)},
+		&BalanceChange{Address: common.Address{0x1}, Prev: big.NewInt(10), New: big.NewInt(9), Reason: 6},
+		&NonceChange{Address: common.Address{0x1}, Prev: 0, New: 1, Reason: 2},
+		&CodeChange{Address: common.Address{0x2}, CodeHash: common.Hash{0x3}, Code: []byte{0x00}, Reason: 1},
+		&StorageChange{Address: common.Address{0x2}, Slot: common.Hash{0x4}, New: common.Hash{0x5}},
+		&Log{Address: common.Address{0x2}, Topics: []common.Hash{{0x6}}, Data: []byte{0x7}, Index: 3},
+		&Exit{Depth: 0, Output: []byte{0x8}, GasUsed: 21000, Error: "execution reverted", Reverted: true},
+		&TxEnd{Status: 1, GasUsed: 21000, CumulativeGasUsed: 21000},
+		&SystemCallStart{},
+		&SystemCallEnd{},
+		&BlockEnd{},
+		&SkippedBlock{Header: &types.Header{Number: big.NewInt(2), Difficulty: big.NewInt(0)}},
+		&Close{},
+		&Gap{Dropped: 7},
+	}
+	var stream []byte
+	for _, ev := range events {
+		var err error
+		if stream, err = AppendFrame(stream, ev); err != nil {
+			t.Fatalf("failed to encode %T: %v", ev, err)
+		}
+	}
+	r := NewReader(bytes.NewReader(stream))
+	for i, want := range events {
+		have, err := r.Next()
+		if err != nil {
+			t.Fatalf("event %d: failed to decode: %v", i, err)
+		}
+		if want, ok := want.(*BlockStart); ok {
+			// Compare headers by hash, as decoding fills in the empty fields
+			if have.(*BlockStart).Header.Hash() != want.Header.Hash() {
+				t.Fatalf("event %d: header mismatch", i)
+			}
+			continue
+		}
+		if want, ok := want.(*SkippedBlock); ok {
+			if have.(*SkippedBlock).Header.Hash() != want.Header.Hash() {
+				t.Fatalf("event %d: header mismatch", i)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Fatalf("event %d: mismatch:\nhave %+v\nwant %+v", i, have, want)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected end of stream, have %v", err)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	header, _ := AppendFrame(nil, &Header{Version: Version})
+	exit, _ := AppendFrame(nil, &Exit{Depth: 1})
+
+	// Streams must start with a header
+	if _, err := NewReader(bytes.NewReader(exit)).Next(); !errors.Is(err, errMissingHeader) {
+		t.Errorf("missing header: have %v, want %v", err, errMissingHeader)
+	}
+	// Unknown versions are rejected
+	future, _ := AppendFrame(nil, &Header{Version: Version + 1})
+	if _, err := NewReader(bytes.NewReader(future)).Next(); !errors.Is(err, errUnsupportedVersion) {
+		t.Errorf("unsupported version: have %v, want %v", err, errUnsupportedVersion)
+	}
+	// Truncated frames are reported
+	r := NewReader(bytes.NewReader(append(header, exit[:len(exit)-1]...)))
+	if _, err := r.Next(); err != nil {
+		t.Fatalf("failed to read header: %v", err)
+	}
+	if _, err := r.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated frame: have %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/live/stream"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// runStreamTracer feeds a short block through the hooks of a stream tracer.
+func runStreamTracer(t *testing.T, config string) {
+	t.Helper()
+
+	hooks, err := tracers.LiveDirectory.New("stream", json.RawMessage(config))
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0)}
+	hooks.OnBlockchainInit(params.TestChainConfig)
+	hooks.OnBlockStart(tracing.BlockEvent{Block: types.NewBlockWithHeader(header)})
+	hooks.OnEnter(0, 0xf1, common.Address{0x1}, common.Address{0x2}, nil, 21000, big.NewInt(1))
+	hooks.OnBalanceChange(common.Address{0x1}, big.NewInt(2), big.NewInt(1), tracing.BalanceChangeTransfer)
+	hooks.OnStorageChange(common.Address{0x2}, common.Hash{0x1}, common.Hash{}, common.Hash{0x2})
+	hooks.OnExit(0, nil, 21000, errors.New("execution reverted"), true)
+	hooks.OnBlockEnd(nil)
+	hooks.OnClose()
+}
+
+// readStream decodes all events of a stream, returning their kinds.
+func readStream(t *testing.T, r io.Reader) []stream.Kind {
+	t.Helper()
+
+	var (
+		reader = stream.NewReader(r)
+		kinds  []stream.Kind
+	)
+	for {
+		ev, err := reader.Next()
+		if err == io.EOF {
+			return kinds
+		}
+		if err != nil {
+			t.Fatalf("failed to read event: %v", err)
+		}
+		kinds = append(kinds, ev.Kind())
+	}
+}
+
+var streamTestKinds = []stream.Kind{
+	stream.KindHeader, stream.KindInit, stream.KindBlockStart, stream.KindEnter, stream.KindBalanceChange,
+	stream.KindStorageChange, stream.KindExit, stream.KindBlockEnd, stream.KindClose,
+}
+
+func TestStreamTracerSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+
+	result := make(chan []stream.Kind)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			result <- nil
+			return
+		}
+		defer conn.Close()
+		result <- readStream(t, conn)
+	}()
+	runStreamTracer(t, `{"socket": "`+path+`", "bufferSize": 2}`)
+
+	if have := <-result; !slices.Equal(have, streamTestKinds) {
+		t.Fatalf("event mismatch: have %v, want %v", have, streamTestKinds)
+	}
+}
+
+func TestStreamTracerFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.bin")
+	runStreamTracer(t, `{"path": "`+path+`"}`)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer f.Close()
+
+	if have := readStream(t, f); !slices.Equal(have, streamTestKinds) {
+		t.Fatalf("event mismatch: have %v, want %v", have, streamTestKinds)
+	}
+}
+
+// streamTestConn is a connection to a stream consumer, which fails all writes
+// if broken.
+type streamTestConn struct {
+	bytes.Buffer
+	broken bool
+}
+
+func (c *streamTestConn) Write(b []byte) (int, error) {
+	if c.broken {
+		return 0, errors.New("broken pipe")
+	}
+	return c.Buffer.Write(b)
+}
+
+func (c *streamTestConn) Close() error { return nil }
+
+func TestStreamTracerReconnect(t *testing.T) {
+	var conns []*streamTestConn
+	tracer := &streamTracer{
+		open: func() (io.WriteCloser, error) {
+			// The first connection fails, after the frames are buffered.
+			conn := &streamTestConn{broken: len(conns) == 0}
+			conns = append(conns, conn)
+			return conn, nil
+		},
+		frames:  make(chan []byte, 16),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	// Queue the events up front, so that they are buffered by the writer and
+	// only flushed out once the queue is drained.
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0)}
+	tracer.onBlockchainInit(params.TestChainConfig)
+	tracer.onBlockStart(tracing.BlockEvent{Block: types.NewBlockWithHeader(header)})
+	tracer.onEnter(0, 0xf1, common.Address{0x1}, common.Address{0x2}, nil, 21000, big.NewInt(1))
+	tracer.onBalanceChange(common.Address{0x1}, big.NewInt(2), big.NewInt(1), tracing.BalanceChangeTransfer)
+	tracer.onStorageChange(common.Address{0x2}, common.Hash{0x1}, common.Hash{}, common.Hash{0x2})
+	tracer.onExit(0, nil, 21000, errors.New("execution reverted"), true)
+	tracer.onBlockEnd(nil)
+
+	go tracer.loop()
+	tracer.onClose()
+
+	if len(conns) != 2 {
+		t.Fatalf("unexpected connection count: have %d, want 2", len(conns))
+	}
+	// All the unflushed frames are resent on the new connection.
+	if have := readStream(t, &conns[1].Buffer); !slices.Equal(have, streamTestKinds) {
+		t.Fatalf("event mismatch: have %v, want %v", have, streamTestKinds)
+	}
+}
+
+// Tests that an unreachable consumer doesn't stall block processing, but the
+// events are dropped once the buffer is full.
+func TestStreamTracerUnreachable(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "missing.sock")
+	config := `{"socket": "` + socket + `", "bufferSize": 2, "overflowWait": "0s"}`
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			runStreamTracer(t, config)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("stream tracer stalled on unreachable consumer")
+	}
+}
+
+// Tests that dropped events are replaced by a gap marker once there's space in
+// the buffer again.
+func TestStreamTracerGap(t *testing.T) {
+	tracer := &streamTracer{frames: make(chan []byte, 2)}
+
+	tracer.onSystemCallStart()
+	tracer.onSystemCallEnd()
+	for range 3 {
+		tracer.onBlockEnd(nil) // Dropped, the buffer is full
+	}
+	if tracer.dropped != 3 {
+		t.Fatalf("dropped count mismatch: have %d, want 3", tracer.dropped)
+	}
+	buf := bytes.NewBuffer(nil)
+	header, _ := stream.AppendFrame(nil, &stream.Header{Version: stream.Version})
+	buf.Write(header)
+	for range 2 {
+		buf.Write(<-tracer.frames)
+	}
+	tracer.onSystemCallStart()
+	buf.Write(<-tracer.frames)
+	buf.Write(<-tracer.frames)
+
+	var (
+		reader = stream.NewReader(buf)
+		kinds  []stream.Kind
+	)
+	for {
+		ev, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read event: %v", err)
+		}
+		if gap, ok := ev.(*stream.Gap); ok && gap.Dropped != 3 {
+			t.Fatalf("gap size mismatch: have %d, want 3", gap.Dropped)
+		}
+		kinds = append(kinds, ev.Kind())
+	}
+	want := []stream.Kind{stream.KindHeader, stream.KindSystemCallStart, stream.KindSystemCallEnd, stream.KindGap, stream.KindSystemCallStart}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("event mismatch: have %v, want %v", kinds, want)
+	}
+	if tracer.dropped != 0 {
+		t.Fatalf("dropped count not reset: %d", tracer.dropped)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("gasProfileTracer", newGasProfileTracer, false)
+}
+
+// gasProfileOther is the pseudo-opcode gas is attributed to when it can't be
+// assigned to any executed instruction of a frame, e.g. precompile execution,
+// code deposit or the gas burnt on an exceptional halt.
+const gasProfileOther = "(other)"
+
+// gasProfileIntrinsic is the pseudo-frame the intrinsic gas of the
+// transaction is attributed to.
+const gasProfileIntrinsic = "(intrinsic)"
+
+type gasProfileFrame struct {
+	path     string // Folded stack of the frame, including itself
+	typ      vm.OpCode
+	gas      uint64    // Gas available to the frame when entered
+	attrib   uint64    // Gas attributed to instructions and child frames so far
+	lastCall string    // Key of the last call opcode, refunded with the forwarded gas
+	lastOp   vm.OpCode // The last call opcode, EOF calls are entered as plain calls
+}
+
+// gasProfileTracer aggregates the gas spent by a transaction by call frame and
+// instruction. The result contains the profile in folded stack format, one
+// line per stack and executed instruction followed by the gas spent, which can
+// be fed directly into flame graph tools.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "gasProfileTracer", tracerConfig: {withSelectors: true}})
+//	{
+//	  gasUsed: 43726,
+//	  intrinsicGas: 21512,
+//	  refund: 0,
+//	  folded: [
+//	    "(intrinsic) 21512",
+//	    "CALL 0x...:0xa9059cbb;SLOAD 2100",
+//	    "CALL 0x...:0xa9059cbb;SSTORE 20000",
+//	    ...
+//	  ]
+//	}
+type gasProfileTracer struct {
+	config    gasProfileTracerConfig
+	stacks    map[string]uint64
+	frames    []*gasProfileFrame
+	gasLimit  uint64
+	gasUsed   uint64
+	intrinsic uint64
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+type gasProfileTracerConfig struct {
+	ByPC          bool `json:"byPC"`          // If true, instructions are additionally keyed by their program counter
+	WithSelectors bool `json:"withSelectors"` // If true, frames are labelled with the 4-byte selector of their input
+}
+
+type gasProfileResult struct {
+	GasUsed      uint64   `json:"gasUsed"`
+	IntrinsicGas uint64   `json:"intrinsicGas"`
+	Refund       uint64   `json:"refund"`
+	Folded       []string `json:"folded"`
+}
+
+// newGasProfileTracer returns a native go tracer which profiles the gas usage
+// of a transaction.
+func newGasProfileTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	var config gasProfileTracerConfig
+	if err := json.Unmarshal(cfg, &config); err != nil {
+		return nil, err
+	}
+	t := &gasProfileTracer{
+		config: config,
+		stacks: make(map[string]uint64),
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnTxEnd:   t.OnTxEnd,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+			OnOpcode:  t.OnOpcode,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *gasProfileTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.stacks = make(map[string]uint64)
+	t.frames = t.frames[:0]
+	t.gasLimit = tx.Gas()
+	t.gasUsed, t.intrinsic = 0, 0
+}
+
+func (t *gasProfileTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	if err != nil || receipt == nil {
+		return
+	}
+	t.gasUsed = receipt.GasUsed
+}
+
+// label returns the name of a frame in the folded stack.
+func (t *gasProfileTracer) label(typ vm.OpCode, to common.Address, input []byte) string {
+	label := typ.String() + " " + to.Hex()
+	if t.config.WithSelectors && typ != vm.CREATE && typ != vm.CREATE2 && len(input) >= 4 {
+		label += ":" + bytesToHex(input[:4])
+	}
+	return label
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *gasProfileTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	op := vm.OpCode(typ)
+	frame := &gasProfileFrame{
+		path: t.label(op, to, input),
+		typ:  op,
+		gas:  gas,
+	}
+	if depth == 0 {
+		if t.gasLimit > gas {
+			t.intrinsic = t.gasLimit - gas
+			t.stacks[gasProfileIntrinsic] = t.intrinsic
+		}
+		t.frames = append(t.frames[:0], frame)
+		return
+	}
+	if len(t.frames) == 0 {
+		return
+	}
+	parent := t.frames[len(t.frames)-1]
+	frame.path = parent.path + ";" + frame.path
+
+	// The gas forwarded by call opcodes is charged as part of their cost, but
+	// is spent in (or returned from) the callee. Move it out of the opcode so
+	// it doesn't get accounted twice. The call stipend is free and thus never
+	// part of the cost. The EOF calls don't have a stipend.
+	if parent.lastCall != "" {
+		forwarded := gas
+		if (parent.lastOp == vm.CALL || parent.lastOp == vm.CALLCODE) && value != nil && value.Sign() != 0 && forwarded >= params.CallStipend {
+			forwarded -= params.CallStipend
+		}
+		forwarded = min(forwarded, t.stacks[parent.lastCall])
+		t.stacks[parent.lastCall] -= forwarded
+		parent.attrib -= forwarded
+		parent.lastCall = ""
+	}
+	t.frames = append(t.frames, frame)
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *gasProfileTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	// Anything not explained by the executed instructions and child frames is
+	// accounted to the frame itself.
+	if gasUsed > frame.attrib {
+		t.stacks[frame.path+";"+gasProfileOther] += gasUsed - frame.attrib
+	}
+	if depth == 0 {
+		if t.gasUsed == 0 {
+			t.gasUsed = t.intrinsic + gasUsed
+		}
+		return
+	}
+	if len(t.frames) > 0 {
+		t.frames[len(t.frames)-1].attrib += gasUsed
+	}
+}
+
+// OnOpcode attributes the cost of an instruction to the current frame.
+func (t *gasProfileTracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	var (
+		op    = vm.OpCode(opcode)
+		frame = t.frames[len(t.frames)-1]
+		key   = frame.path + ";" + op.String()
+	)
+	if t.config.ByPC {
+		key += fmt.Sprintf("@%#x", pc)
+	}
+	t.stacks[key] += cost
+	frame.attrib += cost
+
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.EXTCALL, vm.EXTDELEGATECALL, vm.EXTSTATICCALL:
+		frame.lastCall, frame.lastOp = key, op
+	default:
+		frame.lastCall = ""
+	}
+}
+
+// GetResult returns the json-encoded gas profile, and any error arising from
+// the encoding or forceful termination (via `Stop`).
+func (t *gasProfileTracer) GetResult() (json.RawMessage, error) {
+	result := gasProfileResult{
+		GasUsed:      t.gasUsed,
+		IntrinsicGas: t.intrinsic,
+		Folded:       make([]string, 0, len(t.stacks)),
+	}
+	var total uint64
+	for stack, gas := range t.stacks {
+		if gas == 0 {
+			continue
+		}
+		total += gas
+		result.Folded = append(result.Folded, stack+" "+fmt.Sprint(gas))
+	}
+	sort.Strings(result.Folded)
+
+	// Refunds are credited after execution and can't be shown as a stack,
+	// report them separately so the profile adds up to the gas used.
+	if total > t.gasUsed {
+		result.Refund = total - t.gasUsed
+	}
+	res, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *gasProfileTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+type gasProfile struct {
+	GasUsed      uint64   `json:"gasUsed"`
+	IntrinsicGas uint64   `json:"intrinsicGas"`
+	Refund       uint64   `json:"refund"`
+	Folded       []string `json:"folded"`
+}
+
+func TestGasProfile(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("gasProfileTracer", &tracers.Context{}, json.RawMessage(`{"withSelectors": true}`), params.MainnetChainConfig)
+	require.NoError(t, err)
+
+	var (
+		caller = common.HexToAddress("0xc0")
+		callee = common.HexToAddress("0xca")
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+
+	// The callee stores 1 at slot 0. The caller writes a 4-byte selector into
+	// memory and calls the callee with it.
+	statedb.SetCode(callee, []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x0, byte(vm.SSTORE), byte(vm.STOP),
+	}, tracing.CodeChangeUnspecified)
+	statedb.SetCode(caller, []byte{
+		byte(vm.PUSH4), 0xa9, 0x05, 0x9c, 0xbb, byte(vm.PUSH1), 0xe0, byte(vm.SHL), byte(vm.PUSH1), 0x0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x4, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0,
+		byte(vm.PUSH1), 0xca, byte(vm.GAS), byte(vm.CALL), byte(vm.STOP),
+	}, tracing.CodeChangeUnspecified)
+
+	_, _, err = runtime.Call(caller, nil, &runtime.Config{
+		State:     statedb,
+		GasLimit:  1000000,
+		EVMConfig: vm.Config{Tracer: tracer.Hooks},
+	})
+	require.NoError(t, err)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	var profile gasProfile
+	require.NoError(t, json.Unmarshal(res, &profile))
+
+	// The folded stacks minus the refund must add up to the gas used
+	stacks := make(map[string]uint64)
+	var total uint64
+	for _, line := range profile.Folded {
+		idx := strings.LastIndexByte(line, ' ')
+		gas, err := strconv.ParseUint(line[idx+1:], 10, 64)
+		require.NoError(t, err)
+		stacks[line[:idx]] = gas
+		total += gas
+	}
+	require.Equal(t, profile.GasUsed, total-profile.Refund)
+
+	var (
+		outer = "CALL " + caller.Hex()
+		inner = outer + ";CALL " + callee.Hex() + ":0xa9059cbb"
+	)
+	require.Equal(t, uint64(params.SstoreSetGasEIP2200+params.ColdSloadCostEIP2929), stacks[inner+";SSTORE"])
+	require.Equal(t, uint64(params.ColdAccountAccessCostEIP2929), stacks[outer+";CALL"])
+}
+
+func TestGasProfileEOF(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("gasProfileTracer", &tracers.Context{}, nil, params.MainnetChainConfig)
+	require.NoError(t, err)
+
+	var (
+		caller = common.HexToAddress("0xc0")
+		callee = common.HexToAddress("0xca")
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.AddBalance(caller, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+
+	// The callee stores 1 at slot 0. The caller is an EOF container calling
+	// the callee with 1 wei via EXTCALL, which doesn't add a stipend.
+	statedb.SetCode(callee, []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x0, byte(vm.SSTORE), byte(vm.STOP),
+	}, tracing.CodeChangeUnspecified)
+	code := []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.PUSH1), 0xca,
+		byte(vm.EXTCALL), byte(vm.POP), byte(vm.STOP),
+	}
+	container := []byte{
+		0xef, 0x00, 0x01, // magic and version
+		0x01, 0x00, 0x04, // types section header
+		0x02, 0x00, 0x01, 0x00, byte(len(code)), // code section header
+		0xff, 0x00, 0x00, // data section header
+		0x00,                   // terminator
+		0x00, 0x80, 0x00, 0x04, // non-returning, max stack increase of 4
+	}
+	statedb.SetCode(caller, append(container, code...), tracing.CodeChangeUnspecified)
+
+	_, _, err = runtime.Call(caller, nil, &runtime.Config{
+		State:     statedb,
+		GasLimit:  1000000,
+		EVMConfig: vm.Config{Tracer: tracer.Hooks, ExtraEips: []int{7692}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), statedb.GetState(callee, common.Hash{}).Big().Uint64())
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	var profile gasProfile
+	require.NoError(t, json.Unmarshal(res, &profile))
+
+	stacks := make(map[string]uint64)
+	for _, line := range profile.Folded {
+		idx := strings.LastIndexByte(line, ' ')
+		gas, err := strconv.ParseUint(line[idx+1:], 10, 64)
+		require.NoError(t, err)
+		stacks[line[:idx]] = gas
+	}
+	// The forwarded gas must not be accounted to EXTCALL itself
+	outer := "CALL " + caller.Hex()
+	require.Equal(t, uint64(params.ColdAccountAccessCostEIP2929+params.CallValueTransferGas), stacks[outer+";EXTCALL"])
+}
+
+func TestGasProfileStop(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("gasProfileTracer", &tracers.Context{}, nil, params.MainnetChainConfig)
+	require.NoError(t, err)
+
+	stopError := errors.New("stop error")
+	tx := types.NewTx(&types.LegacyTx{To: &common.Address{}, Gas: 21000})
+
+	tracer.OnTxStart(&tracing.VMContext{}, tx, common.Address{})
+	tracer.OnEnter(0, byte(vm.CALL), common.Address{}, common.Address{}, nil, 0, nil)
+	tracer.Stop(stopError)
+	tracer.OnTxEnd(&types.Receipt{GasUsed: 21000}, nil)
+
+	_, tracerError := tracer.GetResult()
+	require.Equal(t, stopError, tracerError)
+}
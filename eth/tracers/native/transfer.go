@@ -0,0 +1,246 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("transferTracer", newTransferTracer, false)
+}
+
+var (
+	// transferTopic is shared by ERC-20 and ERC-721, which are told apart by
+	// the number of indexed parameters.
+	transferTopic       = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	transferBatchTopic  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+
+	transferBatchArgs = func() abi.Arguments {
+		typ, _ := abi.NewType("uint256[]", "", nil)
+		return abi.Arguments{{Type: typ}, {Type: typ}}
+	}()
+)
+
+// Types of transfers reported by the transferTracer.
+const (
+	transferTypeCall         = "call"         // Ether sent along a call or as the transaction value
+	transferTypeCreate       = "create"       // Ether endowed to a newly created contract
+	transferTypeSelfdestruct = "selfdestruct" // Ether swept to the beneficiary of a selfdestruct
+	transferTypeERC20        = "erc20"
+	transferTypeERC721       = "erc721"
+	transferTypeERC1155      = "erc1155"
+)
+
+type transfer struct {
+	Type    string          `json:"type"`
+	Token   *common.Address `json:"token,omitempty"` // Emitting contract, nil for ether transfers
+	From    common.Address  `json:"from"`
+	To      common.Address  `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	TokenID *hexutil.Big    `json:"tokenId,omitempty"`
+}
+
+// transferTracer reports all movements of ether and tokens within a
+// transaction in execution order. Transfers made by reverted frames are
+// discarded together with the frame.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "transferTracer"})
+//	[
+//	  {type: "call", from: "0x...", to: "0x...", value: "0xde0b6b3a7640000"},
+//	  {type: "erc20", token: "0x...", from: "0x...", to: "0x...", value: "0x5f5e100"}
+//	]
+type transferTracer struct {
+	frames    [][]transfer // Transfers of the currently open call frames
+	transfers []transfer   // Transfers of all finished top level frames
+	interrupt atomic.Bool  // Atomic flag to signal execution interruption
+	reason    error        // Textual reason for the interruption
+}
+
+// newTransferTracer returns a native go tracer which collects the ether and
+// token transfers of a transaction.
+func newTransferTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	t := &transferTracer{
+		transfers: []transfer{},
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnEnter: t.OnEnter,
+			OnExit:  t.OnExit,
+			OnLog:   t.OnLog,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+// add records a transfer in the innermost open frame.
+func (t *transferTracer) add(tr transfer) {
+	if len(t.frames) == 0 {
+		t.transfers = append(t.transfers, tr)
+		return
+	}
+	t.frames[len(t.frames)-1] = append(t.frames[len(t.frames)-1], tr)
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *transferTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.frames = append(t.frames, nil)
+
+	if value == nil || value.Sign() == 0 {
+		return
+	}
+	var kind string
+	switch vm.OpCode(typ) {
+	case vm.CALL:
+		kind = transferTypeCall
+	case vm.CREATE, vm.CREATE2:
+		kind = transferTypeCreate
+	case vm.SELFDESTRUCT:
+		kind = transferTypeSelfdestruct
+	default:
+		// CALLCODE moves the value to the caller itself, DELEGATECALL only
+		// inherits it, neither of them transfers anything.
+		return
+	}
+	t.add(transfer{
+		Type:  kind,
+		From:  from,
+		To:    to,
+		Value: (*hexutil.Big)(new(big.Int).Set(value)),
+	})
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *transferTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	// A failing frame undoes all its value movements, including the ones of
+	// its successful subcalls.
+	if err != nil {
+		return
+	}
+	if len(t.frames) == 0 {
+		t.transfers = append(t.transfers, frame...)
+	} else {
+		t.frames[len(t.frames)-1] = append(t.frames[len(t.frames)-1], frame...)
+	}
+}
+
+// OnLog decodes the token transfer events of the ERC-20, ERC-721 and ERC-1155
+// standards. Events not matching the expected layout are ignored.
+func (t *transferTracer) OnLog(log *types.Log) {
+	if t.interrupt.Load() || len(log.Topics) == 0 {
+		return
+	}
+	token := log.Address
+	switch log.Topics[0] {
+	case transferTopic:
+		switch {
+		case len(log.Topics) == 3 && len(log.Data) == 32:
+			t.add(transfer{
+				Type:  transferTypeERC20,
+				Token: &token,
+				From:  common.BytesToAddress(log.Topics[1][:]),
+				To:    common.BytesToAddress(log.Topics[2][:]),
+				Value: (*hexutil.Big)(new(big.Int).SetBytes(log.Data)),
+			})
+		case len(log.Topics) == 4 && len(log.Data) == 0:
+			t.add(transfer{
+				Type:    transferTypeERC721,
+				Token:   &token,
+				From:    common.BytesToAddress(log.Topics[1][:]),
+				To:      common.BytesToAddress(log.Topics[2][:]),
+				Value:   (*hexutil.Big)(big.NewInt(1)),
+				TokenID: (*hexutil.Big)(log.Topics[3].Big()),
+			})
+		}
+	case transferSingleTopic:
+		if len(log.Topics) != 4 || len(log.Data) != 64 {
+			return
+		}
+		t.add(transfer{
+			Type:    transferTypeERC1155,
+			Token:   &token,
+			From:    common.BytesToAddress(log.Topics[2][:]),
+			To:      common.BytesToAddress(log.Topics[3][:]),
+			Value:   (*hexutil.Big)(new(big.Int).SetBytes(log.Data[32:])),
+			TokenID: (*hexutil.Big)(new(big.Int).SetBytes(log.Data[:32])),
+		})
+	case transferBatchTopic:
+		if len(log.Topics) != 4 {
+			return
+		}
+		values, err := transferBatchArgs.Unpack(log.Data)
+		if err != nil {
+			return
+		}
+		ids, amounts := values[0].([]*big.Int), values[1].([]*big.Int)
+		if len(ids) != len(amounts) {
+			return
+		}
+		for i := range ids {
+			t.add(transfer{
+				Type:    transferTypeERC1155,
+				Token:   &token,
+				From:    common.BytesToAddress(log.Topics[2][:]),
+				To:      common.BytesToAddress(log.Topics[3][:]),
+				Value:   (*hexutil.Big)(amounts[i]),
+				TokenID: (*hexutil.Big)(ids[i]),
+			})
+		}
+	}
+}
+
+// GetResult returns the json-encoded list of transfers, and any error arising
+// from the encoding or forceful termination (via `Stop`).
+func (t *transferTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.transfers)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *transferTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
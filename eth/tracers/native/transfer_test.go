@@ -0,0 +1,118 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+type transfer struct {
+	Type    string          `json:"type"`
+	Token   *common.Address `json:"token"`
+	From    common.Address  `json:"from"`
+	To      common.Address  `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	TokenID *hexutil.Big    `json:"tokenId"`
+}
+
+func TestTransferTracer(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("transferTracer", &tracers.Context{}, nil, params.MainnetChainConfig)
+	require.NoError(t, err)
+
+	var (
+		alice = common.Address{0xa}
+		bob   = common.Address{0xb}
+		token = common.Address{0xc}
+		nft   = common.Address{0xd}
+
+		transferSig = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+		batchSig    = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+	)
+	// The batch payload holds the ids [1, 2] and the amounts [10, 20]
+	batch := common.FromHex("0x" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"000000000000000000000000000000000000000000000000000000000000000a" +
+		"0000000000000000000000000000000000000000000000000000000000000014")
+
+	tracer.OnEnter(0, byte(vm.CALL), alice, token, nil, 100000, big.NewInt(5))
+
+	// Successful ERC-20 transfer and a reverted one
+	tracer.OnLog(&types.Log{Address: token, Topics: []common.Hash{transferSig, common.BytesToHash(alice[:]), common.BytesToHash(bob[:])}, Data: common.LeftPadBytes([]byte{7}, 32)})
+	tracer.OnEnter(1, byte(vm.CALL), token, bob, nil, 50000, big.NewInt(3))
+	tracer.OnLog(&types.Log{Address: token, Topics: []common.Hash{transferSig, common.BytesToHash(bob[:]), common.BytesToHash(alice[:])}, Data: common.LeftPadBytes([]byte{9}, 32)})
+	tracer.OnExit(1, nil, 1000, errors.New("execution reverted"), true)
+
+	// NFT transfers and a delegate call which doesn't move value
+	tracer.OnEnter(1, byte(vm.DELEGATECALL), token, nft, nil, 50000, big.NewInt(5))
+	tracer.OnLog(&types.Log{Address: nft, Topics: []common.Hash{transferSig, common.BytesToHash(alice[:]), common.BytesToHash(bob[:]), common.BigToHash(big.NewInt(42))}})
+	tracer.OnLog(&types.Log{Address: nft, Topics: []common.Hash{batchSig, common.BytesToHash(token[:]), common.BytesToHash(alice[:]), common.BytesToHash(bob[:])}, Data: batch})
+	tracer.OnExit(1, nil, 1000, nil, false)
+
+	// Selfdestruct sweep
+	tracer.OnEnter(1, byte(vm.SELFDESTRUCT), token, bob, nil, 0, big.NewInt(2))
+	tracer.OnExit(1, nil, 0, nil, false)
+
+	tracer.OnExit(0, nil, 30000, nil, false)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	var have []transfer
+	require.NoError(t, json.Unmarshal(res, &have))
+
+	amount := func(n int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(n)) }
+	want := []transfer{
+		{Type: "call", From: alice, To: token, Value: amount(5)},
+		{Type: "erc20", Token: &token, From: alice, To: bob, Value: amount(7)},
+		{Type: "erc721", Token: &nft, From: alice, To: bob, Value: amount(1), TokenID: amount(42)},
+		{Type: "erc1155", Token: &nft, From: alice, To: bob, Value: amount(10), TokenID: amount(1)},
+		{Type: "erc1155", Token: &nft, From: alice, To: bob, Value: amount(20), TokenID: amount(2)},
+		{Type: "selfdestruct", From: token, To: bob, Value: amount(2)},
+	}
+	require.Equal(t, want, have)
+}
+
+func TestTransferTracerRevertedTx(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("transferTracer", &tracers.Context{}, nil, params.MainnetChainConfig)
+	require.NoError(t, err)
+
+	tracer.OnEnter(0, byte(vm.CALL), common.Address{0xa}, common.Address{0xb}, nil, 100000, big.NewInt(5))
+	tracer.OnEnter(1, byte(vm.CALL), common.Address{0xb}, common.Address{0xc}, nil, 50000, big.NewInt(3))
+	tracer.OnExit(1, nil, 1000, nil, false)
+	tracer.OnExit(0, nil, 30000, errors.New("execution reverted"), true)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	require.JSONEq(t, `[]`, string(res))
+}
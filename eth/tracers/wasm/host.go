@@ -0,0 +1,230 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// errOutOfBounds is raised when a host function is handed a pointer outside
+// of the guest memory. It traps the calling module.
+var errOutOfBounds = errors.New("memory access out of bounds")
+
+// instantiateHost registers the host modules tracers can import into the runtime.
+func instantiateHost(ctx context.Context, rt wazero.Runtime) error {
+	b := rt.NewHostModuleBuilder("geth")
+	export := func(name string, fn interface{}) {
+		b.NewFunctionBuilder().WithFunc(fn).Export(name)
+	}
+	// Tracer environment
+	export("config", func(ctx context.Context, m api.Module, offset, length, ptr uint32) uint32 {
+		return copyOut(m, tracerFromContext(ctx).config, offset, length, ptr)
+	})
+	export("set_result", func(ctx context.Context, m api.Module, ptr, length uint32) {
+		tracerFromContext(ctx).res = common.CopyBytes(read(m, ptr, length))
+	})
+	export("log", func(ctx context.Context, m api.Module, ptr, length uint32) {
+		log.Debug("WASM tracer", "msg", string(read(m, ptr, length)))
+	})
+	export("block_number", func(ctx context.Context) uint64 {
+		t := tracerFromContext(ctx)
+		if t.env != nil && t.env.BlockNumber != nil {
+			return t.env.BlockNumber.Uint64()
+		}
+		if t.txCtx.BlockNumber != nil {
+			return t.txCtx.BlockNumber.Uint64()
+		}
+		return 0
+	})
+	export("tx_hash", func(ctx context.Context, m api.Module, ptr uint32) {
+		write(m, ptr, tracerFromContext(ctx).txCtx.TxHash.Bytes())
+	})
+
+	// Execution scope of step and fault
+	export("stack_len", func(ctx context.Context) uint32 {
+		if scope := tracerFromContext(ctx).scope; scope != nil {
+			return uint32(len(scope.StackData()))
+		}
+		return 0
+	})
+	export("stack_peek", func(ctx context.Context, m api.Module, idx, ptr uint32) uint32 {
+		scope := tracerFromContext(ctx).scope
+		if scope == nil {
+			return 0
+		}
+		stack := scope.StackData()
+		if uint64(idx) >= uint64(len(stack)) {
+			return 0
+		}
+		word := stack[len(stack)-1-int(idx)].Bytes32()
+		write(m, ptr, word[:])
+		return 1
+	})
+	export("memory_read", func(ctx context.Context, m api.Module, offset, length, ptr uint32) uint32 {
+		return copyOut(m, scopeData(ctx, tracing.OpContext.MemoryData), offset, length, ptr)
+	})
+	export("code_read", func(ctx context.Context, m api.Module, offset, length, ptr uint32) uint32 {
+		return copyOut(m, scopeData(ctx, tracing.OpContext.ContractCode), offset, length, ptr)
+	})
+	export("input_read", func(ctx context.Context, m api.Module, offset, length, ptr uint32) uint32 {
+		return copyOut(m, scopeData(ctx, tracing.OpContext.CallInput), offset, length, ptr)
+	})
+	export("address", func(ctx context.Context, m api.Module, ptr uint32) {
+		var addr common.Address
+		if scope := tracerFromContext(ctx).scope; scope != nil {
+			addr = scope.Address()
+		}
+		write(m, ptr, addr.Bytes())
+	})
+	export("caller", func(ctx context.Context, m api.Module, ptr uint32) {
+		var addr common.Address
+		if scope := tracerFromContext(ctx).scope; scope != nil {
+			addr = scope.Caller()
+		}
+		write(m, ptr, addr.Bytes())
+	})
+	export("call_value", func(ctx context.Context, m api.Module, ptr uint32) {
+		var word [32]byte
+		if scope := tracerFromContext(ctx).scope; scope != nil && scope.CallValue() != nil {
+			word = scope.CallValue().Bytes32()
+		}
+		write(m, ptr, word[:])
+	})
+
+	// Call frame events
+	export("event_from", func(ctx context.Context, m api.Module, ptr uint32) {
+		write(m, ptr, tracerFromContext(ctx).from.Bytes())
+	})
+	export("event_to", func(ctx context.Context, m api.Module, ptr uint32) {
+		write(m, ptr, tracerFromContext(ctx).to.Bytes())
+	})
+	export("event_value", func(ctx context.Context, m api.Module, ptr uint32) {
+		var word [32]byte
+		if value := tracerFromContext(ctx).value; value != nil {
+			value.FillBytes(word[:])
+		}
+		write(m, ptr, word[:])
+	})
+	export("event_data", func(ctx context.Context, m api.Module, offset, length, ptr uint32) uint32 {
+		return copyOut(m, tracerFromContext(ctx).data, offset, length, ptr)
+	})
+	export("event_error", func(ctx context.Context, m api.Module, offset, length, ptr uint32) uint32 {
+		return copyOut(m, []byte(tracerFromContext(ctx).errMsg), offset, length, ptr)
+	})
+
+	// State access
+	export("get_balance", func(ctx context.Context, m api.Module, addr, ptr uint32) {
+		var word [32]byte
+		if db := stateDB(ctx); db != nil {
+			word = db.GetBalance(readAddress(m, addr)).Bytes32()
+		}
+		write(m, ptr, word[:])
+	})
+	export("get_nonce", func(ctx context.Context, m api.Module, addr uint32) uint64 {
+		if db := stateDB(ctx); db != nil {
+			return db.GetNonce(readAddress(m, addr))
+		}
+		return 0
+	})
+	export("get_code", func(ctx context.Context, m api.Module, addr, offset, length, ptr uint32) uint32 {
+		var code []byte
+		if db := stateDB(ctx); db != nil {
+			code = db.GetCode(readAddress(m, addr))
+		}
+		return copyOut(m, code, offset, length, ptr)
+	})
+	export("get_state", func(ctx context.Context, m api.Module, addr, slot, ptr uint32) {
+		var value common.Hash
+		if db := stateDB(ctx); db != nil {
+			value = db.GetState(readAddress(m, addr), common.BytesToHash(read(m, slot, common.HashLength)))
+		}
+		write(m, ptr, value.Bytes())
+	})
+	export("exists", func(ctx context.Context, m api.Module, addr uint32) uint32 {
+		if db := stateDB(ctx); db != nil && db.Exist(readAddress(m, addr)) {
+			return 1
+		}
+		return 0
+	})
+	if _, err := b.Instantiate(ctx); err != nil {
+		return err
+	}
+	// AssemblyScript imports env.abort unless told otherwise at compile time.
+	_, err := rt.NewHostModuleBuilder("env").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, msg, file, line, column uint32) {
+			panic(fmt.Errorf("abort called at %d:%d", line, column))
+		}).
+		Export("abort").
+		Instantiate(ctx)
+	return err
+}
+
+// stateDB returns the state of the transaction being traced, if any.
+func stateDB(ctx context.Context) tracing.StateDB {
+	if env := tracerFromContext(ctx).env; env != nil {
+		return env.StateDB
+	}
+	return nil
+}
+
+// scopeData returns a field of the current execution scope, if any.
+func scopeData(ctx context.Context, field func(tracing.OpContext) []byte) []byte {
+	if scope := tracerFromContext(ctx).scope; scope != nil {
+		return field(scope)
+	}
+	return nil
+}
+
+// read returns a view of the guest memory, trapping if it is out of bounds.
+func read(m api.Module, ptr, length uint32) []byte {
+	data, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		panic(errOutOfBounds)
+	}
+	return data
+}
+
+// readAddress reads an account address from the guest memory.
+func readAddress(m api.Module, ptr uint32) common.Address {
+	return common.BytesToAddress(read(m, ptr, common.AddressLength))
+}
+
+// write copies data into the guest memory, trapping if it is out of bounds.
+func write(m api.Module, ptr uint32, data []byte) {
+	if !m.Memory().Write(ptr, data) {
+		panic(errOutOfBounds)
+	}
+}
+
+// copyOut copies at most length bytes of data starting at offset into the
+// guest memory and returns the full length of data.
+func copyOut(m api.Module, data []byte, offset, length, ptr uint32) uint32 {
+	if uint64(offset) < uint64(len(data)) {
+		end := min(uint64(offset)+uint64(length), uint64(len(data)))
+		write(m, ptr, data[offset:end])
+	}
+	return uint32(len(data))
+}
@@ -0,0 +1,423 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package wasm implements transaction tracers backed by WebAssembly modules.
+//
+// A tracer module is executed by a pure-Go runtime and interacts with the node
+// through a fixed ABI. The module must export its linear memory as "memory"
+// and a function "result". All other callbacks are optional and only hooked
+// into the EVM if exported:
+//
+//	setup()                                             called once after instantiation
+//	tx_start()                                          transaction execution starts
+//	tx_end(gasUsed i64, failed i32)                     transaction execution ends
+//	enter(depth i32, typ i32, gas i64)                  call frame entered (typ is the opcode)
+//	exit(depth i32, gasUsed i64, reverted i32)          call frame exited
+//	step(pc i64, op i32, gas i64, cost i64, depth i32)  opcode about to be executed
+//	fault(pc i64, op i32, gas i64, cost i64, depth i32) opcode execution failed
+//	result()                                            tracing done, must call set_result
+//
+// The host functions are imported from the "geth" module. Pointers refer to
+// the guest memory, addresses are 20 bytes and words 32 bytes (big endian).
+// Variable length data is read with (offset, len, ptr) triplets: at most len
+// bytes starting at offset are copied to ptr and the full length of the data
+// is returned, so calling with len 0 queries the size.
+//
+//	config(offset, len, ptr i32) i32            tracer config passed by the user
+//	set_result(ptr, len i32)                    sets the JSON encoded trace result
+//	log(ptr, len i32)                           emits a debug log line
+//	block_number() i64                          number of the traced block
+//	tx_hash(ptr i32)                            hash of the traced transaction
+//
+//	stack_len() i32                             [step, fault] stack depth
+//	stack_peek(idx, ptr i32) i32                [step, fault] item idx from the top, 0 if out of range
+//	memory_read(offset, len, ptr i32) i32       [step, fault] EVM memory
+//	code_read(offset, len, ptr i32) i32         [step, fault] code being executed
+//	input_read(offset, len, ptr i32) i32        [step, fault] call data of the frame
+//	address(ptr i32)                            [step, fault] address of the executing contract
+//	caller(ptr i32)                             [step, fault] caller of the frame
+//	call_value(ptr i32)                         [step, fault] value of the frame
+//
+//	event_from(ptr i32)                         [enter] sender of the call
+//	event_to(ptr i32)                           [enter] recipient of the call
+//	event_value(ptr i32)                        [enter] value transferred
+//	event_data(offset, len, ptr i32) i32        [enter, exit] call input or return data
+//	event_error(offset, len, ptr i32) i32       [exit, fault, tx_end] error message
+//
+//	get_balance(addr, ptr i32)                  balance of an account
+//	get_nonce(addr i32) i64                     nonce of an account
+//	get_code(addr, offset, len, ptr i32) i32    code of an account
+//	get_state(addr, slot, ptr i32)              storage slot of an account
+//	exists(addr i32) i32                        1 if the account exists
+//
+// Accessors used outside of the callbacks they are listed for behave as if the
+// data was empty. Modules compiled from AssemblyScript may additionally import
+// env.abort, which traps the tracer.
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+const (
+	// memoryLimitPages caps the linear memory of a tracer module (64 KiB pages).
+	memoryLimitPages = 1024
+
+	// moduleCacheSize is the number of compiled modules kept around.
+	moduleCacheSize = 32
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("wasmTracer", newWasmTracerFromConfig, true)
+}
+
+// engine holds the shared WebAssembly runtime along with the compiled modules.
+type engine struct {
+	runtime wazero.Runtime
+	lock    sync.Mutex
+	modules lru.BasicLRU[common.Hash, wazero.CompiledModule]
+}
+
+var (
+	engineOnce   sync.Once
+	sharedEngine *engine
+)
+
+// getEngine creates the shared runtime and its host modules if needed.
+func getEngine() *engine {
+	engineOnce.Do(func() {
+		ctx := context.Background()
+		cfg := wazero.NewRuntimeConfig().
+			WithMemoryLimitPages(memoryLimitPages).
+			WithCloseOnContextDone(true)
+		rt := wazero.NewRuntimeWithConfig(ctx, cfg)
+		if err := instantiateHost(ctx, rt); err != nil {
+			panic(fmt.Sprintf("failed to instantiate wasm host modules: %v", err))
+		}
+		sharedEngine = &engine{
+			runtime: rt,
+			modules: lru.NewBasicLRU[common.Hash, wazero.CompiledModule](moduleCacheSize),
+		}
+	})
+	return sharedEngine
+}
+
+// compile returns the compiled form of a tracer module, reusing a cached one
+// if the same code was seen before.
+func (e *engine) compile(code []byte) (wazero.CompiledModule, error) {
+	hash := crypto.Keccak256Hash(code)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if mod, ok := e.modules.Get(hash); ok {
+		return mod, nil
+	}
+	mod, err := e.runtime.CompileModule(context.Background(), code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wasm module: %w", err)
+	}
+	if _, ok := mod.ExportedFunctions()["result"]; !ok {
+		mod.Close(context.Background())
+		return nil, errors.New("wasm module must export a function result()")
+	}
+	if _, ok := mod.ExportedMemories()["memory"]; !ok {
+		mod.Close(context.Background())
+		return nil, errors.New("wasm module must export its memory")
+	}
+	// Instances of evicted modules stay usable after closing the compiled code.
+	if _, evicted, ok := e.modules.Add3(hash, mod); ok {
+		evicted.Close(context.Background())
+	}
+	return mod, nil
+}
+
+// Register makes the given WebAssembly module available as a named tracer in
+// the default directory. The tracer config is handed to the module as is.
+func Register(name string, code []byte) error {
+	if _, err := getEngine().compile(code); err != nil {
+		return err
+	}
+	tracers.DefaultDirectory.Register(name, func(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+		return newWasmTracer(code, ctx, cfg)
+	}, true)
+	return nil
+}
+
+// wasmTracerConfig is the config of the generic wasmTracer, which receives
+// the module to run along with its own configuration.
+type wasmTracerConfig struct {
+	Code   hexutil.Bytes   `json:"code"`
+	Config json.RawMessage `json:"config"`
+}
+
+func newWasmTracerFromConfig(ctx *tracers.Context, cfg json.RawMessage, _ *params.ChainConfig) (*tracers.Tracer, error) {
+	var config wasmTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if len(config.Code) == 0 {
+		return nil, errors.New("wasm tracer requires a module in the code field")
+	}
+	return newWasmTracer(config.Code, ctx, config.Config)
+}
+
+// wasmTracer drives a WebAssembly tracer module from the EVM hooks.
+type wasmTracer struct {
+	ctx    context.Context // Carries the tracer to the host functions
+	cancel context.CancelFunc
+	mod    api.Module
+	mem    api.Memory
+
+	txCtx  *tracers.Context
+	env    *tracing.VMContext
+	config []byte
+
+	setup, txStart, txEnd, enter, exit, step, fault, result api.Function
+
+	// Data of the callback in progress, exposed through the host functions.
+	scope    tracing.OpContext
+	from, to common.Address
+	value    *big.Int
+	data     []byte
+	errMsg   string
+
+	res       []byte
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+type tracerKey struct{}
+
+// tracerFromContext returns the tracer on whose behalf a host function runs.
+func tracerFromContext(ctx context.Context) *wasmTracer {
+	return ctx.Value(tracerKey{}).(*wasmTracer)
+}
+
+func newWasmTracer(code []byte, ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer, error) {
+	t, err := instantiate(code, ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	hooks := &tracing.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnTxEnd:   t.OnTxEnd,
+	}
+	if t.enter != nil {
+		hooks.OnEnter = t.OnEnter
+	}
+	if t.exit != nil {
+		hooks.OnExit = t.OnExit
+	}
+	if t.step != nil {
+		hooks.OnOpcode = t.OnOpcode
+	}
+	if t.fault != nil {
+		hooks.OnFault = t.OnFault
+	}
+	return &tracers.Tracer{
+		Hooks:     hooks,
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+// instantiate creates a tracer running a fresh instance of the given module.
+func instantiate(code []byte, ctx *tracers.Context, cfg json.RawMessage) (*wasmTracer, error) {
+	e := getEngine()
+	compiled, err := e.compile(code)
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = new(tracers.Context)
+	}
+	t := &wasmTracer{
+		txCtx:  ctx,
+		config: cfg,
+	}
+	t.ctx, t.cancel = context.WithCancel(context.WithValue(context.Background(), tracerKey{}, t))
+
+	// Anonymous instances allow the same module to be used concurrently.
+	modcfg := wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize")
+	t.mod, err = e.runtime.InstantiateModule(t.ctx, compiled, modcfg)
+	if err != nil {
+		t.cancel()
+		return nil, fmt.Errorf("failed to instantiate wasm module: %w", err)
+	}
+	// Release the module instance once the tracer is done, regardless if the
+	// result is retrieved or the trace is abandoned via Stop.
+	context.AfterFunc(t.ctx, func() { t.mod.Close(context.Background()) })
+	t.mem = t.mod.ExportedMemory("memory")
+	t.setup = t.mod.ExportedFunction("setup")
+	t.txStart = t.mod.ExportedFunction("tx_start")
+	t.txEnd = t.mod.ExportedFunction("tx_end")
+	t.enter = t.mod.ExportedFunction("enter")
+	t.exit = t.mod.ExportedFunction("exit")
+	t.step = t.mod.ExportedFunction("step")
+	t.fault = t.mod.ExportedFunction("fault")
+	t.result = t.mod.ExportedFunction("result")
+
+	if t.setup != nil {
+		if _, err := t.setup.Call(t.ctx); err != nil {
+			t.close()
+			return nil, fmt.Errorf("wasm tracer setup failed: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// call invokes a guest callback, aborting the trace if it traps.
+func (t *wasmTracer) call(fn api.Function, params ...uint64) {
+	if _, err := fn.Call(t.ctx, params...); err != nil {
+		t.onError(fn.Definition().Name(), err)
+	}
+}
+
+func (t *wasmTracer) onError(context string, err error) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.reason = fmt.Errorf("%v    in server-side tracer function '%v'", err, context)
+	t.interrupt.Store(true)
+}
+
+func (t *wasmTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+	if t.txStart == nil || t.interrupt.Load() {
+		return
+	}
+	t.call(t.txStart)
+}
+
+func (t *wasmTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	if t.txEnd == nil || t.interrupt.Load() {
+		return
+	}
+	var (
+		gasUsed uint64
+		failed  uint64
+	)
+	if receipt != nil {
+		gasUsed = receipt.GasUsed
+	}
+	if err != nil {
+		failed = 1
+		t.errMsg = err.Error()
+	}
+	t.call(t.txEnd, gasUsed, failed)
+	t.errMsg = ""
+}
+
+func (t *wasmTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.from, t.to, t.data, t.value = from, to, input, value
+	t.call(t.enter, uint64(depth), uint64(typ), gas)
+	t.from, t.to, t.data, t.value = common.Address{}, common.Address{}, nil, nil
+}
+
+func (t *wasmTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() {
+		return
+	}
+	var rev uint64
+	if reverted {
+		rev = 1
+	}
+	if err != nil {
+		t.errMsg = err.Error()
+	}
+	t.data = output
+	t.call(t.exit, uint64(depth), gasUsed, rev)
+	t.data, t.errMsg = nil, ""
+}
+
+func (t *wasmTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.scope = scope
+	t.call(t.step, pc, uint64(op), gas, cost, uint64(depth))
+	t.scope = nil
+}
+
+func (t *wasmTracer) OnFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.scope = scope
+	if err != nil {
+		t.errMsg = err.Error()
+	}
+	t.call(t.fault, pc, uint64(op), gas, cost, uint64(depth))
+	t.scope, t.errMsg = nil, ""
+}
+
+// GetResult calls the module's result function and returns the JSON value it
+// reported. The module instance is released afterwards, so the tracer can not
+// be reused.
+func (t *wasmTracer) GetResult() (json.RawMessage, error) {
+	defer t.close()
+
+	if t.reason != nil {
+		return nil, t.reason
+	}
+	if _, err := t.result.Call(t.ctx); err != nil {
+		return nil, fmt.Errorf("%v    in server-side tracer function 'result'", err)
+	}
+	if t.res == nil {
+		return nil, errors.New("wasm tracer did not set a result")
+	}
+	if !json.Valid(t.res) {
+		return nil, errors.New("wasm tracer result is not valid JSON")
+	}
+	return t.res, nil
+}
+
+// Stop terminates execution of the tracer at the first opportune moment,
+// including aborting a guest function which is currently running. The module
+// instance is released as well.
+func (t *wasmTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+	t.cancel()
+}
+
+// close releases the module instance.
+func (t *wasmTracer) close() {
+	t.cancel()
+}
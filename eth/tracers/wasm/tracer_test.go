@@ -0,0 +1,230 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+// section encodes a wasm module section.
+func section(id byte, items ...[]byte) []byte {
+	var body []byte
+	body = append(body, byte(len(items)))
+	for _, item := range items {
+		body = append(body, item...)
+	}
+	return append(append([]byte{id}, uleb(len(body))...), body...)
+}
+
+// uleb encodes an unsigned LEB128 integer.
+func uleb(n int) []byte {
+	var out []byte
+	for n >= 0x80 {
+		out = append(out, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(out, byte(n))
+}
+
+// name encodes a wasm name.
+func name(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// body encodes a function body with the given local declarations.
+func body(locals []byte, code ...byte) []byte {
+	b := append(locals, code...)
+	return append(uleb(len(b)), b...)
+}
+
+// counterModule returns a tracer module equivalent to the following WAT. It
+// counts steps and call frames and sums up the low byte of the stack top at
+// every step, reporting [steps, sum, frames].
+//
+//	(import "geth" "set_result" (func $set_result (param i32 i32)))
+//	(import "geth" "stack_len" (func $stack_len (result i32)))
+//	(import "geth" "stack_peek" (func $stack_peek (param i32 i32) (result i32)))
+//	(memory (export "memory") 1)
+//	(global $steps (mut i32)) (global $sum (mut i32)) (global $frames (mut i32))
+//	(func (export "step") (param i64 i32 i64 i64 i32) ...)
+//	(func (export "result") ...)
+//	(func $itoa (param $n i32) (param $p i32) (result i32) ...)
+//	(func (export "enter") (param i32 i32 i64) ...)
+func counterModule() []byte {
+	const (
+		i32 = 0x7f
+		i64 = 0x7e
+	)
+	var (
+		fn = func(params, results []byte) []byte {
+			return append(append(append([]byte{0x60, byte(len(params))}, params...), byte(len(results))), results...)
+		}
+		imp  = func(field string, typ byte) []byte { return append(append(name("geth"), name(field)...), 0x00, typ) }
+		exp  = func(field string, kind, idx byte) []byte { return append(name(field), kind, idx) }
+		glob = []byte{i32, 0x01, 0x41, 0x00, 0x0b}
+		// push writes the byte c in front of the string built backwards at $p
+		push = func(c ...byte) []byte {
+			return append(append([]byte{0x20, 0x00, 0x41, 0x01, 0x6b, 0x22, 0x00, 0x41}, c...), 0x3a, 0x00, 0x00)
+		}
+		// number prepends the decimal value of global g to the string at $p
+		number = func(g byte) []byte { return []byte{0x23, g, 0x20, 0x00, 0x10, 0x05, 0x21, 0x00} }
+	)
+	result := []byte{0x41, 0xc8, 0x01, 0x21, 0x00} // $p = 200
+	result = append(result, push(0xdd, 0x00)...)   // ']'
+	result = append(result, number(2)...)
+	result = append(result, push(0x2c)...) // ','
+	result = append(result, number(1)...)
+	result = append(result, push(0x2c)...) // ','
+	result = append(result, number(0)...)
+	result = append(result, push(0xdb, 0x00)...)                                        // '['
+	result = append(result, 0x20, 0x00, 0x41, 0xc8, 0x01, 0x20, 0x00, 0x6b, 0x10, 0x00) // set_result($p, 200-$p)
+	result = append(result, 0x0b)
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1,
+		fn([]byte{i32, i32}, nil),                // 0: set_result
+		fn(nil, []byte{i32}),                     // 1: stack_len
+		fn([]byte{i32, i32}, []byte{i32}),        // 2: stack_peek, itoa
+		fn([]byte{i64, i32, i64, i64, i32}, nil), // 3: step
+		fn(nil, nil),                             // 4: result
+		fn([]byte{i32, i32, i64}, nil),           // 5: enter
+	)...)
+	module = append(module, section(2, imp("set_result", 0), imp("stack_len", 1), imp("stack_peek", 2))...)
+	module = append(module, section(3, []byte{3}, []byte{4}, []byte{2}, []byte{5})...)
+	module = append(module, section(5, []byte{0x00, 0x01})...)
+	module = append(module, section(6, glob, glob, glob)...)
+	module = append(module, section(7, exp("memory", 0x02, 0), exp("step", 0x00, 3), exp("result", 0x00, 4), exp("enter", 0x00, 6))...)
+	module = append(module, section(10,
+		// step: $steps++; if stack_len() { stack_peek(0, 1024); $sum += mem[1055] }
+		body([]byte{0x00},
+			0x23, 0x00, 0x41, 0x01, 0x6a, 0x24, 0x00,
+			0x10, 0x01, 0x04, 0x40,
+			0x41, 0x00, 0x41, 0x80, 0x08, 0x10, 0x02, 0x1a,
+			0x23, 0x01, 0x41, 0x9f, 0x08, 0x2d, 0x00, 0x00, 0x6a, 0x24, 0x01,
+			0x0b, 0x0b),
+		// result: build the JSON array backwards from offset 200
+		body([]byte{0x01, 0x01, i32}, result...),
+		// itoa: do { $p--; mem[$p] = '0' + $n % 10; $n /= 10 } while ($n); return $p
+		body([]byte{0x00},
+			0x03, 0x40,
+			0x20, 0x01, 0x41, 0x01, 0x6b, 0x21, 0x01,
+			0x20, 0x01, 0x20, 0x00, 0x41, 0x0a, 0x70, 0x41, 0x30, 0x6a, 0x3a, 0x00, 0x00,
+			0x20, 0x00, 0x41, 0x0a, 0x6e, 0x22, 0x00, 0x0d, 0x00,
+			0x0b, 0x20, 0x01, 0x0b),
+		// enter: $frames++
+		body([]byte{0x00}, 0x23, 0x02, 0x41, 0x01, 0x6a, 0x24, 0x02, 0x0b),
+	)...)
+	return module
+}
+
+func runTracer(t *testing.T, tracer *tracers.Tracer, code []byte) {
+	t.Helper()
+
+	address := common.HexToAddress("0xaa")
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.SetCode(address, code, tracing.CodeChangeUnspecified)
+
+	_, _, err := runtime.Call(address, nil, &runtime.Config{
+		State:     statedb,
+		GasLimit:  1000000,
+		EVMConfig: vm.Config{Tracer: tracer.Hooks},
+	})
+	require.NoError(t, err)
+}
+
+func TestWasmTracer(t *testing.T) {
+	cfg, _ := json.Marshal(map[string]interface{}{"code": hexutil.Bytes(counterModule())})
+	tracer, err := tracers.DefaultDirectory.New("wasmTracer", new(tracers.Context), cfg, params.MainnetChainConfig)
+	require.NoError(t, err)
+
+	runTracer(t, tracer, []byte{byte(vm.PUSH1), 3, byte(vm.PUSH1), 4, byte(vm.ADD), byte(vm.STOP)})
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	// Stack tops seen by the four steps are none, 3, 4 and 7.
+	require.JSONEq(t, `[4,14,1]`, string(res))
+}
+
+func TestWasmTracerRegister(t *testing.T) {
+	require.NoError(t, Register("testCounterTracer", counterModule()))
+	require.True(t, tracers.DefaultDirectory.IsJS("testCounterTracer"))
+
+	// Instances of the same module do not share any state.
+	for i := 0; i < 2; i++ {
+		tracer, err := tracers.DefaultDirectory.New("testCounterTracer", new(tracers.Context), nil, params.MainnetChainConfig)
+		require.NoError(t, err)
+		runTracer(t, tracer, []byte{byte(vm.PUSH1), 1, byte(vm.STOP)})
+
+		res, err := tracer.GetResult()
+		require.NoError(t, err)
+		require.JSONEq(t, `[2,1,1]`, string(res))
+	}
+}
+
+func TestWasmTracerStop(t *testing.T) {
+	tracer, err := newWasmTracer(counterModule(), nil, nil)
+	require.NoError(t, err)
+
+	stop := errors.New("stop err")
+	tracer.Stop(stop)
+	runTracer(t, tracer, []byte{byte(vm.PUSH1), 1, byte(vm.STOP)})
+
+	_, err = tracer.GetResult()
+	require.ErrorIs(t, err, stop)
+}
+
+func TestWasmTracerRelease(t *testing.T) {
+	// The module instance is released if the trace is abandoned without
+	// retrieving the result.
+	tracer, err := instantiate(counterModule(), nil, nil)
+	require.NoError(t, err)
+	tracer.Stop(errors.New("tracing failed"))
+	require.Eventually(t, tracer.mod.IsClosed, time.Second, time.Millisecond)
+
+	// The module instance is released after retrieving the result.
+	tracer, err = instantiate(counterModule(), nil, nil)
+	require.NoError(t, err)
+	_, err = tracer.GetResult()
+	require.NoError(t, err)
+	require.Eventually(t, tracer.mod.IsClosed, time.Second, time.Millisecond)
+}
+
+func TestWasmTracerInvalid(t *testing.T) {
+	_, err := newWasmTracer([]byte{0x00, 0x61, 0x73, 0x6d}, nil, nil)
+	require.ErrorContains(t, err, "invalid wasm module")
+
+	// A module without any exports
+	_, err = newWasmTracer([]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}, nil, nil)
+	require.ErrorContains(t, err, "must export a function result()")
+
+	_, err = tracers.DefaultDirectory.New("wasmTracer", new(tracers.Context), json.RawMessage(`{}`), params.MainnetChainConfig)
+	require.ErrorContains(t, err, "requires a module")
+}
@@ -18,9 +18,11 @@ package simulated
 
 import (
 	"math/big"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // WithBlockGasLimit configures the simulated backend to target a specific gas limit
@@ -53,3 +55,13 @@ func WithMinerMinTip(tip *big.Int) func(nodeConf *node.Config, ethConf *ethconfi
 		ethConf.Miner.GasPrice = tip
 	}
 }
+
+// WithPrecompiles configures the simulated backend to install custom precompiled
+// contracts, implemented by modules registered via vm.RegisterPrecompile.
+func WithPrecompiles(precompiles ...*params.PrecompileConfig) func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+	return func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+		config := *ethConf.Genesis.Config
+		config.Precompiles = append(slices.Clone(config.Precompiles), precompiles...)
+		ethConf.Genesis.Config = &config
+	}
+}
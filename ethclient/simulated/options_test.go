@@ -17,14 +17,18 @@
 package simulated
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"math/big"
 	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -72,3 +76,43 @@ func TestWithCallGasLimitOption(t *testing.T) {
 		t.Fatalf("error mismatch: have %v, want %v", err, core.ErrIntrinsicGas)
 	}
 }
+
+// callerPrecompile is a stateful precompile returning its caller.
+type callerPrecompile struct{}
+
+func (p *callerPrecompile) RequiredGas(input []byte) uint64 { return 0 }
+func (p *callerPrecompile) Name() string                    { return "CALLER" }
+
+func (p *callerPrecompile) Run(ctx *vm.PrecompileContext, input []byte) ([]byte, error) {
+	return common.LeftPadBytes(ctx.Caller().Bytes(), 32), nil
+}
+
+func init() {
+	vm.RegisterPrecompile("simulated-caller", func(config json.RawMessage) (vm.StatefulPrecompiledContract, error) {
+		return new(callerPrecompile), nil
+	})
+}
+
+// Tests that the simulator installs the custom precompiles set by the options.
+func TestWithPrecompilesOption(t *testing.T) {
+	var (
+		addr = common.HexToAddress("0x0300000000000000000000000000000000000001")
+		time = uint64(0)
+	)
+	sim := NewBackend(types.GenesisAlloc{
+		testAddr: {Balance: big.NewInt(10000000000000000)},
+	}, WithPrecompiles(&params.PrecompileConfig{Module: "simulated-caller", Address: addr, Time: &time}))
+	defer sim.Close()
+
+	client := sim.Client()
+	ret, err := client.CallContract(context.Background(), ethereum.CallMsg{
+		From: testAddr,
+		To:   &addr,
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to call precompile: %v", err)
+	}
+	if !bytes.Equal(ret, common.LeftPadBytes(testAddr.Bytes(), 32)) {
+		t.Fatalf("return mismatch: have %x, want %x", ret, testAddr)
+	}
+}
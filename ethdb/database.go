@@ -141,6 +141,15 @@ type AncientReader interface {
 	ReadAncients(fn func(AncientReaderOp) error) (err error)
 }
 
+// EraReader is an optional interface implemented by ancient stores which are
+// backed by era1 archives.
+type EraReader interface {
+	// EraAvailable returns the number of blocks, counting from genesis, whose bodies
+	// and receipts can be retrieved from era1 archives backing the ancient store,
+	// even if they have been pruned from the store itself.
+	EraAvailable() uint64
+}
+
 // AncientWriter contains the methods required to write to immutable ancient data.
 type AncientWriter interface {
 	// ModifyAncients runs a write operation on the ancient store.
@@ -211,6 +211,10 @@ type Config struct {
 	EnablePersonal bool `toml:"-"`
 
 	DBEngine string `toml:",omitempty"`
+
+	// AncientEngine is the engine of the chain ancient store ("file" or "pebble").
+	// If empty, the engine of the existing ancient store is used.
+	AncientEngine string `toml:",omitempty"`
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
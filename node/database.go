@@ -41,9 +41,23 @@ type DatabaseOptions struct {
 	ReadOnly         bool   // if true, no writes can be performed
 }
 
+// The resource allowance of the pebble instance backing the chain ancients, the
+// ancient data is rarely accessed and only appended in large batches.
+const (
+	ancientCache   = 16 // megabytes
+	ancientHandles = 64
+)
+
+// OpenAncientStore opens the pebble instance backing the chain ancients at the
+// given path, with the resource allowance of the node.
+func OpenAncientStore(path string, namespace string, readonly bool) (ethdb.KeyValueStore, error) {
+	return newPebbleDBDatabase(path, ancientCache, ancientHandles, namespace, readonly)
+}
+
 type internalOpenOptions struct {
-	directory string
-	dbEngine  string // "leveldb" | "pebble"
+	directory     string
+	dbEngine      string // "leveldb" | "pebble"
+	ancientEngine string // "file" | "pebble"
 	DatabaseOptions
 }
 
@@ -62,6 +76,11 @@ func openDatabase(o internalOpenOptions) (ethdb.Database, error) {
 		Era:              o.EraDirectory,
 		MetricsNamespace: o.MetricsNamespace,
 		ReadOnly:         o.ReadOnly,
+		AncientEngine:    o.ancientEngine,
+		OpenAncientStore: func(path string) (ethdb.KeyValueStore, error) {
+			log.Info("Using pebble as the backing ancient database", "path", path)
+			return OpenAncientStore(path, o.MetricsNamespace+"ancient/", o.ReadOnly)
+		},
 	}
 	frdb, err := rawdb.Open(kvdb, opts)
 	if err != nil {
@@ -717,6 +717,7 @@ func (n *Node) OpenDatabaseWithOptions(name string, opt DatabaseOptions) (ethdb.
 		db, err = openDatabase(internalOpenOptions{
 			directory:       n.ResolvePath(name),
 			dbEngine:        n.config.DBEngine,
+			ancientEngine:   n.config.AncientEngine,
 			DatabaseOptions: opt,
 		})
 	}
@@ -784,6 +785,15 @@ func (db *closeTrackingDB) Close() error {
 	return db.Database.Close()
 }
 
+// EraAvailable forwards the request to the wrapped database, if it is backed
+// by era1 archives.
+func (db *closeTrackingDB) EraAvailable() uint64 {
+	if era, ok := db.Database.(ethdb.EraReader); ok {
+		return era.EraAvailable()
+	}
+	return 0
+}
+
 // wrapDatabase ensures the database will be auto-closed when Node is closed.
 func (n *Node) wrapDatabase(db ethdb.Database) ethdb.Database {
 	wrapper := &closeTrackingDB{db, n}
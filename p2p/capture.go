@@ -0,0 +1,279 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Capture files contain the decrypted message stream of a single peer session.
+// The file starts with captureMagic, followed by the RLP encoded CaptureHeader
+// and a sequence of RLP encoded CaptureRecords, one per message.
+const (
+	captureMagic   = "rlpxcap"
+	captureVersion = 1
+
+	// maxCaptureRecordSize is the size limit of records accepted by the reader.
+	maxCaptureRecordSize = 64 * 1024 * 1024
+)
+
+// maxCaptureFileSize is the size limit of a single capture file. Recording of
+// the session stops when the next record would exceed it.
+var maxCaptureFileSize int64 = 256 * 1024 * 1024
+
+var errCaptureMagic = errors.New("not a capture file")
+
+// CaptureHeader describes the session recorded in a capture file.
+type CaptureHeader struct {
+	Version   uint
+	Time      uint64 // start of the session, unix time in nanoseconds
+	Local     enode.ID
+	Remote    enode.ID
+	Name      string // client name of the remote node
+	Inbound   bool   // whether the connection was initiated by the remote node
+	Protocols []CaptureProtocol
+}
+
+// CaptureProtocol is a subprotocol negotiated in a recorded session.
+type CaptureProtocol struct {
+	Name    string
+	Version uint
+	Length  uint64 // number of message codes used by the protocol
+}
+
+// CaptureRecord is a single message recorded in a capture file.
+type CaptureRecord struct {
+	Time     uint64 // unix time in nanoseconds
+	Inbound  bool   // whether the message was received from the remote node
+	Protocol string // empty for base protocol messages
+	Version  uint
+	Code     uint64 // message code, relative to the protocol
+	Payload  []byte
+}
+
+// Timestamp returns the time at which the message was sent or received.
+func (r *CaptureRecord) Timestamp() time.Time {
+	return time.Unix(0, int64(r.Time))
+}
+
+// CaptureWriter writes a capture file.
+type CaptureWriter struct {
+	w io.Writer
+}
+
+// NewCaptureWriter writes the file header to w and returns a writer for the
+// records of the session.
+func NewCaptureWriter(w io.Writer, header CaptureHeader) (*CaptureWriter, error) {
+	header.Version = captureVersion
+	if _, err := io.WriteString(w, captureMagic); err != nil {
+		return nil, err
+	}
+	if err := rlp.Encode(w, &header); err != nil {
+		return nil, err
+	}
+	return &CaptureWriter{w: w}, nil
+}
+
+// Write appends a record to the capture file.
+func (cw *CaptureWriter) Write(rec *CaptureRecord) error {
+	return rlp.Encode(cw.w, rec)
+}
+
+// CaptureReader reads a capture file.
+type CaptureReader struct {
+	Header CaptureHeader
+	stream *rlp.Stream
+}
+
+// NewCaptureReader reads the file header from r and returns a reader for the
+// records of the session.
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(captureMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != captureMagic {
+		return nil, errCaptureMagic
+	}
+	cr := &CaptureReader{stream: rlp.NewStream(br, maxCaptureRecordSize)}
+	if err := cr.stream.Decode(&cr.Header); err != nil {
+		return nil, fmt.Errorf("invalid capture header: %v", err)
+	}
+	if cr.Header.Version != captureVersion {
+		return nil, fmt.Errorf("unsupported capture file version %d", cr.Header.Version)
+	}
+	return cr, nil
+}
+
+// Next reads the next record. It returns io.EOF at the end of the file.
+func (cr *CaptureReader) Next() (*CaptureRecord, error) {
+	rec := new(CaptureRecord)
+	if err := cr.stream.Decode(rec); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("invalid capture record: %v", err)
+	}
+	return rec, nil
+}
+
+// captureTransport wraps the transport of a peer, recording all messages sent
+// and received after the protocol handshake.
+type captureTransport struct {
+	transport
+	file      *os.File
+	protocols []*protoRW // running protocols, sorted by offset
+
+	mu      sync.Mutex
+	buf     *bufio.Writer
+	cnt     *captureCounter // counts the bytes written into buf
+	stopped bool            // set after a write failure or when the size limit is hit
+}
+
+// captureCounter tracks the number of bytes written to a capture file.
+type captureCounter struct {
+	w    io.Writer
+	size int64
+}
+
+func (c *captureCounter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.size += int64(n)
+	return n, err
+}
+
+// startCapture creates a capture file for the peer in the given directory and
+// hooks the capturing transport into its connection.
+func startCapture(dir string, local enode.ID, p *Peer) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	var (
+		now  = time.Now()
+		id   = p.ID()
+		name = fmt.Sprintf("%x-%s.rlpxcap", id[:8], now.UTC().Format("20060102T150405.000"))
+	)
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	t := &captureTransport{transport: p.rw.transport, file: file, buf: bufio.NewWriter(file)}
+	t.cnt = &captureCounter{w: t.buf}
+	header := CaptureHeader{
+		Time:    uint64(now.UnixNano()),
+		Local:   local,
+		Remote:  id,
+		Name:    p.rw.name,
+		Inbound: p.Inbound(),
+	}
+	for _, proto := range p.running {
+		t.protocols = append(t.protocols, proto)
+	}
+	slices.SortFunc(t.protocols, func(a, b *protoRW) int { return int(a.offset) - int(b.offset) })
+	for _, proto := range t.protocols {
+		header.Protocols = append(header.Protocols, CaptureProtocol{Name: proto.Name, Version: proto.Version, Length: proto.Length})
+	}
+	if _, err = NewCaptureWriter(t.cnt, header); err != nil {
+		file.Close()
+		return err
+	}
+	p.rw.transport = t
+	p.log.Debug("Capturing peer session", "file", file.Name())
+	return nil
+}
+
+func (t *captureTransport) ReadMsg() (Msg, error) {
+	msg, err := t.transport.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	return t.record(true, msg)
+}
+
+func (t *captureTransport) WriteMsg(msg Msg) error {
+	msg, err := t.record(false, msg)
+	if err != nil {
+		return err
+	}
+	return t.transport.WriteMsg(msg)
+}
+
+func (t *captureTransport) close(err error) {
+	t.transport.close(err)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	if err := t.buf.Flush(); err != nil {
+		log.Warn("Failed to flush capture file", "file", t.file.Name(), "err", err)
+	}
+	t.file.Close()
+}
+
+// record writes the message into the capture file. The payload of the message
+// is consumed, so a copy of the message with a fresh payload is returned. Writes
+// are buffered, the file is flushed when the connection is closed.
+func (t *captureTransport) record(inbound bool, msg Msg) (Msg, error) {
+	payload := make([]byte, msg.Size)
+	if _, err := io.ReadFull(msg.Payload, payload); err != nil {
+		return msg, err
+	}
+	msg.Payload = bytes.NewReader(payload)
+
+	rec := &CaptureRecord{
+		Time:    uint64(time.Now().UnixNano()),
+		Inbound: inbound,
+		Code:    msg.Code,
+		Payload: payload,
+	}
+	for _, proto := range t.protocols {
+		if msg.Code >= proto.offset && msg.Code < proto.offset+proto.Length {
+			rec.Protocol, rec.Version, rec.Code = proto.Name, proto.Version, msg.Code-proto.offset
+			break
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return msg, nil
+	}
+	enc, err := rlp.EncodeToBytes(rec)
+	if err == nil {
+		if t.cnt.size+int64(len(enc)) > maxCaptureFileSize {
+			log.Warn("Capture file size limit reached, stopping capture", "file", t.file.Name(), "size", t.cnt.size)
+			t.stopped = true
+			return msg, nil
+		}
+		_, err = t.cnt.Write(enc)
+	}
+	if err != nil {
+		log.Warn("Failed to write capture record, stopping capture", "file", t.file.Name(), "err", err)
+		t.stopped = true
+	}
+	return msg, nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestCaptureFileRoundtrip(t *testing.T) {
+	header := CaptureHeader{
+		Time:      1,
+		Local:     uintID(1),
+		Remote:    uintID(2),
+		Name:      "test",
+		Protocols: []CaptureProtocol{{Name: "a", Version: 1, Length: 5}},
+	}
+	records := []*CaptureRecord{
+		{Time: 2, Inbound: true, Protocol: "a", Version: 1, Code: 3, Payload: []byte{0xc0}},
+		{Time: 3, Code: pingMsg, Payload: []byte{0xc0}},
+	}
+	var buf bytes.Buffer
+	w, err := NewCaptureWriter(&buf, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewCaptureReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.Version = captureVersion
+	if !reflect.DeepEqual(r.Header, header) {
+		t.Fatalf("wrong header: have %+v, want %+v", r.Header, header)
+	}
+	for i, want := range records {
+		rec, err := r.Next()
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(rec, want) {
+			t.Fatalf("record %d mismatch: have %+v, want %+v", i, rec, want)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected EOF after last record, got %v", err)
+	}
+
+	if _, err := NewCaptureReader(bytes.NewReader([]byte("garbage"))); err != errCaptureMagic {
+		t.Fatalf("wrong error for invalid file: %v", err)
+	}
+}
+
+func TestCapturePeerSession(t *testing.T) {
+	proto := Protocol{
+		Name:    "a",
+		Version: 1,
+		Length:  5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			if err := ExpectMsg(rw, 2, []uint{1}); err != nil {
+				t.Error(err)
+			}
+			return SendItems(rw, 3, "foo")
+		},
+	}
+	var (
+		dir        = t.TempDir()
+		fd1, fd2   = net.Pipe()
+		key1, key2 = newkey(), newkey()
+		c1         = &conn{fd: fd1, node: newNode(uintID(1), ""), transport: newTestTransport(&key2.PublicKey, fd1, nil), caps: []Cap{proto.cap()}}
+		c2         = &conn{fd: fd2, node: newNode(uintID(2), ""), transport: newTestTransport(&key1.PublicKey, fd2, &key1.PublicKey)}
+	)
+	defer c2.close(errors.New("test done"))
+
+	peer := newPeer(log.Root(), c1, []Protocol{proto})
+	if err := startCapture(dir, uintID(2), peer); err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := peer.run()
+		errc <- err
+	}()
+	if err := Send(c2, baseProtocolLength+2, []uint{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpectMsg(c2, baseProtocolLength+3, []string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-errc:
+	case <-time.After(2 * time.Second):
+		t.Fatal("peer did not stop")
+	}
+
+	// Check the capture file.
+	files, _ := filepath.Glob(filepath.Join(dir, "*.rlpxcap"))
+	if len(files) != 1 {
+		t.Fatalf("expected one capture file, found %d", len(files))
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := NewCaptureReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Header.Remote != uintID(1) || r.Header.Local != uintID(2) {
+		t.Errorf("wrong node IDs in header: local %v, remote %v", r.Header.Local, r.Header.Remote)
+	}
+	wantProtos := []CaptureProtocol{{Name: "a", Version: 1, Length: 5}}
+	if !reflect.DeepEqual(r.Header.Protocols, wantProtos) {
+		t.Errorf("wrong protocols in header: %+v", r.Header.Protocols)
+	}
+	inPayload, _ := rlp.EncodeToBytes([]uint{1})
+	outPayload, _ := rlp.EncodeToBytes([]string{"foo"})
+	want := []CaptureRecord{
+		{Inbound: true, Protocol: "a", Version: 1, Code: 2, Payload: inPayload},
+		{Inbound: false, Protocol: "a", Version: 1, Code: 3, Payload: outPayload},
+	}
+	for i := range want {
+		rec, err := r.Next()
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		rec.Time = 0
+		if !reflect.DeepEqual(*rec, want[i]) {
+			t.Fatalf("record %d mismatch: have %+v, want %+v", i, rec, want[i])
+		}
+	}
+}
+
+func TestCaptureFileSizeLimit(t *testing.T) {
+	defer func(old int64) { maxCaptureFileSize = old }(maxCaptureFileSize)
+	maxCaptureFileSize = 512
+
+	const messages = 10
+	payload := make([]byte, 100)
+	proto := Protocol{
+		Name:    "a",
+		Version: 1,
+		Length:  5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			for range messages {
+				if err := SendItems(rw, 3, payload); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	var (
+		dir        = t.TempDir()
+		fd1, fd2   = net.Pipe()
+		key1, key2 = newkey(), newkey()
+		c1         = &conn{fd: fd1, node: newNode(uintID(1), ""), transport: newTestTransport(&key2.PublicKey, fd1, nil), caps: []Cap{proto.cap()}}
+		c2         = &conn{fd: fd2, node: newNode(uintID(2), ""), transport: newTestTransport(&key1.PublicKey, fd2, &key1.PublicKey)}
+	)
+	defer c2.close(errors.New("test done"))
+
+	peer := newPeer(log.Root(), c1, []Protocol{proto})
+	if err := startCapture(dir, uintID(2), peer); err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := peer.run()
+		errc <- err
+	}()
+	for i := range messages {
+		if err := ExpectMsg(c2, baseProtocolLength+3, []any{payload}); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+	}
+	select {
+	case <-errc:
+	case <-time.After(2 * time.Second):
+		t.Fatal("peer did not stop")
+	}
+
+	// The capture file must stay within the limit, holding only the records
+	// written before it was reached.
+	files, _ := filepath.Glob(filepath.Join(dir, "*.rlpxcap"))
+	if len(files) != 1 {
+		t.Fatalf("expected one capture file, found %d", len(files))
+	}
+	if stat, err := os.Stat(files[0]); err != nil {
+		t.Fatal(err)
+	} else if stat.Size() > maxCaptureFileSize {
+		t.Fatalf("capture file too large: %d bytes", stat.Size())
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := NewCaptureReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	for ; ; count++ {
+		if _, err := r.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("record %d: %v", count, err)
+		}
+	}
+	if count == 0 || count >= messages {
+		t.Fatalf("wrong number of records: %d", count)
+	}
+}
@@ -105,6 +105,11 @@ type Config struct {
 	// for TCP and DiscAddr for the UDP discovery protocol.
 	DiscAddr string
 
+	// If QUICListenAddr is set, the server also accepts connections over the
+	// experimental QUIC transport on this UDP address. The port is announced in
+	// the local node record, and QUIC is used when dialing nodes announcing it.
+	QUICListenAddr string `toml:",omitempty"`
+
 	// If set to a non-nil value, the given NAT port mapper
 	// is used to make the listening port available to the
 	// Internet.
@@ -114,6 +119,11 @@ type Config struct {
 	// is used to dial outbound peer connections.
 	Dialer NodeDialer `toml:"-"`
 
+	// Membership restricts connections to the members of a permissioned network.
+	// If set, nodes which are not members are neither dialed nor accepted, and
+	// peers are dropped when they are removed from the member set.
+	Membership Membership `toml:"-"`
+
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
@@ -121,6 +131,12 @@ type Config struct {
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
 
+	// If CaptureDir is set, the decrypted message stream of every peer session
+	// is recorded into a capture file in this directory. Capture files can be
+	// inspected and replayed using the devp2p tool. Recording of a session stops
+	// once its capture file reaches 256MB.
+	CaptureDir string `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:"-"`
 
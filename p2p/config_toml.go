@@ -33,10 +33,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Protocols        []Protocol       `toml:"-" json:"-"`
 		ListenAddr       string
 		DiscAddr         string
+		QUICListenAddr   string        `toml:",omitempty"`
 		NAT              nat.Interface `toml:",omitempty"`
 		Dialer           NodeDialer    `toml:"-"`
+		Membership       Membership    `toml:"-"`
 		NoDial           bool          `toml:",omitempty"`
 		EnableMsgEvents  bool
+		CaptureDir       string     `toml:",omitempty"`
 		Logger           log.Logger `toml:"-"`
 	}
 	var enc Config
@@ -57,10 +60,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Protocols = c.Protocols
 	enc.ListenAddr = c.ListenAddr
 	enc.DiscAddr = c.DiscAddr
+	enc.QUICListenAddr = c.QUICListenAddr
 	enc.NAT = c.NAT
 	enc.Dialer = c.Dialer
+	enc.Membership = c.Membership
 	enc.NoDial = c.NoDial
 	enc.EnableMsgEvents = c.EnableMsgEvents
+	enc.CaptureDir = c.CaptureDir
 	enc.Logger = c.Logger
 	return &enc, nil
 }
@@ -85,10 +91,13 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Protocols        []Protocol       `toml:"-" json:"-"`
 		ListenAddr       *string
 		DiscAddr         *string
+		QUICListenAddr   *string    `toml:",omitempty"`
 		NAT              *configNAT `toml:",omitempty"`
 		Dialer           NodeDialer `toml:"-"`
+		Membership       Membership `toml:"-"`
 		NoDial           *bool      `toml:",omitempty"`
 		EnableMsgEvents  *bool
+		CaptureDir       *string    `toml:",omitempty"`
 		Logger           log.Logger `toml:"-"`
 	}
 	var dec Config
@@ -146,18 +155,27 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DiscAddr != nil {
 		c.DiscAddr = *dec.DiscAddr
 	}
+	if dec.QUICListenAddr != nil {
+		c.QUICListenAddr = *dec.QUICListenAddr
+	}
 	if dec.NAT != nil {
 		c.NAT = dec.NAT
 	}
 	if dec.Dialer != nil {
 		c.Dialer = dec.Dialer
 	}
+	if dec.Membership != nil {
+		c.Membership = dec.Membership
+	}
 	if dec.NoDial != nil {
 		c.NoDial = *dec.NoDial
 	}
 	if dec.EnableMsgEvents != nil {
 		c.EnableMsgEvents = *dec.EnableMsgEvents
 	}
+	if dec.CaptureDir != nil {
+		c.CaptureDir = *dec.CaptureDir
+	}
 	if dec.Logger != nil {
 		c.Logger = dec.Logger
 	}
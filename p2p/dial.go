@@ -49,6 +49,11 @@ const (
 	// Endpoint resolution is throttled with bounded backoff.
 	initialResolveDelay = 60 * time.Second
 	maxResolveDelay     = time.Hour
+
+	// Dynamic dial candidates with a negative reputation score are skipped
+	// with a probability proportional to their score, reaching certainty at
+	// dialSkipReputation.
+	dialSkipReputation = 20
 )
 
 // NodeDialer is used to connect to nodes in the network, typically by using
@@ -78,6 +83,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNetRestrict      = errors.New("not contained in netrestrict list")
+	errNotMember        = errors.New("not a network member")
 	errNoPort           = errors.New("node does not provide TCP port")
 	errNoResolvedIP     = errors.New("node does not provide a resolved IP")
 )
@@ -139,6 +145,9 @@ type dialConfig struct {
 	log            log.Logger
 	clock          mclock.Clock
 	rand           *mrand.Rand
+	reputation     *reputation // optional, biases dynamic dials towards useful nodes
+	quic           bool        // whether nodes announcing only a QUIC endpoint can be dialed
+	membership     Membership  // permitted nodes, nil if all nodes are permitted
 }
 
 func (cfg dialConfig) withDefaults() dialConfig {
@@ -246,6 +255,8 @@ loop:
 		case node := <-nodesCh:
 			if err := d.checkDial(node); err != nil {
 				d.log.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IPAddr(), "reason", err)
+			} else if d.skipLowReputation(node) {
+				d.log.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IPAddr(), "reason", "low reputation")
 			} else {
 				d.startDial(newDialTask(node, dynDialedConn))
 			}
@@ -382,7 +393,9 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 		// This check can trigger if a non-TCP node is found
 		// by discovery. If there is no IP, the node is a static
 		// node and the actual endpoint will be resolved later in dialTask.
-		return errNoPort
+		if _, ok := quicEndpoint(n); !ok || !d.quic {
+			return errNoPort
+		}
 	}
 	if _, ok := d.dialing[n.ID()]; ok {
 		return errAlreadyDialing
@@ -393,12 +406,29 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if d.netRestrict != nil && !d.netRestrict.ContainsAddr(n.IPAddr()) {
 		return errNetRestrict
 	}
+	if d.membership != nil && !d.membership.IsMember(n.ID()) {
+		return errNotMember
+	}
 	if d.history.contains(string(n.ID().Bytes())) {
 		return errRecentlyDialed
 	}
 	return nil
 }
 
+// skipLowReputation reports whether a dynamic dial candidate should be skipped
+// because of its negative reputation score. The candidates are skipped randomly,
+// so that nodes are still retried occasionally while their score decays.
+func (d *dialScheduler) skipLowReputation(n *enode.Node) bool {
+	if d.reputation == nil {
+		return false
+	}
+	score := d.reputation.score(n.ID())
+	if score >= 0 {
+		return false
+	}
+	return d.rand.Float64() < -score/dialSkipReputation
+}
+
 // startStaticDials starts n static dial tasks.
 func (d *dialScheduler) startStaticDials(n int) (started int) {
 	for started = 0; started < n && len(d.staticPool) > 0; started++ {
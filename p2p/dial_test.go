@@ -153,6 +153,36 @@ func TestDialSchedNetRestrict(t *testing.T) {
 	})
 }
 
+// This test checks that candidates which are not network members are not dialed.
+func TestDialSchedMembership(t *testing.T) {
+	t.Parallel()
+
+	nodes := []*enode.Node{
+		newNode(uintID(0x01), "127.0.0.1:30303"),
+		newNode(uintID(0x02), "127.0.0.2:30303"),
+		newNode(uintID(0x03), "127.0.0.3:30303"),
+		newNode(uintID(0x04), "127.0.0.4:30303"),
+	}
+	members := &testMembership{members: map[enode.ID]bool{
+		nodes[1].ID(): true,
+		nodes[3].ID(): true,
+	}}
+	config := dialConfig{
+		membership:     members,
+		maxActiveDials: 10,
+		maxDialPeers:   10,
+	}
+	runDialTest(t, config, []dialTestRound{
+		{
+			discovered:   nodes,
+			wantNewDials: []*enode.Node{nodes[1], nodes[3]},
+		},
+		{
+			succeeded: []enode.ID{nodes[1].ID(), nodes[3].ID()},
+		},
+	})
+}
+
 // This test checks that static dials work and obey the limits.
 func TestDialSchedStaticDial(t *testing.T) {
 	t.Parallel()
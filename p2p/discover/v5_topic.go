@@ -0,0 +1,442 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	topicAdLifetime       = 15 * time.Minute // lifetime of a placed advertisement
+	topicMaxAdsPerTopic   = 100              // advertisements stored for a single topic
+	topicMaxAds           = 5000             // advertisements stored for all topics
+	topicMinWaitTime      = time.Second      // minimum waiting time of a ticket
+	topicTicketValidity   = 10 * time.Second // time to use a ticket after its waiting time
+	topicQueryResultLimit = 16               // applies in TOPICQUERY handler
+	topicRegistrars       = 8                // nodes asked to advertise a topic
+	topicQueryInterval    = 30 * time.Second // time between topic search rounds
+)
+
+var (
+	errTopicWaitTooLong = errors.New("topic registration wait time too long")
+	errTopicInvalidENR  = errors.New("invalid record in topic registration")
+)
+
+// Topic is the identifier of a service advertised in the DHT. The advertisements
+// of a topic are placed on the nodes closest to it in the node ID space.
+type Topic [32]byte
+
+// NewTopic creates the topic identifier of a service name.
+func NewTopic(name string) Topic {
+	return sha256.Sum256([]byte(name))
+}
+
+func (t Topic) String() string {
+	return fmt.Sprintf("%x", t[:])
+}
+
+// topicAd is an advertisement stored on the local node.
+type topicAd struct {
+	node    *enode.Node
+	expires mclock.AbsTime
+}
+
+// topicTicket is the content of the tickets issued by the local node. Tickets are
+// authenticated with a local secret, so they can't be forged or transferred to
+// other topics or nodes.
+type topicTicket struct {
+	Topic    Topic
+	ID       enode.ID
+	Issued   uint64 // mclock.AbsTime of issuance
+	WaitTime uint64 // nanoseconds to wait before registering
+	Waited   uint64 // nanoseconds waited for the previous tickets
+}
+
+// topicTable stores the advertisements placed on the local node, and decides when
+// a registrant may place its advertisement. The waiting times grow with the
+// occupancy of the table and the popularity of the topic, and the time waited for
+// previous tickets is credited to the registrant.
+//
+// The topic table is accessed by the dispatch loop only.
+type topicTable struct {
+	clock mclock.Clock
+	key   [32]byte // ticket authentication secret
+	ads   map[Topic][]topicAd
+	count int
+}
+
+func newTopicTable(clock mclock.Clock) *topicTable {
+	tt := &topicTable{clock: clock, ads: make(map[Topic][]topicAd)}
+	crand.Read(tt.key[:])
+	return tt
+}
+
+// register processes a registration of the node for the topic. It returns zero
+// wait time if the advertisement was placed, otherwise the ticket to present
+// after waiting.
+func (tt *topicTable) register(n *enode.Node, topic Topic, ticket []byte) ([]byte, time.Duration) {
+	now := tt.clock.Now()
+	tt.expire(now)
+
+	// Renew the advertisement if the node is registered already.
+	ads := tt.ads[topic]
+	if i := slices.IndexFunc(ads, func(ad topicAd) bool { return ad.node.ID() == n.ID() }); i >= 0 {
+		ads = slices.Delete(ads, i, i+1)
+		tt.ads[topic] = append(ads, topicAd{n, now.Add(topicAdLifetime)})
+		return nil, 0
+	}
+	// Credit the time waited for a valid ticket.
+	var waited time.Duration
+	if t, ok := tt.decodeTicket(ticket); ok && t.Topic == topic && t.ID == n.ID() {
+		due := mclock.AbsTime(t.Issued).Add(time.Duration(t.WaitTime))
+		switch {
+		case now < due:
+			return ticket, due.Sub(now)
+		case now <= due.Add(topicTicketValidity):
+			waited = time.Duration(t.Waited + t.WaitTime)
+		}
+	}
+	wait := tt.waitTime(topic, now) - waited
+	if wait <= 0 && tt.hasSpace(topic) {
+		tt.ads[topic] = append(tt.ads[topic], topicAd{n, now.Add(topicAdLifetime)})
+		tt.count++
+		return nil, 0
+	}
+	wait = max(wait, topicMinWaitTime)
+	return tt.encodeTicket(&topicTicket{
+		Topic:    topic,
+		ID:       n.ID(),
+		Issued:   uint64(now),
+		WaitTime: uint64(wait),
+		Waited:   uint64(waited),
+	}), wait
+}
+
+// waitTime computes the time a new registrant of the topic has to wait.
+func (tt *topicTable) waitTime(topic Topic, now mclock.AbsTime) time.Duration {
+	ads := tt.ads[topic]
+	switch {
+	case tt.count >= topicMaxAds:
+		// Wait for the first advertisement of any topic to expire.
+		earliest := now.Add(topicAdLifetime)
+		for _, ads := range tt.ads {
+			earliest = min(earliest, ads[0].expires)
+		}
+		return earliest.Sub(now)
+	case len(ads) >= topicMaxAdsPerTopic:
+		return ads[0].expires.Sub(now)
+	}
+	occupancy := float64(tt.count) / topicMaxAds
+	popularity := float64(len(ads)) / topicMaxAdsPerTopic
+	return min(topicAdLifetime, time.Duration(float64(topicAdLifetime)*popularity/(1-occupancy)))
+}
+
+// hasSpace reports whether a new advertisement of the topic can be placed.
+func (tt *topicTable) hasSpace(topic Topic) bool {
+	return tt.count < topicMaxAds && len(tt.ads[topic]) < topicMaxAdsPerTopic
+}
+
+// query returns a random selection of the nodes advertising the topic.
+func (tt *topicTable) query(topic Topic, limit int) []*enode.Node {
+	tt.expire(tt.clock.Now())
+
+	ads := tt.ads[topic]
+	nodes := make([]*enode.Node, 0, min(len(ads), limit))
+	for _, i := range rand.Perm(len(ads)) {
+		if len(nodes) == limit {
+			break
+		}
+		nodes = append(nodes, ads[i].node)
+	}
+	return nodes
+}
+
+// expire removes the expired advertisements. Advertisements of a topic are kept
+// in the order of expiry.
+func (tt *topicTable) expire(now mclock.AbsTime) {
+	for topic, ads := range tt.ads {
+		n := 0
+		for n < len(ads) && ads[n].expires <= now {
+			n++
+		}
+		if n == len(ads) {
+			delete(tt.ads, topic)
+		} else if n > 0 {
+			tt.ads[topic] = slices.Delete(ads, 0, n)
+		}
+		tt.count -= n
+	}
+}
+
+// encodeTicket serializes and authenticates a ticket.
+func (tt *topicTable) encodeTicket(t *topicTicket) []byte {
+	enc, _ := rlp.EncodeToBytes(t)
+	mac := hmac.New(sha256.New, tt.key[:])
+	mac.Write(enc)
+	return mac.Sum(enc)
+}
+
+// decodeTicket verifies and deserializes a ticket.
+func (tt *topicTable) decodeTicket(ticket []byte) (*topicTicket, bool) {
+	if len(ticket) <= sha256.Size {
+		return nil, false
+	}
+	enc, sum := ticket[:len(ticket)-sha256.Size], ticket[len(ticket)-sha256.Size:]
+	mac := hmac.New(sha256.New, tt.key[:])
+	mac.Write(enc)
+	if !hmac.Equal(mac.Sum(nil), sum) {
+		return nil, false
+	}
+	var t topicTicket
+	if err := rlp.DecodeBytes(enc, &t); err != nil {
+		return nil, false
+	}
+	return &t, true
+}
+
+// handleRegtopic processes a topic registration and replies with a ticket.
+func (t *UDPv5) handleRegtopic(p *v5wire.Regtopic, fromID enode.ID, fromAddr netip.AddrPort) {
+	n, err := t.verifyRegtopicNode(p, fromID, fromAddr)
+	if err != nil {
+		t.log.Debug("Invalid "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	ticket, wait := t.topics.register(n, p.Topic, p.Ticket)
+	t.sendResponse(fromID, fromAddr, &v5wire.Ticket{
+		ReqID:    p.ReqID,
+		Ticket:   ticket,
+		WaitTime: uint((wait + time.Second - 1) / time.Second),
+	})
+}
+
+// verifyRegtopicNode checks that the record of a registrant belongs to the sender
+// and points to the endpoint it was sent from, so that the advertisements can't
+// direct traffic to third parties.
+func (t *UDPv5) verifyRegtopicNode(p *v5wire.Regtopic, fromID enode.ID, fromAddr netip.AddrPort) (*enode.Node, error) {
+	if p.ENR == nil {
+		return nil, errTopicInvalidENR
+	}
+	n, err := enode.New(t.validSchemes, p.ENR)
+	if err != nil {
+		return nil, err
+	}
+	if n.ID() != fromID || n.IPAddr() != fromAddr.Addr() {
+		return nil, errTopicInvalidENR
+	}
+	return n, nil
+}
+
+// handleTopicQuery returns the nodes advertising a topic to the requester.
+func (t *UDPv5) handleTopicQuery(p *v5wire.TopicQuery, fromID enode.ID, fromAddr netip.AddrPort) {
+	var nodes []*enode.Node
+	for _, n := range t.topics.query(p.Topic, topicQueryResultLimit) {
+		if netutil.CheckRelayAddr(fromAddr.Addr(), n.IPAddr()) == nil {
+			nodes = append(nodes, n)
+		}
+	}
+	for _, resp := range packNodes(p.ReqID, nodes) {
+		t.sendResponse(fromID, fromAddr, resp)
+	}
+}
+
+// Regtopic calls REGTOPIC on a node and waits for the TICKET response. The ticket
+// of a previous attempt should be passed after waiting for the time it specifies.
+func (t *UDPv5) Regtopic(n *enode.Node, topic Topic, ticket []byte) (*v5wire.Ticket, error) {
+	req := &v5wire.Regtopic{Topic: topic, ENR: t.localNode.Node().Record(), Ticket: ticket}
+	resp := t.callToNode(n, v5wire.TicketMsg, req)
+	defer t.callDone(resp)
+
+	select {
+	case respMsg := <-resp.ch:
+		return respMsg.(*v5wire.Ticket), nil
+	case err := <-resp.err:
+		return nil, err
+	}
+}
+
+// TopicQuery calls TOPICQUERY on a node and returns the nodes advertising the topic.
+func (t *UDPv5) TopicQuery(n *enode.Node, topic Topic) ([]*enode.Node, error) {
+	resp := t.callToNode(n, v5wire.NodesMsg, &v5wire.TopicQuery{Topic: topic})
+	return t.waitForNodes(resp, nil)
+}
+
+// RegisterTopic advertises the local node for the topic on the nodes closest to
+// it. The advertisements are renewed until the context is canceled or the
+// transport is closed.
+func (t *UDPv5) RegisterTopic(ctx context.Context, topic Topic) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-t.closeCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		var (
+			start  = t.clock.Now()
+			placed = 0
+			lock   sync.Mutex
+			wg     sync.WaitGroup
+		)
+		registrars := t.newLookup(ctx, enode.ID(topic)).run()
+		for _, n := range registrars[:min(len(registrars), topicRegistrars)] {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := t.registerTopicAt(ctx, n, topic); err != nil {
+					t.log.Debug("Topic registration failed", "topic", topic, "id", n.ID(), "err", err)
+					return
+				}
+				lock.Lock()
+				placed++
+				lock.Unlock()
+			}()
+		}
+		wg.Wait()
+		t.log.Debug("Registered topic", "topic", topic, "registrars", len(registrars), "placed", placed)
+
+		// Renew the advertisements before they expire.
+		select {
+		case <-t.clock.After(topicAdLifetime - topicTicketValidity - t.clock.Now().Sub(start)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// registerTopicAt places an advertisement of the local node on a registrar,
+// waiting for the tickets issued by it.
+func (t *UDPv5) registerTopicAt(ctx context.Context, n *enode.Node, topic Topic) error {
+	var ticket []byte
+	for {
+		resp, err := t.Regtopic(n, topic, ticket)
+		if err != nil {
+			return err
+		}
+		if resp.WaitTime == 0 {
+			return nil
+		}
+		wait := time.Duration(resp.WaitTime) * time.Second
+		if wait > topicAdLifetime {
+			return errTopicWaitTooLong
+		}
+		ticket = resp.Ticket
+
+		select {
+		case <-t.clock.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TopicNodes returns an iterator over the nodes advertising the topic. The nodes
+// closest to the topic are queried periodically, and the nodes found in a round
+// are returned once.
+func (t *UDPv5) TopicNodes(topic Topic) enode.Iterator {
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	return &topicIterator{transport: t, topic: topic, ctx: ctx, cancel: cancel}
+}
+
+// topicIterator runs topic search rounds and iterates over the advertised nodes.
+type topicIterator struct {
+	transport *UDPv5
+	topic     Topic
+	ctx       context.Context
+	cancel    func()
+	rounds    int
+	buffer    []*enode.Node
+}
+
+// Node returns the current node.
+func (it *topicIterator) Node() *enode.Node {
+	if len(it.buffer) == 0 {
+		return nil
+	}
+	return it.buffer[0]
+}
+
+// Next moves to the next node.
+func (it *topicIterator) Next() bool {
+	if len(it.buffer) > 0 {
+		it.buffer = it.buffer[1:]
+	}
+	for len(it.buffer) == 0 {
+		if it.rounds > 0 {
+			select {
+			case <-it.transport.clock.After(topicQueryInterval):
+			case <-it.ctx.Done():
+			}
+		}
+		if it.ctx.Err() != nil {
+			it.buffer = nil
+			return false
+		}
+		it.buffer = it.search()
+		it.rounds++
+	}
+	return true
+}
+
+// search queries the nodes closest to the topic for its advertisements.
+func (it *topicIterator) search() []*enode.Node {
+	var (
+		t     = it.transport
+		seen  = map[enode.ID]bool{t.Self().ID(): true}
+		found []*enode.Node
+	)
+	for _, n := range t.newLookup(it.ctx, enode.ID(it.topic)).run() {
+		if it.ctx.Err() != nil {
+			break
+		}
+		nodes, err := t.TopicQuery(n, it.topic)
+		if err != nil {
+			t.log.Debug("Topic query failed", "topic", it.topic, "id", n.ID(), "err", err)
+		}
+		for _, ad := range nodes {
+			if !seen[ad.ID()] {
+				seen[ad.ID()] = true
+				found = append(found, ad)
+			}
+		}
+	}
+	return found
+}
+
+// Close ends the iterator.
+func (it *topicIterator) Close() {
+	it.cancel()
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"bytes"
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestTopicTableRegister(t *testing.T) {
+	var (
+		clock = new(mclock.Simulated)
+		tt    = newTopicTable(clock)
+		topic = NewTopic("test")
+		nodes = nodesAtDistance(enode.ID{}, 256, topicMaxAdsPerTopic/2+1)
+	)
+	// Registrations are accepted right away into an empty table.
+	if ticket, wait := tt.register(nodes[0], topic, nil); ticket != nil || wait != 0 {
+		t.Fatalf("registration into empty table not accepted, wait %v", wait)
+	}
+	for _, n := range nodes[1 : len(nodes)-1] {
+		tt.ads[topic] = append(tt.ads[topic], topicAd{n, clock.Now().Add(topicAdLifetime)})
+		tt.count++
+	}
+	// The topic is popular now, a new registrant has to wait.
+	n := nodes[len(nodes)-1]
+	ticket, wait := tt.register(n, topic, nil)
+	if ticket == nil || wait < topicMinWaitTime {
+		t.Fatalf("registration into popular topic accepted, wait %v", wait)
+	}
+	// Tickets can't be used early, or by other nodes and for other topics.
+	clock.Run(wait / 2)
+	if _, early := tt.register(n, topic, ticket); early != wait-wait/2 {
+		t.Fatalf("wrong remaining wait time: have %v, want %v", early, wait-wait/2)
+	}
+	clock.Run(wait - wait/2)
+	stranger := nodeAtDistance(enode.ID{}, 256, intIP(len(nodes)))
+	if ticket, _ := tt.register(stranger, topic, ticket); ticket == nil {
+		t.Fatal("registration with ticket of another node accepted")
+	}
+	forged := bytes.Clone(ticket)
+	forged[0]++
+	if ticket, _ := tt.register(n, topic, forged); ticket == nil {
+		t.Fatal("registration with forged ticket accepted")
+	}
+	// The waiting time is credited when the ticket is used in time.
+	if ticket, wait := tt.register(n, topic, ticket); ticket != nil || wait != 0 {
+		t.Fatalf("registration with valid ticket not accepted, wait %v", wait)
+	}
+	if have := tt.query(topic, len(nodes)+1); len(have) != len(nodes) {
+		t.Fatalf("wrong number of advertisements: have %d, want %d", len(have), len(nodes))
+	}
+	if have := tt.query(topic, topicQueryResultLimit); len(have) != topicQueryResultLimit {
+		t.Fatalf("wrong number of queried advertisements: have %d, want %d", len(have), topicQueryResultLimit)
+	}
+	// Advertisements expire after their lifetime.
+	clock.Run(topicAdLifetime)
+	if have := tt.query(topic, len(nodes)); len(have) != 0 || tt.count != 0 {
+		t.Fatalf("advertisements not expired: %d left, count %d", len(have), tt.count)
+	}
+}
+
+func TestTopicTableExpiredTicket(t *testing.T) {
+	var (
+		clock = new(mclock.Simulated)
+		tt    = newTopicTable(clock)
+		topic = NewTopic("test")
+		nodes = nodesAtDistance(enode.ID{}, 256, 2)
+	)
+	tt.register(nodes[0], topic, nil)
+	ticket, wait := tt.register(nodes[1], topic, nil)
+	if ticket == nil {
+		t.Fatal("registration into popular topic accepted")
+	}
+	clock.Run(wait + topicTicketValidity + time.Second)
+	if ticket, _ := tt.register(nodes[1], topic, ticket); ticket == nil {
+		t.Fatal("registration with expired ticket accepted")
+	}
+}
+
+// This test checks that incoming REGTOPIC and TOPICQUERY calls are handled correctly.
+func TestUDPv5_topicHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		topic     = NewTopic("test")
+		remote    = test.getNode(test.remotekey, test.remoteaddr).Node()
+		otherkey  = newkey()
+		otheraddr = netip.MustParseAddrPort("10.0.1.100:30303")
+	)
+	// Registrations carrying the record of another node are ignored.
+	other := test.getNode(otherkey, otheraddr).Node()
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{0}, Topic: topic, ENR: other.Record()})
+	test.packetIn(&v5wire.Ping{ReqID: []byte{1}})
+	test.waitPacketOut(func(p *v5wire.Pong, addr netip.AddrPort, _ v5wire.Nonce) {})
+
+	// The first registration is accepted right away.
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{2}, Topic: topic, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr netip.AddrPort, _ v5wire.Nonce) {
+		if !bytes.Equal(p.ReqID, []byte{2}) {
+			t.Error("wrong request ID in response:", p.ReqID)
+		}
+		if p.WaitTime != 0 {
+			t.Errorf("registration not accepted, wait %d", p.WaitTime)
+		}
+	})
+	// The advertisement is returned to other nodes.
+	test.packetInFrom(otherkey, otheraddr, &v5wire.TopicQuery{ReqID: []byte{3}, Topic: topic})
+	test.expectNodes([]byte{3}, 1, []*enode.Node{remote})
+
+	test.packetInFrom(otherkey, otheraddr, &v5wire.TopicQuery{ReqID: []byte{4}, Topic: NewTopic("other")})
+	test.expectNodes([]byte{4}, 1, nil)
+}
+
+// Real sockets, real crypto: this test checks that advertised nodes can be found.
+func TestUDPv5_topicE2E(t *testing.T) {
+	t.Parallel()
+
+	const N = 5
+	var nodes []*UDPv5
+	for i := 0; i < N; i++ {
+		var cfg Config
+		if len(nodes) > 0 {
+			cfg.Bootnodes = []*enode.Node{nodes[0].Self()}
+		}
+		node := startLocalhostV5(t, cfg)
+		nodes = append(nodes, node)
+		defer node.Close()
+	}
+	topic := NewTopic("test")
+	advertiser, searcher := nodes[N-1], nodes[N-2]
+
+	// Place the advertisement on the nodes closest to the topic.
+	registrars := advertiser.Lookup(enode.ID(topic))
+	if len(registrars) == 0 {
+		t.Fatal("no registrars found")
+	}
+	for _, n := range registrars {
+		if err := advertiser.registerTopicAt(context.Background(), n, topic); err != nil {
+			t.Fatalf("registration at %v failed: %v", n.ID(), err)
+		}
+	}
+	it := searcher.TopicNodes(topic)
+	defer it.Close()
+	if !it.Next() {
+		t.Fatal("topic iterator ended")
+	}
+	if it.Node().ID() != advertiser.Self().ID() {
+		t.Fatalf("wrong advertised node %v", it.Node().ID())
+	}
+}
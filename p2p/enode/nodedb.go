@@ -18,11 +18,14 @@ package enode
 
 import (
 	"bytes"
+	"cmp"
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"net/netip"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -41,6 +44,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbScorePrefix  = "score:" // Identifier to prefix node reputation scores with
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -57,9 +61,10 @@ const (
 )
 
 const (
-	dbNodeExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
-	dbCleanupCycle   = time.Hour      // Time period for running the expiration task.
-	dbVersion        = 9
+	dbNodeExpiration  = 24 * time.Hour      // Time after which an unseen node should be dropped.
+	dbCleanupCycle    = time.Hour           // Time period for running the expiration task.
+	dbScoreExpiration = 30 * 24 * time.Hour // Time after which an unchanged score should be dropped.
+	dbVersion         = 9
 )
 
 var (
@@ -194,6 +199,11 @@ func localItemKey(id ID, field string) []byte {
 	return key
 }
 
+// scoreKey returns the database key for the reputation score of a node.
+func scoreKey(id ID) []byte {
+	return append([]byte(dbScorePrefix), id[:]...)
+}
+
 // fetchInt64 retrieves an integer associated with a particular key.
 func (db *DB) fetchInt64(key []byte) int64 {
 	blob, err := db.lvl.Get(key, nil)
@@ -315,6 +325,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireScores()
 		case <-db.quit:
 			return
 		}
@@ -360,6 +371,20 @@ func (db *DB) expireNodes() {
 	}
 }
 
+// expireScores deletes all reputation scores that have not been updated for
+// some time.
+func (db *DB) expireScores() {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbScorePrefix)), nil)
+	defer it.Release()
+
+	threshold := time.Now().Add(-dbScoreExpiration)
+	for it.Next() {
+		if _, updated, _, ok := decodeScore(it.Value()); !ok || updated.Before(threshold) {
+			db.lvl.Delete(it.Key(), nil)
+		}
+	}
+}
+
 // LastPingReceived retrieves the time of the last ping packet received from
 // a remote node.
 func (db *DB) LastPingReceived(id ID, ip netip.Addr) time.Time {
@@ -427,6 +452,97 @@ func (db *DB) UpdateFindFailsV5(id ID, ip netip.Addr, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// encodeScore encodes a reputation score along with the time of its last update
+// and the optional RLP-encoded record of the node.
+func encodeScore(score float64, updated time.Time, record []byte) []byte {
+	blob := make([]byte, 16, 16+len(record))
+	binary.BigEndian.PutUint64(blob, math.Float64bits(score))
+	binary.BigEndian.PutUint64(blob[8:], uint64(updated.Unix()))
+	return append(blob, record...)
+}
+
+// decodeScore decodes a reputation score stored by encodeScore.
+func decodeScore(blob []byte) (score float64, updated time.Time, record []byte, ok bool) {
+	if len(blob) < 16 {
+		return 0, time.Time{}, nil, false
+	}
+	score = math.Float64frombits(binary.BigEndian.Uint64(blob))
+	updated = time.Unix(int64(binary.BigEndian.Uint64(blob[8:])), 0)
+	return score, updated, blob[16:], true
+}
+
+// NodeScore retrieves the reputation score of a node along with the time it
+// was last updated. The zero score is returned if the node is unknown.
+func (db *DB) NodeScore(id ID) (float64, time.Time) {
+	blob, err := db.lvl.Get(scoreKey(id), nil)
+	if err != nil {
+		return 0, time.Time{}
+	}
+	score, updated, _, ok := decodeScore(blob)
+	if !ok {
+		return 0, time.Time{}
+	}
+	return score, updated
+}
+
+// UpdateNodeScore stores the reputation score of a node. The record of the node
+// is stored along with the score if it's given, otherwise the previously stored
+// one is retained. The record is kept separate from the discovery data, as it
+// expires together with the score.
+func (db *DB) UpdateNodeScore(id ID, score float64, updated time.Time, node *Node) error {
+	var record []byte
+	if node != nil {
+		blob, err := rlp.EncodeToBytes(&node.r)
+		if err != nil {
+			return err
+		}
+		record = blob
+	} else if blob, err := db.lvl.Get(scoreKey(id), nil); err == nil {
+		if _, _, stored, ok := decodeScore(blob); ok {
+			record = stored
+		}
+	}
+	db.ensureExpirer()
+	return db.lvl.Put(scoreKey(id), encodeScore(score, updated, record), nil)
+}
+
+// QueryScoredNodes retrieves at most n nodes with a stored score of at least
+// minScore, in descending order of their score. Nodes without a stored record
+// are skipped.
+func (db *DB) QueryScoredNodes(n int, minScore float64) []*Node {
+	type scored struct {
+		node  *Node
+		score float64
+	}
+	var (
+		candidates []scored
+		it         = db.lvl.NewIterator(util.BytesPrefix([]byte(dbScorePrefix)), nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		score, _, record, ok := decodeScore(it.Value())
+		if !ok || score < minScore || len(record) == 0 {
+			continue
+		}
+		var r enr.Record
+		if err := rlp.DecodeBytes(record, &r); err != nil {
+			continue
+		}
+		var id ID
+		copy(id[:], it.Key()[len(dbScorePrefix):])
+		candidates = append(candidates, scored{newNodeWithID(&r, id), score})
+	}
+	slices.SortFunc(candidates, func(a, b scored) int {
+		return cmp.Compare(b.score, a.score)
+	})
+	nodes := make([]*Node, 0, min(n, len(candidates)))
+	for _, c := range candidates[:min(n, len(candidates))] {
+		nodes = append(nodes, c.node)
+	}
+	return nodes
+}
+
 // localSeq retrieves the local record sequence counter, defaulting to the current
 // timestamp if no previous exists. This ensures that wiping all data associated
 // with a node (apart from its key) will not generate already used sequence nums.
@@ -470,3 +470,60 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+func TestDBScores(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	now := time.Unix(time.Now().Unix(), 0)
+	if score, updated := db.NodeScore(ID{}); score != 0 || !updated.IsZero() {
+		t.Fatalf("unexpected score of unknown node: %v %v", score, updated)
+	}
+	// Store the scores of a few nodes, skipping the record of the last one
+	scores := []float64{-10, 5, 20, 1.5, 30}
+	for i, score := range scores {
+		node := nodeDBSeedQueryNodes[i].node
+		if i == len(scores)-1 {
+			node = nil
+		}
+		if err := db.UpdateNodeScore(nodeDBSeedQueryNodes[i].node.ID(), score, now, node); err != nil {
+			t.Fatalf("node %d: failed to store score: %v", i, err)
+		}
+	}
+	for i, want := range scores {
+		score, updated := db.NodeScore(nodeDBSeedQueryNodes[i].node.ID())
+		if score != want || !updated.Equal(now) {
+			t.Errorf("node %d: score mismatch: have %v (%v), want %v (%v)", i, score, updated, want, now)
+		}
+	}
+	// Query the nodes above the threshold, in descending order
+	nodes := db.QueryScoredNodes(10, 1.5)
+	want := []ID{nodeDBSeedQueryNodes[2].node.ID(), nodeDBSeedQueryNodes[1].node.ID(), nodeDBSeedQueryNodes[3].node.ID()}
+	if len(nodes) != len(want) {
+		t.Fatalf("scored node count mismatch: have %d, want %d", len(nodes), len(want))
+	}
+	for i, node := range nodes {
+		if node.ID() != want[i] {
+			t.Errorf("scored node %d mismatch: have %v, want %v", i, node.ID(), want[i])
+		}
+	}
+	if nodes := db.QueryScoredNodes(1, 0); len(nodes) != 1 || nodes[0].ID() != want[0] {
+		t.Errorf("unexpected limited query result: %v", nodes)
+	}
+	// Ensure the stored record is retained if the score is updated without it
+	db.UpdateNodeScore(nodeDBSeedQueryNodes[3].node.ID(), 100, now, nil)
+	if nodes := db.QueryScoredNodes(1, 0); len(nodes) != 1 || nodes[0].ID() != nodeDBSeedQueryNodes[3].node.ID() {
+		t.Errorf("unexpected query result after score update: %v", nodes)
+	}
+	// Expire the outdated scores
+	stale := nodeDBSeedQueryNodes[1].node.ID()
+	db.UpdateNodeScore(stale, 5, now.Add(-dbScoreExpiration-time.Hour), nil)
+	db.expireScores()
+
+	if score, updated := db.NodeScore(stale); score != 0 || !updated.IsZero() {
+		t.Errorf("stale score should be removed after expiration: %v %v", score, updated)
+	}
+	if score, _ := db.NodeScore(nodeDBSeedQueryNodes[2].node.ID()); score != 20 {
+		t.Errorf("fresh score should be present after expiration, have %v", score)
+	}
+}
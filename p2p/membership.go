@@ -0,0 +1,33 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Membership is the set of nodes allowed to connect in a permissioned network.
+// Implementations must be safe for concurrent use.
+type Membership interface {
+	// IsMember reports whether the node may connect.
+	IsMember(id enode.ID) bool
+
+	// SubscribeChanges notifies about updates of the member set. The server
+	// drops the peers which are not members anymore on every notification.
+	SubscribeChanges(ch chan<- struct{}) event.Subscription
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package membership implements signed membership lists for permissioned networks.
+//
+// The network administrator publishes the node IDs allowed to join the network in
+// a list signed with the admin key. Nodes only connect to the members of the most
+// recent list signed by the configured admin. Lists are distributed as JSON files
+// or published through a contract on the chain of the network.
+package membership
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var errMissingSignature = errors.New("membership list is not signed")
+
+// List is a membership list signed by the network administrator.
+type List struct {
+	Version   uint64        `json:"version"` // must increase with every update
+	Members   []enode.ID    `json:"members"`
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+}
+
+// ParseList decodes a JSON encoded membership list.
+func ParseList(data []byte) (*List, error) {
+	list := new(List)
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("invalid membership list: %v", err)
+	}
+	return list, nil
+}
+
+// LoadList reads a JSON encoded membership list from a file.
+func LoadList(file string) (*List, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseList(data)
+}
+
+// sigHash returns the hash signed by the administrator.
+func (l *List) sigHash() common.Hash {
+	enc, _ := rlp.EncodeToBytes([]any{l.Version, l.Members})
+	return crypto.Keccak256Hash(enc)
+}
+
+// Sign signs the list with the admin key.
+func (l *List) Sign(key *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(l.sigHash().Bytes(), key)
+	if err != nil {
+		return err
+	}
+	l.Signature = sig
+	return nil
+}
+
+// Signer returns the address of the key which signed the list.
+func (l *List) Signer() (common.Address, error) {
+	if len(l.Signature) == 0 {
+		return common.Address{}, errMissingSignature
+	}
+	pub, err := crypto.SigToPub(l.sigHash().Bytes(), l.Signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid membership list signature: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package membership
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func newSignedList(t *testing.T, version uint64, members ...enode.ID) (*List, *Set) {
+	t.Helper()
+	key, _ := crypto.GenerateKey()
+	list := &List{Version: version, Members: members}
+	if err := list.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	return list, NewSet(crypto.PubkeyToAddress(key.PublicKey), nil)
+}
+
+func TestListSignature(t *testing.T) {
+	list, set := newSignedList(t, 1, enode.ID{1}, enode.ID{2})
+
+	signer, err := list.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != set.admin {
+		t.Fatalf("wrong signer %v, want %v", signer, set.admin)
+	}
+	// The signature must survive JSON encoding.
+	enc, _ := json.Marshal(list)
+	dec, err := ParseList(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer, _ := dec.Signer(); signer != set.admin {
+		t.Fatalf("wrong signer %v after decoding, want %v", signer, set.admin)
+	}
+	// Modifying the members invalidates the signature.
+	dec.Members = append(dec.Members, enode.ID{3})
+	if _, err := set.Update(dec); err == nil {
+		t.Fatal("modified list accepted")
+	}
+	dec.Signature = nil
+	if _, err := set.Update(dec); err != errMissingSignature {
+		t.Fatalf("wrong error for unsigned list: %v", err)
+	}
+}
+
+func TestSetUpdate(t *testing.T) {
+	list, set := newSignedList(t, 2, enode.ID{1})
+	if set.IsMember(enode.ID{1}) {
+		t.Fatal("node is member before list is loaded")
+	}
+	ch := make(chan struct{}, 1)
+	sub := set.SubscribeChanges(ch)
+	defer sub.Unsubscribe()
+
+	if updated, err := set.Update(list); !updated || err != nil {
+		t.Fatalf("update failed: updated=%v err=%v", updated, err)
+	}
+	if !set.IsMember(enode.ID{1}) || set.IsMember(enode.ID{2}) {
+		t.Fatal("wrong members after update")
+	}
+	select {
+	case <-ch:
+	default:
+		t.Fatal("no change notification")
+	}
+	// Reapplying the same version is a no-op.
+	if updated, err := set.Update(list); updated || err != nil {
+		t.Fatalf("same version: updated=%v err=%v", updated, err)
+	}
+	// Lists signed by another key are rejected.
+	other, _ := newSignedList(t, 3, enode.ID{2})
+	if _, err := set.Update(other); err == nil {
+		t.Fatal("list of other signer accepted")
+	}
+}
+
+func TestSetBootstrap(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	set := NewSet(crypto.PubkeyToAddress(key.PublicKey), []enode.ID{{1}})
+
+	// Only the bootstrap nodes are members until a list is loaded.
+	if !set.IsMember(enode.ID{1}) || set.IsMember(enode.ID{2}) {
+		t.Fatal("wrong members before list is loaded")
+	}
+	list := &List{Version: 1, Members: []enode.ID{{2}}}
+	list.Sign(key)
+	if _, err := set.Update(list); err != nil {
+		t.Fatal(err)
+	}
+	if set.IsMember(enode.ID{1}) || !set.IsMember(enode.ID{2}) {
+		t.Fatal("wrong members after list is loaded")
+	}
+}
+
+func TestSetVersion(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	set := NewSet(crypto.PubkeyToAddress(key.PublicKey), nil)
+	sign := func(version uint64, members ...enode.ID) *List {
+		list := &List{Version: version, Members: members}
+		list.Sign(key)
+		return list
+	}
+	if _, err := set.Update(sign(5, enode.ID{1})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := set.Update(sign(4, enode.ID{2})); err == nil {
+		t.Fatal("older list accepted")
+	}
+	if _, err := set.Update(sign(6, enode.ID{2})); err != nil {
+		t.Fatal(err)
+	}
+	if set.IsMember(enode.ID{1}) || !set.IsMember(enode.ID{2}) {
+		t.Fatal("wrong members after update")
+	}
+	if v, loaded := set.Version(); v != 6 || !loaded {
+		t.Fatalf("wrong version %d (loaded %v)", v, loaded)
+	}
+}
+
+func TestFileWatcher(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	set := NewSet(crypto.PubkeyToAddress(key.PublicKey), nil)
+	file := filepath.Join(t.TempDir(), "members.json")
+	write := func(version uint64, members ...enode.ID) {
+		list := &List{Version: version, Members: members}
+		list.Sign(key)
+		enc, _ := json.Marshal(list)
+		if err := os.WriteFile(file, enc, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Starting fails if the file is missing.
+	w := NewWatcher(set, FileSource(file), 10*time.Millisecond)
+	if err := w.Start(); err == nil {
+		t.Fatal("watcher started without list file")
+	}
+
+	write(1, enode.ID{1})
+	w = NewWatcher(set, FileSource(file), 10*time.Millisecond)
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if !set.IsMember(enode.ID{1}) {
+		t.Fatal("list not loaded on start")
+	}
+
+	write(2, enode.ID{2})
+	deadline := time.Now().Add(5 * time.Second)
+	for !set.IsMember(enode.ID{2}) {
+		if time.Now().After(deadline) {
+			t.Fatal("list not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type testCaller struct {
+	output []byte
+}
+
+func (c *testCaller) CallContract(ctx context.Context, call ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	return c.output, nil
+}
+
+func TestContractSource(t *testing.T) {
+	list, set := newSignedList(t, 1, enode.ID{1})
+	enc, _ := json.Marshal(list)
+	output, err := parsedContractABI.Methods["membershipList"].Outputs.Pack(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := NewContractSource(&testCaller{output: output}, [20]byte{1})
+	loaded, err := src.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := set.Update(loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !set.IsMember(enode.ID{1}) {
+		t.Fatal("contract list not applied")
+	}
+}
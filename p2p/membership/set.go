@@ -0,0 +1,120 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package membership
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Set holds the members of the latest list signed by the network administrator.
+// It implements p2p.Membership. Until a list has been loaded, only the bootstrap
+// nodes are members, e.g. to sync the chain holding the list.
+type Set struct {
+	admin     common.Address
+	bootstrap map[enode.ID]struct{}
+	feed      event.Feed
+
+	mu      sync.RWMutex
+	version uint64
+	loaded  bool
+	members map[enode.ID]struct{}
+}
+
+// NewSet creates an empty set accepting the lists signed by the given admin.
+// The bootstrap nodes are permitted until the first list is loaded.
+func NewSet(admin common.Address, bootstrap []enode.ID) *Set {
+	s := &Set{
+		admin:     admin,
+		bootstrap: make(map[enode.ID]struct{}, len(bootstrap)),
+		members:   make(map[enode.ID]struct{}),
+	}
+	for _, id := range bootstrap {
+		s.bootstrap[id] = struct{}{}
+	}
+	return s
+}
+
+// IsMember reports whether the node is on the current membership list, or is a
+// bootstrap node if no list has been loaded yet.
+func (s *Set) IsMember(id enode.ID) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.loaded {
+		_, ok := s.bootstrap[id]
+		return ok
+	}
+	_, ok := s.members[id]
+	return ok
+}
+
+// Version returns the version of the current membership list, and whether any
+// list has been loaded.
+func (s *Set) Version() (uint64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.version, s.loaded
+}
+
+// Len returns the number of members.
+func (s *Set) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.members)
+}
+
+// Update replaces the members with the given list if it is signed by the admin
+// and newer than the current list. It reports whether the set was changed.
+func (s *Set) Update(list *List) (bool, error) {
+	signer, err := list.Signer()
+	if err != nil {
+		return false, err
+	}
+	if signer != s.admin {
+		return false, fmt.Errorf("membership list signed by %v, want admin %v", signer, s.admin)
+	}
+	members := make(map[enode.ID]struct{}, len(list.Members))
+	for _, id := range list.Members {
+		members[id] = struct{}{}
+	}
+
+	s.mu.Lock()
+	if s.loaded && list.Version <= s.version {
+		s.mu.Unlock()
+		if list.Version < s.version {
+			return false, fmt.Errorf("membership list version %d is older than current version %d", list.Version, s.version)
+		}
+		return false, nil
+	}
+	s.version, s.loaded, s.members = list.Version, true, members
+	s.mu.Unlock()
+
+	s.feed.Send(struct{}{})
+	return true, nil
+}
+
+// SubscribeChanges notifies about updates of the member set.
+func (s *Set) SubscribeChanges(ch chan<- struct{}) event.Subscription {
+	return s.feed.Subscribe(ch)
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package membership
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// DefaultReloadInterval is the default interval between source reloads.
+	DefaultReloadInterval = 30 * time.Second
+
+	loadTimeout = 10 * time.Second
+)
+
+// Source loads the current membership list.
+type Source interface {
+	Load(ctx context.Context) (*List, error)
+	String() string
+}
+
+// FileSource loads the membership list from a JSON file.
+type FileSource string
+
+// Load implements Source.
+func (f FileSource) Load(ctx context.Context) (*List, error) {
+	return LoadList(string(f))
+}
+
+func (f FileSource) String() string {
+	return "file " + string(f)
+}
+
+// contractABI is the interface of membership list contracts. The list is returned
+// as the JSON encoding of List.
+const contractABI = `[{"type":"function","name":"membershipList","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes"}]}]`
+
+var parsedContractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// ContractSource loads the membership list from a contract.
+type ContractSource struct {
+	caller  ethereum.ContractCaller
+	address common.Address
+}
+
+// NewContractSource creates a source reading the list published by the contract
+// at the given address, as of the latest block.
+func NewContractSource(caller ethereum.ContractCaller, address common.Address) *ContractSource {
+	return &ContractSource{caller: caller, address: address}
+}
+
+// Load implements Source.
+func (c *ContractSource) Load(ctx context.Context) (*List, error) {
+	input, err := parsedContractABI.Pack("membershipList")
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.caller.CallContract(ctx, ethereum.CallMsg{To: &c.address, Data: input}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(output) == 0 {
+		return nil, errors.New("no membership list contract at address")
+	}
+	var data []byte
+	if err := parsedContractABI.UnpackIntoInterface(&data, "membershipList", output); err != nil {
+		return nil, err
+	}
+	return ParseList(data)
+}
+
+func (c *ContractSource) String() string {
+	return "contract " + c.address.Hex()
+}
+
+// Watcher periodically reloads the membership list from a source into a set.
+// It implements node.Lifecycle.
+type Watcher struct {
+	set      *Set
+	source   Source
+	interval time.Duration
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewWatcher creates a watcher loading lists from source into set.
+func NewWatcher(set *Set, source Source, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	return &Watcher{set: set, source: source, interval: interval, quit: make(chan struct{})}
+}
+
+// Reload loads the list from the source and applies it to the set.
+func (w *Watcher) Reload() error {
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	list, err := w.source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	updated, err := w.set.Update(list)
+	if err != nil {
+		return err
+	}
+	if updated {
+		log.Info("Loaded network membership list", "source", w.source, "version", list.Version, "members", w.set.Len())
+	}
+	return nil
+}
+
+// Start implements node.Lifecycle. The first load of a file list has to succeed,
+// contract lists may only become available once the chain is synced.
+func (w *Watcher) Start() error {
+	if err := w.Reload(); err != nil {
+		if _, ok := w.source.(FileSource); ok {
+			return err
+		}
+		log.Warn("Network membership list unavailable", "source", w.source, "err", err)
+	}
+	w.wg.Add(1)
+	go w.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle.
+func (w *Watcher) Stop() error {
+	close(w.quit)
+	w.wg.Wait()
+	return nil
+}
+
+func (w *Watcher) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Reload(); err != nil {
+				log.Warn("Failed to reload network membership list", "source", w.source, "err", err)
+			}
+		case <-w.quit:
+			return
+		}
+	}
+}
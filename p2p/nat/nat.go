@@ -59,6 +59,9 @@ type Interface interface {
 //	"upnp"               uses the Universal Plug and Play protocol
 //	"pmp"                uses NAT-PMP with an auto-detected gateway address
 //	"pmp:192.168.0.1"    uses NAT-PMP with the given gateway address
+//	"pcp"                uses PCP with an auto-detected gateway address
+//	"pcp:192.168.0.1"    uses PCP with the given gateway address, which may also be
+//	                     an IPv6 firewall opening pinholes for the local IPv6 address
 //	"stun"       uses stun protocol with default stun server
 //	"stun:192.168.0.1:1234"   uses stun protocol with stun server address 192.168.0.1:1234
 func Parse(spec string) (Interface, error) {
@@ -88,6 +91,8 @@ func Parse(spec string) (Interface, error) {
 		return UPnP(), nil
 	case "pmp", "natpmp", "nat-pmp":
 		return PMP(ip), nil
+	case "pcp":
+		return PCP(ip), nil
 	case "stun":
 		return newSTUN(after)
 	default:
@@ -155,9 +160,10 @@ func Any() Interface {
 	// TODO: attempt to discover whether the local machine has an
 	// Internet-class address. Return ExtIP in this case.
 	return startautodisc("any", func() Interface {
-		found := make(chan Interface, 2)
+		found := make(chan Interface, 3)
 		go func() { found <- discoverUPnP() }()
 		go func() { found <- discoverPMP() }()
+		go func() { found <- discoverPCP() }()
 		for i := 0; i < cap(found); i++ {
 			if c := <-found; c != nil {
 				return c
@@ -183,6 +189,16 @@ func PMP(gateway net.IP) Interface {
 	return startautodisc("natpmp", discoverPMP)
 }
 
+// PCP returns a port mapper that uses the Port Control Protocol. The
+// provided gateway address should be the IP of your router. If the given
+// gateway address is nil, PCP will attempt to auto-discover the router.
+func PCP(gateway net.IP) Interface {
+	if gateway != nil {
+		return newPCP(gateway, pcpPort)
+	}
+	return startautodisc("pcp", discoverPCP)
+}
+
 // autodisc represents a port mapping mechanism that is still being
 // auto-discovered. Calls to the Interface methods on this type will
 // wait until the discovery is done and then call the method on the
@@ -84,3 +84,27 @@ func TestParseStun(t *testing.T) {
 		assert.Equal(t, stun.serverList, tc.want.serverList)
 	}
 }
+
+func TestParsePCP(t *testing.T) {
+	nat, err := Parse("pcp:192.168.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gw := nat.(*pcp).gw; !gw.Equal(net.IP{192, 168, 0, 1}) {
+		t.Errorf("wrong gateway %v", gw)
+	}
+	nat, err = Parse("pcp:fd00::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gw := nat.(*pcp).gw; !gw.Equal(net.ParseIP("fd00::1")) {
+		t.Errorf("wrong gateway %v", gw)
+	}
+	nat, err = Parse("pcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nat.(*autodisc); !ok {
+		t.Errorf("wrong interface type %T", nat)
+	}
+}
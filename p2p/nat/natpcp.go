@@ -0,0 +1,429 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// This file implements the Port Control Protocol (RFC 6887). PCP supersedes NAT-PMP and
+// runs on the same server port. It is able to open mappings on IPv4 NATs as well as
+// pinholes on IPv6 firewalls, in which case the external address is the internal one.
+
+const (
+	pcpPort    = 5351
+	pcpVersion = 2
+
+	pcpOpAnnounce  = 0
+	pcpOpMap       = 1
+	pcpResponseBit = 0x80
+
+	pcpProtoTCP  = 6
+	pcpProtoUDP  = 17
+	pcpProbePort = 9 // discard service, mapped by ExternalIP
+
+	pcpHeaderSize  = 24
+	pcpMapSize     = 36
+	pcpMaxRespSize = 1100
+
+	pcpInitialTimeout = 250 * time.Millisecond // doubled on each retransmission
+	pcpMaxAttempts    = 4
+	pcpRenewRetry     = 10 * time.Second // retry interval of failed renewals
+	pcpProbeLifetime  = 2 * time.Second  // lifetime of the mapping created by ExternalIP
+)
+
+// pcpResult is a PCP result code.
+type pcpResult byte
+
+const (
+	pcpSuccess pcpResult = iota
+	pcpUnsuppVersion
+	pcpNotAuthorized
+	pcpMalformedRequest
+	pcpUnsuppOpcode
+	pcpUnsuppOption
+	pcpMalformedOption
+	pcpNetworkFailure
+	pcpNoResources
+	pcpUnsuppProtocol
+	pcpUserExQuota
+	pcpCannotProvideExternal
+	pcpAddressMismatch
+	pcpExcessiveRemotePeers
+)
+
+var pcpResultNames = [...]string{
+	"SUCCESS", "UNSUPP_VERSION", "NOT_AUTHORIZED", "MALFORMED_REQUEST", "UNSUPP_OPCODE",
+	"UNSUPP_OPTION", "MALFORMED_OPTION", "NETWORK_FAILURE", "NO_RESOURCES", "UNSUPP_PROTOCOL",
+	"USER_EX_QUOTA", "CANNOT_PROVIDE_EXTERNAL", "ADDRESS_MISMATCH", "EXCESSIVE_REMOTE_PEERS",
+}
+
+func (r pcpResult) Error() string {
+	if int(r) < len(pcpResultNames) {
+		return "PCP error " + pcpResultNames[r]
+	}
+	return fmt.Sprintf("PCP error %d", byte(r))
+}
+
+var errPCPTimeout = errors.New("PCP request timed out")
+
+// pcpMappingKey identifies a mapping of the local node.
+type pcpMappingKey struct {
+	protocol byte
+	intport  uint16
+}
+
+// pcpMapping is a mapping created on the gateway. The nonce identifies the
+// mapping on the gateway, it has to be the same for renewal and deletion.
+type pcpMapping struct {
+	nonce   [12]byte
+	extport uint16
+	expires time.Time   // end of the lifetime requested by the caller
+	renew   *time.Timer // renews the mapping if the gateway grants shorter lifetimes
+}
+
+// pcpMapResult is the content of a MAP response.
+type pcpMapResult struct {
+	nonce    [12]byte
+	protocol byte
+	intport  uint16
+	extport  uint16
+	extIP    net.IP
+	lifetime time.Duration
+}
+
+// pcp implements the Port Control Protocol client.
+type pcp struct {
+	gw   net.IP
+	port int
+
+	mu       sync.Mutex // serializes requests and protects the fields below
+	extIP    net.IP
+	epoch    uint32    // server epoch of the last response
+	epochAt  time.Time // time of the last response
+	mappings map[pcpMappingKey]*pcpMapping
+}
+
+func newPCP(gw net.IP, port int) *pcp {
+	return &pcp{gw: gw, port: port, mappings: make(map[pcpMappingKey]*pcpMapping)}
+}
+
+func (n *pcp) String() string {
+	return fmt.Sprintf("PCP(%v)", n.gw)
+}
+
+func (n *pcp) MarshalText() ([]byte, error) {
+	return fmt.Appendf(nil, "pcp:%v", n.gw), nil
+}
+
+// ExternalIP returns the external address reported by the gateway. PCP reports it in
+// mapping responses only, so a short-lived mapping of an unused port is created if
+// there are no mappings yet.
+func (n *pcp) ExternalIP() (net.IP, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.extIP != nil && len(n.mappings) > 0 {
+		return n.extIP, nil
+	}
+	var nonce [12]byte
+	crand.Read(nonce[:])
+	res, err := n.requestMap(nonce, pcpProtoUDP, pcpProbePort, 0, pcpProbeLifetime)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := n.requestMap(nonce, pcpProtoUDP, pcpProbePort, 0, 0); err != nil {
+		log.Debug("Couldn't delete PCP probe mapping", "err", err)
+	}
+	return res.extIP, nil
+}
+
+func (n *pcp) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) (uint16, error) {
+	if lifetime <= 0 {
+		return 0, errors.New("lifetime must not be <= 0")
+	}
+	proto, err := pcpProtocol(protocol)
+	if err != nil {
+		return 0, err
+	}
+	if extport == 0 {
+		extport = intport
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := pcpMappingKey{proto, uint16(intport)}
+	m := n.mappings[key]
+	if m == nil {
+		m = new(pcpMapping)
+		crand.Read(m.nonce[:])
+		n.mappings[key] = m
+	}
+	m.expires = time.Now().Add(lifetime)
+	if err := n.refresh(key, m, uint16(extport)); err != nil {
+		return 0, err
+	}
+	// Like NAT-PMP, PCP may assign an alternative port. Handling of alternate
+	// port numbers is done by the caller.
+	return m.extport, nil
+}
+
+func (n *pcp) DeleteMapping(protocol string, extport, intport int) error {
+	proto, err := pcpProtocol(protocol)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := pcpMappingKey{proto, uint16(intport)}
+	m := n.mappings[key]
+	if m == nil {
+		return nil // The gateway only accepts deletions with the nonce of the mapping.
+	}
+	if m.renew != nil {
+		m.renew.Stop()
+	}
+	delete(n.mappings, key)
+	_, err = n.requestMap(m.nonce, proto, key.intport, 0, 0)
+	return err
+}
+
+// refresh creates or renews a mapping on the gateway, for the remainder of the
+// lifetime requested by the caller. If the gateway grants a shorter lifetime, the
+// mapping is renewed at half of it as recommended by RFC 6887.
+func (n *pcp) refresh(key pcpMappingKey, m *pcpMapping, extport uint16) error {
+	if m.renew != nil {
+		m.renew.Stop()
+		m.renew = nil
+	}
+	remaining := time.Until(m.expires)
+	if remaining < time.Second {
+		return nil
+	}
+	res, err := n.requestMap(m.nonce, key.protocol, key.intport, extport, remaining)
+	if err != nil {
+		return err
+	}
+	m.extport = res.extport
+	if res.lifetime < remaining {
+		m.renew = time.AfterFunc(res.lifetime/2, func() { n.renew(key, m) })
+	}
+	return nil
+}
+
+// renew is invoked by the renewal timer of a mapping.
+func (n *pcp) renew(key pcpMappingKey, m *pcpMapping) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.mappings[key] != m {
+		return // deleted in the meantime
+	}
+	if err := n.refresh(key, m, m.extport); err != nil {
+		log.Debug("Couldn't renew PCP mapping", "proto", key.protocol, "intport", key.intport, "err", err)
+		if time.Until(m.expires) > pcpRenewRetry {
+			m.renew = time.AfterFunc(pcpRenewRetry, func() { n.renew(key, m) })
+		}
+	}
+}
+
+// requestMap sends a MAP request. A zero lifetime deletes the mapping.
+func (n *pcp) requestMap(nonce [12]byte, protocol byte, intport, extport uint16, lifetime time.Duration) (*pcpMapResult, error) {
+	payload := make([]byte, pcpMapSize)
+	copy(payload, nonce[:])
+	payload[12] = protocol
+	binary.BigEndian.PutUint16(payload[16:], intport)
+	binary.BigEndian.PutUint16(payload[18:], extport)
+	if n.gw.To4() != nil {
+		copy(payload[20:], net.IPv4zero.To16()) // no suggested IPv4 address
+	}
+	resp, err := n.request(pcpOpMap, uint32(lifetime/time.Second), payload, func(payload []byte) bool {
+		return len(payload) >= pcpMapSize && [12]byte(payload) == nonce
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &pcpMapResult{
+		nonce:    nonce,
+		protocol: resp.payload[12],
+		intport:  binary.BigEndian.Uint16(resp.payload[16:]),
+		extport:  binary.BigEndian.Uint16(resp.payload[18:]),
+		extIP:    pcpAddress(resp.payload[20:36]),
+		lifetime: time.Duration(resp.lifetime) * time.Second,
+	}
+	if lifetime > 0 {
+		n.extIP = res.extIP
+	}
+	return res, nil
+}
+
+// pcpResponse is a successful response of the gateway.
+type pcpResponse struct {
+	lifetime uint32
+	epoch    uint32
+	payload  []byte
+}
+
+// request sends a request to the gateway and waits for the matching response,
+// retransmitting the request with exponential backoff.
+func (n *pcp) request(op byte, lifetime uint32, payload []byte, match func([]byte) bool) (*pcpResponse, error) {
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: n.gw, Port: n.port})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// The request has to carry the address the gateway sees as the source.
+	req := make([]byte, pcpHeaderSize+len(payload))
+	req[0] = pcpVersion
+	req[1] = op
+	binary.BigEndian.PutUint32(req[4:], lifetime)
+	copy(req[8:24], conn.LocalAddr().(*net.UDPAddr).IP.To16())
+	copy(req[pcpHeaderSize:], payload)
+
+	buf := make([]byte, pcpMaxRespSize)
+	timeout := pcpInitialTimeout
+	for i := 0; i < pcpMaxAttempts; i++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		for {
+			nbytes, err := conn.Read(buf)
+			if err != nil {
+				var nerr net.Error
+				if errors.As(err, &nerr) && nerr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			resp := buf[:nbytes]
+			if len(resp) < pcpHeaderSize || resp[0] != pcpVersion || resp[1] != op|pcpResponseBit {
+				continue
+			}
+			if match != nil && !match(resp[pcpHeaderSize:]) {
+				continue
+			}
+			if result := pcpResult(resp[3]); result != pcpSuccess {
+				return nil, result
+			}
+			res := &pcpResponse{
+				lifetime: binary.BigEndian.Uint32(resp[4:]),
+				epoch:    binary.BigEndian.Uint32(resp[8:]),
+				payload:  resp[pcpHeaderSize:],
+			}
+			n.checkEpoch(res.epoch)
+			return res, nil
+		}
+		timeout *= 2
+	}
+	return nil, errPCPTimeout
+}
+
+// checkEpoch detects the loss of the gateway state, e.g. due to a reboot, by
+// comparing the progress of the server epoch with the local clock as described in
+// RFC 6887 section 8.5. All mappings are recreated if the state was lost.
+func (n *pcp) checkEpoch(epoch uint32) {
+	now := time.Now()
+	prev, prevAt := n.epoch, n.epochAt
+	n.epoch, n.epochAt = epoch, now
+	if prevAt.IsZero() {
+		return
+	}
+	var lost bool
+	if epoch+1 < prev {
+		lost = true
+	} else {
+		clientDelta := uint32(now.Sub(prevAt) / time.Second)
+		serverDelta := epoch - min(epoch, prev)
+		lost = clientDelta+2 < serverDelta-serverDelta/16 || serverDelta+2 < clientDelta-clientDelta/16
+	}
+	if !lost {
+		return
+	}
+	log.Debug("PCP gateway state lost, recreating mappings", "gateway", n.gw)
+	n.extIP = nil
+	for key, m := range n.mappings {
+		if m.renew != nil {
+			m.renew.Stop()
+		}
+		m.renew = time.AfterFunc(0, func() { n.renew(key, m) })
+	}
+}
+
+// pcpProtocol returns the IANA protocol number of a protocol name.
+func pcpProtocol(protocol string) (byte, error) {
+	switch strings.ToUpper(protocol) {
+	case "TCP":
+		return pcpProtoTCP, nil
+	case "UDP":
+		return pcpProtoUDP, nil
+	default:
+		return 0, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+}
+
+// pcpAddress decodes an address field, which holds IPv4 addresses in their
+// IPv4-mapped IPv6 form.
+func pcpAddress(b []byte) net.IP {
+	ip := net.IP(b).To16()
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+func discoverPCP() Interface {
+	// Announce ourselves to all potential gateways, the gateways speaking PCP
+	// respond with success.
+	gws := potentialGateways()
+	found := make(chan *pcp, len(gws))
+	for i := range gws {
+		gw := newPCP(gws[i], pcpPort)
+		go func() {
+			if _, err := gw.request(pcpOpAnnounce, 0, nil, nil); err != nil {
+				found <- nil
+			} else {
+				found <- gw
+			}
+		}()
+	}
+	// Return the one that responds first.
+	timeout := time.NewTimer(1 * time.Second)
+	defer timeout.Stop()
+	for range gws {
+		select {
+		case c := <-found:
+			if c != nil {
+				return c
+			}
+		case <-timeout.C:
+			return nil
+		}
+	}
+	return nil
+}
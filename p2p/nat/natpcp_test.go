@@ -0,0 +1,299 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePCPGateway is a PCP server for testing. It assigns the requested external
+// ports if they are free, and reports the internal address as the external one
+// for IPv6 clients, like an IPv6 firewall.
+type fakePCPGateway struct {
+	conn        *net.UDPConn
+	extIP       net.IP
+	maxLifetime uint32
+
+	mu       sync.Mutex
+	epoch    uint32
+	mappings map[[12]byte]fakePCPMapping
+	requests map[[12]byte]int
+}
+
+type fakePCPMapping struct {
+	client  net.IP
+	extport uint16
+}
+
+func startFakePCPGateway(t *testing.T, network, addr string) *fakePCPGateway {
+	conn, err := net.ListenUDP(network, &net.UDPAddr{IP: net.ParseIP(addr)})
+	if err != nil {
+		t.Skipf("can't listen on %s: %v", addr, err)
+	}
+	gw := &fakePCPGateway{
+		conn:        conn,
+		extIP:       net.IP{198, 51, 100, 1},
+		maxLifetime: 3600,
+		epoch:       1000,
+		mappings:    make(map[[12]byte]fakePCPMapping),
+		requests:    make(map[[12]byte]int),
+	}
+	t.Cleanup(func() { conn.Close() })
+	go gw.serve()
+	return gw
+}
+
+func (gw *fakePCPGateway) client() *pcp {
+	addr := gw.conn.LocalAddr().(*net.UDPAddr)
+	return newPCP(addr.IP, addr.Port)
+}
+
+func (gw *fakePCPGateway) serve() {
+	buf := make([]byte, pcpMaxRespSize)
+	for {
+		n, from, err := gw.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if resp := gw.handle(buf[:n], from); resp != nil {
+			gw.conn.WriteToUDP(resp, from)
+		}
+	}
+}
+
+func (gw *fakePCPGateway) handle(req []byte, from *net.UDPAddr) []byte {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+
+	if len(req) < pcpHeaderSize || req[0] != pcpVersion {
+		return nil
+	}
+	op := req[1]
+	resp := make([]byte, len(req))
+	copy(resp[pcpHeaderSize:], req[pcpHeaderSize:])
+	resp[0] = pcpVersion
+	resp[1] = op | pcpResponseBit
+	binary.BigEndian.PutUint32(resp[8:], gw.epoch)
+
+	client := pcpAddress(req[8:24])
+	switch {
+	case !client.Equal(from.IP):
+		resp[3] = byte(pcpAddressMismatch)
+	case op == pcpOpAnnounce:
+	case op == pcpOpMap && len(req) == pcpHeaderSize+pcpMapSize:
+		var (
+			lifetime = min(binary.BigEndian.Uint32(req[4:]), gw.maxLifetime)
+			payload  = resp[pcpHeaderSize:]
+			nonce    = [12]byte(payload)
+			m, ok    = gw.mappings[nonce]
+		)
+		gw.requests[nonce]++
+		if lifetime == 0 {
+			delete(gw.mappings, nonce)
+			break
+		}
+		if !ok {
+			m = fakePCPMapping{client: client, extport: binary.BigEndian.Uint16(payload[18:])}
+			for gw.portTaken(m.extport) {
+				m.extport++
+			}
+			gw.mappings[nonce] = m
+		}
+		binary.BigEndian.PutUint32(resp[4:], lifetime)
+		binary.BigEndian.PutUint16(payload[18:], m.extport)
+		if client.To4() != nil {
+			copy(payload[20:], gw.extIP.To16())
+		} else {
+			copy(payload[20:], client.To16())
+		}
+	default:
+		resp[3] = byte(pcpUnsuppOpcode)
+	}
+	return resp
+}
+
+func (gw *fakePCPGateway) portTaken(port uint16) bool {
+	for _, m := range gw.mappings {
+		if m.extport == port {
+			return true
+		}
+	}
+	return false
+}
+
+func (gw *fakePCPGateway) mappingCount() int {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	return len(gw.mappings)
+}
+
+func (gw *fakePCPGateway) requestCount(nonce [12]byte) int {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	return gw.requests[nonce]
+}
+
+// reboot drops all mappings and resets the epoch.
+func (gw *fakePCPGateway) reboot() {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	gw.epoch = 0
+	clear(gw.mappings)
+}
+
+func (n *pcp) mapping(protocol byte, intport uint16) *pcpMapping {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.mappings[pcpMappingKey{protocol, intport}]
+}
+
+func TestPCPMapping(t *testing.T) {
+	gw := startFakePCPGateway(t, "udp4", "127.0.0.1")
+	n := gw.client()
+
+	port, err := n.AddMapping("TCP", 30303, 30303, "test", 10*time.Minute)
+	if err != nil {
+		t.Fatal("AddMapping error:", err)
+	}
+	if port != 30303 {
+		t.Fatalf("wrong external port: have %d, want %d", port, 30303)
+	}
+	ip, err := n.ExternalIP()
+	if err != nil {
+		t.Fatal("ExternalIP error:", err)
+	}
+	if !ip.Equal(gw.extIP) {
+		t.Fatalf("wrong external IP: have %v, want %v", ip, gw.extIP)
+	}
+	// Refreshing the mapping should keep it.
+	if port, err := n.AddMapping("TCP", 30303, 30303, "test", 10*time.Minute); err != nil || port != 30303 {
+		t.Fatalf("refresh failed: port %d, err %v", port, err)
+	}
+	// Another client gets an alternative port.
+	port, err = gw.client().AddMapping("TCP", 30303, 30304, "test", 10*time.Minute)
+	if err != nil {
+		t.Fatal("AddMapping error:", err)
+	}
+	if port == 30303 {
+		t.Fatal("conflicting external port assigned")
+	}
+	if count := gw.mappingCount(); count != 2 {
+		t.Fatalf("wrong mapping count: have %d, want %d", count, 2)
+	}
+	if err := n.DeleteMapping("TCP", 30303, 30303); err != nil {
+		t.Fatal("DeleteMapping error:", err)
+	}
+	if count := gw.mappingCount(); count != 1 {
+		t.Fatalf("wrong mapping count after deletion: have %d, want %d", count, 1)
+	}
+}
+
+func TestPCPExternalIPProbe(t *testing.T) {
+	gw := startFakePCPGateway(t, "udp4", "127.0.0.1")
+
+	ip, err := gw.client().ExternalIP()
+	if err != nil {
+		t.Fatal("ExternalIP error:", err)
+	}
+	if !ip.Equal(gw.extIP) {
+		t.Fatalf("wrong external IP: have %v, want %v", ip, gw.extIP)
+	}
+	if count := gw.mappingCount(); count != 0 {
+		t.Fatalf("probe mapping not deleted")
+	}
+}
+
+func TestPCPRenewal(t *testing.T) {
+	gw := startFakePCPGateway(t, "udp4", "127.0.0.1")
+	gw.mu.Lock()
+	gw.maxLifetime = 2
+	gw.mu.Unlock()
+	n := gw.client()
+
+	if _, err := n.AddMapping("UDP", 30303, 30303, "test", 10*time.Minute); err != nil {
+		t.Fatal("AddMapping error:", err)
+	}
+	nonce := n.mapping(pcpProtoUDP, 30303).nonce
+	deadline := time.Now().Add(5 * time.Second)
+	for gw.requestCount(nonce) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("mapping not renewed, %d requests", gw.requestCount(nonce))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := n.DeleteMapping("UDP", 30303, 30303); err != nil {
+		t.Fatal("DeleteMapping error:", err)
+	}
+	if count := gw.mappingCount(); count != 0 {
+		t.Fatalf("mapping not deleted")
+	}
+}
+
+func TestPCPGatewayReboot(t *testing.T) {
+	gw := startFakePCPGateway(t, "udp4", "127.0.0.1")
+	n := gw.client()
+
+	if _, err := n.AddMapping("TCP", 30303, 30303, "test", 10*time.Minute); err != nil {
+		t.Fatal("AddMapping error:", err)
+	}
+	gw.reboot()
+
+	// The reboot is detected on the next response, and the lost mapping is recreated.
+	if _, err := n.AddMapping("UDP", 30303, 30303, "test", 10*time.Minute); err != nil {
+		t.Fatal("AddMapping error:", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for gw.mappingCount() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("lost mapping not recreated")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestPCPPinhole(t *testing.T) {
+	gw := startFakePCPGateway(t, "udp6", "::1")
+	n := gw.client()
+
+	if _, err := n.AddMapping("TCP", 30303, 30303, "test", 10*time.Minute); err != nil {
+		t.Fatal("AddMapping error:", err)
+	}
+	ip, err := n.ExternalIP()
+	if err != nil {
+		t.Fatal("ExternalIP error:", err)
+	}
+	if !ip.Equal(net.IPv6loopback) {
+		t.Fatalf("wrong external IP: have %v, want %v", ip, net.IPv6loopback)
+	}
+}
+
+func TestPCPTimeout(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	n := newPCP(net.IP{127, 0, 0, 1}, conn.LocalAddr().(*net.UDPAddr).Port)
+	if _, err := n.AddMapping("TCP", 30303, 30303, "test", 10*time.Minute); err != errPCPTimeout {
+		t.Fatalf("wrong error: have %v, want %v", err, errPCPTimeout)
+	}
+}
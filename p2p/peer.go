@@ -118,6 +118,9 @@ type Peer struct {
 	// events receives message send / receive events if set
 	events   *event.Feed
 	testPipe *MsgPipeRW // for testing
+
+	// reputation tracks the score of the peer, nil if not tracked
+	reputation *reputation
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -0,0 +1,212 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+const (
+	// Reputation scores are bounded so that a long history of good (or bad)
+	// behaviour can still be overturned in reasonable time.
+	maxReputation = 100
+	minReputation = -100
+
+	// reputationHalfLife is the time it takes for a score to decay to half of
+	// its value, so that the recent behaviour of a node weighs more.
+	reputationHalfLife = 24 * time.Hour
+
+	// slowResponseLatency is the latency of a served request which is neither
+	// rewarded nor penalized. Faster responses increase the score, slower
+	// ones decrease it.
+	slowResponseLatency = 5 * time.Second
+
+	// Scored nodes are fed into the dialer as candidates if their score is at
+	// least goodReputation.
+	goodReputation      = 10
+	goodReputationNodes = 32
+)
+
+// ScoreEvent is an observed behaviour of a peer which affects its reputation.
+type ScoreEvent int
+
+const (
+	ScoreTimeout ScoreEvent = iota // A request was not answered in time
+	ScoreInvalid                   // The peer sent invalid or unrequested data
+	ScoreUseful                    // The peer delivered something new, e.g. unknown transactions
+)
+
+// weight returns the score change caused by the event.
+func (ev ScoreEvent) weight() float64 {
+	switch ev {
+	case ScoreTimeout:
+		return -5
+	case ScoreInvalid:
+		return -20
+	case ScoreUseful:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// Scorer is implemented by the peers whose reputation is tracked. The protocol
+// peers embedding *Peer implement it implicitly, it allows the protocol handlers
+// to report the behaviour of the peers hidden behind their own interfaces.
+type Scorer interface {
+	Score(ev ScoreEvent)
+	ScoreResponse(latency time.Duration)
+}
+
+// responseWeight returns the score change of a request served with the given
+// latency, between -1 and 1.
+func responseWeight(latency time.Duration) float64 {
+	w := 1 - float64(latency)/float64(slowResponseLatency)
+	return max(-1, min(1, w))
+}
+
+// reputationScore is a decaying reputation score.
+type reputationScore struct {
+	value   float64
+	updated time.Time
+}
+
+// at returns the value of the score decayed until the given time.
+func (s reputationScore) at(now time.Time) float64 {
+	elapsed := now.Sub(s.updated)
+	if elapsed <= 0 || s.value == 0 {
+		return s.value
+	}
+	return s.value * math.Exp2(-float64(elapsed)/float64(reputationHalfLife))
+}
+
+// reputation tracks the scores of the nodes based on their behaviour reported
+// by the protocol handlers. The scores of the connected peers are kept in memory
+// and they are persisted into the node database on disconnect, so that the dial
+// scheduler and future connections can use them.
+type reputation struct {
+	db  *enode.DB
+	now func() time.Time
+
+	lock  sync.Mutex
+	peers map[enode.ID]*reputationScore // scores of the connected peers
+}
+
+func newReputation(db *enode.DB) *reputation {
+	return &reputation{
+		db:    db,
+		now:   time.Now,
+		peers: make(map[enode.ID]*reputationScore),
+	}
+}
+
+// load retrieves the persisted score of the given node.
+func (r *reputation) load(id enode.ID) reputationScore {
+	value, updated := r.db.NodeScore(id)
+	return reputationScore{value: value, updated: updated}
+}
+
+// connected starts tracking the score of a connected peer in memory.
+func (r *reputation) connected(id enode.ID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	score := r.load(id)
+	r.peers[id] = &score
+}
+
+// disconnected persists the score of a disconnected peer. The node record is
+// stored along with the score if it's dialable.
+func (r *reputation) disconnected(id enode.ID, node *enode.Node) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	score, ok := r.peers[id]
+	if !ok {
+		return
+	}
+	delete(r.peers, id)
+	r.db.UpdateNodeScore(id, score.value, score.updated, node)
+}
+
+// record applies the given change to the score of the node. The persisted score
+// is updated directly if the node is not connected anymore.
+func (r *reputation) record(id enode.ID, delta float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	score, ok := r.peers[id]
+	if !ok {
+		stored := r.load(id)
+		score = &stored
+	}
+	score.value = max(minReputation, min(maxReputation, score.at(now)+delta))
+	score.updated = now
+	if !ok {
+		r.db.UpdateNodeScore(id, score.value, score.updated, nil)
+	}
+}
+
+// score returns the current score of the node.
+func (r *reputation) score(id enode.ID) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if score, ok := r.peers[id]; ok {
+		return score.at(r.now())
+	}
+	return r.load(id).at(r.now())
+}
+
+// goodNodes returns the previously seen nodes with a good reputation. The
+// persisted scores are filtered again after decaying them.
+func (r *reputation) goodNodes() []*enode.Node {
+	nodes := r.db.QueryScoredNodes(goodReputationNodes, goodReputation)
+	return slices.DeleteFunc(nodes, func(n *enode.Node) bool {
+		return r.score(n.ID()) < goodReputation
+	})
+}
+
+// Score records an observed behaviour of the peer in its reputation score.
+func (p *Peer) Score(ev ScoreEvent) {
+	if p.reputation != nil {
+		p.reputation.record(p.ID(), ev.weight())
+	}
+}
+
+// ScoreResponse records a request served by the peer in its reputation score,
+// rewarding fast responses and penalizing slow ones.
+func (p *Peer) ScoreResponse(latency time.Duration) {
+	if p.reputation != nil {
+		p.reputation.record(p.ID(), responseWeight(latency))
+	}
+}
+
+// Reputation returns the current reputation score of the peer. Peers start from
+// zero, and a positive score indicates a useful peer.
+func (p *Peer) Reputation() float64 {
+	if p.reputation == nil {
+		return 0
+	}
+	return p.reputation.score(p.ID())
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	mrand "math/rand"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func newTestReputation(t *testing.T) (*reputation, *time.Time) {
+	db, err := enode.OpenDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	now := time.Unix(1700000000, 0)
+	r := newReputation(db)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestReputationScore(t *testing.T) {
+	r, now := newTestReputation(t)
+	id := enode.ID{1}
+
+	r.connected(id)
+	r.record(id, ScoreInvalid.weight())
+	r.record(id, responseWeight(0))
+	if score := r.score(id); score != -19 {
+		t.Fatalf("wrong score: have %v, want %v", score, -19)
+	}
+	// The score should decay over time
+	*now = now.Add(reputationHalfLife)
+	if score := r.score(id); score != -9.5 {
+		t.Fatalf("wrong decayed score: have %v, want %v", score, -9.5)
+	}
+	// The score should be bounded
+	for i := 0; i < 100; i++ {
+		r.record(id, ScoreInvalid.weight())
+	}
+	if score := r.score(id); score != minReputation {
+		t.Fatalf("wrong bounded score: have %v, want %v", score, minReputation)
+	}
+	// The score should be persisted on disconnect, and reloaded on reconnect
+	r.disconnected(id, nil)
+	if len(r.peers) != 0 {
+		t.Fatal("disconnected peer is still tracked")
+	}
+	if score, _ := r.db.NodeScore(id); score != minReputation {
+		t.Fatalf("wrong persisted score: have %v, want %v", score, minReputation)
+	}
+	r.connected(id)
+	if score := r.score(id); score != minReputation {
+		t.Fatalf("wrong reloaded score: have %v, want %v", score, minReputation)
+	}
+	// Events reported after disconnect should update the persisted score
+	r.disconnected(id, nil)
+	r.record(id, 50)
+	if score, _ := r.db.NodeScore(id); score != minReputation+50 {
+		t.Fatalf("wrong persisted score: have %v, want %v", score, minReputation+50)
+	}
+}
+
+func TestReputationResponseWeight(t *testing.T) {
+	tests := []struct {
+		latency time.Duration
+		want    float64
+	}{
+		{0, 1},
+		{slowResponseLatency / 2, 0.5},
+		{slowResponseLatency, 0},
+		{slowResponseLatency * 3 / 2, -0.5},
+		{slowResponseLatency * 10, -1},
+	}
+	for _, test := range tests {
+		if have := responseWeight(test.latency); math.Abs(have-test.want) > 1e-9 {
+			t.Errorf("latency %v: wrong weight: have %v, want %v", test.latency, have, test.want)
+		}
+	}
+}
+
+func TestReputationGoodNodes(t *testing.T) {
+	r, now := newTestReputation(t)
+
+	var (
+		good    = newNode(enode.ID{1}, "127.0.0.1:30303")
+		decayed = newNode(enode.ID{2}, "127.0.0.1:30304")
+		bad     = newNode(enode.ID{3}, "127.0.0.1:30305")
+		inbound = enode.ID{4}
+	)
+	// Score the decayed node long ago
+	r.connected(decayed.ID())
+	r.record(decayed.ID(), 2*goodReputation)
+	r.disconnected(decayed.ID(), decayed)
+	*now = now.Add(2 * reputationHalfLife)
+
+	for id, delta := range map[enode.ID]float64{good.ID(): 2 * goodReputation, bad.ID(): -goodReputation, inbound: 2 * goodReputation} {
+		r.connected(id)
+		r.record(id, delta)
+	}
+	r.disconnected(good.ID(), good)
+	r.disconnected(bad.ID(), bad)
+	r.disconnected(inbound, nil)
+
+	nodes := r.goodNodes()
+	if len(nodes) != 1 || nodes[0].ID() != good.ID() {
+		t.Fatalf("wrong good nodes: %v", nodes)
+	}
+}
+
+func TestDialSkipLowReputation(t *testing.T) {
+	r, _ := newTestReputation(t)
+	d := &dialScheduler{dialConfig: dialConfig{rand: mrand.New(mrand.NewSource(1)), reputation: r}}
+
+	var (
+		good = newNode(enode.ID{1}, "127.0.0.1:30303")
+		poor = newNode(enode.ID{2}, "127.0.0.1:30304")
+		bad  = newNode(enode.ID{3}, "127.0.0.1:30305")
+	)
+	r.record(good.ID(), 10)
+	r.record(poor.ID(), -dialSkipReputation/2)
+	r.record(bad.ID(), -dialSkipReputation)
+
+	var skipped = make(map[enode.ID]int)
+	for i := 0; i < 1000; i++ {
+		for _, n := range []*enode.Node{good, poor, bad} {
+			if d.skipLowReputation(n) {
+				skipped[n.ID()]++
+			}
+		}
+	}
+	if skipped[good.ID()] != 0 {
+		t.Errorf("node with good reputation skipped %d times", skipped[good.ID()])
+	}
+	if n := skipped[poor.ID()]; n < 400 || n > 600 {
+		t.Errorf("node with poor reputation skipped %d times, want ~500", n)
+	}
+	if skipped[bad.ID()] != 1000 {
+		t.Errorf("node with bad reputation skipped %d times, want 1000", skipped[bad.ID()])
+	}
+}
@@ -20,7 +20,9 @@ package p2p
 import (
 	"bytes"
 	"cmp"
+	"context"
 	"crypto/ecdsa"
+	"crypto/tls"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -40,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/quic-go/quic-go"
 )
 
 const (
@@ -85,17 +88,21 @@ type Server struct {
 	running bool
 
 	listener     net.Listener
+	quic         *quic.Transport // the QUIC transport, nil if disabled
+	quicListener *quic.Listener
+	quicDialTLS  *tls.Config
 	ourHandshake *protoHandshake
 	loopWG       sync.WaitGroup // loop, listenLoop
 	peerFeed     event.Feed
 	log          log.Logger
 
-	nodedb    *enode.DB
-	localnode *enode.LocalNode
-	discv4    *discover.UDPv4
-	discv5    *discover.UDPv5
-	discmix   *enode.FairMix
-	dialsched *dialScheduler
+	nodedb     *enode.DB
+	reputation *reputation
+	localnode  *enode.LocalNode
+	discv4     *discover.UDPv4
+	discv5     *discover.UDPv5
+	discmix    *enode.FairMix
+	dialsched  *dialScheduler
 
 	// This is read by the NAT port mapping loop.
 	portMappingRegister chan *portMapping
@@ -325,9 +332,15 @@ func (srv *Server) Stop() {
 		// this unblocks listener Accept
 		srv.listener.Close()
 	}
+	if srv.quicListener != nil {
+		srv.quicListener.Close()
+	}
 	close(srv.quit)
 	srv.lock.Unlock()
 	srv.loopWG.Wait()
+	if srv.quic != nil {
+		srv.quic.Close()
+	}
 }
 
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
@@ -405,6 +418,11 @@ func (srv *Server) Start() (err error) {
 			return err
 		}
 	}
+	if srv.QUICListenAddr != "" {
+		if err := srv.setupQUICListening(); err != nil {
+			return err
+		}
+	}
 	if err := srv.setupDiscovery(); err != nil {
 		return err
 	}
@@ -430,6 +448,7 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.reputation = newReputation(db)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	// TODO: check conflicts
@@ -515,6 +534,12 @@ func (srv *Server) setupDiscovery() error {
 			srv.discmix.AddSource(enode.WithSourceName("discv5-default", it))
 		}
 	}
+
+	// Redial the nodes which proved to be useful in the past.
+	if nodes := srv.reputation.goodNodes(); len(nodes) > 0 {
+		srv.log.Debug("Adding nodes with good reputation as dial candidates", "count", len(nodes))
+		srv.discmix.AddSource(enode.WithSourceName("reputation", enode.IterNodes(nodes)))
+	}
 	return nil
 }
 
@@ -527,6 +552,8 @@ func (srv *Server) setupDialScheduler() {
 		netRestrict:    srv.NetRestrict,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
+		reputation:     srv.reputation,
+		membership:     srv.Membership,
 	}
 	if srv.discv4 != nil {
 		config.resolver = srv.discv4
@@ -534,6 +561,10 @@ func (srv *Server) setupDialScheduler() {
 	if config.dialer == nil {
 		config.dialer = tcpDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
+	if srv.quic != nil {
+		config.dialer = &quicDialer{fallback: config.dialer, transport: srv.quic, tls: srv.quicDialTLS}
+		config.quic = true
+	}
 	srv.dialsched = newDialScheduler(config, srv.discmix, srv.SetupConn)
 	for _, n := range srv.StaticNodes {
 		srv.dialsched.addStatic(n)
@@ -586,6 +617,90 @@ func (srv *Server) setupListening() error {
 	return nil
 }
 
+func (srv *Server) setupQUICListening() error {
+	addr, err := net.ResolveUDPAddr("udp", srv.QUICListenAddr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	listenTLS, dialTLS, err := newQUICTLSConfig()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	tr := &quic.Transport{Conn: conn}
+	listener, err := tr.Listen(listenTLS, quicConfig)
+	if err != nil {
+		tr.Close()
+		conn.Close()
+		return err
+	}
+	srv.quic, srv.quicListener, srv.quicDialTLS = tr, listener, dialTLS
+
+	// Update the local node record and map the QUIC port if NAT is configured.
+	laddr := conn.LocalAddr().(*net.UDPAddr)
+	srv.QUICListenAddr = laddr.String()
+	srv.localnode.Set(quicEntry(laddr.Port))
+	if !laddr.IP.IsLoopback() && !laddr.IP.IsPrivate() {
+		srv.portMappingRegister <- &portMapping{
+			protocol: "UDP",
+			name:     quicPortMappingName,
+			port:     laddr.Port,
+		}
+	}
+
+	srv.loopWG.Add(1)
+	go srv.quicListenLoop()
+	return nil
+}
+
+// quicListenLoop accepts inbound connections of the QUIC transport.
+func (srv *Server) quicListenLoop() {
+	srv.log.Debug("QUIC listener up", "addr", srv.quicListener.Addr())
+
+	// The slots channel limits accepts of new connections.
+	tokens := defaultMaxPendingPeers
+	if srv.MaxPendingPeers > 0 {
+		tokens = srv.MaxPendingPeers
+	}
+	slots := make(chan struct{}, tokens)
+	for i := 0; i < tokens; i++ {
+		slots <- struct{}{}
+	}
+	defer srv.loopWG.Done()
+	defer func() {
+		for i := 0; i < cap(slots); i++ {
+			<-slots
+		}
+	}()
+
+	for {
+		<-slots
+		qc, err := srv.quicListener.Accept(context.Background())
+		if err != nil {
+			srv.log.Debug("QUIC accept error", "err", err)
+			slots <- struct{}{}
+			return
+		}
+		remoteIP := netutil.AddrAddr(qc.RemoteAddr())
+		if err := srv.checkInboundConn(remoteIP); err != nil {
+			srv.log.Debug("Rejected inbound QUIC connection", "addr", qc.RemoteAddr(), "err", err)
+			qc.CloseWithError(quicCloseNoReason, "")
+			slots <- struct{}{}
+			continue
+		}
+		serveMeter.Mark(1)
+		srv.log.Trace("Accepted QUIC connection", "addr", qc.RemoteAddr())
+		go func() {
+			srv.SetupConn(&quicNetConn{qc}, inboundConn, nil)
+			slots <- struct{}{}
+		}()
+	}
+}
+
 func (srv *Server) setupUDPListening() (*net.UDPConn, error) {
 	listenAddr := srv.ListenAddr
 
@@ -643,6 +758,13 @@ func (srv *Server) run() {
 	for _, n := range srv.TrustedNodes {
 		trusted[n.ID()] = true
 	}
+	// Track updates of the member set to drop peers which are no longer members.
+	var membershipCh chan struct{}
+	if srv.Membership != nil {
+		membershipCh = make(chan struct{}, 1)
+		sub := srv.Membership.SubscribeChanges(membershipCh)
+		defer sub.Unsubscribe()
+	}
 
 running:
 	for {
@@ -669,6 +791,14 @@ running:
 				p.rw.set(trustedConn, false)
 			}
 
+		case <-membershipCh:
+			for id, p := range peers {
+				if !srv.Membership.IsMember(id) {
+					p.Log().Debug("Dropping peer which is not a network member")
+					p.Disconnect(DiscUselessPeer)
+				}
+			}
+
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -710,7 +840,8 @@ running:
 			// A peer disconnected.
 			d := common.PrettyDuration(mclock.Now() - pd.created)
 			delete(peers, pd.ID())
-			srv.log.Debug("Removing p2p peer", "peercount", len(peers), "id", pd.ID(), "duration", d, "req", pd.requested, "err", pd.err)
+			srv.log.Debug("Removing p2p peer", "peercount", len(peers), "id", pd.ID(), "duration", d, "req", pd.requested, "err", pd.err, "score", pd.Reputation())
+			srv.releasePeer(pd.Peer)
 			srv.dialsched.peerRemoved(pd.rw)
 			if pd.Inbound() {
 				inboundCount--
@@ -742,6 +873,7 @@ running:
 		p := <-srv.delpeer
 		p.log.Trace("<-delpeer (spindown)")
 		delete(peers, p.ID())
+		srv.releasePeer(p.Peer)
 	}
 }
 
@@ -755,6 +887,8 @@ func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount in
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case srv.Membership != nil && !srv.Membership.IsMember(c.node.ID()):
+		return errNotMember
 	default:
 		return nil
 	}
@@ -863,10 +997,14 @@ func (srv *Server) checkInboundConn(remoteIP netip.Addr) error {
 // or the handshakes have failed.
 func (srv *Server) SetupConn(fd net.Conn, flags connFlag, dialDest *enode.Node) error {
 	c := &conn{fd: fd, flags: flags, cont: make(chan error)}
-	if dialDest == nil {
-		c.transport = srv.newTransport(fd, nil)
+	var dialPubkey *ecdsa.PublicKey
+	if dialDest != nil {
+		dialPubkey = dialDest.Pubkey()
+	}
+	if qc, ok := asQUICConn(fd); ok {
+		c.transport = newQUICTransport(qc, dialPubkey)
 	} else {
-		c.transport = srv.newTransport(fd, dialDest.Pubkey())
+		c.transport = srv.newTransport(fd, dialPubkey)
 	}
 
 	err := srv.setupConn(c, dialDest)
@@ -941,9 +1079,13 @@ func (srv *Server) setupConn(c *conn, dialDest *enode.Node) error {
 func nodeFromConn(pubkey *ecdsa.PublicKey, conn net.Conn) *enode.Node {
 	var ip net.IP
 	var port int
-	if tcp, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
-		ip = tcp.IP
-		port = tcp.Port
+	switch addr := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+		port = addr.Port
+	case *net.UDPAddr:
+		// The listening port of nodes connecting over QUIC is unknown.
+		ip = addr.IP
 	}
 	return enode.NewV4(pubkey, ip, port, port)
 }
@@ -961,15 +1103,36 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 
 func (srv *Server) launchPeer(c *conn) *Peer {
 	p := newPeer(srv.log, c, srv.Protocols)
+	if t, ok := c.transport.(*quicTransport); ok {
+		t.setProtocols(p.running)
+	}
+	p.reputation = srv.reputation
+	p.reputation.connected(c.node.ID())
 	if srv.EnableMsgEvents {
 		// If message events are enabled, pass the peerFeed
 		// to the peer.
 		p.events = &srv.peerFeed
 	}
+	if srv.CaptureDir != "" {
+		if err := startCapture(srv.CaptureDir, srv.localnode.ID(), p); err != nil {
+			p.log.Warn("Failed to capture peer session", "err", err)
+		}
+	}
 	go srv.runPeer(p)
 	return p
 }
 
+// releasePeer persists the reputation score of a disconnected peer. The node
+// record is only stored for dialed peers, as the endpoint of inbound ones is
+// not known to be dialable.
+func (srv *Server) releasePeer(p *Peer) {
+	var node *enode.Node
+	if !p.Inbound() {
+		node = p.Node()
+	}
+	srv.reputation.disconnected(p.ID(), node)
+}
+
 // runPeer runs in its own goroutine for each peer.
 func (srv *Server) runPeer(p *Peer) {
 	if srv.newPeerHook != nil {
@@ -93,7 +93,7 @@ func (srv *Server) portMappingLoop() {
 	}
 
 	var (
-		mappings  = make(map[string]*portMapping, 2)
+		mappings  = make(map[string]*portMapping, 3)
 		refresh   = mclock.NewAlarm(srv.clock)
 		extip     = mclock.NewAlarm(srv.clock)
 		lastExtIP net.IP
@@ -143,7 +143,7 @@ func (srv *Server) portMappingLoop() {
 			if m.protocol != "TCP" && m.protocol != "UDP" {
 				panic("unknown NAT protocol name: " + m.protocol)
 			}
-			mappings[m.protocol] = m
+			mappings[m.name] = m
 			m.nextTime = srv.clock.Now()
 
 		case <-refresh.C():
@@ -191,10 +191,12 @@ func (srv *Server) portMappingLoop() {
 					}
 
 					// Update port in local ENR.
-					switch m.protocol {
-					case "TCP":
+					switch {
+					case m.name == quicPortMappingName:
+						srv.localnode.Set(quicEntry(m.extPort))
+					case m.protocol == "TCP":
 						srv.localnode.Set(enr.TCP(m.extPort))
-					case "UDP":
+					case m.protocol == "UDP":
 						srv.localnode.SetFallbackUDP(m.extPort)
 					}
 				}
@@ -26,10 +26,12 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/testlog"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -310,6 +312,79 @@ func TestServerAtCap(t *testing.T) {
 	}
 }
 
+type testMembership struct {
+	mu      sync.Mutex
+	members map[enode.ID]bool
+	feed    event.Feed
+}
+
+func (m *testMembership) IsMember(id enode.ID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.members[id]
+}
+
+func (m *testMembership) SubscribeChanges(ch chan<- struct{}) event.Subscription {
+	return m.feed.Subscribe(ch)
+}
+
+func (m *testMembership) set(id enode.ID, member bool) {
+	m.mu.Lock()
+	m.members[id] = member
+	m.mu.Unlock()
+	m.feed.Send(struct{}{})
+}
+
+// This test checks that connections from nodes which are not on the membership list
+// are rejected, and that peers removed from the list are dropped.
+func TestServerMembership(t *testing.T) {
+	remoteKey := newkey()
+	memberID := enode.PubkeyToIDV4(&remoteKey.PublicKey)
+	members := &testMembership{members: map[enode.ID]bool{memberID: true}}
+	srv := &Server{
+		Config: Config{
+			PrivateKey:  newkey(),
+			MaxPeers:    10,
+			NoDial:      true,
+			NoDiscovery: true,
+			Membership:  members,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id enode.ID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&remoteKey.PublicKey, fd, nil)
+		node := enode.SignNull(new(enr.Record), id)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+
+	// Non-members are rejected after the handshake.
+	if err := srv.checkpoint(newconn(randomID()), srv.checkpointPostHandshake); err != errNotMember {
+		t.Fatalf("wrong error for non-member: %v", err)
+	}
+	// Members are accepted.
+	if err := srv.checkpoint(newconn(memberID), srv.checkpointAddPeer); err != nil {
+		t.Fatalf("could not add member: %v", err)
+	}
+	if srv.PeerCount() != 1 {
+		t.Fatalf("wrong peer count %d, want 1", srv.PeerCount())
+	}
+	// Removing the peer from the list drops it.
+	members.set(memberID, false)
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.PeerCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("peer not dropped after removal from the membership list")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestServerPeerLimits(t *testing.T) {
 	srvkey := newkey()
 	clientkey := newkey()
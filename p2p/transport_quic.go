@@ -0,0 +1,549 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/quic-go/quic-go"
+	"golang.org/x/sync/semaphore"
+)
+
+// The QUIC transport is an experimental alternative to RLPx over TCP. Peers
+// authenticate with their node keys after the TLS handshake by signing keying
+// material exported from the TLS session, which binds the node identity to the
+// encrypted connection.
+//
+// The dialer opens a bidirectional control stream, which carries the
+// authentication, the protocol handshake and the base protocol messages. The
+// messages of each subprotocol are sent on a separate unidirectional stream, so
+// that a large response of one protocol doesn't delay the messages of others.
+// All streams use the same framing: the message code and the payload size as
+// unsigned varints, followed by the payload.
+//
+// A stream may only carry the messages of its own protocol. The messages read
+// from all streams of a peer share a common buffer budget, so that opening many
+// streams doesn't multiply the memory a peer can make the local node allocate.
+const (
+	quicALPN          = "devp2p"
+	quicAuthLabel     = "devp2p quic authentication"
+	quicMaxFrameSize  = 1<<24 - 1 // same as the RLPx frame limit
+	quicMaxBuffered   = 1 << 24   // total size of messages buffered per peer
+	quicKeepAlive     = 10 * time.Second
+	quicMaxUniStreams = 32 // at most one per subprotocol
+
+	// quicPortMappingName is the name of the NAT port mapping of the QUIC port.
+	quicPortMappingName = "ethereum p2p quic"
+
+	// quicCloseNoReason is the QUIC application error code used when closing the
+	// connection without a disconnect reason. Disconnect reasons are sent as the
+	// error code of the connection close.
+	quicCloseNoReason = 0x100
+)
+
+var (
+	errQUICAuth        = errors.New("invalid QUIC authentication")
+	errQUICReadTimeout = errors.New("read timeout")
+	errQUICNetConn     = errors.New("QUIC connections are read and written through streams")
+)
+
+var quicConfig = &quic.Config{
+	HandshakeIdleTimeout:  handshakeTimeout,
+	MaxIdleTimeout:        frameReadTimeout,
+	KeepAlivePeriod:       quicKeepAlive,
+	MaxIncomingStreams:    1,
+	MaxIncomingUniStreams: quicMaxUniStreams,
+}
+
+// quicEntry is the "dquic" ENR key, which holds the UDP port of the devp2p QUIC
+// transport. The "quic" key isn't used because it denotes the libp2p QUIC port in
+// the node records of consensus layer clients.
+type quicEntry uint16
+
+func (quicEntry) ENRKey() string { return "dquic" }
+
+// quicEndpoint returns the announced QUIC endpoint of the node.
+func quicEndpoint(n *enode.Node) (netip.AddrPort, bool) {
+	var port quicEntry
+	if n.Load(&port) != nil || port == 0 || !n.IPAddr().IsValid() {
+		return netip.AddrPort{}, false
+	}
+	return netip.AddrPortFrom(n.IPAddr(), uint16(port)), true
+}
+
+// newQUICTLSConfig creates the TLS configurations of the QUIC transport. Since
+// peers are authenticated by their node keys, the listener uses an ephemeral
+// self-signed certificate and the dialer doesn't verify it.
+func newQUICTLSConfig() (listen, dial *tls.Config, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	listen = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: key}},
+		NextProtos:   []string{quicALPN},
+		MinVersion:   tls.VersionTLS13,
+	}
+	dial = &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{quicALPN},
+		MinVersion:         tls.VersionTLS13,
+	}
+	return listen, dial, nil
+}
+
+// quicDialer dials nodes over QUIC if they announce a QUIC endpoint, falling back
+// to the regular dialer otherwise.
+type quicDialer struct {
+	fallback  NodeDialer
+	transport *quic.Transport
+	tls       *tls.Config
+}
+
+func (d *quicDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	addr, ok := quicEndpoint(dest)
+	if !ok {
+		return d.fallback.Dial(ctx, dest)
+	}
+	conn, err := d.transport.Dial(ctx, net.UDPAddrFromAddrPort(addr), d.tls, quicConfig)
+	if err != nil {
+		if _, hasTCP := dest.TCPEndpoint(); hasTCP {
+			return d.fallback.Dial(ctx, dest)
+		}
+		return nil, err
+	}
+	return &quicNetConn{conn}, nil
+}
+
+// quicNetConn adapts a QUIC connection to net.Conn, which the server uses to track
+// the endpoints of a peer. Messages are exchanged on the streams of the connection,
+// so Read and Write are not supported.
+type quicNetConn struct {
+	*quic.Conn
+}
+
+func (c *quicNetConn) Read([]byte) (int, error)         { return 0, errQUICNetConn }
+func (c *quicNetConn) Write([]byte) (int, error)        { return 0, errQUICNetConn }
+func (c *quicNetConn) Close() error                     { return c.CloseWithError(quicCloseNoReason, "") }
+func (c *quicNetConn) SetDeadline(time.Time) error      { return nil }
+func (c *quicNetConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *quicNetConn) SetWriteDeadline(time.Time) error { return nil }
+
+// asQUICConn returns the QUIC connection underlying fd, if any.
+func asQUICConn(fd net.Conn) (*quic.Conn, bool) {
+	if mc, ok := fd.(*meteredConn); ok {
+		fd = mc.Conn
+	}
+	if qc, ok := fd.(*quicNetConn); ok {
+		return qc.Conn, true
+	}
+	return nil, false
+}
+
+// quicSendStream is implemented by both bidirectional and unidirectional streams.
+type quicSendStream interface {
+	io.Writer
+	SetWriteDeadline(time.Time) error
+}
+
+// quicTransport is the transport of peers connected over QUIC.
+type quicTransport struct {
+	conn        *quic.Conn
+	dialDest    *ecdsa.PublicKey
+	ctrl        *quic.Stream
+	ctrlReader  *bufio.Reader
+	readTimeout time.Duration
+
+	in        chan Msg
+	readErr   chan error
+	closing   chan struct{}
+	closeOnce sync.Once
+	budget    *semaphore.Weighted // buffer space for the messages being read
+
+	rmu     sync.Mutex
+	ready   chan struct{}   // closed when the running protocols are known
+	claimed map[uint64]bool // protocol offsets with an inbound stream
+
+	wmu     sync.Mutex
+	wbuf    bytes.Buffer
+	protos  []*protoRW                  // running protocols, for assigning streams
+	streams map[uint64]*quic.SendStream // outbound streams by protocol offset
+}
+
+func newQUICTransport(conn *quic.Conn, dialDest *ecdsa.PublicKey) transport {
+	return &quicTransport{
+		conn:        conn,
+		dialDest:    dialDest,
+		readTimeout: handshakeTimeout,
+		in:          make(chan Msg),
+		readErr:     make(chan error, 1),
+		closing:     make(chan struct{}),
+		budget:      semaphore.NewWeighted(quicMaxBuffered),
+		ready:       make(chan struct{}),
+		claimed:     make(map[uint64]bool),
+		streams:     make(map[uint64]*quic.SendStream),
+	}
+}
+
+func (t *quicTransport) doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+
+	var err error
+	initiator := t.dialDest != nil
+	if initiator {
+		t.ctrl, err = t.conn.OpenStreamSync(ctx)
+	} else {
+		t.ctrl, err = t.conn.AcceptStream(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.ctrlReader = bufio.NewReader(t.ctrl)
+	t.ctrl.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer t.ctrl.SetDeadline(time.Time{})
+
+	// The initiator authenticates first, as the recipient only sees the control
+	// stream once data is sent on it.
+	var remote *ecdsa.PublicKey
+	if initiator {
+		if err = t.writeAuth(prv, true); err == nil {
+			remote, err = t.readAuth(false)
+		}
+	} else {
+		if remote, err = t.readAuth(true); err == nil {
+			err = t.writeAuth(prv, false)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if initiator && !remote.Equal(t.dialDest) {
+		return nil, errors.New("remote node key mismatch")
+	}
+	go t.readStream(t.ctrlReader, true)
+	go t.acceptStreams()
+	return remote, nil
+}
+
+// authDigest computes the message signed by the initiator or the recipient of
+// the connection for authentication.
+func (t *quicTransport) authDigest(initiator bool) ([]byte, error) {
+	tlsState := t.conn.ConnectionState().TLS
+	material, err := tlsState.ExportKeyingMaterial(quicAuthLabel, nil, 32)
+	if err != nil {
+		return nil, err
+	}
+	role := byte(0)
+	if !initiator {
+		role = 1
+	}
+	return crypto.Keccak256(material, []byte{role}), nil
+}
+
+func (t *quicTransport) writeAuth(prv *ecdsa.PrivateKey, initiator bool) error {
+	digest, err := t.authDigest(initiator)
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(digest, prv)
+	if err != nil {
+		return err
+	}
+	return writeQUICFrame(t.ctrl, handshakeMsg, sig)
+}
+
+func (t *quicTransport) readAuth(initiator bool) (*ecdsa.PublicKey, error) {
+	code, sig, err := readQUICFrame(t.ctrlReader)
+	if err != nil {
+		return nil, err
+	}
+	if code != handshakeMsg || len(sig) != crypto.SignatureLength {
+		return nil, errQUICAuth
+	}
+	digest, err := t.authDigest(initiator)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return nil, errQUICAuth
+	}
+	return remote, nil
+}
+
+func (t *quicTransport) doProtoHandshake(our *protoHandshake) (their *protoHandshake, err error) {
+	werr := make(chan error, 1)
+	go func() { werr <- Send(t, handshakeMsg, our) }()
+	if their, err = readProtocolHandshake(t); err != nil {
+		<-werr // make sure the write terminates too
+		return nil, err
+	}
+	if err := <-werr; err != nil {
+		return nil, fmt.Errorf("write error: %v", err)
+	}
+	t.readTimeout = frameReadTimeout
+	return their, nil
+}
+
+// setProtocols assigns the streams of the running protocols. The messages of
+// subprotocols are only read once the protocols are known.
+func (t *quicTransport) setProtocols(running map[string]*protoRW) {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+
+	for _, proto := range running {
+		t.protos = append(t.protos, proto)
+	}
+	close(t.ready)
+}
+
+// acceptStreams reads the messages of all unidirectional streams opened by the
+// remote node.
+func (t *quicTransport) acceptStreams() {
+	for {
+		stream, err := t.conn.AcceptUniStream(context.Background())
+		if err != nil {
+			t.readFailed(err)
+			return
+		}
+		go t.readStream(bufio.NewReader(stream), false)
+	}
+}
+
+// readStream reads the messages of a stream until it fails. The control stream
+// carries the base protocol messages, any other stream the messages of the
+// subprotocol its first message belongs to.
+func (t *quicTransport) readStream(r *bufio.Reader, ctrl bool) {
+	var proto *protoRW
+	for {
+		code, size, err := readQUICHeader(r)
+		if err == nil {
+			switch {
+			case ctrl:
+				if code >= baseProtocolLength {
+					err = newPeerError(errInvalidMsgCode, "%d on control stream", code)
+				}
+			case proto == nil:
+				proto, err = t.claimStream(code)
+			case code < proto.offset || code >= proto.offset+proto.Length:
+				err = newPeerError(errInvalidMsgCode, "%d on %s stream", code, proto.Name)
+			}
+		}
+		if err != nil {
+			t.readFailed(err)
+			return
+		}
+		// Reserve the buffer space of the message before reading it. The space
+		// is released once the message is handed over to the peer.
+		if err := t.budget.Acquire(t.conn.Context(), int64(size)); err != nil {
+			t.readFailed(err)
+			return
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			t.budget.Release(int64(size))
+			t.readFailed(err)
+			return
+		}
+		msg := Msg{
+			Code:       code,
+			Size:       uint32(len(data)),
+			Payload:    bytes.NewReader(data),
+			ReceivedAt: time.Now(),
+			meterSize:  uint32(len(data)),
+		}
+		select {
+		case t.in <- msg:
+		case <-t.closing:
+			t.budget.Release(int64(size))
+			return
+		}
+	}
+}
+
+// claimStream assigns an inbound stream to the subprotocol of its first message,
+// waiting until the running protocols are known. Each subprotocol can only have
+// a single inbound stream.
+func (t *quicTransport) claimStream(code uint64) (*protoRW, error) {
+	select {
+	case <-t.ready:
+	case <-t.closing:
+		return nil, errors.New("transport closed")
+	}
+	for _, proto := range t.protos {
+		if code < proto.offset || code >= proto.offset+proto.Length {
+			continue
+		}
+		t.rmu.Lock()
+		defer t.rmu.Unlock()
+
+		if t.claimed[proto.offset] {
+			return nil, fmt.Errorf("duplicate %s stream", proto.Name)
+		}
+		t.claimed[proto.offset] = true
+		return proto, nil
+	}
+	return nil, newPeerError(errInvalidMsgCode, "%d on protocol stream", code)
+}
+
+// readFailed reports a read error. If the remote node closed the connection with
+// a disconnect reason, the reason is reported as the error.
+func (t *quicTransport) readFailed(err error) {
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) && appErr.Remote && appErr.ErrorCode < quicCloseNoReason {
+		err = DiscReason(appErr.ErrorCode)
+	}
+	select {
+	case t.readErr <- err:
+	default:
+	}
+}
+
+func (t *quicTransport) ReadMsg() (Msg, error) {
+	timeout := time.NewTimer(t.readTimeout)
+	defer timeout.Stop()
+
+	select {
+	case msg := <-t.in:
+		t.budget.Release(int64(msg.Size))
+		return msg, nil
+	case err := <-t.readErr:
+		t.readFailed(err) // keep it for subsequent reads
+		return Msg{}, err
+	case <-timeout.C:
+		return Msg{}, errQUICReadTimeout
+	}
+}
+
+func (t *quicTransport) WriteMsg(msg Msg) error {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+
+	// Copy message data to write buffer.
+	t.wbuf.Reset()
+	if _, err := io.CopyN(&t.wbuf, msg.Payload, int64(msg.Size)); err != nil {
+		return err
+	}
+	stream, err := t.sendStream(msg.Code)
+	if err != nil {
+		return err
+	}
+	stream.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+	if err := writeQUICFrame(stream, msg.Code, t.wbuf.Bytes()); err != nil {
+		return err
+	}
+	msg.meterSize = msg.Size
+	meterEgress(msg)
+	return nil
+}
+
+// sendStream returns the stream carrying the messages with the given code. The
+// streams of the subprotocols are opened on first use.
+func (t *quicTransport) sendStream(code uint64) (quicSendStream, error) {
+	for _, proto := range t.protos {
+		if code < proto.offset || code >= proto.offset+proto.Length {
+			continue
+		}
+		if stream := t.streams[proto.offset]; stream != nil {
+			return stream, nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), frameWriteTimeout)
+		defer cancel()
+		stream, err := t.conn.OpenUniStreamSync(ctx)
+		if err != nil {
+			return nil, err
+		}
+		t.streams[proto.offset] = stream
+		return stream, nil
+	}
+	return t.ctrl, nil
+}
+
+func (t *quicTransport) close(err error) {
+	t.closeOnce.Do(func() { close(t.closing) })
+
+	code, desc := quic.ApplicationErrorCode(quicCloseNoReason), ""
+	if reason, ok := err.(DiscReason); ok && reason != DiscNetworkError {
+		code, desc = quic.ApplicationErrorCode(reason), reason.String()
+	}
+	t.conn.CloseWithError(code, desc)
+}
+
+// writeQUICFrame writes a message to a stream.
+func writeQUICFrame(w io.Writer, code uint64, payload []byte) error {
+	frame := make([]byte, 0, 2*binary.MaxVarintLen64+len(payload))
+	frame = binary.AppendUvarint(frame, code)
+	frame = binary.AppendUvarint(frame, uint64(len(payload)))
+	frame = append(frame, payload...)
+	_, err := w.Write(frame)
+	return err
+}
+
+// readQUICFrame reads a message from a stream.
+func readQUICFrame(r *bufio.Reader) (code uint64, payload []byte, err error) {
+	code, size, err := readQUICHeader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	payload = make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return code, payload, nil
+}
+
+// readQUICHeader reads the code and the payload size of a message from a stream.
+func readQUICHeader(r *bufio.Reader) (code uint64, size uint64, err error) {
+	if code, err = binary.ReadUvarint(r); err != nil {
+		return 0, 0, err
+	}
+	if size, err = binary.ReadUvarint(r); err != nil {
+		return 0, 0, err
+	}
+	if size > quicMaxFrameSize {
+		return 0, 0, fmt.Errorf("message too large (%d bytes)", size)
+	}
+	return code, size, nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/quic-go/quic-go"
+)
+
+// newTestQUICConns creates a pair of connected QUIC connections.
+func newTestQUICConns(t *testing.T) (dialed, accepted *quic.Conn) {
+	listenTLS, dialTLS, err := newQUICTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := quic.ListenAddr("127.0.0.1:0", listenTLS, quicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	acceptc := make(chan *quic.Conn, 1)
+	go func() {
+		conn, _ := ln.Accept(ctx)
+		acceptc <- conn
+	}()
+	dialed, err = quic.DialAddr(ctx, ln.Addr().String(), dialTLS, quicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted = <-acceptc; accepted == nil {
+		t.Fatal("accept failed")
+	}
+	return dialed, accepted
+}
+
+// quicEncHandshake runs the authentication on both ends of a connection.
+func quicEncHandshake(dialer, listener transport, dialKey, listenKey *ecdsa.PrivateKey) (dialerSaw, listenerSaw *ecdsa.PublicKey, err error) {
+	errc := make(chan error, 1)
+	go func() {
+		var err error
+		listenerSaw, err = listener.doEncHandshake(listenKey)
+		errc <- err
+	}()
+	dialerSaw, err = dialer.doEncHandshake(dialKey)
+	if lerr := <-errc; err == nil {
+		err = lerr
+	}
+	return dialerSaw, listenerSaw, err
+}
+
+func TestQUICTransport(t *testing.T) {
+	var (
+		dialKey, listenKey = newkey(), newkey()
+		dialed, accepted   = newTestQUICConns(t)
+		dialer             = newQUICTransport(dialed, &listenKey.PublicKey)
+		listener           = newQUICTransport(accepted, nil)
+	)
+	dialerSaw, listenerSaw, err := quicEncHandshake(dialer, listener, dialKey, listenKey)
+	if err != nil {
+		t.Fatal("handshake failed:", err)
+	}
+	if !dialerSaw.Equal(&listenKey.PublicKey) || !listenerSaw.Equal(&dialKey.PublicKey) {
+		t.Fatal("wrong remote keys after handshake")
+	}
+
+	// Run the protocol handshake.
+	caps := []Cap{{"a", 1}, {"b", 1}}
+	errc := make(chan error, 1)
+	go func() {
+		hs := &protoHandshake{Version: baseProtocolVersion, ID: crypto.FromECDSAPub(&listenKey.PublicKey)[1:], Caps: caps}
+		_, err := listener.doProtoHandshake(hs)
+		errc <- err
+	}()
+	hs := &protoHandshake{Version: baseProtocolVersion, ID: crypto.FromECDSAPub(&dialKey.PublicKey)[1:], Name: "dialer", Caps: caps}
+	their, err := dialer.doProtoHandshake(hs)
+	if err != nil {
+		t.Fatal("protocol handshake failed:", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal("protocol handshake failed:", err)
+	}
+	if len(their.Caps) != 2 {
+		t.Fatalf("wrong caps after handshake: %v", their.Caps)
+	}
+
+	// Send messages of both protocols and the base protocol.
+	protos := map[string]*protoRW{
+		"a": {Protocol: Protocol{Name: "a", Length: 5}, offset: baseProtocolLength},
+		"b": {Protocol: Protocol{Name: "b", Length: 3}, offset: baseProtocolLength + 5},
+	}
+	dialer.(*quicTransport).setProtocols(protos)
+	listener.(*quicTransport).setProtocols(protos)
+	codes := []uint64{baseProtocolLength + 1, baseProtocolLength + 6, pingMsg, baseProtocolLength + 2}
+	for i, code := range codes {
+		if err := Send(dialer, code, []uint{uint(i)}); err != nil {
+			t.Fatal("send failed:", err)
+		}
+	}
+	if n := len(dialer.(*quicTransport).streams); n != 2 {
+		t.Errorf("wrong number of protocol streams: %d", n)
+	}
+	// Messages of different streams may arrive in any order.
+	received := make(map[uint64]bool)
+	for range codes {
+		msg, err := listener.ReadMsg()
+		if err != nil {
+			t.Fatal("read failed:", err)
+		}
+		var content []uint
+		if err := msg.Decode(&content); err != nil {
+			t.Fatal(err)
+		}
+		if received[msg.Code] || len(content) != 1 || codes[content[0]] != msg.Code {
+			t.Fatalf("unexpected message code %d, content %v", msg.Code, content)
+		}
+		received[msg.Code] = true
+	}
+	// The buffer space of the messages is released once they are read.
+	if !listener.(*quicTransport).budget.TryAcquire(quicMaxBuffered) {
+		t.Error("message buffer space not released")
+	}
+
+	// Check that the disconnect reason is delivered.
+	dialer.close(DiscTooManyPeers)
+	if _, err := listener.ReadMsg(); err != DiscTooManyPeers {
+		t.Fatalf("wrong error after disconnect: %v", err)
+	}
+}
+
+// This test checks that the messages on a stream are rejected if they don't
+// belong to the protocol of the stream.
+func TestQUICTransportStreamCodes(t *testing.T) {
+	protos := map[string]*protoRW{
+		"a": {Protocol: Protocol{Name: "a", Length: 5}, offset: baseProtocolLength},
+		"b": {Protocol: Protocol{Name: "b", Length: 3}, offset: baseProtocolLength + 5},
+	}
+	tests := []struct {
+		name    string
+		streams [][]uint64 // message codes sent on each stream
+	}{
+		{"unknown protocol", [][]uint64{{baseProtocolLength + 8}}},
+		{"base protocol", [][]uint64{{pingMsg}}},
+		{"other protocol", [][]uint64{{baseProtocolLength + 1, baseProtocolLength + 5}}},
+		{"duplicate stream", [][]uint64{{baseProtocolLength + 1}, {baseProtocolLength + 2}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				dialKey, listenKey = newkey(), newkey()
+				dialed, accepted   = newTestQUICConns(t)
+				dialer             = newQUICTransport(dialed, &listenKey.PublicKey)
+				listener           = newQUICTransport(accepted, nil)
+			)
+			defer dialer.close(errors.New("test done"))
+			defer listener.close(errors.New("test done"))
+
+			if _, _, err := quicEncHandshake(dialer, listener, dialKey, listenKey); err != nil {
+				t.Fatal("handshake failed:", err)
+			}
+			listener.(*quicTransport).setProtocols(protos)
+
+			// Send the messages on raw streams, the transport would pick the
+			// stream of the protocol.
+			for _, codes := range test.streams {
+				stream, err := dialed.OpenUniStream()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, code := range codes {
+					if err := writeQUICFrame(stream, code, []byte{0xc0}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			var err error
+			for i := 0; err == nil && i < 4; i++ {
+				_, err = listener.ReadMsg()
+			}
+			if err == nil || err == errQUICReadTimeout {
+				t.Fatalf("invalid message not rejected, err %v", err)
+			}
+		})
+	}
+}
+
+func TestQUICTransportWrongKey(t *testing.T) {
+	var (
+		dialKey, listenKey = newkey(), newkey()
+		dialed, accepted   = newTestQUICConns(t)
+		dialer             = newQUICTransport(dialed, &newkey().PublicKey)
+		listener           = newQUICTransport(accepted, nil)
+	)
+	defer dialer.close(errors.New("test done"))
+	defer listener.close(errors.New("test done"))
+
+	if _, _, err := quicEncHandshake(dialer, listener, dialKey, listenKey); err == nil {
+		t.Fatal("handshake with unexpected node key succeeded")
+	}
+}
+
+func TestServerQUIC(t *testing.T) {
+	connected := make(chan *Peer, 2)
+	proto := Protocol{
+		Name:    "test",
+		Version: 1,
+		Length:  1,
+		Run: func(p *Peer, rw MsgReadWriter) error {
+			if err := SendItems(rw, 0, "hello"); err != nil {
+				return err
+			}
+			if err := ExpectMsg(rw, 0, []string{"hello"}); err != nil {
+				return err
+			}
+			connected <- p
+			for {
+				if _, err := rw.ReadMsg(); err != nil {
+					return err
+				}
+			}
+		},
+	}
+	newServer := func(name string) *Server {
+		srv := &Server{Config: Config{
+			Name:           name,
+			MaxPeers:       10,
+			NoDiscovery:    true,
+			QUICListenAddr: "127.0.0.1:0",
+			PrivateKey:     newkey(),
+			Protocols:      []Protocol{proto},
+			Logger:         testlog.Logger(t, log.LvlTrace).New("server", name),
+		}}
+		if err := srv.Start(); err != nil {
+			t.Fatal("could not start server:", err)
+		}
+		return srv
+	}
+	srv1, srv2 := newServer("1"), newServer("2")
+	defer srv1.Stop()
+	defer srv2.Stop()
+
+	// The node announces no TCP endpoint, so it can only be dialed over QUIC.
+	var r enr.Record
+	r.Set(enr.IPv4{127, 0, 0, 1})
+	r.Set(quicEntry(srv2.quicListener.Addr().(*net.UDPAddr).Port))
+	if err := enode.SignV4(&r, srv2.PrivateKey); err != nil {
+		t.Fatal(err)
+	}
+	node, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := quicEndpoint(srv2.Self()); !ok {
+		t.Error("QUIC endpoint missing in local node record")
+	}
+	srv1.AddPeer(node)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case p := <-connected:
+			if _, ok := p.RemoteAddr().(*net.UDPAddr); !ok {
+				t.Errorf("peer not connected over QUIC: %v", p.RemoteAddr())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("peers did not connect")
+		}
+	}
+}
@@ -17,6 +17,8 @@
 package params
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -440,6 +442,18 @@ type ChainConfig struct {
 	Ethash             *EthashConfig       `json:"ethash,omitempty"`
 	Clique             *CliqueConfig       `json:"clique,omitempty"`
 	BlobScheduleConfig *BlobScheduleConfig `json:"blobSchedule,omitempty"`
+
+	// Precompiles schedules custom precompiled contracts of private networks,
+	// implemented by modules registered with the vm package.
+	Precompiles []*PrecompileConfig `json:"precompiles,omitempty"`
+}
+
+// PrecompileConfig schedules the activation of a custom precompiled contract.
+type PrecompileConfig struct {
+	Module  string          `json:"module"`           // Name of the registered implementation
+	Address common.Address  `json:"address"`          // Address the contract is installed at
+	Time    *uint64         `json:"time"`             // Activation time (nil = disabled, 0 = active at genesis)
+	Config  json.RawMessage `json:"config,omitempty"` // Module specific configuration
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -551,6 +565,15 @@ func (c *ChainConfig) Description() string {
 	if c.BPO5Time != nil {
 		banner += fmt.Sprintf(" - BPO5:                      @%-10v\n", *c.BPO5Time)
 	}
+	if len(c.Precompiles) > 0 {
+		banner += "\n"
+		banner += "Custom precompiles (timestamp based):\n"
+		for _, p := range c.Precompiles {
+			if p.Time != nil {
+				banner += fmt.Sprintf(" - %-28s @%-10v (%v)\n", p.Module+":", *p.Time, p.Address)
+			}
+		}
+	}
 	return banner
 }
 
@@ -679,6 +702,28 @@ func (c *ChainConfig) IsVerkle(num *big.Int, time uint64) bool {
 	return c.IsLondon(num) && isTimestampForked(c.VerkleTime, time)
 }
 
+// ActivePrecompiles returns the custom precompiled contracts active at time.
+func (c *ChainConfig) ActivePrecompiles(time uint64) []*PrecompileConfig {
+	var active []*PrecompileConfig
+	for _, p := range c.Precompiles {
+		if isTimestampForked(p.Time, time) {
+			active = append(active, p)
+		}
+	}
+	return active
+}
+
+// precompile returns the custom precompiled contract scheduled at the given
+// address, or nil if there's none.
+func (c *ChainConfig) precompile(addr common.Address) *PrecompileConfig {
+	for _, p := range c.Precompiles {
+		if p.Address == addr {
+			return p
+		}
+	}
+	return nil
+}
+
 // IsBPO1 returns whether time is either equal to the BPO1 fork time or greater.
 func (c *ChainConfig) IsBPO1(num *big.Int, time uint64) bool {
 	return c.IsLondon(num) && isTimestampForked(c.BPO1Time, time)
@@ -953,6 +998,39 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.BPO5Time, newcfg.BPO5Time, headTimestamp) {
 		return newTimestampCompatError("BPO5 fork timestamp", c.BPO5Time, newcfg.BPO5Time)
 	}
+	if err := c.checkPrecompilesCompatible(newcfg, headTimestamp); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkPrecompilesCompatible checks whether the custom precompiled contracts
+// which are already active are scheduled and configured identically.
+func (c *ChainConfig) checkPrecompilesCompatible(newcfg *ChainConfig, headTimestamp uint64) *ConfigCompatError {
+	var configs []*PrecompileConfig
+	configs = append(configs, c.Precompiles...)
+	configs = append(configs, newcfg.Precompiles...)
+
+	for _, p := range configs {
+		var (
+			stored  = c.precompile(p.Address)
+			updated = newcfg.precompile(p.Address)
+
+			storedTime, updatedTime *uint64
+		)
+		if stored != nil {
+			storedTime = stored.Time
+		}
+		if updated != nil {
+			updatedTime = updated.Time
+		}
+		if isForkTimestampIncompatible(storedTime, updatedTime, headTimestamp) {
+			return newTimestampCompatError(fmt.Sprintf("precompile %v activation timestamp", p.Address), storedTime, updatedTime)
+		}
+		if isTimestampForked(storedTime, headTimestamp) && (stored.Module != updated.Module || !precompileConfigEqual(stored.Config, updated.Config)) {
+			return newTimestampCompatError(fmt.Sprintf("precompile %v configuration", p.Address), storedTime, updatedTime)
+		}
+	}
 	return nil
 }
 
@@ -1078,6 +1156,16 @@ func isTimestampForked(s *uint64, head uint64) bool {
 	return *s <= head
 }
 
+// precompileConfigEqual reports whether two module configurations are equal,
+// ignoring their JSON formatting.
+func precompileConfigEqual(x, y json.RawMessage) bool {
+	var bx, by bytes.Buffer
+	if json.Compact(&bx, x) != nil || json.Compact(&by, y) != nil {
+		return bytes.Equal(x, y)
+	}
+	return bytes.Equal(bx.Bytes(), by.Bytes())
+}
+
 func configTimestampEqual(x, y *uint64) bool {
 	if x == nil {
 		return y == nil
@@ -1178,6 +1266,9 @@ type Rules struct {
 	IsBerlin, IsLondon                                      bool
 	IsMerge, IsShanghai, IsCancun, IsPrague, IsOsaka        bool
 	IsVerkle                                                bool
+
+	// Precompiles are the custom precompiled contracts of private networks
+	Precompiles []*PrecompileConfig
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -1209,5 +1300,6 @@ func (c *ChainConfig) Rules(num *big.Int, isMerge bool, timestamp uint64) Rules
 		IsOsaka:          isMerge && c.IsOsaka(num, timestamp),
 		IsVerkle:         isVerkle,
 		IsEIP4762:        isVerkle,
+		Precompiles:      c.ActivePrecompiles(timestamp),
 	}
 }
@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -110,6 +111,40 @@ func TestCheckCompatible(t *testing.T) {
 				RewindToTime: 9,
 			},
 		},
+		{
+			stored:        &ChainConfig{Precompiles: []*PrecompileConfig{{Module: "a", Address: common.Address{0xaa}, Time: newUint64(10)}}},
+			new:           &ChainConfig{Precompiles: []*PrecompileConfig{{Module: "a", Address: common.Address{0xaa}, Time: newUint64(20)}}},
+			headTimestamp: 9,
+			wantErr:       nil,
+		},
+		{
+			stored:        &ChainConfig{Precompiles: []*PrecompileConfig{{Module: "a", Address: common.Address{0xaa}, Time: newUint64(10), Config: []byte(`{"a": 1}`)}}},
+			new:           &ChainConfig{Precompiles: []*PrecompileConfig{{Module: "a", Address: common.Address{0xaa}, Time: newUint64(10), Config: []byte(`{"a":1}`)}}},
+			headTimestamp: 25,
+			wantErr:       nil,
+		},
+		{
+			stored:        &ChainConfig{Precompiles: []*PrecompileConfig{{Module: "a", Address: common.Address{0xaa}, Time: newUint64(10)}}},
+			new:           &ChainConfig{Precompiles: []*PrecompileConfig{{Module: "a", Address: common.Address{0xaa}, Time: newUint64(20)}}},
+			headTimestamp: 25,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0xaa00000000000000000000000000000000000000 activation timestamp",
+				StoredTime:   newUint64(10),
+				NewTime:      newUint64(20),
+				RewindToTime: 9,
+			},
+		},
+		{
+			stored:        &ChainConfig{Precompiles: []*PrecompileConfig{{Module: "a", Address: common.Address{0xaa}, Time: newUint64(10)}}},
+			new:           &ChainConfig{Precompiles: []*PrecompileConfig{{Module: "b", Address: common.Address{0xaa}, Time: newUint64(10)}}},
+			headTimestamp: 25,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0xaa00000000000000000000000000000000000000 configuration",
+				StoredTime:   newUint64(10),
+				NewTime:      newUint64(10),
+				RewindToTime: 9,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -139,6 +174,22 @@ func TestConfigRules(t *testing.T) {
 	}
 }
 
+func TestConfigRulesPrecompiles(t *testing.T) {
+	c := &ChainConfig{
+		Precompiles: []*PrecompileConfig{
+			{Module: "a", Address: common.Address{0xaa}, Time: newUint64(0)},
+			{Module: "b", Address: common.Address{0xbb}, Time: newUint64(500)},
+			{Module: "c", Address: common.Address{0xcc}},
+		},
+	}
+	if r := c.Rules(big.NewInt(0), true, 499); len(r.Precompiles) != 1 || r.Precompiles[0].Module != "a" {
+		t.Errorf("unexpected precompiles before activation: %v", r.Precompiles)
+	}
+	if r := c.Rules(big.NewInt(0), true, 500); len(r.Precompiles) != 2 {
+		t.Errorf("unexpected precompiles after activation: %v", r.Precompiles)
+	}
+}
+
 func TestTimestampCompatError(t *testing.T) {
 	require.Equal(t, new(ConfigCompatError).Error(), "")
 
@@ -42,6 +42,9 @@ const (
 	LogDataGas            uint64 = 8     // Per byte in a LOG* operation's data.
 	CallStipend           uint64 = 2300  // Free gas given at beginning of call.
 
+	ExtCallMinRetainedGas uint64 = 5000 // Minimum gas retained by the caller of an EXTCALL, EXTDELEGATECALL or EXTSTATICCALL.
+	ExtCallMinCalleeGas   uint64 = 2300 // Minimum gas the callee of an EXTCALL, EXTDELEGATECALL or EXTSTATICCALL must receive.
+
 	Keccak256Gas     uint64 = 30 // Once per KECCAK256 operation.
 	Keccak256WordGas uint64 = 6  // Once per word of the KECCAK256 operation's data.
 	InitCodeWordGas  uint64 = 2  // Once per word of the init code when creating a contract.
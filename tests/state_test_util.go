@@ -158,11 +158,22 @@ type stAuthorizationMarshaling struct {
 	S       *math.HexOrDecimal256
 }
 
+// forkAliases maps the fork names used by test fixtures of opt-in upgrades to
+// the base fork and the EIPs they enable.
+var forkAliases = map[string]string{
+	"EOFv1":         "Osaka+7692",
+	"PragueEIP7692": "Prague+7692",
+}
+
 // GetChainConfig takes a fork definition and returns a chain config.
 // The fork definition can be
 // - a plain forkname, e.g. `Byzantium`,
-// - a fork basename, and a list of EIPs to enable; e.g. `Byzantium+1884+1283`.
+// - a fork basename, and a list of EIPs to enable; e.g. `Byzantium+1884+1283`,
+// - an alias of an opt-in upgrade, e.g. `EOFv1`.
 func GetChainConfig(forkString string) (baseConfig *params.ChainConfig, eips []int, err error) {
+	if alias, ok := forkAliases[forkString]; ok {
+		forkString = alias
+	}
 	var (
 		splitForks            = strings.Split(forkString, "+")
 		ok                    bool
@@ -321,7 +332,11 @@ func (t *StateTest) RunNoVerify(subtest StateSubtest, vmconfig vm.Config, snapsh
 		context.BlobBaseFee = eip4844.CalcBlobFee(config, header)
 	}
 
-	evm := vm.NewEVM(context, st.StateDB, config, vmconfig)
+	var tracingStateDB = vm.StateDB(st.StateDB)
+	if hooks := vmconfig.Tracer; hooks != nil {
+		tracingStateDB = state.NewHookedState(st.StateDB, hooks)
+	}
+	evm := vm.NewEVM(context, tracingStateDB, config, vmconfig)
 
 	if tracer := vmconfig.Tracer; tracer != nil && tracer.OnTxStart != nil {
 		tracer.OnTxStart(evm.GetVMContext(), nil, msg.From)
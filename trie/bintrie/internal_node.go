@@ -128,14 +128,34 @@ func (bt *InternalNode) InsertValuesAtStem(stem []byte, values [][]byte, resolve
 	} else {
 		child = &bt.right
 	}
+	// Resolve the child node first if it's not loaded yet, otherwise the
+	// values can't be inserted into a trie opened from the database.
+	if hn, ok := (*child).(HashedNode); ok {
+		path, err := keyToPath(bt.depth, stem)
+		if err != nil {
+			return bt, fmt.Errorf("InsertValuesAtStem resolve error: %w", err)
+		}
+		data, err := resolver(path, common.Hash(hn))
+		if err != nil {
+			return bt, fmt.Errorf("InsertValuesAtStem resolve error: %w", err)
+		}
+		node, err := DeserializeNode(data, bt.depth+1)
+		if err != nil {
+			return bt, fmt.Errorf("InsertValuesAtStem node deserialization error: %w", err)
+		}
+		*child = node
+	}
 	*child, err = (*child).InsertValuesAtStem(stem, values, resolver, depth+1)
 	return bt, err
 }
 
 // CollectNodes collects all child nodes at a given path, and flushes it
 // into the provided node collector.
+//
+// The unresolved children are skipped, they are not modified and already
+// present in the database.
 func (bt *InternalNode) CollectNodes(path []byte, flushfn NodeFlushFn) error {
-	if bt.left != nil {
+	if _, ok := bt.left.(HashedNode); !ok && bt.left != nil {
 		var p [256]byte
 		copy(p[:], path)
 		childpath := p[:len(path)]
@@ -144,7 +164,7 @@ func (bt *InternalNode) CollectNodes(path []byte, flushfn NodeFlushFn) error {
 			return err
 		}
 	}
-	if bt.right != nil {
+	if _, ok := bt.right.(HashedNode); !ok && bt.right != nil {
 		var p [256]byte
 		copy(p[:], path)
 		childpath := p[:len(path)]
@@ -69,7 +69,7 @@ func (it *binaryNodeIterator) Next(descend bool) bool {
 
 		// recurse into both children
 		if context.Index == 0 {
-			if _, isempty := node.left.(Empty); node.left != nil && !isempty {
+			if !isEmptyChild(node.left) {
 				it.stack = append(it.stack, binaryNodeIteratorState{Node: node.left})
 				it.current = node.left
 				return it.Next(descend)
@@ -79,7 +79,7 @@ func (it *binaryNodeIterator) Next(descend bool) bool {
 		}
 
 		if context.Index == 1 {
-			if _, isempty := node.right.(Empty); node.right != nil && !isempty {
+			if !isEmptyChild(node.right) {
 				it.stack = append(it.stack, binaryNodeIteratorState{Node: node.right})
 				it.current = node.right
 				return it.Next(descend)
@@ -140,6 +140,18 @@ func (it *binaryNodeIterator) Next(descend bool) bool {
 	}
 }
 
+// isEmptyChild reports whether the child of an internal node holds nothing to
+// iterate. Resolved internal nodes reference missing children by zero hash.
+func isEmptyChild(n BinaryNode) bool {
+	switch n := n.(type) {
+	case nil, Empty:
+		return true
+	case HashedNode:
+		return common.Hash(n) == (common.Hash{})
+	}
+	return false
+}
+
 // Error returns the error status of the iterator.
 func (it *binaryNodeIterator) Error() error {
 	if it.lastErr == errIteratorEnd {
@@ -17,6 +17,7 @@
 package bintrie
 
 import (
+	"encoding/binary"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -81,3 +82,52 @@ func TestBinaryIterator(t *testing.T) {
 		t.Fatalf("invalid leaf count: %d != 6", leafcount)
 	}
 }
+
+func TestBinaryIteratorResolved(t *testing.T) {
+	var (
+		db    = make(testNodeDatabase)
+		tr, _ = NewBinaryTrie(types.EmptyBinaryHash, db)
+	)
+	for i := 0; i < 100; i++ {
+		var addr common.Address
+		binary.BigEndian.PutUint64(addr[:], uint64(i))
+		acc := &types.StateAccount{
+			Nonce:    uint64(i),
+			Balance:  uint256.NewInt(uint64(i)),
+			CodeHash: types.EmptyCodeHash[:],
+		}
+		if err := tr.UpdateAccount(addr, acc, 0); err != nil {
+			t.Fatal(err)
+		}
+		slot := common.BytesToHash(addr[:])
+		if err := tr.UpdateStorage(addr, slot[:], slot[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, nodes := tr.Commit(false)
+	db.commit(nodes)
+
+	// Iterate the reopened trie, in which all nodes below the root are hashed
+	// and empty children are referenced by zero hash.
+	tr, err := NewBinaryTrie(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it, err := tr.NodeIterator(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leafcount int
+	for it.Next(true) {
+		if it.Leaf() {
+			leafcount++
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+	// Basic data, code hash and a single slot per account
+	if leafcount != 300 {
+		t.Fatalf("invalid leaf count: %d != 300", leafcount)
+	}
+}
@@ -35,7 +35,10 @@ type StemNode struct {
 
 // Get retrieves the value for the given key.
 func (bt *StemNode) Get(key []byte, _ NodeResolverFn) ([]byte, error) {
-	panic("this should not be called directly")
+	if !bytes.Equal(bt.Stem, key[:StemSize]) {
+		return nil, nil
+	}
+	return bt.Values[key[StemSize]], nil
 }
 
 // Insert inserts a new key-value pair into the node.
@@ -22,6 +22,10 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb/database"
+	"github.com/holiman/uint256"
 )
 
 var (
@@ -195,3 +199,83 @@ func TestMerkleizeMultipleEntries(t *testing.T) {
 		t.Fatalf("invalid root, expected=%x, got = %x", expected, got)
 	}
 }
+
+// testNodeDatabase is a path-based node database used for testing the trie
+// persistence, it implements the database.NodeDatabase interface.
+type testNodeDatabase map[string][]byte
+
+func (db testNodeDatabase) NodeReader(common.Hash) (database.NodeReader, error) {
+	return db, nil
+}
+
+func (db testNodeDatabase) Node(_ common.Hash, path []byte, _ common.Hash) ([]byte, error) {
+	return db[string(path)], nil
+}
+
+func (db testNodeDatabase) commit(nodes *trienode.NodeSet) {
+	for path, n := range nodes.Nodes {
+		db[path] = n.Blob
+	}
+}
+
+func TestCommitAndReopen(t *testing.T) {
+	var (
+		db       = make(testNodeDatabase)
+		fresh, _ = NewBinaryTrie(types.EmptyBinaryHash, db)
+		tr, _    = NewBinaryTrie(types.EmptyBinaryHash, db)
+		accounts = make(map[common.Address]*types.StateAccount)
+	)
+	update := func(tr *BinaryTrie, addr common.Address, acc *types.StateAccount, slot common.Hash) {
+		if err := tr.UpdateAccount(addr, acc, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.UpdateStorage(addr, slot[:], slot[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Commit the trie periodically, the first commit contains a single account
+	// in which the root is a stem node.
+	for i := 0; i < 100; i++ {
+		var addr common.Address
+		binary.BigEndian.PutUint64(addr[:], uint64(i))
+		acc := &types.StateAccount{
+			Nonce:    uint64(i),
+			Balance:  uint256.NewInt(uint64(i)),
+			CodeHash: types.EmptyCodeHash[:],
+		}
+		accounts[addr] = acc
+		update(fresh, addr, acc, common.BytesToHash(addr[:]))
+		update(tr, addr, acc, common.BytesToHash(addr[:]))
+
+		if i == 0 || i%10 == 9 {
+			root, nodes := tr.Commit(false)
+			if root != fresh.Hash() {
+				t.Fatalf("Unexpected root, want %x, got %x", fresh.Hash(), root)
+			}
+			db.commit(nodes)
+
+			var err error
+			tr, err = NewBinaryTrie(root, db)
+			if err != nil {
+				t.Fatalf("Failed to reopen trie, %v", err)
+			}
+		}
+	}
+	for addr, want := range accounts {
+		got, err := tr.GetAccount(addr)
+		if err != nil {
+			t.Fatalf("Failed to read account, %v", err)
+		}
+		if got == nil || got.Nonce != want.Nonce || got.Balance.Cmp(want.Balance) != 0 {
+			t.Fatalf("Unexpected account %x, want %v, got %v", addr, want, got)
+		}
+		slot := common.BytesToHash(addr[:])
+		val, err := tr.GetStorage(addr, slot[:])
+		if err != nil {
+			t.Fatalf("Failed to read storage, %v", err)
+		}
+		if !bytes.Equal(val, slot[:]) {
+			t.Fatalf("Unexpected storage, want %x, got %x", slot, val)
+		}
+	}
+}
@@ -138,6 +138,27 @@ func (db *Database) HistoricReader(root common.Hash) (*pathdb.HistoricalStateRea
 	return pdb.HistoricReader(root)
 }
 
+// HistoricNodeDatabase returns a node database for opening the tries of a
+// historical state. It's only supported by path-based databases.
+func (db *Database) HistoricNodeDatabase(root common.Hash) (database.NodeDatabase, error) {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil, errors.New("not supported")
+	}
+	return pdb.HistoricNodeDatabase(root)
+}
+
+// CachedHistoricNodeDatabase returns the node database of a historical state if
+// it's readily available without reconstruction, or nil otherwise. It's only
+// supported by path-based databases.
+func (db *Database) CachedHistoricNodeDatabase(root common.Hash) database.NodeDatabase {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil
+	}
+	return pdb.CachedHistoricNodeDatabase(root)
+}
+
 // Update performs a state transition by committing dirty nodes contained in the
 // given set in order to update state from the specified parent to the specified
 // root. The held pre-images accumulated up to this point will be flushed in case
@@ -356,6 +377,17 @@ func (db *Database) StorageIterator(root common.Hash, account common.Hash, seek
 	return pdb.StorageIterator(root, account, seek)
 }
 
+// MutatedStates returns the set of states mutated between the two specified
+// states. It's only supported by path-based database and will return an error
+// for others.
+func (db *Database) MutatedStates(from common.Hash, to common.Hash) (*pathdb.MutatedStates, error) {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil, errors.New("not supported")
+	}
+	return pdb.MutatedStates(from, to)
+}
+
 // IndexProgress returns the indexing progress made so far. It provides the
 // number of states that remain unindexed.
 func (db *Database) IndexProgress() (uint64, error) {
@@ -70,3 +70,39 @@ func (db *Database) HistoryRange() (uint64, uint64, error) {
 	}
 	return pdb.HistoryRange()
 }
+
+// VerifyHistory checks the state histories within the specified range against
+// the associated state index and validates all the index records.
+//
+// This function is only supported by path mode database.
+func (db *Database) VerifyHistory(start, end uint64) (*pathdb.HistoryVerifyStats, error) {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil, errors.New("not supported")
+	}
+	return pdb.VerifyHistory(start, end)
+}
+
+// ReindexHistory discards the state index of the histories starting from the
+// specified state ID and rebuilds it. 0 implies the entire index is rebuilt.
+//
+// This function is only supported by path mode database.
+func (db *Database) ReindexHistory(start uint64) error {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return errors.New("not supported")
+	}
+	return pdb.ReindexHistory(start)
+}
+
+// TruncateHistory removes the state histories older than the specified state
+// ID, returning the number of removed histories.
+//
+// This function is only supported by path mode database.
+func (db *Database) TruncateHistory(first uint64) (int, error) {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return 0, errors.New("not supported")
+	}
+	return pdb.TruncateHistory(first)
+}
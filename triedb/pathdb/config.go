@@ -75,6 +75,7 @@ type Config struct {
 	WriteBufferSize     int    // Maximum memory allowance (in bytes) for write buffer
 	ReadOnly            bool   // Flag whether the database is opened in read only mode
 	JournalDirectory    string // Absolute path of journal directory (null means the journal data is persisted in key-value store)
+	CleanCacheJournal   bool   // Whether to persist the clean caches into the journal directory across restarts
 
 	// Testing configurations
 	SnapshotNoBuild   bool // Flag Whether the state generation is disabled
@@ -113,6 +114,9 @@ func (c *Config) fields() []interface{} {
 	}
 	if c.JournalDirectory != "" {
 		list = append(list, "journal-dir", c.JournalDirectory)
+		if c.CleanCacheJournal {
+			list = append(list, "cache-journal", true)
+		}
 	}
 	return list
 }
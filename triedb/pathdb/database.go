@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -138,6 +139,10 @@ type Database struct {
 	stateIndexer *historyIndexer              // History indexer historical state data, nil possible
 
 	lock sync.RWMutex // Lock to prevent mutations from happening at the same time
+
+	historic      lru.BasicLRU[common.Hash, *historicNodes] // Trie nodes of the recently requested historical states
+	historicLock  sync.Mutex                                // Lock protecting the historical node overlays
+	historicBuild sync.Mutex                                // Lock serializing the historical node reconstructions
 }
 
 // New attempts to load an already existing layer from a persistent key-value
@@ -155,6 +160,7 @@ func New(diskdb ethdb.Database, config *Config, isVerkle bool) *Database {
 		config:   config,
 		diskdb:   diskdb,
 		hasher:   merkleNodeHasher,
+		historic: lru.NewBasicLRU[common.Hash, *historicNodes](historicNodeCacheSize),
 	}
 	// Establish a dedicated database namespace tailored for verkle-specific
 	// data, ensuring the isolation of both verkle and merkle tree data. It's
@@ -169,6 +175,10 @@ func New(diskdb ethdb.Database, config *Config, isVerkle bool) *Database {
 	// and in-memory layer journal.
 	db.tree = newLayerTree(db.loadLayers())
 
+	// Restore the clean caches persisted on the last shutdown, avoiding the
+	// cold start with heavy disk reads.
+	db.restoreCleanCache()
+
 	// Repair the state history, which might not be aligned with the state
 	// in the key-value store due to an unclean shutdown.
 	if err := db.repairHistory(); err != nil {
@@ -560,6 +570,14 @@ func (db *Database) Close() error {
 	if err := dl.terminate(); err != nil {
 		return err
 	}
+	// Persist the clean caches for the next startup if it's configured.
+	// The background flushing has been terminated, the cached items are
+	// consistent with the persistent state.
+	if !db.config.ReadOnly {
+		if err := db.journalCleanCache(dl); err != nil {
+			log.Warn("Failed to persist clean cache", "err", err)
+		}
+	}
 	dl.resetCache() // release the memory held by clean cache
 
 	// Terminate the background state history indexer
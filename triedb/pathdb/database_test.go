@@ -26,6 +26,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/VictoriaMetrics/fastcache"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -35,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb/database"
 	"github.com/holiman/uint256"
 	"golang.org/x/exp/maps"
 )
@@ -141,6 +143,7 @@ type testerConfig struct {
 	layers       int
 	enableIndex  bool
 	journalDir   string
+	cacheJournal bool
 }
 
 func newTester(t *testing.T, config *testerConfig) *tester {
@@ -154,6 +157,7 @@ func newTester(t *testing.T, config *testerConfig) *tester {
 			WriteBufferSize:     256 * 1024,
 			NoAsyncFlush:        true,
 			JournalDirectory:    config.journalDir,
+			CleanCacheJournal:   config.cacheJournal,
 		}, config.isVerkle)
 
 		obj = &tester{
@@ -471,7 +475,12 @@ func (t *tester) lastHash() common.Hash {
 }
 
 func (t *tester) verifyState(root common.Hash) error {
-	tr, err := trie.New(trie.StateTrieID(root), t.db)
+	return t.verifyStateWith(t.db, root)
+}
+
+// verifyStateWith checks the tries of the given state opened through db.
+func (t *tester) verifyStateWith(db database.NodeDatabase, root common.Hash) error {
+	tr, err := trie.New(trie.StateTrieID(root), db)
 	if err != nil {
 		return err
 	}
@@ -490,7 +499,7 @@ func (t *tester) verifyState(root common.Hash) error {
 		if err := rlp.DecodeBytes(blob, account); err != nil {
 			return err
 		}
-		storageIt, err := trie.New(trie.StorageTrieID(root, addrHash, account.Root), t.db)
+		storageIt, err := trie.New(trie.StorageTrieID(root, addrHash, account.Root), db)
 		if err != nil {
 			return err
 		}
@@ -780,6 +789,59 @@ func testJournal(t *testing.T, journalDir string) {
 	}
 }
 
+func TestCleanCacheJournal(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	journalDir := filepath.Join(t.TempDir(), strconv.Itoa(rand.Intn(10000)))
+	tester := newTester(t, &testerConfig{layers: 12, journalDir: journalDir, cacheJournal: true})
+	defer tester.release()
+
+	// Warm up the clean caches
+	dl := tester.db.tree.bottom()
+	dl.nodes.Set(nodeCacheKey(common.Hash{}, nil), rawdb.ReadAccountTrieNode(tester.db.diskdb, nil))
+	for hash, blob := range tester.accounts {
+		dl.states.Set(hash.Bytes(), blob)
+	}
+	countEntries := func(cache *fastcache.Cache) uint64 {
+		var stats fastcache.Stats
+		cache.UpdateStats(&stats)
+		return stats.EntriesCount
+	}
+	nodes, states := countEntries(dl.nodes), countEntries(dl.states)
+	if nodes == 0 || states == 0 {
+		t.Fatal("Clean caches are not warmed up")
+	}
+	if err := tester.db.Journal(tester.lastHash()); err != nil {
+		t.Errorf("Failed to journal, err: %v", err)
+	}
+	tester.db.Close()
+	tester.db = New(tester.db.diskdb, tester.db.config, false)
+
+	dl = tester.db.tree.bottom()
+	if n := countEntries(dl.nodes); n != nodes {
+		t.Fatalf("Unexpected node cache entries, want: %d, got: %d", nodes, n)
+	}
+	if n := countEntries(dl.states); n != states {
+		t.Fatalf("Unexpected state cache entries, want: %d, got: %d", states, n)
+	}
+	if _, err := os.Stat(filepath.Join(journalDir, "merkle.cache")); !os.IsNotExist(err) {
+		t.Fatal("Clean cache journal is not removed after loading")
+	}
+	// Mutate the persistent state, the stale cache journal must be discarded
+	tester.db.Close()
+	rawdb.WritePersistentStateID(tester.db.diskdb, rawdb.ReadPersistentStateID(tester.db.diskdb)+1)
+	tester.db = New(tester.db.diskdb, tester.db.config, false)
+
+	dl = tester.db.tree.bottom()
+	if n := countEntries(dl.nodes); n != 0 {
+		t.Fatalf("Unexpected node cache entries, want: 0, got: %d", n)
+	}
+}
+
 func TestCorruptedJournal(t *testing.T) {
 	testCorruptedJournal(t, "", func(db ethdb.Database) {
 		// Mutate the journal in disk, it should be regarded as invalid
@@ -0,0 +1,299 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb/database"
+)
+
+// maxHistoricNodeDepth is the maximum number of state histories reverted to
+// reconstruct the trie nodes of a historical state. The nodes changed between
+// the historical state and the disk layer are held in memory, so reconstructing
+// arbitrarily old states is not permitted.
+const maxHistoricNodeDepth = 128
+
+// maxHistoricNodeStates is the maximum number of accounts and storage slots
+// reverted to reconstruct the trie nodes of a historical state, bounding the
+// work and memory spent on a single reconstruction.
+var maxHistoricNodeStates = 256 * 1024
+
+// historicNodeCacheSize is the number of historical states whose reconstructed
+// trie nodes are cached, allowing a few states to be served alternately without
+// rebuilding them on every request.
+const historicNodeCacheSize = 4
+
+// historicNodes is an in-memory overlay on top of the disk layer, holding the
+// trie nodes which were changed since a historical state. Together with the
+// disk layer, it forms the complete trie of the historical state.
+type historicNodes struct {
+	root  common.Hash
+	disk  *diskLayer
+	nodes map[common.Hash]map[string]*trienode.Node
+}
+
+// Node implements database.NodeReader, retrieving the trie node of the historical
+// state from the overlay first and falling back to the disk layer.
+func (h *historicNodes) Node(owner common.Hash, path []byte, hash common.Hash) ([]byte, error) {
+	if subset, ok := h.nodes[owner]; ok {
+		if n, ok := subset[string(path)]; ok {
+			if n.Hash != hash {
+				return nil, fmt.Errorf("unexpected historic node: (%x %v), %x!=%x", owner, path, hash, n.Hash)
+			}
+			return n.Blob, nil
+		}
+	}
+	blob, got, _, err := h.disk.node(owner, path, 0)
+	if err != nil {
+		return nil, err
+	}
+	if got != hash {
+		return nil, fmt.Errorf("unexpected node: (%x %v), %x!=%x", owner, path, hash, got)
+	}
+	return blob, nil
+}
+
+// NodeReader implements database.NodeDatabase, opening all tries of the historical
+// state on top of the overlay.
+func (h *historicNodes) NodeReader(root common.Hash) (database.NodeReader, error) {
+	if root != h.root {
+		return nil, fmt.Errorf("state %#x is not available", root)
+	}
+	return h, nil
+}
+
+// stale reports whether the disk layer below the overlay has been replaced,
+// making the overlay unusable.
+func (h *historicNodes) stale() bool {
+	h.disk.lock.RLock()
+	defer h.disk.lock.RUnlock()
+
+	return h.disk.stale
+}
+
+// HistoricNodeDatabase returns a node database for opening the tries of a
+// historical state, which is older than the disk layer but still covered by the
+// retained state histories. The trie nodes changed since the requested state are
+// reconstructed in memory by reverting the state histories on top of the disk
+// layer, the few most recently requested states are cached.
+//
+// The returned database becomes unusable once the disk layer is replaced, it
+// should be requested again in that case. Only a single reconstruction runs at
+// a time, the requests arriving meanwhile wait for it to finish.
+func (db *Database) HistoricNodeDatabase(root common.Hash) (database.NodeDatabase, error) {
+	if db.isVerkle {
+		return nil, errors.New("historic trie nodes are not supported in verkle")
+	}
+	if db.stateFreezer == nil {
+		return nil, fmt.Errorf("historical state %x is not available", root)
+	}
+	if h := db.cachedHistoricNodes(root); h != nil {
+		return h, nil
+	}
+	db.historicBuild.Lock()
+	defer db.historicBuild.Unlock()
+
+	// The state might have been reconstructed while waiting for the lock
+	if h := db.cachedHistoricNodes(root); h != nil {
+		return h, nil
+	}
+	h, err := db.reconstructHistoricNodes(root)
+	if err != nil {
+		return nil, err
+	}
+	db.historicLock.Lock()
+	db.historic.Add(root, h)
+	db.historicLock.Unlock()
+	return h, nil
+}
+
+// CachedHistoricNodeDatabase returns the node database of a historical state if
+// its trie nodes are already reconstructed, or nil otherwise. It never triggers
+// a reconstruction.
+func (db *Database) CachedHistoricNodeDatabase(root common.Hash) database.NodeDatabase {
+	if h := db.cachedHistoricNodes(root); h != nil {
+		return h
+	}
+	return nil
+}
+
+// cachedHistoricNodes returns the cached trie nodes of a historical state, or
+// nil if they are not cached or the disk layer below has been replaced since.
+func (db *Database) cachedHistoricNodes(root common.Hash) *historicNodes {
+	db.historicLock.Lock()
+	defer db.historicLock.Unlock()
+
+	h, ok := db.historic.Get(root)
+	if !ok {
+		return nil
+	}
+	if h.stale() {
+		db.historic.Remove(root)
+		return nil
+	}
+	return h
+}
+
+// reconstructHistoricNodes reverts the state histories between the disk layer and
+// the requested state in memory, collecting the trie nodes of the historical state.
+func (db *Database) reconstructHistoricNodes(root common.Hash) (*historicNodes, error) {
+	dl := db.tree.bottom()
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil {
+		return nil, fmt.Errorf("state %#x is not available", root)
+	}
+	if *id >= dl.stateID() {
+		return nil, fmt.Errorf("state %#x is not historical", root)
+	}
+	if dl.stateID()-*id > maxHistoricNodeDepth {
+		return nil, fmt.Errorf("state %#x is too old, %d states behind disk layer", root, dl.stateID()-*id)
+	}
+	// Aggregate the state histories into the set of values in the requested
+	// state. The first history touching an item holds its historical value,
+	// since the item was not changed before.
+	var (
+		accounts = make(map[common.Hash][]byte)
+		storages = make(map[common.Hash]map[common.Hash][]byte)
+		states   int
+	)
+	for i := *id + 1; i <= dl.stateID(); i++ {
+		h, err := readStateHistory(db.stateFreezer, i)
+		if err != nil {
+			return nil, err // e.g., the referred state history has been pruned
+		}
+		if i == *id+1 && h.meta.parent != root {
+			return nil, fmt.Errorf("state %#x is not canonical", root)
+		}
+		for addr, blob := range h.accounts {
+			addrHash := crypto.Keccak256Hash(addr.Bytes())
+			if _, ok := accounts[addrHash]; !ok {
+				accounts[addrHash] = blob
+				states++
+			}
+		}
+		for addr, slots := range h.storages {
+			addrHash := crypto.Keccak256Hash(addr.Bytes())
+			subset := storages[addrHash]
+			if subset == nil {
+				subset = make(map[common.Hash][]byte)
+				storages[addrHash] = subset
+			}
+			for key, val := range slots {
+				if h.meta.version != stateHistoryV0 {
+					key = crypto.Keccak256Hash(key.Bytes())
+				}
+				if _, ok := subset[key]; !ok {
+					subset[key] = val
+					states++
+				}
+			}
+		}
+		if states > maxHistoricNodeStates {
+			return nil, fmt.Errorf("state %#x is too expensive to reconstruct, %d states changed since", root, states)
+		}
+	}
+	// Apply the historical values on top of the disk layer tries.
+	var (
+		base = &historicNodes{root: dl.rootHash(), disk: dl}
+		set  = trienode.NewMergedNodeSet()
+	)
+	accTrie, err := trie.New(trie.StateTrieID(dl.rootHash()), base)
+	if err != nil {
+		return nil, err
+	}
+	for addrHash, blob := range accounts {
+		post, err := accTrie.Get(addrHash.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		postRoot := types.EmptyRootHash
+		if len(post) != 0 {
+			var acc types.StateAccount
+			if err := rlp.DecodeBytes(post, &acc); err != nil {
+				return nil, err
+			}
+			postRoot = acc.Root
+		}
+		var prev *types.StateAccount
+		if len(blob) != 0 {
+			if prev, err = types.FullAccount(blob); err != nil {
+				return nil, err
+			}
+		}
+		if slots := storages[addrHash]; len(slots) > 0 {
+			st, err := trie.New(trie.StorageTrieID(dl.rootHash(), addrHash, postRoot), base)
+			if err != nil {
+				return nil, err
+			}
+			for key, val := range slots {
+				if len(val) == 0 {
+					err = st.Delete(key.Bytes())
+				} else {
+					err = st.Update(key.Bytes(), val)
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+			want := types.EmptyRootHash
+			if prev != nil {
+				want = prev.Root
+			}
+			got, nodes := st.Commit(false)
+			if got != want {
+				return nil, fmt.Errorf("failed to revert storage of %#x, want %#x, got %#x", addrHash, want, got)
+			}
+			if nodes != nil {
+				if err := set.Merge(nodes); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if prev == nil {
+			err = accTrie.Delete(addrHash.Bytes())
+		} else {
+			var full []byte
+			if full, err = rlp.EncodeToBytes(prev); err == nil {
+				err = accTrie.Update(addrHash.Bytes(), full)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	got, nodes := accTrie.Commit(false)
+	if got != root {
+		return nil, fmt.Errorf("failed to revert state, want %#x, got %#x", root, got)
+	}
+	if nodes != nil {
+		if err := set.Merge(nodes); err != nil {
+			return nil, err
+		}
+	}
+	log.Debug("Reconstructed historic trie nodes", "root", root, "id", *id, "disk", dl.stateID(), "accounts", len(accounts))
+	return &historicNodes{root: root, disk: dl, nodes: set.Nodes()}, nil
+}
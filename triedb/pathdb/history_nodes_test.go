@@ -0,0 +1,139 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/triedb/database"
+)
+
+func TestHistoricNodeDatabase(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, &testerConfig{layers: 32})
+	defer tester.release()
+
+	// All states below the disk layer are accessible through the reconstructed
+	// trie nodes.
+	bottom := tester.bottomIndex()
+	for i := 0; i < bottom; i++ {
+		db, err := tester.db.HistoricNodeDatabase(tester.roots[i])
+		if err != nil {
+			t.Fatalf("Failed to open historic state %d: %v", i, err)
+		}
+		if err := tester.verifyStateWith(db, tester.roots[i]); err != nil {
+			t.Fatalf("Invalid historic state %d: %v", i, err)
+		}
+	}
+	// States in the layer tree and unknown states are not historical.
+	for _, root := range []common.Hash{tester.roots[bottom], tester.lastHash(), {0x1}} {
+		if _, err := tester.db.HistoricNodeDatabase(root); err == nil {
+			t.Fatalf("Unexpected historic state %x", root)
+		}
+	}
+	// The reconstructed nodes are rebuilt once the disk layer moves on.
+	root := tester.roots[bottom-1]
+	db, _ := tester.db.HistoricNodeDatabase(root)
+	tester.extend(4)
+	if !db.(*historicNodes).stale() {
+		t.Fatal("Historic nodes not stale after disk layer update")
+	}
+	db, err := tester.db.HistoricNodeDatabase(root)
+	if err != nil {
+		t.Fatalf("Failed to reopen historic state: %v", err)
+	}
+	if err := tester.verifyStateWith(db, root); err != nil {
+		t.Fatalf("Invalid historic state after disk layer update: %v", err)
+	}
+}
+
+func TestHistoricNodeDatabaseLimits(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, &testerConfig{layers: 32})
+	defer tester.release()
+
+	// The states changing too much since are rejected.
+	bottom := tester.bottomIndex()
+	maxHistoricNodeStates = 1
+	if _, err := tester.db.HistoricNodeDatabase(tester.roots[0]); err == nil {
+		t.Fatal("Expected error for expensive historic state")
+	}
+	maxHistoricNodeStates = 256 * 1024
+
+	// Alternately requested states are served from the cache, as long as they
+	// fit into it, without being reconstructed again.
+	var (
+		roots = tester.roots[bottom-historicNodeCacheSize : bottom]
+		dbs   = make(map[common.Hash]database.NodeDatabase)
+	)
+	for _, root := range roots {
+		db, err := tester.db.HistoricNodeDatabase(root)
+		if err != nil {
+			t.Fatalf("Failed to open historic state: %v", err)
+		}
+		dbs[root] = db
+	}
+	for _, root := range roots {
+		db := tester.db.CachedHistoricNodeDatabase(root)
+		if db == nil || db != dbs[root] {
+			t.Fatalf("Historic state %x not cached", root)
+		}
+	}
+	if tester.db.CachedHistoricNodeDatabase(tester.roots[0]) != nil {
+		t.Fatal("Unexpected cached historic state")
+	}
+	// Concurrent requests wait for the running reconstruction instead of being
+	// rejected.
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 8)
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(root common.Hash) {
+			defer wg.Done()
+			_, err := tester.db.HistoricNodeDatabase(root)
+			errs <- err
+		}(tester.roots[i%2])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Failed to open historic state: %v", err)
+		}
+	}
+	// The least recently used state is evicted from the cache.
+	if tester.db.CachedHistoricNodeDatabase(roots[0]) != nil {
+		t.Fatal("Historic state not evicted")
+	}
+	if tester.db.CachedHistoricNodeDatabase(tester.roots[0]) == nil {
+		t.Fatal("Historic state not cached")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -182,10 +183,13 @@ func (r *indexReaderWithLimitTag) readGreaterThan(id uint64, lastID uint64) (uin
 }
 
 // historyReader is the structure to access historic state data.
+//
+// historyReader is safe for concurrent access.
 type historyReader struct {
 	disk    ethdb.KeyValueReader
 	freezer ethdb.AncientReader
 	readers map[string]*indexReaderWithLimitTag
+	lock    sync.Mutex // Lock for protecting the index readers
 }
 
 // newHistoryReader constructs the history reader with the supplied db.
@@ -312,6 +316,28 @@ func (r *historyReader) readStorage(address common.Address, storageKey common.Ha
 	return data[offset : offset+length], nil
 }
 
+// lookup finds the ID of the first state history after the specified stateID
+// in which the given state element was modified. MaxUint64 is returned if the
+// element has not been modified since then.
+//
+// The index readers are cached and mutated internally, the access to them must
+// be serialized.
+func (r *historyReader) lookup(state stateIdent, stateID uint64, lastID uint64, indexed uint64) (uint64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ir, ok := r.readers[state.String()]
+	if !ok {
+		var err error
+		ir, err = newIndexReaderWithLimitTag(r.disk, state, indexed)
+		if err != nil {
+			return 0, err
+		}
+		r.readers[state.String()] = ir
+	}
+	return ir.readGreaterThan(stateID, lastID)
+}
+
 // read retrieves the state element data associated with the stateID.
 // stateID: represents the ID of the state of the specified version;
 // lastID: represents the ID of the latest/newest state history;
@@ -340,17 +366,8 @@ func (r *historyReader) read(state stateIdentQuery, stateID uint64, lastID uint6
 		return nil, fmt.Errorf("state history is not fully indexed, requested: %d, indexed: %s", stateID, indexed)
 	}
 
-	// Construct the index reader to locate the corresponding history for
-	// state retrieval
-	ir, ok := r.readers[state.String()]
-	if !ok {
-		ir, err = newIndexReaderWithLimitTag(r.disk, state.stateIdent, metadata.Last)
-		if err != nil {
-			return nil, err
-		}
-		r.readers[state.String()] = ir
-	}
-	historyID, err := ir.readGreaterThan(stateID, lastID)
+	// Locate the corresponding history for state retrieval
+	historyID, err := r.lookup(state.stateIdent, stateID, lastID, metadata.Last)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +176,40 @@ func testHistoryReader(t *testing.T, historyLimit uint64) {
 	}
 }
 
+func TestHistoryReaderConcurrent(t *testing.T) {
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	env := newTester(t, &testerConfig{stateHistory: 0, layers: 64, enableIndex: true})
+	defer env.release()
+	waitIndexing(env.db)
+
+	var (
+		roots = env.roots
+		dl    = env.db.tree.bottom()
+		hr    = newHistoryReader(env.db.diskdb, env.db.stateFreezer)
+		errs  = make(chan error, len(roots))
+	)
+	for i, root := range roots {
+		if root == dl.rootHash() {
+			break
+		}
+		go func(id uint64, root common.Hash) {
+			errs <- checkHistoricalState(env, root, id, hr)
+		}(uint64(i+1), root)
+	}
+	for i, root := range roots {
+		if root == dl.rootHash() {
+			break
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("Failed to read historical state %d: %v", i+1, err)
+		}
+	}
+}
+
 func TestHistoricalStateReader(t *testing.T) {
 	maxDiffLayers = 4
 	defer func() {
@@ -0,0 +1,502 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/
+
+package pathdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// errIndexerRunning is returned if the history repair is requested while the
+// state history indexer is running in the background.
+var errIndexerRunning = errors.New("state history indexer is running")
+
+// HistoryIssue describes an inconsistency detected within the state histories
+// or the associated state history index.
+type HistoryIssue struct {
+	ID     uint64 // The ID of the state history involved, zero if not applicable
+	Reason string // The human-readable description of the inconsistency
+}
+
+// String implements fmt.Stringer, returning the issue in string format.
+func (i HistoryIssue) String() string {
+	if i.ID == 0 {
+		return i.Reason
+	}
+	return fmt.Sprintf("history #%d: %s", i.ID, i.Reason)
+}
+
+// HistoryVerifyStats wraps the statistics of state history verification.
+type HistoryVerifyStats struct {
+	First   uint64         // The ID of the first verified state history
+	Last    uint64         // The ID of the last verified state history
+	Indexed uint64         // The ID of the last indexed state history, zero means not indexed
+	Records uint64         // The number of verified index records
+	Issues  []HistoryIssue // The list of detected inconsistencies
+}
+
+// readIndexElements decodes all the elements tracked in the index of the given
+// state. Structural corruption, such as a missing index block or out-of-order
+// elements, is reported as an error.
+func readIndexElements(db ethdb.KeyValueReader, state stateIdent) ([]uint64, error) {
+	descList, err := loadIndexData(db, state)
+	if err != nil {
+		return nil, err
+	}
+	var elements []uint64
+	for _, desc := range descList {
+		var blob []byte
+		if state.account {
+			blob = rawdb.ReadAccountHistoryIndexBlock(db, state.addressHash, desc.id)
+		} else {
+			blob = rawdb.ReadStorageHistoryIndexBlock(db, state.addressHash, state.storageHash, desc.id)
+		}
+		if len(blob) == 0 {
+			return nil, fmt.Errorf("index block %d is missing", desc.id)
+		}
+		restarts, data, err := parseIndexBlock(blob)
+		if err != nil {
+			return nil, fmt.Errorf("index block %d is corrupted: %v", desc.id, err)
+		}
+		var (
+			entries int
+			prev    uint64
+			section int
+		)
+		for pos := 0; pos < len(data); {
+			x, n := binary.Uvarint(data[pos:])
+			if n <= 0 {
+				return nil, fmt.Errorf("index block %d is corrupted at %d", desc.id, pos)
+			}
+			value := prev + x
+			if section < len(restarts) && int(restarts[section]) == pos {
+				value = x
+				section++
+			}
+			if len(elements) > 0 && value <= elements[len(elements)-1] {
+				return nil, fmt.Errorf("index block %d is out of order, prev: %d, this: %d", desc.id, elements[len(elements)-1], value)
+			}
+			elements = append(elements, value)
+			prev = value
+			entries++
+			pos += n
+		}
+		if entries != int(desc.entries) {
+			return nil, fmt.Errorf("index block %d has %d entries, want %d", desc.id, entries, desc.entries)
+		}
+		if prev != desc.max {
+			return nil, fmt.Errorf("index block %d has max element %d, want %d", desc.id, prev, desc.max)
+		}
+	}
+	return elements, nil
+}
+
+// rewriteIndex replaces the index of the given state with the supplied element
+// list. The index is removed entirely if the list is empty.
+func rewriteIndex(db ethdb.KeyValueReader, batch ethdb.Batch, state stateIdent, elements []uint64) error {
+	// Drop all the existing index blocks, the structure is assumed to be
+	// corrupted and can't be reused.
+	descList, _ := loadIndexData(db, state)
+	for _, desc := range descList {
+		if state.account {
+			rawdb.DeleteAccountHistoryIndexBlock(batch, state.addressHash, desc.id)
+		} else {
+			rawdb.DeleteStorageHistoryIndexBlock(batch, state.addressHash, state.storageHash, desc.id)
+		}
+	}
+	if len(elements) == 0 {
+		if state.account {
+			rawdb.DeleteAccountHistoryIndex(batch, state.addressHash)
+		} else {
+			rawdb.DeleteStorageHistoryIndex(batch, state.addressHash, state.storageHash)
+		}
+		return nil
+	}
+	desc := newIndexBlockDesc(0)
+	bw, _ := newBlockWriter(nil, desc)
+	w := &indexWriter{
+		descList: []*indexBlockDesc{desc},
+		bw:       bw,
+		state:    state,
+		db:       db,
+	}
+	for _, id := range elements {
+		if err := w.append(id); err != nil {
+			return err
+		}
+	}
+	w.finish(batch)
+	return nil
+}
+
+// iterateIndexes traverses all the state index records in the database and
+// invokes the callback for each of them.
+func iterateIndexes(db ethdb.Iteratee, fn func(state stateIdent) error) error {
+	it := db.NewIterator(rawdb.StateHistoryAccountMetadataPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(rawdb.StateHistoryAccountMetadataPrefix)+common.HashLength {
+			continue
+		}
+		addrHash := common.BytesToHash(key[len(rawdb.StateHistoryAccountMetadataPrefix):])
+		if err := fn(newAccountIdent(addrHash)); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	sit := db.NewIterator(rawdb.StateHistoryStorageMetadataPrefix, nil)
+	defer sit.Release()
+
+	for sit.Next() {
+		key := sit.Key()
+		if len(key) != len(rawdb.StateHistoryStorageMetadataPrefix)+2*common.HashLength {
+			continue
+		}
+		key = key[len(rawdb.StateHistoryStorageMetadataPrefix):]
+		addrHash := common.BytesToHash(key[:common.HashLength])
+		slotHash := common.BytesToHash(key[common.HashLength:])
+		if err := fn(newStorageIdent(addrHash, slotHash)); err != nil {
+			return err
+		}
+	}
+	return sit.Error()
+}
+
+// historyIdents returns the state identifiers mutated in the given history.
+func historyIdents(h *stateHistory) []stateIdent {
+	var idents []stateIdent
+	for _, address := range h.accountList {
+		addrHash := crypto.Keccak256Hash(address.Bytes())
+		idents = append(idents, newAccountIdent(addrHash))
+
+		for _, slotKey := range h.storageList[address] {
+			// The hash of the storage slot key is used as the identifier, see
+			// batchIndexer.process for more details.
+			slotHash := slotKey
+			if h.meta.version != stateHistoryV0 {
+				slotHash = crypto.Keccak256Hash(slotKey.Bytes())
+			}
+			idents = append(idents, newStorageIdent(addrHash, slotHash))
+		}
+	}
+	return idents
+}
+
+// verifyHistory checks the state histories within the specified range against
+// the associated state index, and validates the structure of all the index
+// records in the database.
+//
+// The state histories are checked for the linkage between the consecutive
+// objects and for the root->id mapping. Each mutated state element must be
+// tracked by the index if the history has been indexed; while each index record
+// must be structurally valid and must not reference unindexed histories.
+func verifyHistory(disk ethdb.KeyValueStore, freezer ethdb.AncientReader, start, end uint64) (*HistoryVerifyStats, error) {
+	tail, err := freezer.Tail()
+	if err != nil {
+		return nil, err
+	}
+	head, err := freezer.Ancients()
+	if err != nil {
+		return nil, err
+	}
+	if start <= tail {
+		start = tail + 1
+	}
+	if end == 0 || end > head {
+		end = head
+	}
+	if start > end {
+		return nil, fmt.Errorf("range is invalid, first: %d, last: %d", start, end)
+	}
+	var (
+		stats  = &HistoryVerifyStats{First: start, Last: end}
+		init   = time.Now()
+		logged = time.Now()
+		parent *meta
+		report = func(id uint64, format string, args ...any) {
+			stats.Issues = append(stats.Issues, HistoryIssue{ID: id, Reason: fmt.Sprintf(format, args...)})
+		}
+	)
+	if metadata := loadIndexMetadata(disk); metadata != nil {
+		stats.Indexed = metadata.Last
+	}
+	if start > 1 {
+		parent, err = readStateHistoryMeta(freezer, start-1)
+		if err != nil {
+			parent = nil // the preceding history has been pruned
+		}
+	}
+	for id := start; id <= end; id++ {
+		h, err := readStateHistory(freezer, id)
+		if err != nil {
+			report(id, "failed to decode: %v", err)
+			parent = nil
+			continue
+		}
+		if parent != nil && parent.root != h.meta.parent {
+			report(id, "broken linkage, parent: %#x, want: %#x", h.meta.parent, parent.root)
+		}
+		if sid := rawdb.ReadStateID(disk, h.meta.root); sid == nil {
+			report(id, "state id of root %#x is missing", h.meta.root)
+		} else if *sid != id {
+			report(id, "state id of root %#x is %d", h.meta.root, *sid)
+		}
+		parent = h.meta
+
+		// Ensure every mutated state element is tracked by the index.
+		if id <= stats.Indexed {
+			for _, ident := range historyIdents(h) {
+				ir, err := newIndexReader(disk, ident)
+				if err != nil {
+					report(id, "failed to open index of %s: %v", ident, err)
+					continue
+				}
+				found, err := ir.readGreaterThan(id - 1)
+				if err != nil {
+					report(id, "failed to read index of %s: %v", ident, err)
+					continue
+				}
+				if found != id {
+					report(id, "index of %s is missing", ident)
+				}
+			}
+		}
+		if time.Since(logged) > time.Second*8 {
+			logged = time.Now()
+			eta := common.CalculateETA(id-start+1, end-id, time.Since(init))
+			log.Info("Verifying state history", "checked", id-start+1, "left", end-id, "issues", len(stats.Issues), "elapsed", common.PrettyDuration(time.Since(init)), "eta", common.PrettyDuration(eta))
+		}
+	}
+	// Validate the structure of all the index records
+	err = iterateIndexes(disk, func(state stateIdent) error {
+		elements, err := readIndexElements(disk, state)
+		if err != nil {
+			report(0, "index of %s is corrupted: %v", state, err)
+			return nil
+		}
+		stats.Records++
+		if len(elements) > 0 && elements[len(elements)-1] > stats.Indexed {
+			report(elements[len(elements)-1], "index of %s references unindexed history, indexed: %d", state, stats.Indexed)
+		}
+		if time.Since(logged) > time.Second*8 {
+			logged = time.Now()
+			log.Info("Verifying state history index", "records", stats.Records, "issues", len(stats.Issues), "elapsed", common.PrettyDuration(time.Since(init)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Verified state history", "first", start, "last", end, "records", stats.Records, "issues", len(stats.Issues), "elapsed", common.PrettyDuration(time.Since(init)))
+	return stats, nil
+}
+
+// reindexHistory discards the index data of the state histories starting from
+// the specified ID and rebuilds it from the state histories in the freezer.
+// The entire index is rebuilt from scratch if the start is not above the first
+// available state history, or if no history has been indexed yet. The start is
+// lowered to the first unindexed history if it's above that.
+func reindexHistory(disk ethdb.KeyValueStore, freezer ethdb.AncientReader, start uint64) error {
+	tail, err := freezer.Tail()
+	if err != nil {
+		return err
+	}
+	head, err := freezer.Ancients()
+	if err != nil {
+		return err
+	}
+	if head == tail {
+		return errors.New("no state history available")
+	}
+	var (
+		last     = head // the id of the last state history
+		init     = time.Now()
+		logged   = time.Now()
+		rewrites int
+		metadata = loadIndexMetadata(disk)
+	)
+	if start > last {
+		return fmt.Errorf("start %d is beyond the last state history %d", start, last)
+	}
+	// The histories above the last indexed one have never been indexed,
+	// rebuild the index from there to not leave a gap behind.
+	if metadata != nil && metadata.Last+1 < start {
+		log.Info("Lowered state history reindex start", "requested", start, "start", metadata.Last+1)
+		start = metadata.Last + 1
+	}
+	if start <= tail+1 || metadata == nil {
+		start = tail + 1
+
+		batch := disk.NewBatch()
+		rawdb.DeleteStateHistoryIndexMetadata(batch)
+		rawdb.DeleteStateHistoryIndex(batch)
+		storeIndexMetadata(batch, tail)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		log.Info("Purged state history index", "elapsed", common.PrettyDuration(time.Since(init)))
+	} else {
+		// Truncate the index records which reference the histories above
+		// the start, including the structurally corrupted ones.
+		batch := disk.NewBatch()
+		err := iterateIndexes(disk, func(state stateIdent) error {
+			elements, err := readIndexElements(disk, state)
+			if err != nil {
+				log.Warn("Dropping corrupted state index", "state", state, "err", err)
+				elements = nil
+			} else {
+				var n int
+				for n < len(elements) && elements[n] < start {
+					n++
+				}
+				if n == len(elements) {
+					return nil
+				}
+				elements = elements[:n]
+			}
+			if err := rewriteIndex(disk, batch, state, elements); err != nil {
+				return err
+			}
+			rewrites++
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				batch.Reset()
+			}
+			if time.Since(logged) > time.Second*8 {
+				logged = time.Now()
+				log.Info("Truncating state history index", "rewritten", rewrites, "elapsed", common.PrettyDuration(time.Since(init)))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		storeIndexMetadata(batch, start-1)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		log.Info("Truncated state history index", "start", start, "rewritten", rewrites, "elapsed", common.PrettyDuration(time.Since(init)))
+	}
+	// Rebuild the index for the histories in range [start, last]
+	var (
+		current = start
+		batch   = newBatchIndexer(disk, false)
+	)
+	for current <= last {
+		count := min(last-current+1, historyReadBatch)
+		histories, err := readStateHistories(freezer, current, count)
+		if err != nil {
+			return err
+		}
+		for _, h := range histories {
+			if err := batch.process(h, current); err != nil {
+				return err
+			}
+			current += 1
+		}
+		if time.Since(logged) > time.Second*8 {
+			logged = time.Now()
+			eta := common.CalculateETA(current-start, last-current+1, time.Since(init))
+			log.Info("Reindexing state history", "processed", current-start, "left", last-current+1, "elapsed", common.PrettyDuration(time.Since(init)), "eta", common.PrettyDuration(eta))
+		}
+	}
+	if err := batch.finish(true); err != nil {
+		return err
+	}
+	log.Info("Reindexed state history", "from", start, "to", last, "elapsed", common.PrettyDuration(time.Since(init)))
+	return nil
+}
+
+// VerifyHistory checks the state histories within the specified range against
+// the associated state index and validates all the index records.
+//
+// Start: State ID of the first history object for the check. 0 implies the first
+// available object is selected as the starting point.
+//
+// End: State ID of the last history for the check. 0 implies the last available
+// object is selected as the ending point. Note end is included in the check.
+func (db *Database) VerifyHistory(start, end uint64) (*HistoryVerifyStats, error) {
+	if db.stateFreezer == nil {
+		return nil, errors.New("state history is not available")
+	}
+	return verifyHistory(db.diskdb, db.stateFreezer, start, end)
+}
+
+// ReindexHistory discards the state index of the histories starting from the
+// specified ID and rebuilds it from the freezer. 0 implies the entire index
+// is rebuilt from scratch.
+//
+// It's only permitted if the background state history indexing is disabled.
+func (db *Database) ReindexHistory(start uint64) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.readOnly {
+		return errDatabaseReadOnly
+	}
+	if db.stateFreezer == nil {
+		return errors.New("state history is not available")
+	}
+	if db.stateIndexer != nil {
+		return errIndexerRunning
+	}
+	return reindexHistory(db.diskdb, db.stateFreezer, start)
+}
+
+// TruncateHistory removes the state histories older than the specified ID
+// from the freezer, retaining the history with the given ID as the first one.
+// The associated state index is left untouched, as the pruned histories are
+// no longer visible.
+//
+// It's only permitted if the background state history indexing is disabled.
+func (db *Database) TruncateHistory(first uint64) (int, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.readOnly {
+		return 0, errDatabaseReadOnly
+	}
+	if db.stateFreezer == nil {
+		return 0, errors.New("state history is not available")
+	}
+	if db.stateIndexer != nil {
+		return 0, errIndexerRunning
+	}
+	if first == 0 {
+		return 0, errors.New("invalid zero history ID")
+	}
+	// The history associated with the persistent state must be retained, see
+	// diskLayer.truncateTail for more details.
+	if persistentID := rawdb.ReadPersistentStateID(db.diskdb); persistentID < first {
+		return 0, fmt.Errorf("history %d is beyond the persistent state %d", first, persistentID)
+	}
+	return truncateFromTail(db.stateFreezer, first-1)
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/
+
+package pathdb
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestHistoryRepair(t *testing.T) {
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	env := newTester(t, &testerConfig{stateHistory: 0, layers: 32, enableIndex: true})
+	defer env.release()
+	waitIndexing(env.db)
+
+	// Terminate the background indexer, the repair is only permitted offline
+	env.db.stateIndexer.close()
+	env.db.stateIndexer = nil
+
+	verify := func(issues int) {
+		t.Helper()
+		stats, err := env.db.VerifyHistory(0, 0)
+		if err != nil {
+			t.Fatalf("Failed to verify history: %v", err)
+		}
+		if len(stats.Issues) != issues {
+			t.Fatalf("Unexpected issues, want: %d, got: %v", issues, stats.Issues)
+		}
+	}
+	verify(0)
+
+	// Drop the index of an account mutated in the middle of the histories
+	h, err := readStateHistory(env.db.stateFreezer, 16)
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	rawdb.DeleteAccountHistoryIndex(env.db.diskdb, crypto.Keccak256Hash(h.accountList[0].Bytes()))
+
+	stats, err := env.db.VerifyHistory(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to verify history: %v", err)
+	}
+	if len(stats.Issues) == 0 {
+		t.Fatal("Expected issues are not detected")
+	}
+	// Reindex a partial range, the dropped index is not recovered for the
+	// histories below the start
+	if err := env.db.ReindexHistory(20); err != nil {
+		t.Fatalf("Failed to reindex history: %v", err)
+	}
+	stats, err = env.db.VerifyHistory(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to verify history: %v", err)
+	}
+	if len(stats.Issues) == 0 {
+		t.Fatal("Expected issues are not detected")
+	}
+	// Reindex the entire range, all the issues should be fixed
+	if err := env.db.ReindexHistory(0); err != nil {
+		t.Fatalf("Failed to reindex history: %v", err)
+	}
+	verify(0)
+
+	var (
+		dl = env.db.tree.bottom()
+		hr = newHistoryReader(env.db.diskdb, env.db.stateFreezer)
+	)
+	for i, root := range env.roots {
+		if root == dl.rootHash() {
+			break
+		}
+		if err := checkHistoricalState(env, root, uint64(i+1), hr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Truncate the histories from tail, the remaining ones should be intact
+	if _, err := env.db.TruncateHistory(10); err != nil {
+		t.Fatalf("Failed to truncate history: %v", err)
+	}
+	tail, _ := env.db.stateFreezer.Tail()
+	if tail != 9 {
+		t.Fatalf("Unexpected history tail, want: %d, got: %d", 9, tail)
+	}
+	verify(0)
+}
+
+func TestHistoryReindexAboveIndexed(t *testing.T) {
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	env := newTester(t, &testerConfig{stateHistory: 0, layers: 32, enableIndex: true})
+	defer env.release()
+	waitIndexing(env.db)
+
+	env.db.stateIndexer.close()
+	env.db.stateIndexer = nil
+
+	// Pretend the histories above 10 have never been indexed
+	batch := env.db.diskdb.NewBatch()
+	err := iterateIndexes(env.db.diskdb, func(state stateIdent) error {
+		elements, err := readIndexElements(env.db.diskdb, state)
+		if err != nil {
+			return err
+		}
+		var n int
+		for n < len(elements) && elements[n] <= 10 {
+			n++
+		}
+		return rewriteIndex(env.db.diskdb, batch, state, elements[:n])
+	})
+	if err != nil {
+		t.Fatalf("Failed to truncate index: %v", err)
+	}
+	storeIndexMetadata(batch, 10)
+	if err := batch.Write(); err != nil {
+		t.Fatalf("Failed to write batch: %v", err)
+	}
+	// Reindex from above the last indexed history, the gap in between must
+	// be indexed as well
+	if err := env.db.ReindexHistory(20); err != nil {
+		t.Fatalf("Failed to reindex history: %v", err)
+	}
+	stats, err := env.db.VerifyHistory(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to verify history: %v", err)
+	}
+	if len(stats.Issues) != 0 {
+		t.Fatalf("Unexpected issues: %v", stats.Issues)
+	}
+	head, _ := env.db.stateFreezer.Ancients()
+	if metadata := loadIndexMetadata(env.db.diskdb); metadata == nil || metadata.Last != head {
+		t.Fatalf("Unexpected index metadata, want last: %d, got: %v", head, metadata)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// cacheJournalVersion is the version of the clean cache journal. The journal
+// is discarded if the version is not matched.
+const cacheJournalVersion uint64 = 0
+
+// cacheJournalMeta is the metadata of the clean cache journal, describing the
+// persistent state the cached items are associated with.
+//
+// The clean caches only contain the items present in the key-value store,
+// they are therefore only valid if the persistent state is not mutated since
+// the caches were dumped.
+type cacheJournalMeta struct {
+	Version uint64      // Version of the journal format
+	Root    common.Hash // Root hash of the persistent state
+	ID      uint64      // State ID of the persistent state
+}
+
+// cacheJournalPath returns the absolute path of the clean cache journal. Empty
+// is returned if the clean cache journal is disabled.
+func (db *Database) cacheJournalPath() string {
+	if !db.config.CleanCacheJournal || db.config.JournalDirectory == "" {
+		return ""
+	}
+	if db.isVerkle {
+		return filepath.Join(db.config.JournalDirectory, "verkle.cache")
+	}
+	return filepath.Join(db.config.JournalDirectory, "merkle.cache")
+}
+
+// persistentState returns the root hash and the state ID of the persistent
+// state in the key-value store.
+func (db *Database) persistentState() (common.Hash, uint64, error) {
+	root, err := db.hasher(rawdb.ReadAccountTrieNode(db.diskdb, nil))
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	return root, rawdb.ReadPersistentStateID(db.diskdb), nil
+}
+
+// journalCleanCache dumps the clean caches of the disk layer into the journal
+// directory. It's expected to be called on shutdown, after the background
+// flushing has been terminated, ensuring the cached items are consistent
+// with the persistent state.
+func (db *Database) journalCleanCache(dl *diskLayer) error {
+	path := db.cacheJournalPath()
+	if path == "" {
+		return nil
+	}
+	root, id, err := db.persistentState()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+
+	// Remove the stale journal first, so that a partially written journal
+	// is never loaded.
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	if dl.nodes != nil {
+		if err := dl.nodes.SaveToFileConcurrent(filepath.Join(path, "nodes"), runtime.GOMAXPROCS(0)); err != nil {
+			return err
+		}
+	}
+	if dl.states != nil {
+		if err := dl.states.SaveToFileConcurrent(filepath.Join(path, "states"), runtime.GOMAXPROCS(0)); err != nil {
+			return err
+		}
+	}
+	blob, err := rlp.EncodeToBytes(&cacheJournalMeta{
+		Version: cacheJournalVersion,
+		Root:    root,
+		ID:      id,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(path, "meta"), blob, 0644); err != nil {
+		return err
+	}
+	log.Info("Persisted clean cache", "root", root, "id", id, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// loadCleanCache loads the clean caches from the journal directory and
+// validates them against the persistent state. The journal is removed once
+// it's consumed, regardless of whether it's valid or not.
+func (db *Database) loadCleanCache() (*fastcache.Cache, *fastcache.Cache, error) {
+	path := db.cacheJournalPath()
+	if path == "" {
+		return nil, nil, nil
+	}
+	blob, err := os.ReadFile(filepath.Join(path, "meta"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	defer os.RemoveAll(path)
+
+	var meta cacheJournalMeta
+	if err := rlp.DecodeBytes(blob, &meta); err != nil {
+		return nil, nil, err
+	}
+	if meta.Version != cacheJournalVersion {
+		return nil, nil, fmt.Errorf("version mismatch, want: %d, got: %d", cacheJournalVersion, meta.Version)
+	}
+	root, id, err := db.persistentState()
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta.Root != root || meta.ID != id {
+		return nil, nil, fmt.Errorf("state mismatch, want: %#x(%d), got: %#x(%d)", root, id, meta.Root, meta.ID)
+	}
+	var nodes, states *fastcache.Cache
+	if db.config.TrieCleanSize != 0 {
+		nodes = loadCache(filepath.Join(path, "nodes"), db.config.TrieCleanSize)
+	}
+	if db.config.StateCleanSize != 0 {
+		states = loadCache(filepath.Join(path, "states"), db.config.StateCleanSize)
+	}
+	return nodes, states, nil
+}
+
+// loadCache loads the fastcache from the given path. Nil is returned if the
+// cache is empty or was dumped with a different memory allowance.
+func loadCache(path string, size int) *fastcache.Cache {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	// LoadFromFileOrNew silently creates an empty cache if the dumped one
+	// is not compatible.
+	cache := fastcache.LoadFromFileOrNew(path, size)
+
+	var stats fastcache.Stats
+	cache.UpdateStats(&stats)
+	if stats.EntriesCount == 0 {
+		cache.Reset()
+		return nil
+	}
+	return cache
+}
+
+// restoreCleanCache replaces the clean caches of the disk layer with the ones
+// loaded from the journal. It must be called during database initialization
+// before the disk layer is accessed.
+func (db *Database) restoreCleanCache() {
+	start := time.Now()
+	nodes, states, err := db.loadCleanCache()
+	if err != nil {
+		log.Info("Discarded clean cache journal", "err", err)
+		return
+	}
+	if nodes == nil && states == nil {
+		return
+	}
+	dl := db.tree.bottom()
+	if nodes != nil {
+		if dl.nodes != nil {
+			dl.nodes.Reset()
+		}
+		dl.nodes = nodes
+	}
+	if states != nil {
+		if dl.states != nil {
+			dl.states.Reset()
+		}
+		dl.states = states
+	}
+	log.Info("Loaded clean cache journal", "elapsed", common.PrettyDuration(time.Since(start)))
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// MutatedStates is the set of states mutated between two states. Accounts are
+// identified by the address, storage slots are identified by the raw slot key,
+// or by the hash of the slot key if the mutation was recorded in the legacy
+// format.
+type MutatedStates struct {
+	Accounts       map[common.Address]struct{}                 // Set of mutated accounts
+	Storages       map[common.Address]map[common.Hash]struct{} // Set of mutated storage slots keyed by the raw slot key
+	HashedStorages map[common.Address]map[common.Hash]struct{} // Set of mutated storage slots keyed by the slot key hash
+}
+
+// newMutatedStates constructs an empty mutation set.
+func newMutatedStates() *MutatedStates {
+	return &MutatedStates{
+		Accounts:       make(map[common.Address]struct{}),
+		Storages:       make(map[common.Address]map[common.Hash]struct{}),
+		HashedStorages: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// add merges the states mutated by a single state transition into the set.
+func (s *MutatedStates) add(accounts map[common.Address][]byte, storages map[common.Address]map[common.Hash][]byte, rawStorageKey bool) {
+	for addr := range accounts {
+		s.Accounts[addr] = struct{}{}
+	}
+	target := s.Storages
+	if !rawStorageKey {
+		target = s.HashedStorages
+	}
+	for addr, slots := range storages {
+		if _, ok := target[addr]; !ok {
+			target[addr] = make(map[common.Hash]struct{})
+		}
+		for key := range slots {
+			target[addr][key] = struct{}{}
+		}
+	}
+}
+
+// MutatedStates returns the set of states mutated between the state `from`
+// and the state `to`. The mutations recorded in the in-memory layers are
+// resolved from the layers directly, while the persisted ones are resolved
+// from the state histories.
+//
+// The two states are not required to be on the same branch, in which case
+// the mutations of both branches since the common ancestor are returned.
+func (db *Database) MutatedStates(from common.Hash, to common.Hash) (*MutatedStates, error) {
+	target := db.tree.get(to)
+	if target == nil {
+		return nil, fmt.Errorf("state %#x is not available", to)
+	}
+	// Resolve the in-memory layers of the `from` state, or the identifier of
+	// it if the state has already been persisted.
+	var (
+		fromID     uint64
+		fromLayers = make(map[common.Hash]*diffLayer)
+	)
+	if l := db.tree.get(from); l != nil {
+		fromID = l.stateID()
+		for {
+			dl, ok := l.(*diffLayer)
+			if !ok {
+				break
+			}
+			fromLayers[dl.root] = dl
+			l = dl.parentLayer()
+		}
+	} else {
+		id := rawdb.ReadStateID(db.diskdb, from)
+		if id == nil {
+			return nil, fmt.Errorf("state %#x is not available", from)
+		}
+		fromID = *id
+	}
+	set := newMutatedStates()
+
+	// Traverse the layers of the `to` state until the common ancestor is found,
+	// or the disk layer is reached.
+	current := target
+	for {
+		if current.rootHash() == from {
+			return set, nil
+		}
+		dl, ok := current.(*diffLayer)
+		if !ok {
+			break
+		}
+		if _, ok := fromLayers[dl.root]; ok {
+			// The common ancestor in memory is found, include the mutations
+			// of the `from` branch since the ancestor.
+			for _, fl := range fromLayers {
+				if fl.stateID() > dl.stateID() {
+					set.add(fl.states.accountOrigin, fl.states.storageOrigin, fl.states.rawStorageKey)
+				}
+			}
+			return set, nil
+		}
+		set.add(dl.states.accountOrigin, dl.states.storageOrigin, dl.states.rawStorageKey)
+		current = dl.parentLayer()
+	}
+	// The disk layer is reached, it's the common ancestor of all in-memory
+	// layers. Include the entire `from` branch if it's still in memory.
+	if len(fromLayers) > 0 {
+		for _, fl := range fromLayers {
+			set.add(fl.states.accountOrigin, fl.states.storageOrigin, fl.states.rawStorageKey)
+		}
+		return set, nil
+	}
+	// The `from` state has been persisted, resolve the mutations from the
+	// state histories.
+	diskID := current.stateID()
+	if fromID >= diskID {
+		return nil, fmt.Errorf("state %#x is not an ancestor of %#x", from, to)
+	}
+	if db.stateFreezer == nil {
+		return nil, errors.New("state histories are not available")
+	}
+	for id := fromID + 1; id <= diskID; id++ {
+		h, err := readStateHistory(db.stateFreezer, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state history %d: %w", id, err)
+		}
+		if id == fromID+1 && h.meta.parent != from {
+			return nil, fmt.Errorf("state %#x is not an ancestor of %#x", from, to)
+		}
+		set.add(h.accounts, h.storages, h.meta.version != stateHistoryV0)
+	}
+	return set, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestMutatedStates(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+	tester := newTester(t, &testerConfig{layers: 16})
+	defer tester.release()
+
+	// stateAt returns the account and storage set of the specified state.
+	stateAt := func(index int) (map[common.Hash][]byte, map[common.Hash]map[common.Hash][]byte) {
+		if index == len(tester.roots)-1 {
+			return tester.accounts, tester.storages
+		}
+		root := tester.roots[index]
+		return tester.snapAccounts[root], tester.snapStorages[root]
+	}
+	for _, c := range [][2]int{{0, 11}, {0, 15}, {3, 12}, {9, 13}, {11, 15}, {12, 15}, {14, 15}} {
+		from, to := c[0], c[1]
+		set, err := tester.db.MutatedStates(tester.roots[from], tester.roots[to])
+		if err != nil {
+			t.Fatalf("Failed to resolve mutations from %d to %d: %v", from, to, err)
+		}
+		accounts := make(map[common.Hash]struct{})
+		for addr := range set.Accounts {
+			accounts[crypto.Keccak256Hash(addr.Bytes())] = struct{}{}
+		}
+		slots := make(map[common.Hash]map[common.Hash]struct{})
+		for addr, keys := range set.Storages {
+			addrHash := crypto.Keccak256Hash(addr.Bytes())
+			slots[addrHash] = make(map[common.Hash]struct{})
+			for key := range keys {
+				slots[addrHash][crypto.Keccak256Hash(key.Bytes())] = struct{}{}
+			}
+		}
+		for addr, keys := range set.HashedStorages {
+			addrHash := crypto.Keccak256Hash(addr.Bytes())
+			if _, ok := slots[addrHash]; !ok {
+				slots[addrHash] = make(map[common.Hash]struct{})
+			}
+			for key := range keys {
+				slots[addrHash][key] = struct{}{}
+			}
+		}
+		// Ensure all the states differing between two states are covered
+		fromAccounts, fromStorages := stateAt(from)
+		toAccounts, toStorages := stateAt(to)
+		check := func(a, b map[common.Hash][]byte, aSlots, bSlots map[common.Hash]map[common.Hash][]byte) {
+			for addrHash, blob := range a {
+				if !bytes.Equal(blob, b[addrHash]) {
+					if _, ok := accounts[addrHash]; !ok {
+						t.Fatalf("Mutated account %x is missing (%d -> %d)", addrHash, from, to)
+					}
+				}
+			}
+			for addrHash, storage := range aSlots {
+				for slotHash, blob := range storage {
+					if bytes.Equal(blob, bSlots[addrHash][slotHash]) {
+						continue
+					}
+					if _, ok := slots[addrHash][slotHash]; !ok {
+						t.Fatalf("Mutated slot %x:%x is missing (%d -> %d)", addrHash, slotHash, from, to)
+					}
+				}
+			}
+		}
+		check(fromAccounts, toAccounts, fromStorages, toStorages)
+		check(toAccounts, fromAccounts, toStorages, fromStorages)
+	}
+	// Resolving the mutations towards a persisted state should be rejected
+	if _, err := tester.db.MutatedStates(tester.roots[0], tester.roots[3]); err == nil {
+		t.Fatal("Expected error for the unavailable state")
+	}
+	// Resolving the mutations in the reverse order should be rejected
+	if _, err := tester.db.MutatedStates(tester.roots[13], tester.roots[9]); err == nil {
+		t.Fatal("Expected error for the reversed state range")
+	}
+}
@@ -198,6 +198,8 @@ func (db *Database) StateReader(root common.Hash) (database.StateReader, error)
 
 // HistoricalStateReader is a wrapper over history reader, providing access to
 // historical state.
+//
+// HistoricalStateReader is safe for concurrent access.
 type HistoricalStateReader struct {
 	db     *Database
 	reader *historyReader